@@ -0,0 +1,382 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reviewers/internal/auth"
+	"reviewers/internal/models"
+	"reviewers/pkg/client"
+	"reviewers/pkg/errs"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func importRoster(r *gin.Engine, query, contentType, roster string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/admin/import"+query, strings.NewReader(roster))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func importFields(t *testing.T, w *httptest.ResponseRecorder) []map[string]interface{} {
+	t.Helper()
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	var resp struct {
+		Error struct {
+			Code   string                   `json:"code"`
+			Fields []map[string]interface{} `json:"fields"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "VALIDATION_FAILED", resp.Error.Code)
+	return resp.Error.Fields
+}
+
+func TestImport_YAML(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+		w := performRequest(r, "POST", "/team/add", map[string]interface{}{
+			"team_name": "backend",
+			"members":   []map[string]interface{}{{"username": "alice", "is_active": true}, {"username": "bob", "is_active": true}},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		roster := `teams:
+  - name: engineering
+  - name: backend
+    parent: engineering
+    members:
+      - username: alice
+        email: alice@example.com
+      - username: carol
+  - name: frontend
+    parent: engineering
+    members:
+      - username: bob
+        is_active: false
+`
+		expected := []models.ImportChange{
+			{Line: 2, Action: models.ImportCreateTeam, TeamName: "engineering"},
+			{Line: 3, Action: models.ImportSetParent, TeamName: "backend", ParentTeamName: "engineering"},
+			{Line: 6, Action: models.ImportUpdateEmail, TeamName: "backend", Username: "alice"},
+			{Line: 8, Action: models.ImportCreateUser, TeamName: "backend", Username: "carol"},
+			{Line: 9, Action: models.ImportCreateTeam, TeamName: "frontend", ParentTeamName: "engineering"},
+			{Line: 12, Action: models.ImportMoveUser, TeamName: "frontend", Username: "bob", OldTeamName: "backend"},
+			{Line: 12, Action: models.ImportDeactivateUser, TeamName: "frontend", Username: "bob"},
+		}
+
+		w = importRoster(r, "?dry_run=true", "application/yaml", roster)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var result models.ImportResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.True(t, result.DryRun)
+		assert.Equal(t, expected, result.Changes)
+
+		w = performRequest(r, "GET", "/team/get?team_name=engineering", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code, "dry run must not create teams")
+
+		w = importRoster(r, "", "application/yaml", roster)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		result = models.ImportResult{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.False(t, result.DryRun)
+		assert.Equal(t, expected, result.Changes)
+
+		var frontend models.Team
+		w = performRequest(r, "GET", "/team/get?team_name=frontend", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &frontend))
+		assert.Equal(t, "engineering", frontend.ParentName)
+		require.Len(t, frontend.Members, 1)
+		assert.Equal(t, "bob", frontend.Members[0].Username)
+		assert.False(t, frontend.Members[0].IsActive)
+
+		var alice models.User
+		require.NoError(t, tx.First(&alice, "username = ?", "alice").Error)
+		assert.Equal(t, "alice@example.com", alice.Email)
+
+		// The roster is applied, so importing it again changes nothing
+		w = importRoster(r, "", "application/yaml", roster)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.JSONEq(t, `{"dry_run": false, "changes": []}`, w.Body.String())
+	})
+}
+
+func TestImport_CSV(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+		w := performRequest(r, "POST", "/team/add", map[string]interface{}{
+			"team_name": "backend",
+			"members":   []map[string]interface{}{{"username": "alice", "is_active": true}, {"username": "bob", "is_active": false}},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		roster := "team,username,is_active\n" +
+			"frontend,alice,false\n" +
+			"backend,bob,true\n" +
+			"frontend,dave,\n"
+		w = importRoster(r, "?format=csv", "text/plain", roster)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var result models.ImportResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, []models.ImportChange{
+			{Line: 2, Action: models.ImportCreateTeam, TeamName: "frontend"},
+			{Line: 2, Action: models.ImportMoveUser, TeamName: "frontend", Username: "alice", OldTeamName: "backend"},
+			{Line: 2, Action: models.ImportDeactivateUser, TeamName: "frontend", Username: "alice"},
+			{Line: 3, Action: models.ImportActivateUser, TeamName: "backend", Username: "bob"},
+			{Line: 4, Action: models.ImportCreateUser, TeamName: "frontend", Username: "dave"},
+		}, result.Changes)
+
+		var users []models.User
+		require.NoError(t, tx.Where("username IN ?", []string{"alice", "bob", "dave"}).Order("username").Find(&users).Error)
+		require.Len(t, users, 3)
+		assert.Equal(t, []bool{false, true, true}, []bool{users[0].IsActive, users[1].IsActive, users[2].IsActive})
+	})
+}
+
+func TestImport_Errors(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		fields := importFields(t, importRoster(r, "", "text/csv", "team,parent,username,email,is_active\n"+
+			"backend,,alice,,maybe\n"+
+			"backend,,bob,,\n"+
+			"frontend,,bob,,\n"))
+		assert.Equal(t, []map[string]interface{}{
+			{"line": 2.0, "field": "is_active", "message": "must be true or false"},
+			{"line": 4.0, "field": "username", "message": "is already listed on line 3"},
+		}, fields)
+
+		fields = importFields(t, importRoster(r, "", "text/csv", "team,username,email\n"+
+			"backend,alice,alice\n"+
+			" ,bob,\n"))
+		assert.Equal(t, []map[string]interface{}{
+			{"line": 2.0, "field": "email", "message": "must be an email address"},
+			{"line": 3.0, "field": "team_name", "message": "is required"},
+		}, fields)
+
+		fields = importFields(t, importRoster(r, "", "application/yaml", "teams:\n  - name: backend\n    lead: alice\n"))
+		assert.Equal(t, []map[string]interface{}{
+			{"line": 3.0, "field": "lead", "message": "is not a known key"},
+		}, fields)
+
+		// Entries that are valid by themselves are not applied either
+		fields = importFields(t, importRoster(r, "", "text/csv", "team,parent,username\n"+
+			"a,b,alice\n"+
+			"b,a,\n"+
+			"c,unknown,\n"))
+		assert.Equal(t, []map[string]interface{}{
+			{"line": 2.0, "field": "parent_team_name", "message": "team cannot be nested under its own subteam"},
+			{"line": 3.0, "field": "parent_team_name", "message": "team cannot be nested under its own subteam"},
+			{"line": 4.0, "field": "parent_team_name", "message": "team not found"},
+		}, fields)
+
+		w := performRequest(r, "GET", "/team/get?team_name=a", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		var users int64
+		tx.Model(&models.User{}).Where("username = ?", "alice").Count(&users)
+		assert.Zero(t, users)
+
+		fields = importFields(t, importRoster(r, "", "application/json", "{}"))
+		assert.Equal(t, "format", fields[0]["field"])
+	})
+}
+
+func TestImport_RequiresToken(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		// Not even a trusted proxy may import without a token
+		for _, r := range []*gin.Engine{setupRouter(tx), setupRouterWithAuth(tx, auth.Config{TrustOrganizationHeader: true})} {
+			req, _ := http.NewRequest("POST", "/admin/import", strings.NewReader("team,username\nbackend,alice\n"))
+			req.Header.Set("Content-Type", "text/csv")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+			assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+		}
+
+		var teams int64
+		tx.Model(&models.Team{}).Where("name = ?", "backend").Count(&teams)
+		assert.Zero(t, teams)
+	})
+}
+
+func TestBackup_ExportRestore(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		server := httptest.NewServer(setupRouter(tx))
+		defer server.Close()
+		r := setupRouter(tx)
+
+		w := performRequest(r, "POST", "/team/add", map[string]interface{}{"team_name": "engineering"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		w = performRequest(r, "POST", "/team/add", map[string]interface{}{
+			"team_name":        "backend",
+			"parent_team_name": "engineering",
+			"members": []map[string]interface{}{
+				{"username": "alice", "email": "alice@example.com", "is_active": true},
+				{"username": "bob", "is_active": true},
+				{"username": "carol", "is_active": false},
+			},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var team models.Team
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &team))
+		alice, bob := team.Members[0].ID, team.Members[1].ID
+
+		w = performRequest(r, "POST", "/repository/add", map[string]interface{}{"name": "api", "team_name": "backend", "reviewers_count": 1})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		w = performRequest(r, "POST", "/codeOwners/set", map[string]interface{}{
+			"repository": "api",
+			"rules": []map[string]interface{}{
+				{"pattern": "*.go", "owners": []string{bob}},
+				{"pattern": "docs/", "owners": []string{}},
+			},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		w = performRequest(r, "POST", "/pullRequest/create", map[string]interface{}{
+			"pull_request_id":   "pr-1",
+			"pull_request_name": "api",
+			"author_id":         alice,
+			"repository":        "api",
+			"changed_files":     []string{"main.go", "docs/README.md"},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		w = performRequest(r, "POST", "/pullRequest/approve", map[string]interface{}{"repository": "api", "pull_request_id": "pr-1", "reviewer_id": bob})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		w = performRequest(r, "POST", "/pullRequest/merge", map[string]interface{}{"repository": "api", "pull_request_id": "pr-1"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		w = performRequest(r, "POST", "/pullRequest/create", map[string]interface{}{
+			"pull_request_id":   "pr-1",
+			"pull_request_name": "default",
+			"author_id":         bob,
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		ctx := context.Background()
+		c := client.New(server.URL, client.WithToken(testToken))
+		var exported bytes.Buffer
+		require.NoError(t, c.Export(ctx, &exported))
+		lines := strings.Split(strings.TrimSpace(exported.String()), "\n")
+		assert.Contains(t, lines[0], `"type":"header","version":1`)
+		assert.JSONEq(t, `{"type": "end", "records": 15}`, lines[len(lines)-1])
+
+		_, err := c.Restore(ctx, bytes.NewReader(exported.Bytes()))
+		assert.ErrorIs(t, err, errs.NotEmpty)
+
+		// Users, pull requests and their history go with their teams
+		require.NoError(t, tx.Where("organization_id = ?", models.DefaultOrganizationID).Delete(&models.Team{}).Error)
+		require.NoError(t, tx.Where("organization_id = ? AND name <> ?", models.DefaultOrganizationID, models.DefaultRepository).Delete(&models.Repository{}).Error)
+
+		result, err := c.Restore(ctx, bytes.NewReader(exported.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, &models.RestoreResult{
+			Teams:          2,
+			Users:          3,
+			Repositories:   2,
+			CodeOwnerRules: 2,
+			PullRequests:   2,
+			Events:         4,
+		}, result)
+
+		// The restored data is exported the same way, except for the time
+		// of the export and the order of the repositories, which get new IDs
+		var restored bytes.Buffer
+		require.NoError(t, c.Export(ctx, &restored))
+		restoredLines := strings.Split(strings.TrimSpace(restored.String()), "\n")
+		assert.ElementsMatch(t, lines[1:], restoredLines[1:])
+
+		w = performRequest(r, "GET", "/pullRequest/get?repository=api&pull_request_id=pr-1", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var details struct {
+			Status       string   `json:"status"`
+			ChangedFiles []string `json:"changed_files"`
+			Reviewers    []struct {
+				UserID     string  `json:"user_id"`
+				ApprovedAt *string `json:"approved_at"`
+			} `json:"reviewers"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+		assert.Equal(t, models.StatusMerged, details.Status)
+		assert.ElementsMatch(t, []string{"main.go", "docs/README.md"}, details.ChangedFiles)
+		require.Len(t, details.Reviewers, 1)
+		assert.Equal(t, bob, details.Reviewers[0].UserID)
+		assert.NotNil(t, details.Reviewers[0].ApprovedAt)
+	})
+}
+
+func TestBackup_RestoreErrors(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+		restore := func(backup ...string) []map[string]interface{} {
+			t.Helper()
+			req, _ := http.NewRequest("POST", "/admin/restore", strings.NewReader(strings.Join(backup, "\n")+"\n"))
+			req.Header.Set("Content-Type", "application/x-ndjson")
+			req.Header.Set("Authorization", "Bearer "+testToken)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			return importFields(t, w)
+		}
+		header := `{"type":"header","version":1,"exported_at":"2025-01-02T03:04:05Z"}`
+		teamID, userID := uuid.New().String(), uuid.New().String()
+		team := `{"type":"team","data":{"team_id":"` + teamID + `","name":"backend"}}`
+
+		fields := restore(`{"type":"header","version":2}`)
+		assert.Equal(t, []map[string]interface{}{{"line": 1.0, "field": "version", "message": "must be 1"}}, fields)
+
+		fields = restore(header, team)
+		assert.Equal(t, []map[string]interface{}{{"line": 3.0, "field": "type", "message": "end is missing, the backup is truncated"}}, fields)
+
+		fields = restore(header, team, `{"type":"end","records":2}`)
+		assert.Equal(t, []map[string]interface{}{{"line": 3.0, "field": "records", "message": "must be 1"}}, fields)
+
+		fields = restore(header, `{"type":"user","data":{"user_id":"`+userID+`","username":"alice","is_active":true,"team_name":"backend"}}`, team)
+		assert.Equal(t, []map[string]interface{}{{"line": 2.0, "field": "team_name", "message": "team not found, teams must precede their members"}}, fields)
+
+		fields = restore(header, team, `{"type":"pull_request","data":{"repository":"default","pull_request_id":"pr-1","status":"CLOSED","author_id":"`+userID+`"}}`)
+		assert.Equal(t, []map[string]interface{}{{"line": 3.0, "field": "status", "message": "must be one of OPEN, MERGED"}}, fields)
+
+		fields = restore(header, team, `{"type":"label","data":{}}`)
+		assert.Equal(t, []map[string]interface{}{{"line": 3.0, "field": "type", "message": "is not a known record type"}}, fields)
+
+		// Nothing is saved when the backup fails
+		var teams int64
+		tx.Model(&models.Team{}).Where("team_id = ?", teamID).Count(&teams)
+		assert.Zero(t, teams)
+	})
+}
+
+func TestBackup_RequiresToken(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+		w := performRequest(r, "POST", "/team/add", map[string]interface{}{
+			"team_name": "backend",
+			"members":   []map[string]interface{}{{"username": "alice", "is_active": true}},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		server := httptest.NewServer(setupRouterWithAuth(tx, auth.Config{TrustOrganizationHeader: true}))
+		defer server.Close()
+
+		ctx := context.Background()
+		for _, c := range []*client.Client{client.New(server.URL), client.New(server.URL, client.WithToken("unknown-token"))} {
+			var exported bytes.Buffer
+			err := c.Export(ctx, &exported)
+			assert.ErrorIs(t, err, errs.Unauthorized)
+			assert.NotContains(t, exported.String(), "alice")
+
+			_, err = c.Restore(ctx, strings.NewReader(`{"type":"header","version":1}`+"\n"+`{"type":"end","records":0}`+"\n"))
+			assert.ErrorIs(t, err, errs.Unauthorized)
+		}
+	})
+}
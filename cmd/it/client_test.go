@@ -0,0 +1,131 @@
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reviewers/pkg/api"
+	"reviewers/pkg/client"
+	"reviewers/pkg/errs"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestClient_PullRequestWorkflow(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		server := httptest.NewServer(setupRouter(tx))
+		defer server.Close()
+
+		ctx := context.Background()
+		c := client.New(server.URL)
+
+		team, err := c.CreateTeam(ctx, &api.Team{
+			Name: "squad",
+			Members: []api.User{
+				{Username: "author", IsActive: true},
+				{Username: "reviewer", IsActive: true},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, team.Members, 2)
+		authorID, reviewerID := team.Members[0].ID, team.Members[1].ID
+
+		pr, err := c.CreatePullRequest(ctx, &api.PullRequest{ID: "pr-0001", Name: "test", AuthorID: authorID})
+		require.NoError(t, err)
+		assert.Equal(t, api.StatusOpen, pr.Status)
+		assert.Equal(t, []string{reviewerID}, pr.AssignedReviewers)
+
+		reviews, err := c.GetUserReviews(ctx, reviewerID, api.PullRequestFilter{Status: api.StatusOpen})
+		require.NoError(t, err)
+		assert.Len(t, reviews.PullRequests, 1)
+
+		_, err = c.CreatePullRequest(ctx, &api.PullRequest{ID: "pr-0001", Name: "test", AuthorID: authorID})
+		assert.ErrorIs(t, err, errs.PullRequestExists)
+		var apiErr *client.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+		assert.NotEmpty(t, apiErr.RequestID)
+
+		_, err = c.ReassignReviewer(ctx, "", "pr-0001", reviewerID)
+		assert.ErrorIs(t, err, errs.NoCandidate)
+
+		pr, err = c.MergePullRequest(ctx, "", "pr-0001")
+		require.NoError(t, err)
+		assert.Equal(t, api.StatusMerged, pr.Status)
+	})
+}
+
+func TestClient_Errors(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		server := httptest.NewServer(setupRouter(tx))
+		defer server.Close()
+
+		ctx := context.Background()
+		c := client.New(server.URL)
+
+		_, err := c.GetTeam(ctx, "unknown", false)
+		assert.ErrorIs(t, err, errs.ResourceNotFound)
+
+		_, err = c.CreatePullRequest(ctx, &api.PullRequest{ID: "pr 1"})
+		assert.ErrorIs(t, err, errs.ValidationFailed)
+		var validationErr *errs.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.NotEmpty(t, validationErr.Fields)
+
+		// Another organization needs a token of the organization
+		otherOrg := client.WithOrganization("00000000-0000-0000-0000-000000000001")
+		_, err = client.New(server.URL, otherOrg).GetOrganization(ctx)
+		assert.ErrorIs(t, err, errs.Unauthorized)
+		_, err = client.New(server.URL, otherOrg, client.WithToken(testToken)).GetOrganization(ctx)
+		assert.ErrorIs(t, err, errs.Forbidden)
+	})
+}
+
+func TestClient_RetriesUnavailableService(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"organization_id": "00000000-0000-0000-0000-000000000000", "name": "default"}`))
+	}))
+	defer server.Close()
+
+	c := client.New(server.URL, client.WithRetries(2, time.Millisecond))
+	org, err := c.GetOrganization(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "default", org.Name)
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestClient_DoesNotRetryProcessedChanges(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusGatewayTimeout)
+	}))
+	defer server.Close()
+
+	c := client.New(server.URL, client.WithRetries(2, time.Millisecond))
+	_, err := c.MergePullRequest(context.Background(), "", "pr-0001")
+	assert.ErrorIs(t, err, errs.Timeout)
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestClient_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	c := client.New(server.URL, client.WithTimeout(10*time.Millisecond), client.WithRetries(0, 0))
+	_, err := c.GetOrganization(context.Background())
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+}
@@ -0,0 +1,74 @@
+package integration_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reviewers/internal/handler"
+	"reviewers/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestErrorResponse_PullRequestExists(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		team := models.Team{
+			ID:   uuid.New().String(),
+			Name: "squad",
+			Members: []models.User{
+				{ID: uuid.New().String(), Username: "author", IsActive: true},
+			},
+		}
+		tx.Create(&team)
+
+		reqBody, _ := json.Marshal(map[string]interface{}{
+			"pull_request_id":   "pr-0001",
+			"pull_request_name": "test",
+			"author_id":         team.Members[0].ID,
+		})
+		req, _ := http.NewRequest("POST", "/pullRequest/create", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		req, _ = http.NewRequest("POST", "/pullRequest/create", bytes.NewBuffer(reqBody))
+		req.Header.Set(handler.RequestIDHeader, "request-1")
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "request-1", w.Header().Get(handler.RequestIDHeader))
+
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, map[string]interface{}{
+			"code":       "PR_EXISTS",
+			"message":    "PR pr-0001 already exists",
+			"request_id": "request-1",
+		}, resp["error"])
+	})
+}
+
+func TestErrorResponse_ValidationFailed(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		req, _ := http.NewRequest("GET", "/stats/reviewers?from=yesterday", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, "VALIDATION_FAILED", resp["error"]["code"])
+		assert.NotEmpty(t, resp["error"]["request_id"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"field": "from", "message": "must be an RFC 3339 timestamp or a date"},
+		}, resp["error"]["fields"])
+	})
+}
@@ -0,0 +1,165 @@
+package integration_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type streamEvent struct {
+	ID   string
+	Type string
+	Data map[string]interface{}
+}
+
+// openStream subscribes to the event stream and returns the received
+// events.
+func openStream(t *testing.T, server *httptest.Server, query, lastEventID string) <-chan streamEvent {
+	t.Helper()
+
+	req, _ := http.NewRequest("GET", server.URL+"/events/stream?"+query, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"))
+	t.Cleanup(func() { resp.Body.Close() })
+
+	received := make(chan streamEvent, 16)
+	go func() {
+		defer close(received)
+		var event streamEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			field, value, _ := strings.Cut(scanner.Text(), ":")
+			switch field {
+			case "id":
+				event.ID = value
+			case "event":
+				event.Type = value
+			case "data":
+				json.Unmarshal([]byte(value), &event.Data)
+			case "":
+				if event.Type != "" {
+					received <- event
+				}
+				event = streamEvent{}
+			}
+		}
+	}()
+	return received
+}
+
+func nextEvent(t *testing.T, received <-chan streamEvent) streamEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-received:
+		require.True(t, ok, "stream closed")
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+		return streamEvent{}
+	}
+}
+
+func TestEvents_Stream(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+		server := httptest.NewServer(r)
+		defer server.Close()
+		defer server.CloseClientConnections()
+
+		w := performRequest(r, "POST", "/team/add", map[string]interface{}{
+			"team_name": "squad",
+			"members": []map[string]interface{}{
+				{"username": "author", "is_active": true},
+				{"username": "reviewer", "is_active": true},
+			},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var team struct {
+			Members []struct {
+				UserID string `json:"user_id"`
+			} `json:"members"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &team))
+		authorID, reviewerID := team.Members[0].UserID, team.Members[1].UserID
+
+		reviewerEvents := openStream(t, server, "user_id="+reviewerID, "")
+		teamEvents := openStream(t, server, "team_name=squad", "")
+		otherEvents := openStream(t, server, "user_id="+uuid.New().String(), "")
+
+		w = performRequest(r, "POST", "/pullRequest/create", map[string]interface{}{
+			"pull_request_id":   "pr-1",
+			"pull_request_name": "test",
+			"author_id":         authorID,
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		w = performRequest(r, "POST", "/pullRequest/merge", map[string]interface{}{"pull_request_id": "pr-1"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		created := nextEvent(t, reviewerEvents)
+		assert.Equal(t, "pr.created", created.Type)
+		assert.Equal(t, "pr-1", created.Data["pull_request_id"])
+		assert.Equal(t, authorID, created.Data["author_id"])
+		assert.Equal(t, []interface{}{reviewerID}, created.Data["assigned_reviewers"])
+
+		assigned := nextEvent(t, reviewerEvents)
+		assert.Equal(t, "reviewer.assigned", assigned.Type)
+		assert.Equal(t, reviewerID, assigned.Data["reviewer_id"])
+
+		merged := nextEvent(t, reviewerEvents)
+		assert.Equal(t, "pr.merged", merged.Type)
+		assert.Equal(t, "MERGED", merged.Data["status"])
+
+		for _, eventType := range []string{"pr.created", "reviewer.assigned", "pr.merged"} {
+			assert.Equal(t, eventType, nextEvent(t, teamEvents).Type)
+		}
+
+		select {
+		case event := <-otherEvents:
+			t.Fatalf("unexpected event %v", event)
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		// A reconnecting client gets the events after the last one it saw
+		replayed := openStream(t, server, "user_id="+reviewerID, created.ID)
+		assert.Equal(t, assigned, nextEvent(t, replayed))
+		assert.Equal(t, merged, nextEvent(t, replayed))
+
+		// Events before a restart of the service cannot be replayed
+		reset := nextEvent(t, openStream(t, server, "user_id="+reviewerID, "1"))
+		assert.Equal(t, "stream.reset", reset.Type)
+		assert.Equal(t, merged.ID, reset.ID)
+	})
+}
+
+func TestEvents_Errors(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		w := performRequest(r, "GET", "/events/stream?team_name=unknown", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = performRequest(r, "GET", "/events/stream?user_id="+uuid.New().String()+"&team_name=squad", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		req, _ := http.NewRequest("GET", "/events/stream", nil)
+		req.Header.Set("Last-Event-ID", "latest")
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
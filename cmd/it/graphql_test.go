@@ -0,0 +1,164 @@
+package integration_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const teamReviewsQuery = `query($name: String!) {
+  team(name: $name) {
+    name
+    members {
+      username
+      reviews { id status author { username } reviewers { username } }
+      open: reviews(status: OPEN) { id }
+    }
+  }
+}`
+
+type graphqlResponse struct {
+	Data   map[string]interface{}   `json:"data"`
+	Errors []map[string]interface{} `json:"errors"`
+}
+
+func graphqlQuery(t *testing.T, r *gin.Engine, query string, variables map[string]interface{}) graphqlResponse {
+	t.Helper()
+
+	reqBody, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	req, _ := http.NewRequest("POST", "/graphql", bytes.NewBuffer(reqBody))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp graphqlResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+// createTeamWithPullRequests creates a team of the given size where every
+// member authors a pull request.
+func createTeamWithPullRequests(t *testing.T, r *gin.Engine, name string, size int) {
+	t.Helper()
+
+	members := make([]map[string]interface{}, size)
+	for i := range members {
+		members[i] = map[string]interface{}{"username": fmt.Sprintf("%s-%d", name, i), "is_active": true}
+	}
+	var team struct {
+		Members []struct {
+			UserID string `json:"user_id"`
+		} `json:"members"`
+	}
+	w := performRequest(r, "POST", "/team/add", map[string]interface{}{"team_name": name, "members": members})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &team))
+
+	for i, member := range team.Members {
+		w := performRequest(r, "POST", "/pullRequest/create", map[string]interface{}{
+			"pull_request_id":   fmt.Sprintf("%s-pr-%d", name, i),
+			"pull_request_name": "test",
+			"author_id":         member.UserID,
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+}
+
+func performRequest(r *gin.Engine, method, target string, body interface{}) *httptest.ResponseRecorder {
+	reqBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, target, bytes.NewBuffer(reqBody))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGraphQL_TeamMembersWithReviews(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+		createTeamWithPullRequests(t, r, "squad", 2)
+
+		w := performRequest(r, "POST", "/pullRequest/merge", map[string]interface{}{"pull_request_id": "squad-pr-1"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		resp := graphqlQuery(t, r, teamReviewsQuery, map[string]interface{}{"name": "squad"})
+		require.Empty(t, resp.Errors)
+
+		assert.Equal(t, map[string]interface{}{
+			"name": "squad",
+			"members": []interface{}{
+				map[string]interface{}{
+					"username": "squad-0",
+					"reviews": []interface{}{
+						map[string]interface{}{
+							"id":        "squad-pr-1",
+							"status":    "MERGED",
+							"author":    map[string]interface{}{"username": "squad-1"},
+							"reviewers": []interface{}{map[string]interface{}{"username": "squad-0"}},
+						},
+					},
+					"open": []interface{}{},
+				},
+				map[string]interface{}{
+					"username": "squad-1",
+					"reviews": []interface{}{
+						map[string]interface{}{
+							"id":        "squad-pr-0",
+							"status":    "OPEN",
+							"author":    map[string]interface{}{"username": "squad-0"},
+							"reviewers": []interface{}{map[string]interface{}{"username": "squad-1"}},
+						},
+					},
+					"open": []interface{}{map[string]interface{}{"id": "squad-pr-0"}},
+				},
+			},
+		}, resp.Data["team"])
+	})
+}
+
+func TestGraphQL_BatchesQueries(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+		createTeamWithPullRequests(t, r, "small", 2)
+		createTeamWithPullRequests(t, r, "large", 8)
+
+		queries := 0
+		require.NoError(t, tx.Callback().Query().After("gorm:query").Register("test:count_queries", func(*gorm.DB) {
+			queries++
+		}))
+		defer tx.Callback().Query().Remove("test:count_queries")
+
+		countQueries := func(team string) int {
+			queries = 0
+			resp := graphqlQuery(t, r, teamReviewsQuery, map[string]interface{}{"name": team})
+			require.Empty(t, resp.Errors)
+			require.NotNil(t, resp.Data["team"])
+			return queries
+		}
+
+		assert.Equal(t, countQueries("small"), countQueries("large"))
+	})
+}
+
+func TestGraphQL_Errors(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		resp := graphqlQuery(t, r, `{ team(name: "unknown") { name } user(id: "user") { username } }`, nil)
+		assert.Empty(t, resp.Errors)
+		assert.Equal(t, map[string]interface{}{"team": nil, "user": nil}, resp.Data)
+
+		resp = graphqlQuery(t, r, `{ team(name: "squad") { unknown } }`, nil)
+		assert.Len(t, resp.Errors, 1)
+
+		w := performRequest(r, "POST", "/graphql", map[string]interface{}{})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,168 @@
+package integration_test
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"reviewers/internal/events"
+	"reviewers/internal/grpcserver"
+	"reviewers/internal/repository"
+	pb "reviewers/pkg/pb/reviewers/v1"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gorm.io/gorm"
+)
+
+func setupGRPC(t *testing.T, tx *gorm.DB) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	server := grpcserver.New(slog.Default(), repository.NewStores(tx, slog.Default()), testAuth(), 0, events.NewBroker(100))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func errorReason(t *testing.T, err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	t.Fatalf("no error info in %v", err)
+	return ""
+}
+
+func TestGRPC_PullRequestWorkflow(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		conn := setupGRPC(t, tx)
+		teams := pb.NewTeamServiceClient(conn)
+		users := pb.NewUserServiceClient(conn)
+		prs := pb.NewPullRequestServiceClient(conn)
+		ctx := context.Background()
+
+		team, err := teams.CreateTeam(ctx, &pb.CreateTeamRequest{Team: &pb.Team{
+			TeamName: "squad",
+			Members: []*pb.User{
+				{Username: "author", IsActive: true},
+				{Username: "reviewer", IsActive: true},
+			},
+		}})
+		require.NoError(t, err)
+		require.Len(t, team.Members, 2)
+		authorID, reviewerID := team.Members[0].UserId, team.Members[1].UserId
+
+		create := &pb.CreatePullRequestRequest{PullRequestId: "pr-0001", PullRequestName: "test", AuthorId: authorID}
+		pr, err := prs.CreatePullRequest(ctx, create)
+		require.NoError(t, err)
+		assert.Equal(t, pb.PullRequestStatus_PULL_REQUEST_STATUS_OPEN, pr.Status)
+		assert.Equal(t, []string{reviewerID}, pr.AssignedReviewers)
+
+		_, err = prs.CreatePullRequest(ctx, create)
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+		assert.Equal(t, "PR pr-0001 already exists", status.Convert(err).Message())
+		assert.Equal(t, "PR_EXISTS", errorReason(t, err))
+
+		reviews, err := users.GetReview(ctx, &pb.GetReviewRequest{UserId: reviewerID})
+		require.NoError(t, err)
+		require.Len(t, reviews.PullRequests, 1)
+		assert.Equal(t, "pr-0001", reviews.PullRequests[0].PullRequestId)
+
+		_, err = prs.ReassignReviewer(ctx, &pb.ReassignReviewerRequest{PullRequestId: "pr-0001", OldReviewerId: reviewerID})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, "NO_CANDIDATE", errorReason(t, err))
+
+		pr, err = prs.MergePullRequest(ctx, &pb.MergePullRequestRequest{PullRequestId: "pr-0001"})
+		require.NoError(t, err)
+		assert.Equal(t, pb.PullRequestStatus_PULL_REQUEST_STATUS_MERGED, pr.Status)
+		assert.NotNil(t, pr.MergedAt)
+	})
+}
+
+func TestGRPC_Errors(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		conn := setupGRPC(t, tx)
+		teams := pb.NewTeamServiceClient(conn)
+		ctx := context.Background()
+
+		_, err := teams.GetTeam(ctx, &pb.GetTeamRequest{TeamName: "unknown"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Equal(t, "NOT_FOUND", errorReason(t, err))
+
+		_, err = teams.DeactivateTeam(ctx, &pb.DeactivateTeamRequest{TeamId: "team"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		var violations []*errdetails.BadRequest_FieldViolation
+		for _, detail := range status.Convert(err).Details() {
+			if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+				violations = badRequest.FieldViolations
+			}
+		}
+		require.Len(t, violations, 1)
+		assert.Equal(t, "team_id", violations[0].Field)
+		assert.Equal(t, "must be a UUID", violations[0].Description)
+	})
+}
+
+func TestGRPC_OtherOrganizationRejected(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		conn := setupGRPC(t, tx)
+		teams := pb.NewTeamServiceClient(conn)
+		otherOrg := uuid.New().String()
+
+		// Without a token only the default organization can be selected
+		ctx := metadata.AppendToOutgoingContext(context.Background(), grpcserver.OrganizationMetadata, otherOrg)
+		_, err := teams.GetTeam(ctx, &pb.GetTeamRequest{TeamName: "backend"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		// A token acts only for its own organization
+		ctx = metadata.AppendToOutgoingContext(ctx, grpcserver.AuthorizationMetadata, "Bearer "+testToken)
+		_, err = teams.GetTeam(ctx, &pb.GetTeamRequest{TeamName: "backend"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+		ctx = metadata.AppendToOutgoingContext(context.Background(), grpcserver.AuthorizationMetadata, "Bearer unknown")
+		_, err = teams.GetTeam(ctx, &pb.GetTeamRequest{TeamName: "backend"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func TestGRPC_Reflection(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		conn := setupGRPC(t, tx)
+
+		stream, err := grpc_reflection_v1.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&grpc_reflection_v1.ServerReflectionRequest{
+			MessageRequest: &grpc_reflection_v1.ServerReflectionRequest_ListServices{},
+		}))
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+
+		var services []string
+		for _, service := range resp.GetListServicesResponse().GetService() {
+			services = append(services, service.Name)
+		}
+		assert.Subset(t, services, []string{
+			"reviewers.v1.TeamService",
+			"reviewers.v1.UserService",
+			"reviewers.v1.PullRequestService",
+		})
+	})
+}
@@ -5,13 +5,15 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"reviewers/internal/auth"
+	reviewersdb "reviewers/internal/db"
+	"reviewers/internal/events"
 	"reviewers/internal/handler"
+	"reviewers/internal/models"
+	"reviewers/internal/repository"
 	"testing"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-migrate/migrate"
-	migratepg "github.com/golang-migrate/migrate/database/postgres"
-	_ "github.com/golang-migrate/migrate/source/file"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 	"gorm.io/driver/postgres"
@@ -49,24 +51,34 @@ func TestMain(m *testing.M) {
 		panic(err)
 	}
 
-	// Run migrations
-	sqlDB, _ := db.DB()
-	driver, _ := migratepg.WithInstance(sqlDB, &migratepg.Config{})
-	mig, err := migrate.NewWithDatabaseInstance(
-		"file://../../migrations",
-		"postgres", driver)
+	// Run the migrations embedded into the binary
+	migrator, err := reviewersdb.NewMigrator(ctx, dsn)
 	if err != nil {
 		panic(err)
 	}
-	mig.Up()
+	if err := migrator.Up(); err != nil {
+		panic(err)
+	}
+	migrator.Close()
 
 	os.Exit(m.Run())
 }
 
+// testToken acts for the default organization.
+const testToken = "test-token"
+
+func testAuth() auth.Config {
+	return auth.Config{Tokens: map[string]string{testToken: models.DefaultOrganizationID}}
+}
+
 func setupRouter(tx *gorm.DB) *gin.Engine {
+	return setupRouterWithAuth(tx, testAuth())
+}
+
+func setupRouterWithAuth(tx *gorm.DB, authCfg auth.Config) *gin.Engine {
 	logger := slog.Default()
 	router := gin.Default()
-	handler.InitHandlers(logger, tx, router)
+	handler.InitHandlers(logger, repository.NewStores(tx, logger), router, events.NewBroker(100), handler.Options{Auth: authCfg})
 	return router
 }
 
@@ -0,0 +1,28 @@
+package integration_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestMetrics(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		req, _ := http.NewRequest("GET", "/team/get?team_name=unknown", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		req, _ = http.NewRequest("GET", "/metrics", nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `reviewers_http_requests_total{method="GET",route="/team/get",status="404"}`)
+		assert.Contains(t, w.Body.String(), "reviewers_pull_requests_created_total")
+	})
+}
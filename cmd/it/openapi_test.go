@@ -0,0 +1,254 @@
+package integration_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"reviewers/internal/openapi"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// contract serves requests and checks that the responses match the
+// OpenAPI spec, including their status codes.
+type contract struct {
+	t      *testing.T
+	engine *gin.Engine
+	routes routers.Router
+	// token authenticates the requests unless it is empty
+	token string
+}
+
+func newContract(t *testing.T, engine *gin.Engine) *contract {
+	openapi3filter.RegisterBodyDecoder("application/scim+json", openapi3filter.JSONBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", openapi3filter.PlainBodyDecoder)
+
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	routes, err := gorillamux.NewRouter(doc)
+	require.NoError(t, err)
+
+	return &contract{t, engine, routes, testToken}
+}
+
+func (c *contract) do(method, target string, body any) map[string]interface{} {
+	c.t.Helper()
+
+	// Bytes are sent as they are, other bodies as JSON
+	var reqBody []byte
+	switch body := body.(type) {
+	case nil:
+	case []byte:
+		reqBody = body
+	default:
+		reqBody, _ = json.Marshal(body)
+	}
+	req, _ := http.NewRequest(method, target, bytes.NewReader(reqBody))
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	w := httptest.NewRecorder()
+	c.engine.ServeHTTP(w, req)
+
+	route, pathParams, err := c.routes.FindRoute(req)
+	require.NoError(c.t, err, "%s %s is not in the spec", method, target)
+
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+		},
+		Status:  w.Code,
+		Header:  w.Header(),
+		Options: &openapi3filter.Options{IncludeResponseStatus: true},
+	}
+	err = openapi3filter.ValidateResponse(req.Context(), input.SetBodyBytes(w.Body.Bytes()))
+	assert.NoError(c.t, err, "%s %s: %d %s", method, target, w.Code, w.Body.String())
+
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+func TestOpenAPI_AllRoutesDocumented(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		doc, err := openapi.Load()
+		require.NoError(t, err)
+
+		param := regexp.MustCompile(`:(\w+)`)
+		for _, route := range r.Routes() {
+			path := param.ReplaceAllString(route.Path, "{$1}")
+			item := doc.Paths.Find(path)
+			if assert.NotNil(t, item, "%s is not in the spec", route.Path) {
+				assert.NotNil(t, item.GetOperation(route.Method), "%s %s is not in the spec", route.Method, route.Path)
+			}
+		}
+	})
+}
+
+func TestOpenAPI_Contract(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		c := newContract(t, setupRouter(tx))
+
+		resp := c.do("POST", "/team/add", map[string]interface{}{
+			"team_name": "squad",
+			"members": []map[string]interface{}{
+				{"username": "author", "is_active": true},
+				{"username": "first", "is_active": true},
+				{"username": "second", "is_active": true},
+			},
+		})
+		members := resp["members"].([]interface{})
+		userID := func(i int) string {
+			return members[i].(map[string]interface{})["user_id"].(string)
+		}
+
+		c.do("POST", "/team/add", map[string]interface{}{"team_name": "squad"})
+		c.do("POST", "/team/add", map[string]interface{}{"team_name": ""})
+		c.do("GET", "/team/get?team_name=squad&include_subteams=true", nil)
+		c.do("GET", "/team/get?team_name=unknown", nil)
+		c.do("GET", "/team/stats?team_name=squad", nil)
+		c.do("POST", "/team/setParent", map[string]interface{}{"team_name": "squad", "parent_team_name": "squad"})
+
+		c.do("POST", "/repository/add", map[string]interface{}{"name": "backend", "team_name": "squad"})
+		c.do("POST", "/repository/update", map[string]interface{}{"name": "backend", "reviewers_count": 1})
+		c.do("GET", "/repository/get?name=backend", nil)
+		c.do("GET", "/repository/get", nil)
+
+		c.do("POST", "/codeOwners/set", map[string]interface{}{
+			"repository": "backend",
+			"rules":      []map[string]interface{}{{"pattern": "*.go", "owners": []string{userID(1)}}},
+		})
+		c.do("GET", "/codeOwners/get?repository=backend", nil)
+
+		pr := map[string]interface{}{
+			"pull_request_id":   "pr-0001",
+			"pull_request_name": "test",
+			"author_id":         userID(0),
+			"repository":        "backend",
+			"changed_files":     []string{"main.go"},
+		}
+		c.do("POST", "/pullRequest/create", pr)
+		c.do("POST", "/pullRequest/create", pr)
+		ref := map[string]interface{}{"repository": "backend", "pull_request_id": "pr-0001"}
+		c.do("POST", "/pullRequest/approve", merge(ref, map[string]interface{}{"reviewer_id": userID(1)}))
+		c.do("POST", "/pullRequest/reassign", merge(ref, map[string]interface{}{"old_reviewer_id": userID(1)}))
+		c.do("POST", "/pullRequest/reassign", merge(ref, map[string]interface{}{"old_reviewer_id": userID(0)}))
+		c.do("GET", "/users/getReview?user_id="+userID(2), nil)
+		c.do("GET", "/users/getReview?user_id="+userID(2)+"&status=OPEN&limit=1&include_total=true", nil)
+		c.do("GET", "/pullRequest/list?sort=name&limit=1", nil)
+		c.do("GET", "/pullRequest/list?sort=author", nil)
+		c.do("GET", "/pullRequest/list?team_name=squad&name=te&min_age=1h&max_age=7d", nil)
+		c.do("GET", "/pullRequest/list?author_id="+userID(0)+"&reviewer_id="+userID(2), nil)
+		c.do("GET", "/pullRequest/list?min_age=soon", nil)
+		c.do("GET", "/pullRequest/get?repository=backend&pull_request_id=pr-0001", nil)
+		c.do("GET", "/pullRequest/get?pull_request_id=unknown", nil)
+		c.do("POST", "/pullRequest/merge", ref)
+		c.do("POST", "/pullRequest/merge", ref)
+
+		c.do("GET", "/stats/reviewers?team_name=squad", nil)
+		c.do("GET", "/stats/reviewers?from=yesterday", nil)
+		c.do("GET", "/stats/teams/squad?from=2000-01-01", nil)
+		c.do("GET", "/stats/teams/squad?format=csv", nil)
+
+		c.do("POST", "/users/create", map[string]interface{}{"username": "third", "team_name": "squad", "email": "third@example.com"})
+		c.do("POST", "/users/create", map[string]interface{}{"username": "third", "team_name": "squad"})
+		c.do("POST", "/users/update", map[string]interface{}{"user_id": userID(2), "username": "renamed", "email": ""})
+		c.do("GET", "/users/get?user_id="+userID(2), nil)
+		c.do("GET", "/users/get?user_id="+uuid.New().String(), nil)
+		c.do("POST", "/users/delete", map[string]interface{}{"user_id": uuid.New().String()})
+		c.do("POST", "/users/setIsActive", map[string]interface{}{"user_id": userID(2), "is_active": false})
+		c.do("POST", "/team/deactivate?team_id="+uuid.New().String(), nil)
+
+		c.do("POST", "/graphql", map[string]interface{}{
+			"query":     `query($name: String!) { team(name: $name) { name members { username reviews { id author { username } } } } }`,
+			"variables": map[string]interface{}{"name": "squad"},
+		})
+		c.do("POST", "/graphql", map[string]interface{}{"query": "{ unknown }"})
+		c.do("POST", "/graphql", map[string]interface{}{})
+
+		c.do("POST", "/admin/import?format=csv&dry_run=true", []byte("team,parent,username\nsquad,,fourth\ncore,squad,\n"))
+		c.do("POST", "/admin/import?format=yaml", []byte("teams:\n  - name: squad\n    members:\n      - username: fifth\n        email: fifth\n"))
+		c.do("POST", "/admin/import", []byte("team\nsquad\n"))
+		c.do("GET", "/admin/export", nil)
+		c.do("POST", "/admin/restore", []byte(`{"type":"header","version":1}`+"\n"+`{"type":"end","records":0}`+"\n"))
+		c.do("POST", "/admin/restore", []byte(`{"type":"header","version":2}`+"\n"))
+		c.token = ""
+		c.do("POST", "/admin/import", []byte("team\nsquad\n"))
+		c.do("GET", "/admin/export", nil)
+		c.do("POST", "/admin/restore", []byte(`{"type":"header","version":1}`+"\n"))
+		c.token = testToken
+
+		scimUser := c.do("POST", "/scim/v2/Users", map[string]interface{}{
+			"userName": "provisioned",
+			"emails":   []map[string]interface{}{{"value": "provisioned@example.com", "primary": true}},
+		})
+		c.do("POST", "/scim/v2/Users", map[string]interface{}{"userName": "provisioned"})
+		c.do("POST", "/scim/v2/Users", map[string]interface{}{})
+		scimUserID := scimUser["id"].(string)
+		c.do("GET", "/scim/v2/ServiceProviderConfig", nil)
+		c.do("GET", "/scim/v2/ResourceTypes", nil)
+		c.token = ""
+		c.do("GET", "/scim/v2/ResourceTypes", nil)
+		c.token = testToken
+		c.do("GET", "/scim/v2/Users?filter="+url.QueryEscape(`userName eq "provisioned"`), nil)
+		c.do("GET", "/scim/v2/Users?filter="+url.QueryEscape(`userName sw "p"`), nil)
+		c.do("GET", "/scim/v2/Users/"+scimUserID, nil)
+		c.do("GET", "/scim/v2/Users/unknown", nil)
+		c.do("PUT", "/scim/v2/Users/"+scimUserID, map[string]interface{}{"userName": "provisioned", "active": true})
+		c.do("PATCH", "/scim/v2/Users/"+scimUserID, map[string]interface{}{
+			"Operations": []map[string]interface{}{{"op": "Replace", "path": "active", "value": "False"}},
+		})
+		c.do("PATCH", "/scim/v2/Users/"+scimUserID, map[string]interface{}{
+			"Operations": []map[string]interface{}{{"op": "remove", "path": "userName"}},
+		})
+		scimGroup := c.do("POST", "/scim/v2/Groups", map[string]interface{}{
+			"displayName": "platform",
+			"members":     []map[string]interface{}{{"value": scimUserID}},
+		})
+		c.do("POST", "/scim/v2/Groups", map[string]interface{}{"displayName": "platform"})
+		scimGroupID := scimGroup["id"].(string)
+		c.do("GET", "/scim/v2/Groups?filter="+url.QueryEscape(`displayName eq "platform"`)+"&excludedAttributes=members", nil)
+		c.do("GET", "/scim/v2/Groups/"+scimGroupID, nil)
+		c.do("PUT", "/scim/v2/Groups/"+scimGroupID, map[string]interface{}{"displayName": "platform", "members": []interface{}{}})
+		c.do("PATCH", "/scim/v2/Groups/"+scimGroupID, map[string]interface{}{
+			"Operations": []map[string]interface{}{{"op": "add", "path": "members", "value": []map[string]interface{}{{"value": uuid.New().String()}}}},
+		})
+		c.do("DELETE", "/scim/v2/Groups/"+scimGroupID, nil)
+		c.do("DELETE", "/scim/v2/Groups/"+uuid.New().String(), nil)
+		c.do("DELETE", "/scim/v2/Users/"+scimUserID, nil)
+
+		c.do("GET", "/events/stream?user_id=user", nil)
+		c.do("GET", "/events/stream?team_name=unknown", nil)
+
+		c.do("GET", "/organization/get", nil)
+		c.do("POST", "/organization/add", map[string]interface{}{"name": "acme"})
+		c.token = ""
+		c.do("POST", "/organization/add", map[string]interface{}{"name": "acme"})
+		c.token = testToken
+		c.do("GET", "/metrics", nil)
+		c.do("GET", "/openapi.json", nil)
+	})
+}
+
+func merge(a, b map[string]interface{}) map[string]interface{} {
+	result := maps.Clone(a)
+	maps.Copy(result, b)
+	return result
+}
@@ -0,0 +1,152 @@
+package integration_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reviewers/internal/auth"
+	"reviewers/internal/handler"
+	"reviewers/internal/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// organizationRequest sends the request with the bearer token and the
+// organization header, empty ones are left out.
+func organizationRequest(r *gin.Engine, method, target, token, orgID string, body interface{}) *httptest.ResponseRecorder {
+	reqBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, target, bytes.NewBuffer(reqBody))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if orgID != "" {
+		req.Header.Set(handler.OrganizationHeader, orgID)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func createOrganization(t *testing.T, tx *gorm.DB, name string) string {
+	w := organizationRequest(setupRouter(tx), "POST", "/organization/add", testToken, "", map[string]interface{}{"name": name})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var org map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &org)
+	return org["organization_id"].(string)
+}
+
+func TestOrganizationIsolation(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		orgID := createOrganization(t, tx, "acme")
+		r := setupRouterWithAuth(tx, auth.Config{Tokens: map[string]string{"acme-token": orgID}})
+
+		// Team with the same name in both organizations
+		team := map[string]interface{}{
+			"team_name": "backend",
+			"members":   []map[string]interface{}{{"username": "igor", "is_active": true}},
+		}
+		for _, token := range []string{"", "acme-token"} {
+			w := organizationRequest(r, "POST", "/team/add", token, "", team)
+			assert.Equal(t, http.StatusOK, w.Code)
+		}
+
+		var defaultTeam, acmeTeam map[string]interface{}
+		w := organizationRequest(r, "GET", "/team/get?team_name=backend", "", "", nil)
+		json.Unmarshal(w.Body.Bytes(), &defaultTeam)
+
+		w = organizationRequest(r, "GET", "/team/get?team_name=backend", "acme-token", orgID, nil)
+		json.Unmarshal(w.Body.Bytes(), &acmeTeam)
+
+		defaultMember := defaultTeam["members"].([]interface{})[0].(map[string]interface{})
+		acmeMember := acmeTeam["members"].([]interface{})[0].(map[string]interface{})
+		assert.NotEqual(t, defaultMember["user_id"], acmeMember["user_id"])
+
+		// Users of another organization are not visible
+		w = organizationRequest(r, "GET", "/users/getReview?user_id="+acmeMember["user_id"].(string), "", "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = organizationRequest(r, "POST", "/users/setIsActive", "", "", map[string]interface{}{
+			"user_id":   acmeMember["user_id"],
+			"is_active": false,
+		})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestOrganization_OtherOrganizationRejected(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		orgID := createOrganization(t, tx, "acme")
+		r := setupRouterWithAuth(tx, auth.Config{Tokens: map[string]string{"acme-token": orgID}})
+
+		// Without a token only the default organization can be selected
+		w := organizationRequest(r, "GET", "/organization/get", "", orgID, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+
+		w = organizationRequest(r, "GET", "/organization/get", "", models.DefaultOrganizationID, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// A token acts only for its own organization
+		w = organizationRequest(r, "GET", "/organization/get", "acme-token", models.DefaultOrganizationID, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		var org map[string]interface{}
+		w = organizationRequest(r, "GET", "/organization/get", "acme-token", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		json.Unmarshal(w.Body.Bytes(), &org)
+		assert.Equal(t, orgID, org["organization_id"])
+
+		w = organizationRequest(r, "GET", "/organization/get", "unknown-token", "", nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		req, _ := http.NewRequest("GET", "/organization/get", nil)
+		req.Header.Set("Authorization", "Basic YWNtZTphY21l")
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		// Behind a trusted proxy the header alone selects the organization
+		trusted := setupRouterWithAuth(tx, auth.Config{TrustOrganizationHeader: true})
+		w = organizationRequest(trusted, "GET", "/organization/get", "", orgID, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestOrganization_CreateRequiresToken(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		// Not even a trusted proxy may create organizations without a token
+		for _, r := range []*gin.Engine{setupRouter(tx), setupRouterWithAuth(tx, auth.Config{TrustOrganizationHeader: true})} {
+			w := organizationRequest(r, "POST", "/organization/add", "", "", map[string]interface{}{"name": "acme"})
+			assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+			assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+		}
+
+		w := organizationRequest(setupRouter(tx), "POST", "/organization/add", "unknown-token", "", map[string]interface{}{"name": "acme"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+
+		var organizations int64
+		tx.Model(&models.Organization{}).Where("name = ?", "acme").Count(&organizations)
+		assert.Zero(t, organizations)
+	})
+}
+
+func TestOrganizationHeader_Invalid(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		w := organizationRequest(r, "GET", "/team/get?team_name=backend", "", "not-a-uuid", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		// The token of a deleted organization
+		r = setupRouterWithAuth(tx, auth.Config{Tokens: map[string]string{"lost-token": uuid.New().String()}})
+		w = organizationRequest(r, "GET", "/team/get?team_name=backend", "lost-token", "", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,386 @@
+package integration_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reviewers/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestCreatePR_ClimbsTeamHierarchy(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		department := models.Team{
+			ID:   uuid.New().String(),
+			Name: "department",
+			Members: []models.User{
+				{ID: uuid.New().String(), Username: "lead", IsActive: true},
+			},
+		}
+		tx.Create(&department)
+
+		squad := models.Team{
+			ID:       uuid.New().String(),
+			Name:     "squad",
+			ParentID: &department.ID,
+			Members: []models.User{
+				{ID: uuid.New().String(), Username: "author", IsActive: true},
+				{ID: uuid.New().String(), Username: "peer", IsActive: true},
+			},
+		}
+		tx.Create(&squad)
+
+		reqBody, _ := json.Marshal(map[string]interface{}{
+			"pull_request_id":   "pr-0001",
+			"pull_request_name": "test",
+			"author_id":         squad.Members[0].ID,
+		})
+		req, _ := http.NewRequest("POST", "/pullRequest/create", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.ElementsMatch(t, []interface{}{
+			squad.Members[1].ID,
+			department.Members[0].ID,
+		}, resp["pr"]["assigned_reviewers"])
+	})
+}
+
+func TestCreatePR_CodeOwnersFirst(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		team := models.Team{
+			ID:   uuid.New().String(),
+			Name: "squad",
+			Members: []models.User{
+				{ID: uuid.New().String(), Username: "author", IsActive: true},
+				{ID: uuid.New().String(), Username: "peer1", IsActive: true},
+				{ID: uuid.New().String(), Username: "peer2", IsActive: true},
+			},
+		}
+		tx.Create(&team)
+
+		owners := models.Team{
+			ID:   uuid.New().String(),
+			Name: "platform",
+			Members: []models.User{
+				{ID: uuid.New().String(), Username: "owner", IsActive: true},
+			},
+		}
+		tx.Create(&owners)
+		tx.Create(&models.Repository{ID: uuid.New().String(), Name: "backend", ReviewersCount: 2})
+
+		reqBody, _ := json.Marshal(map[string]interface{}{
+			"repository": "backend",
+			"rules": []map[string]interface{}{
+				{"pattern": "*", "owners": []string{team.Members[1].ID}},
+				{"pattern": "/deploy/", "owners": []string{owners.Members[0].ID}},
+			},
+		})
+		req, _ := http.NewRequest("POST", "/codeOwners/set", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		reqBody, _ = json.Marshal(map[string]interface{}{
+			"pull_request_id":   "pr-0001",
+			"pull_request_name": "test",
+			"author_id":         team.Members[0].ID,
+			"repository":        "backend",
+			"changed_files":     []string{"deploy/values.yaml"},
+		})
+		req, _ = http.NewRequest("POST", "/pullRequest/create", bytes.NewBuffer(reqBody))
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		reviewers := resp["pr"]["assigned_reviewers"].([]interface{})
+		assert.Len(t, reviewers, 2)
+		assert.Equal(t, owners.Members[0].ID, reviewers[0])
+		assert.Equal(t, []interface{}{"deploy/values.yaml"}, resp["pr"]["changed_files"])
+	})
+}
+
+func TestCreatePR_ScopedByRepository(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		team := models.Team{
+			ID:   uuid.New().String(),
+			Name: "squad",
+			Members: []models.User{
+				{ID: uuid.New().String(), Username: "author", IsActive: true},
+				{ID: uuid.New().String(), Username: "peer1", IsActive: true},
+				{ID: uuid.New().String(), Username: "peer2", IsActive: true},
+			},
+		}
+		tx.Create(&team)
+
+		// Repository with its own policy
+		reqBody, _ := json.Marshal(map[string]interface{}{
+			"name":            "frontend",
+			"team_name":       "squad",
+			"reviewers_count": 1,
+		})
+		req, _ := http.NewRequest("POST", "/repository/add", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var repo map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &repo)
+		assert.Equal(t, map[string]interface{}{
+			"name":            "frontend",
+			"team_name":       "squad",
+			"reviewers_count": float64(1),
+			"climb_hierarchy": true,
+		}, repo)
+
+		// Same PR ID in two repositories
+		for _, repository := range []string{"", "frontend"} {
+			reqBody, _ = json.Marshal(map[string]interface{}{
+				"repository":        repository,
+				"pull_request_id":   "PR-1",
+				"pull_request_name": "test",
+				"author_id":         team.Members[0].ID,
+			})
+			req, _ = http.NewRequest("POST", "/pullRequest/create", bytes.NewBuffer(reqBody))
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+		}
+
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, "frontend", resp["pr"]["repository"])
+		assert.Len(t, resp["pr"]["assigned_reviewers"], 1)
+
+		// Merge is scoped by repository
+		reqBody, _ = json.Marshal(map[string]interface{}{
+			"repository":      "frontend",
+			"pull_request_id": "PR-1",
+		})
+		req, _ = http.NewRequest("POST", "/pullRequest/merge", bytes.NewBuffer(reqBody))
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var statuses []string
+		tx.Model(&models.PullRequest{}).Where("pull_request_id = ?", "PR-1").Order("status").Pluck("status", &statuses)
+		assert.Equal(t, []string{models.StatusOpen, models.StatusMerged}, statuses)
+	})
+}
+
+func TestCreatePR_Validation(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		reqBody, _ := json.Marshal(map[string]interface{}{
+			"pull_request_id":   "pr 0001",
+			"pull_request_name": " ",
+			"author_id":         "author",
+		})
+		req, _ := http.NewRequest("POST", "/pullRequest/create", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, "VALIDATION_FAILED", resp["error"]["code"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{
+				"field":   "pull_request_id",
+				"message": "may contain only letters, digits, '.', '_' and '-'",
+			},
+			map[string]interface{}{"field": "pull_request_name", "message": "must not be blank"},
+			map[string]interface{}{"field": "author_id", "message": "must be a UUID"},
+		}, resp["error"]["fields"])
+	})
+}
+
+func TestListPR_FollowsCursors(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+		createListedPullRequests(t, tx)
+
+		list := func(query string) (int, map[string]interface{}) {
+			req, _ := http.NewRequest("GET", "/pullRequest/list?"+query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			var resp map[string]interface{}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			return w.Code, resp
+		}
+
+		var ids []string
+		query := "sort=name&limit=1"
+		for {
+			code, resp := list(query)
+			require.Equal(t, http.StatusOK, code)
+			ids = append(ids, pullRequestIDs(resp)...)
+			cursor, ok := resp["next_cursor"].(string)
+			if !ok {
+				break
+			}
+			query = "sort=name&limit=1&cursor=" + cursor
+		}
+		assert.Equal(t, []string{"pr-1", "pr-2", "pr-3"}, ids)
+
+		code, resp := list("status=MERGED&include_total=true")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"pr-2"}, pullRequestIDs(resp))
+		assert.Equal(t, float64(1), resp["total"])
+
+		_, resp = list("limit=1")
+		code, _ = list("sort=name&cursor=" + resp["next_cursor"].(string))
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = list("cursor=invalid")
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, resp = list("sort=author&limit=0")
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"field": "sort", "message": "must be one of created_at, -created_at, name, -name"},
+		}, resp["error"].(map[string]interface{})["fields"])
+	})
+}
+
+func TestListPR_Filters(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+		reviewerID := createListedPullRequests(t, tx)
+		var authorID string
+		require.NoError(t, tx.Model(&models.User{}).Where("username = ?", "author").Pluck("user_id", &authorID).Error)
+
+		list := func(query string) (int, map[string]interface{}) {
+			w := performRequest(r, "GET", "/pullRequest/list?"+query, nil)
+			var resp map[string]interface{}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			return w.Code, resp
+		}
+
+		// Only pr-2 and pr-3 are younger than that
+		maxAge := time.Since(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)).Round(time.Hour).String()
+
+		for query, expected := range map[string][]string{
+			"author_id=" + authorID:                      {"pr-3", "pr-2", "pr-1"},
+			"author_id=" + reviewerID:                    {},
+			"reviewer_id=" + reviewerID + "&status=OPEN": {"pr-3", "pr-1"},
+			"team_name=listed&sort=name":                 {"pr-1", "pr-2", "pr-3"},
+			"team_name=unknown":                          {},
+			"name=GE%202":                                {"pr-2"},
+			"name=%25":                                   {},
+			"min_age=7d&max_age=" + maxAge:               {"pr-3", "pr-2"},
+			"max_age=24h":                                {},
+		} {
+			code, resp := list(query)
+			require.Equal(t, http.StatusOK, code, query)
+			assert.Equal(t, expected, pullRequestIDs(resp), query)
+		}
+
+		code, resp := list("author_id=author")
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"field": "author_id", "message": "must be a UUID"},
+		}, resp["error"].(map[string]interface{})["fields"])
+
+		code, resp = list("min_age=-1h")
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"field": "min_age", "message": "must be a positive duration such as 36h or 7d"},
+		}, resp["error"].(map[string]interface{})["fields"])
+	})
+}
+
+func TestGetPR_ReviewerDetails(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		w := performRequest(r, "POST", "/team/add", map[string]interface{}{
+			"team_name": "squad",
+			"members": []map[string]interface{}{
+				{"username": "author", "is_active": true},
+				{"username": "first", "is_active": true},
+				{"username": "second", "is_active": true},
+			},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var team struct {
+			Members []struct {
+				UserID   string `json:"user_id"`
+				Username string `json:"username"`
+			} `json:"members"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &team))
+		userIDs := map[string]string{}
+		for _, member := range team.Members {
+			userIDs[member.Username] = member.UserID
+		}
+
+		w = performRequest(r, "POST", "/pullRequest/create", map[string]interface{}{
+			"pull_request_id":   "pr-1",
+			"pull_request_name": "test",
+			"author_id":         userIDs["author"],
+			"changed_files":     []string{"main.go"},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		w = performRequest(r, "POST", "/pullRequest/approve", map[string]interface{}{
+			"pull_request_id": "pr-1",
+			"reviewer_id":     userIDs["first"],
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = performRequest(r, "GET", "/pullRequest/get?pull_request_id=pr-1", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			PR struct {
+				ID                string   `json:"pull_request_id"`
+				ChangedFiles      []string `json:"changed_files"`
+				AssignedReviewers []string `json:"assigned_reviewers"`
+				Reviewers         []struct {
+					UserID     string     `json:"user_id"`
+					Username   string     `json:"username"`
+					TeamName   string     `json:"team_name"`
+					IsActive   bool       `json:"is_active"`
+					ApprovedAt *time.Time `json:"approved_at"`
+				} `json:"reviewers"`
+			} `json:"pr"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "pr-1", resp.PR.ID)
+		assert.Equal(t, []string{"main.go"}, resp.PR.ChangedFiles)
+		require.Len(t, resp.PR.Reviewers, 2)
+		assert.Equal(t, []string{userIDs["first"], userIDs["second"]}, resp.PR.AssignedReviewers)
+
+		first, second := resp.PR.Reviewers[0], resp.PR.Reviewers[1]
+		assert.Equal(t, "first", first.Username)
+		assert.Equal(t, "squad", first.TeamName)
+		assert.True(t, first.IsActive)
+		assert.NotNil(t, first.ApprovedAt)
+		assert.Equal(t, "second", second.Username)
+		assert.Nil(t, second.ApprovedAt)
+
+		w = performRequest(r, "GET", "/pullRequest/get?pull_request_id=unknown", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = performRequest(r, "GET", "/pullRequest/get", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
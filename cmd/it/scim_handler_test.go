@@ -0,0 +1,368 @@
+package integration_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reviewers/internal/auth"
+	"reviewers/internal/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type scimResource struct {
+	ID          string `json:"id"`
+	UserName    string `json:"userName"`
+	DisplayName string `json:"displayName"`
+	Active      bool   `json:"active"`
+	Emails      []struct {
+		Value string `json:"value"`
+	} `json:"emails"`
+	Groups []struct {
+		Value   string `json:"value"`
+		Display string `json:"display"`
+	} `json:"groups"`
+	Members []struct {
+		Value   string `json:"value"`
+		Display string `json:"display"`
+	} `json:"members"`
+}
+
+// performSCIMRequest performs the request with the token of the default
+// organization, as SCIM clients of the service do.
+func performSCIMRequest(r *gin.Engine, method, target string, body interface{}) *httptest.ResponseRecorder {
+	reqBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, target, bytes.NewBuffer(reqBody))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func scimRequest(t *testing.T, r *gin.Engine, method, target string, body interface{}, status int) scimResource {
+	t.Helper()
+
+	w := performSCIMRequest(r, method, target, body)
+	require.Equal(t, status, w.Code, w.Body.String())
+
+	var resource scimResource
+	if w.Body.Len() > 0 {
+		assert.Equal(t, "application/scim+json", w.Header().Get("Content-Type"))
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resource))
+	}
+	return resource
+}
+
+func provisionUser(t *testing.T, r *gin.Engine, username string) string {
+	t.Helper()
+
+	user := scimRequest(t, r, "POST", "/scim/v2/Users", map[string]interface{}{
+		"schemas":  []string{"urn:ietf:params:scim:schemas:core:2.0:User"},
+		"userName": username,
+		"active":   true,
+	}, http.StatusCreated)
+	return user.ID
+}
+
+func scimError(t *testing.T, w *httptest.ResponseRecorder, status int) map[string]interface{} {
+	t.Helper()
+	require.Equal(t, status, w.Code, w.Body.String())
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []interface{}{"urn:ietf:params:scim:api:messages:2.0:Error"}, resp["schemas"])
+	return resp
+}
+
+func TestSCIM_Users(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		w := performSCIMRequest(r, "POST", "/scim/v2/Users", map[string]interface{}{
+			"userName": "alice",
+			"emails": []map[string]interface{}{
+				{"value": "alice@home.example.com", "type": "home"},
+				{"value": "alice@example.com", "type": "work", "primary": true},
+			},
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var alice scimResource
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &alice))
+		assert.Equal(t, "/scim/v2/Users/"+alice.ID, w.Header().Get("Location"))
+		assert.True(t, alice.Active)
+		require.Len(t, alice.Emails, 1)
+		assert.Equal(t, "alice@example.com", alice.Emails[0].Value)
+		require.Len(t, alice.Groups, 1)
+		assert.Equal(t, "unassigned", alice.Groups[0].Display)
+
+		found := scimRequest(t, r, "GET", "/scim/v2/Users/"+alice.ID, nil, http.StatusOK)
+		assert.Equal(t, alice, found)
+
+		var list struct {
+			TotalResults int64          `json:"totalResults"`
+			Resources    []scimResource `json:"Resources"`
+		}
+		w = performSCIMRequest(r, "GET", "/scim/v2/Users?filter="+url.QueryEscape(`userName eq "ALICE"`), nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		assert.Equal(t, int64(1), list.TotalResults)
+		require.Len(t, list.Resources, 1)
+		assert.Equal(t, alice.ID, list.Resources[0].ID)
+
+		provisionUser(t, r, "bob")
+		w = performSCIMRequest(r, "GET", "/scim/v2/Users?startIndex=2&count=1", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		list.Resources = nil
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		assert.Equal(t, int64(2), list.TotalResults)
+		require.Len(t, list.Resources, 1)
+		assert.Equal(t, "bob", list.Resources[0].UserName)
+
+		patched := scimRequest(t, r, "PATCH", "/scim/v2/Users/"+alice.ID, map[string]interface{}{
+			"schemas": []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+			"Operations": []map[string]interface{}{
+				{"op": "Replace", "value": map[string]interface{}{"userName": "alice.smith"}},
+				{"op": "replace", "path": `emails[type eq "work"].value`, "value": "smith@example.com"},
+			},
+		}, http.StatusOK)
+		assert.Equal(t, "alice.smith", patched.UserName)
+		require.Len(t, patched.Emails, 1)
+		assert.Equal(t, "smith@example.com", patched.Emails[0].Value)
+
+		replaced := scimRequest(t, r, "PUT", "/scim/v2/Users/"+alice.ID, map[string]interface{}{
+			"userName": "alice",
+		}, http.StatusOK)
+		assert.Equal(t, "alice", replaced.UserName)
+		assert.Empty(t, replaced.Emails)
+		assert.True(t, replaced.Active)
+
+		// Deleted users are deactivated and kept
+		w = performSCIMRequest(r, "DELETE", "/scim/v2/Users/"+alice.ID, nil)
+		require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+		deleted := scimRequest(t, r, "GET", "/scim/v2/Users/"+alice.ID, nil, http.StatusOK)
+		assert.False(t, deleted.Active)
+
+		reactivated := scimRequest(t, r, "PATCH", "/scim/v2/Users/"+alice.ID, map[string]interface{}{
+			"Operations": []map[string]interface{}{{"op": "replace", "path": "active", "value": "True"}},
+		}, http.StatusOK)
+		assert.True(t, reactivated.Active)
+	})
+}
+
+func TestSCIM_DeactivationReassignsReviews(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+		authorID := provisionUser(t, r, "author")
+		members := []map[string]interface{}{{"value": authorID}}
+		for _, username := range []string{"first", "second", "third"} {
+			members = append(members, map[string]interface{}{"value": provisionUser(t, r, username)})
+		}
+		scimRequest(t, r, "POST", "/scim/v2/Groups", map[string]interface{}{
+			"displayName": "backend",
+			"members":     members,
+		}, http.StatusCreated)
+
+		w := performRequest(r, "POST", "/pullRequest/create", map[string]interface{}{
+			"pull_request_id":   "pr-1",
+			"pull_request_name": "test",
+			"author_id":         authorID,
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var created struct {
+			PR struct {
+				AssignedReviewers []string `json:"assigned_reviewers"`
+			} `json:"pr"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		require.Len(t, created.PR.AssignedReviewers, 2)
+		deactivated, kept := created.PR.AssignedReviewers[0], created.PR.AssignedReviewers[1]
+
+		user := scimRequest(t, r, "PATCH", "/scim/v2/Users/"+deactivated, map[string]interface{}{
+			"Operations": []map[string]interface{}{{"op": "replace", "path": "active", "value": false}},
+		}, http.StatusOK)
+		assert.False(t, user.Active)
+
+		var reviewers []string
+		tx.Model(&models.PullRequestReviewer{}).Where("pull_request_id = ?", "pr-1").Pluck("user_id", &reviewers)
+		assert.Len(t, reviewers, 2)
+		assert.Contains(t, reviewers, kept)
+		assert.NotContains(t, reviewers, deactivated)
+
+		// Nobody is left to take over the review, the user stays active
+		// with it
+		w = performSCIMRequest(r, "DELETE", "/scim/v2/Users/"+kept, nil)
+		scimError(t, w, http.StatusConflict)
+		user = scimRequest(t, r, "GET", "/scim/v2/Users/"+kept, nil, http.StatusOK)
+		assert.True(t, user.Active)
+
+		// Other changes of a replaced user are kept back with the
+		// deactivation
+		w = performSCIMRequest(r, "PUT", "/scim/v2/Users/"+kept, map[string]interface{}{
+			"userName": "renamed",
+			"active":   false,
+		})
+		scimError(t, w, http.StatusConflict)
+		renamed := scimRequest(t, r, "GET", "/scim/v2/Users/"+kept, nil, http.StatusOK)
+		assert.Equal(t, user.UserName, renamed.UserName)
+		assert.True(t, renamed.Active)
+		reviewers = nil
+		tx.Model(&models.PullRequestReviewer{}).Where("pull_request_id = ?", "pr-1").Pluck("user_id", &reviewers)
+		assert.Contains(t, reviewers, kept)
+	})
+}
+
+func TestSCIM_Groups(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+		aliceID := provisionUser(t, r, "alice")
+		bobID := provisionUser(t, r, "bob")
+
+		w := performSCIMRequest(r, "POST", "/scim/v2/Groups", map[string]interface{}{
+			"displayName": "backend",
+			"members":     []map[string]interface{}{{"value": aliceID}},
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var group scimResource
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &group))
+		assert.Equal(t, "/scim/v2/Groups/"+group.ID, w.Header().Get("Location"))
+		require.Len(t, group.Members, 1)
+		assert.Equal(t, "alice", group.Members[0].Display)
+
+		user := scimRequest(t, r, "GET", "/scim/v2/Users/"+aliceID, nil, http.StatusOK)
+		require.Len(t, user.Groups, 1)
+		assert.Equal(t, group.ID, user.Groups[0].Value)
+
+		group = scimRequest(t, r, "PATCH", "/scim/v2/Groups/"+group.ID, map[string]interface{}{
+			"Operations": []map[string]interface{}{
+				{"op": "Add", "path": "members", "value": []map[string]interface{}{{"value": bobID}}},
+				{"op": "Remove", "path": `members[value eq "` + aliceID + `"]`},
+				{"op": "Replace", "path": "displayName", "value": "platform"},
+			},
+		}, http.StatusOK)
+		assert.Equal(t, "platform", group.DisplayName)
+		require.Len(t, group.Members, 1)
+		assert.Equal(t, bobID, group.Members[0].Value)
+
+		// Users removed from a group are moved to the unassigned team
+		user = scimRequest(t, r, "GET", "/scim/v2/Users/"+aliceID, nil, http.StatusOK)
+		require.Len(t, user.Groups, 1)
+		assert.Equal(t, "unassigned", user.Groups[0].Display)
+
+		group = scimRequest(t, r, "PUT", "/scim/v2/Groups/"+group.ID, map[string]interface{}{
+			"displayName": "platform",
+			"members":     []map[string]interface{}{{"value": aliceID}, {"value": bobID}},
+		}, http.StatusOK)
+		assert.Len(t, group.Members, 2)
+
+		var list struct {
+			TotalResults int64          `json:"totalResults"`
+			Resources    []scimResource `json:"Resources"`
+		}
+		w = performSCIMRequest(r, "GET", "/scim/v2/Groups?filter="+url.QueryEscape(`displayName eq "Platform"`)+"&excludedAttributes=members", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		assert.Equal(t, int64(1), list.TotalResults)
+		require.Len(t, list.Resources, 1)
+		assert.Equal(t, group.ID, list.Resources[0].ID)
+		assert.Empty(t, list.Resources[0].Members)
+
+		w = performSCIMRequest(r, "DELETE", "/scim/v2/Groups/"+group.ID, nil)
+		require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+		w = performSCIMRequest(r, "GET", "/scim/v2/Groups/"+group.ID, nil)
+		scimError(t, w, http.StatusNotFound)
+
+		var teamNames []string
+		tx.Model(&models.User{}).
+			Joins("JOIN teams ON teams.team_id = users.team_id").
+			Where("users.user_id IN ?", []string{aliceID, bobID}).
+			Pluck("teams.name", &teamNames)
+		assert.Equal(t, []string{"unassigned", "unassigned"}, teamNames)
+
+		// The unassigned team holds the users and cannot be deleted
+		w = performSCIMRequest(r, "GET", "/scim/v2/Groups?filter="+url.QueryEscape(`displayName eq "unassigned"`), nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		require.Len(t, list.Resources, 1)
+		w = performSCIMRequest(r, "DELETE", "/scim/v2/Groups/"+list.Resources[0].ID, nil)
+		scimError(t, w, http.StatusBadRequest)
+	})
+}
+
+func TestSCIM_Errors(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+		provisionUser(t, r, "alice")
+
+		w := performSCIMRequest(r, "POST", "/scim/v2/Users", map[string]interface{}{"userName": "alice"})
+		resp := scimError(t, w, http.StatusConflict)
+		assert.Equal(t, "409", resp["status"])
+		assert.Equal(t, "uniqueness", resp["scimType"])
+
+		w = performSCIMRequest(r, "POST", "/scim/v2/Users", map[string]interface{}{"userName": " "})
+		resp = scimError(t, w, http.StatusBadRequest)
+		assert.Equal(t, "invalidValue", resp["scimType"])
+
+		w = performSCIMRequest(r, "GET", "/scim/v2/Users?filter="+url.QueryEscape(`emails co "example"`), nil)
+		resp = scimError(t, w, http.StatusBadRequest)
+		assert.Equal(t, "invalidFilter", resp["scimType"])
+
+		w = performSCIMRequest(r, "GET", "/scim/v2/Users/"+uuid.New().String(), nil)
+		scimError(t, w, http.StatusNotFound)
+		w = performSCIMRequest(r, "GET", "/scim/v2/Users/unknown", nil)
+		scimError(t, w, http.StatusNotFound)
+
+		w = performSCIMRequest(r, "POST", "/scim/v2/Groups", map[string]interface{}{
+			"displayName": "backend",
+			"members":     []map[string]interface{}{{"value": uuid.New().String()}},
+		})
+		scimError(t, w, http.StatusNotFound)
+		w = performSCIMRequest(r, "GET", "/scim/v2/Groups?filter="+url.QueryEscape(`displayName eq "backend"`), nil)
+		assert.JSONEq(t, `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:ListResponse"],
+			"totalResults": 0,
+			"startIndex": 1,
+			"itemsPerPage": 0,
+			"Resources": []
+		}`, w.Body.String())
+	})
+}
+
+func TestSCIM_RequiresToken(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		// Not even a trusted proxy may provision users without a token
+		for _, r := range []*gin.Engine{setupRouter(tx), setupRouterWithAuth(tx, auth.Config{TrustOrganizationHeader: true})} {
+			w := performRequest(r, "POST", "/scim/v2/Users", map[string]interface{}{"userName": "alice"})
+			resp := scimError(t, w, http.StatusUnauthorized)
+			assert.Equal(t, "401", resp["status"])
+			assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+
+			w = performRequest(r, "GET", "/scim/v2/ServiceProviderConfig", nil)
+			scimError(t, w, http.StatusUnauthorized)
+		}
+
+		req, _ := http.NewRequest("GET", "/scim/v2/Users", nil)
+		req.Header.Set("Authorization", "Bearer unknown-token")
+		w := httptest.NewRecorder()
+		setupRouter(tx).ServeHTTP(w, req)
+		scimError(t, w, http.StatusUnauthorized)
+
+		var users int64
+		tx.Model(&models.User{}).Where("username = ?", "alice").Count(&users)
+		assert.Zero(t, users)
+
+		w = performSCIMRequest(setupRouter(tx), "GET", "/scim/v2/ServiceProviderConfig", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var config struct {
+			AuthenticationSchemes []map[string]interface{} `json:"authenticationSchemes"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &config))
+		require.Len(t, config.AuthenticationSchemes, 1)
+		assert.Equal(t, "oauthbearertoken", config.AuthenticationSchemes[0]["type"])
+	})
+}
@@ -0,0 +1,171 @@
+package integration_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reviewers/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestGetReviewerStats(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		team := models.Team{
+			ID:   uuid.New().String(),
+			Name: "squad",
+			Members: []models.User{
+				{ID: uuid.New().String(), Username: "author", IsActive: true},
+				{ID: uuid.New().String(), Username: "reviewer", IsActive: true},
+			},
+		}
+		tx.Create(&team)
+		author, reviewer := team.Members[0], team.Members[1]
+
+		reqBody, _ := json.Marshal(map[string]interface{}{
+			"pull_request_id":   "pr-0001",
+			"pull_request_name": "test",
+			"author_id":         author.ID,
+		})
+		req, _ := http.NewRequest("POST", "/pullRequest/create", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		reqBody, _ = json.Marshal(map[string]interface{}{
+			"pull_request_id": "pr-0001",
+			"reviewer_id":     reviewer.ID,
+		})
+		req, _ = http.NewRequest("POST", "/pullRequest/approve", bytes.NewBuffer(reqBody))
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// Author is not a reviewer
+		reqBody, _ = json.Marshal(map[string]interface{}{
+			"pull_request_id": "pr-0001",
+			"reviewer_id":     author.ID,
+		})
+		req, _ = http.NewRequest("POST", "/pullRequest/approve", bytes.NewBuffer(reqBody))
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		req, _ = http.NewRequest("GET", "/stats/reviewers?team_name=squad&from=2000-01-01", nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string][]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Len(t, resp["reviewers"], 2)
+
+		stats := resp["reviewers"][1]
+		assert.Equal(t, reviewer.ID, stats["user_id"])
+		assert.Equal(t, "squad", stats["team_name"])
+		assert.Equal(t, float64(1), stats["assigned"])
+		assert.Equal(t, float64(0), stats["reassigned_away"])
+		assert.Equal(t, float64(1), stats["approved"])
+		assert.Equal(t, float64(1), stats["open_reviews"])
+		assert.NotNil(t, stats["median_time_to_first_review_seconds"])
+
+		// Invalid range
+		req, _ = http.NewRequest("GET", "/stats/reviewers?from=yesterday", nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGetTeamReport(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		team := models.Team{
+			ID:   uuid.New().String(),
+			Name: "squad",
+			Members: []models.User{
+				{ID: uuid.New().String(), Username: "author", IsActive: true},
+				{ID: uuid.New().String(), Username: "reviewer", IsActive: true},
+			},
+		}
+		tx.Create(&team)
+
+		reqBody, _ := json.Marshal(map[string]interface{}{
+			"pull_request_id":   "pr-0001",
+			"pull_request_name": "test",
+			"author_id":         team.Members[0].ID,
+		})
+		req, _ := http.NewRequest("POST", "/pullRequest/create", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		reqBody, _ = json.Marshal(map[string]interface{}{"pull_request_id": "pr-0001"})
+		req, _ = http.NewRequest("POST", "/pullRequest/merge", bytes.NewBuffer(reqBody))
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// JSON
+		req, _ = http.NewRequest("GET", "/stats/teams/squad", nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, float64(2), resp["members"])
+		assert.Equal(t, float64(1), resp["total_assigned"])
+		assert.Equal(t, 0.5, resp["gini"])
+		assert.Nil(t, resp["max_min_ratio"])
+		assert.Equal(t, float64(1), resp["merged_pull_requests"])
+		assert.Equal(t, float64(0), resp["reassignment_rate"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{
+				"user_id":  team.Members[0].ID,
+				"username": "author",
+			},
+		}, resp["members_without_reviews"])
+
+		// CSV
+		req, _ = http.NewRequest("GET", "/stats/teams/squad?format=csv", nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "squad,,,2,1,0.5,,1,")
+
+		// An explicit format wins over the Accept header
+		req, _ = http.NewRequest("GET", "/stats/teams/squad", nil)
+		req.Header.Set("Accept", "text/csv")
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+
+		req, _ = http.NewRequest("GET", "/stats/teams/squad?format=json", nil)
+		req.Header.Set("Accept", "text/csv")
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+
+		req, _ = http.NewRequest("GET", "/stats/teams/squad?format=xml", nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		// Unknown team
+		req, _ = http.NewRequest("GET", "/stats/teams/unknown", nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reviewers/internal/handler"
 	"reviewers/internal/models"
 	"testing"
 
@@ -56,10 +57,10 @@ func TestCreateTeam(t *testing.T) {
 
 		var resp map[string]interface{}
 		json.Unmarshal(w.Body.Bytes(), &resp)
-		assert.Equal(t, map[string]interface{}{
-			"code":    "TEAM_EXISTS",
-			"message": "test already exists",
-		}, resp["error"])
+		errorResponse := resp["error"].(map[string]interface{})
+		assert.Equal(t, "TEAM_EXISTS", errorResponse["code"])
+		assert.Equal(t, "test already exists", errorResponse["message"])
+		assert.Equal(t, w.Header().Get(handler.RequestIDHeader), errorResponse["request_id"])
 
 		// Check team created
 		var createdTeam models.Team
@@ -123,9 +124,141 @@ func TestGetTeam(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, w.Code)
 
 		json.Unmarshal(w.Body.Bytes(), &resp)
-		assert.Equal(t, map[string]interface{}{
-			"code":    "NOT_FOUND",
-			"message": "resource not found",
-		}, resp["error"])
+		errorResponse := resp["error"].(map[string]interface{})
+		assert.Equal(t, "NOT_FOUND", errorResponse["code"])
+		assert.Equal(t, "resource not found", errorResponse["message"])
+	})
+}
+
+func TestGetTeam_Subteams(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		department := models.Team{ID: uuid.New().String(), Name: "department"}
+		tx.Create(&department)
+
+		squad := models.Team{
+			ID:       uuid.New().String(),
+			Name:     "squad",
+			ParentID: &department.ID,
+			Members: []models.User{
+				{ID: uuid.New().String(), Username: "igor", IsActive: true},
+			},
+		}
+		tx.Create(&squad)
+
+		// Without subteams
+		req, _ := http.NewRequest("GET", "/team/get?team_name=department", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NotContains(t, resp, "subteams")
+
+		// With subteams
+		req, _ = http.NewRequest("GET", "/team/get?team_name=department&include_subteams=true", nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		resp = nil
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{
+				"team_name":        "squad",
+				"parent_team_name": "department",
+				"members": []interface{}{
+					map[string]interface{}{
+						"user_id":   squad.Members[0].ID,
+						"username":  "igor",
+						"is_active": true,
+					},
+				},
+			},
+		}, resp["subteams"])
+	})
+}
+
+func TestSetParent(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		department := models.Team{ID: uuid.New().String(), Name: "department"}
+		tx.Create(&department)
+		squad := models.Team{ID: uuid.New().String(), Name: "squad"}
+		tx.Create(&squad)
+
+		// Success
+		reqBody, _ := json.Marshal(map[string]interface{}{
+			"team_name":        "squad",
+			"parent_team_name": "department",
+		})
+		req, _ := http.NewRequest("POST", "/team/setParent", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var updated models.Team
+		tx.Where("team_id = ?", squad.ID).First(&updated)
+		assert.Equal(t, department.ID, *updated.ParentID)
+
+		// Cycle
+		reqBody, _ = json.Marshal(map[string]interface{}{
+			"team_name":        "department",
+			"parent_team_name": "squad",
+		})
+		req, _ = http.NewRequest("POST", "/team/setParent", bytes.NewBuffer(reqBody))
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		errorResponse := resp["error"].(map[string]interface{})
+		assert.Equal(t, "INVALID_HIERARCHY", errorResponse["code"])
+	})
+}
+
+func TestGetTeamStats(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+
+		department := models.Team{ID: uuid.New().String(), Name: "department"}
+		tx.Create(&department)
+
+		squad := models.Team{
+			ID:       uuid.New().String(),
+			Name:     "squad",
+			ParentID: &department.ID,
+			Members: []models.User{
+				{ID: uuid.New().String(), Username: "igor", IsActive: true},
+				{ID: uuid.New().String(), Username: "dima", IsActive: false},
+			},
+		}
+		tx.Create(&squad)
+
+		tx.Create(&models.PullRequest{
+			ID:        "pr-0001",
+			Name:      "test",
+			Status:    models.StatusOpen,
+			AuthorID:  squad.Members[1].ID,
+			Reviewers: []models.PullRequestReviewer{{PullRequestID: "pr-0001", UserID: squad.Members[0].ID}},
+		})
+
+		req, _ := http.NewRequest("GET", "/team/stats?team_name=department", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, "department", resp["team_name"])
+		assert.Equal(t, float64(2), resp["members"])
+		assert.Equal(t, float64(1), resp["active_members"])
+		assert.Equal(t, float64(1), resp["assigned_reviews"])
+		assert.Equal(t, float64(1), resp["open_reviews"])
+		assert.Len(t, resp["subteams"], 1)
 	})
 }
@@ -8,9 +8,11 @@ import (
 	"net/http/httptest"
 	"reviewers/internal/models"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 )
 
@@ -77,7 +79,11 @@ func TestSetActiveStatus_BadRequest(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 		var resp map[string]interface{}
 		json.Unmarshal(w.Body.Bytes(), &resp)
-		assert.Equal(t, "invalid body", resp["error"])
+		errorResponse := resp["error"].(map[string]interface{})
+		assert.Equal(t, "VALIDATION_FAILED", errorResponse["code"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"field": "user_id", "message": "is required"},
+		}, errorResponse["fields"])
 	})
 }
 
@@ -97,15 +103,299 @@ func TestGetReview(t *testing.T) {
 		assert.Len(t, resp["pull_requests"], 1)
 
 		prs := resp["pull_requests"].([]interface{})
+		review := prs[0].(map[string]interface{})
+		assert.NotEmpty(t, review["created_at"])
+		delete(review, "created_at")
 		assert.Equal(t, map[string]interface{}{
+			"repository":        models.DefaultRepository,
 			"author_id":         pr.AuthorID,
 			"pull_request_id":   pr.ID,
 			"pull_request_name": pr.Name,
 			"status":            pr.Status,
-		}, prs[0])
+		}, review)
+		assert.NotContains(t, resp, "next_cursor")
+		assert.NotContains(t, resp, "total")
+	})
+}
+
+func TestGetReview_Pages(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+		reviewerID := createListedPullRequests(t, tx)
+
+		get := func(query string) (int, map[string]interface{}) {
+			req, _ := http.NewRequest("GET", "/users/getReview?user_id="+reviewerID+query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			var resp map[string]interface{}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			return w.Code, resp
+		}
+
+		code, resp := get("&limit=2&include_total=true")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"pr-3", "pr-2"}, pullRequestIDs(resp))
+		assert.Equal(t, float64(3), resp["total"])
+		require.NotEmpty(t, resp["next_cursor"])
+
+		code, resp = get("&limit=2&cursor=" + resp["next_cursor"].(string))
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"pr-1"}, pullRequestIDs(resp))
+		assert.NotContains(t, resp, "next_cursor")
+
+		_, resp = get("&status=OPEN&sort=created_at")
+		assert.Equal(t, []string{"pr-1", "pr-3"}, pullRequestIDs(resp))
+
+		_, resp = get("&from=2024-01-02&to=2024-01-03")
+		assert.Equal(t, []string{"pr-2"}, pullRequestIDs(resp))
+
+		_, resp = get("&sort=-name")
+		assert.Equal(t, []string{"pr-3", "pr-2", "pr-1"}, pullRequestIDs(resp))
+	})
+}
+
+func TestUser_CreateUpdateGet(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+		for _, name := range []string{"backend", "frontend"} {
+			w := performRequest(r, "POST", "/team/add", map[string]interface{}{"team_name": name})
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		}
+
+		userResponse := func(w *httptest.ResponseRecorder) map[string]interface{} {
+			var resp map[string]map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			return resp["user"]
+		}
+
+		w := performRequest(r, "POST", "/users/create", map[string]interface{}{
+			"username":  "alice",
+			"team_name": "backend",
+			"email":     "alice@example.com",
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		created := userResponse(w)
+		userID := created["user_id"].(string)
+		assert.Equal(t, map[string]interface{}{
+			"user_id":   userID,
+			"username":  "alice",
+			"team_name": "backend",
+			"email":     "alice@example.com",
+			"is_active": true,
+		}, created)
+
+		w = performRequest(r, "POST", "/users/create", map[string]interface{}{"username": "bob", "team_name": "backend"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = performRequest(r, "POST", "/users/update", map[string]interface{}{
+			"user_id":   userID,
+			"username":  "alice.smith",
+			"team_name": "frontend",
+			"email":     "",
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = performRequest(r, "GET", "/users/get?user_id="+userID, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, map[string]interface{}{
+			"user_id":   userID,
+			"username":  "alice.smith",
+			"team_name": "frontend",
+			"is_active": true,
+		}, userResponse(w))
+
+		var user models.User
+		require.NoError(t, tx.First(&user, "user_id = ?", userID).Error)
+		assert.Empty(t, user.Email)
+	})
+}
+
+func TestUser_Errors(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+		w := performRequest(r, "POST", "/team/add", map[string]interface{}{
+			"team_name": "backend",
+			"members":   []map[string]interface{}{{"username": "alice", "is_active": true}, {"username": "bob", "is_active": true}},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var team struct {
+			Members []struct {
+				UserID   string `json:"user_id"`
+				Username string `json:"username"`
+			} `json:"members"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &team))
+		var bobID string
+		for _, member := range team.Members {
+			if member.Username == "bob" {
+				bobID = member.UserID
+			}
+		}
+
+		w = performRequest(r, "POST", "/users/create", map[string]interface{}{"username": "alice", "team_name": "backend"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "USER_EXISTS", errorOf(w)["code"])
+		assert.Equal(t, "alice already exists", errorOf(w)["message"])
+
+		w = performRequest(r, "POST", "/users/update", map[string]interface{}{"user_id": bobID, "username": "alice"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "USER_EXISTS", errorOf(w)["code"])
+
+		w = performRequest(r, "POST", "/team/add", map[string]interface{}{
+			"team_name": "frontend",
+			"members":   []map[string]interface{}{{"username": "bob", "is_active": true}},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "USER_EXISTS", errorOf(w)["code"])
+
+		w = performRequest(r, "POST", "/users/create", map[string]interface{}{"username": "carol", "team_name": "unknown"})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = performRequest(r, "POST", "/users/update", map[string]interface{}{"user_id": bobID, "email": "bob"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"field": "email", "message": "must be an email address"},
+		}, errorOf(w)["fields"])
+
+		w = performRequest(r, "GET", "/users/get?user_id="+uuid.New().String(), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = performRequest(r, "POST", "/users/delete", map[string]interface{}{"user_id": uuid.New().String()})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestUser_DeleteReassignsReviews(t *testing.T) {
+	runInTransaction(t, func(tx *gorm.DB) {
+		r := setupRouter(tx)
+		w := performRequest(r, "POST", "/team/add", map[string]interface{}{
+			"team_name": "backend",
+			"members": []map[string]interface{}{
+				{"username": "author", "is_active": true},
+				{"username": "first", "is_active": true},
+				{"username": "second", "is_active": true},
+				{"username": "third", "is_active": true},
+			},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var team struct {
+			Members []struct {
+				UserID   string `json:"user_id"`
+				Username string `json:"username"`
+			} `json:"members"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &team))
+		var authorID string
+		for _, member := range team.Members {
+			if member.Username == "author" {
+				authorID = member.UserID
+			}
+		}
+
+		w = performRequest(r, "POST", "/pullRequest/create", map[string]interface{}{
+			"pull_request_id":   "pr-1",
+			"pull_request_name": "test",
+			"author_id":         authorID,
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var created struct {
+			PR struct {
+				AssignedReviewers []string `json:"assigned_reviewers"`
+			} `json:"pr"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		require.Len(t, created.PR.AssignedReviewers, 2)
+		deleted, kept := created.PR.AssignedReviewers[0], created.PR.AssignedReviewers[1]
+
+		w = performRequest(r, "POST", "/users/delete", map[string]interface{}{"user_id": deleted})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var reviewers []string
+		tx.Model(&models.PullRequestReviewer{}).Where("pull_request_id = ?", "pr-1").Pluck("user_id", &reviewers)
+		assert.Len(t, reviewers, 2)
+		assert.Contains(t, reviewers, kept)
+		assert.NotContains(t, reviewers, deleted)
+
+		// Events of the deleted user stay in the history of the pull
+		// request
+		var events int64
+		tx.Model(&models.PullRequestEvent{}).Where("pull_request_id = ? AND user_id IS NULL", "pr-1").Count(&events)
+		assert.Equal(t, int64(2), events)
+
+		// Nobody is left to take over the review
+		w = performRequest(r, "POST", "/users/delete", map[string]interface{}{"user_id": kept})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "NO_CANDIDATE", errorOf(w)["code"])
+
+		var count int64
+		tx.Model(&models.User{}).Where("user_id = ?", kept).Count(&count)
+		assert.Equal(t, int64(1), count)
+
+		// Authors and reviewers of merged pull requests keep their history
+		w = performRequest(r, "POST", "/pullRequest/merge", map[string]interface{}{"pull_request_id": "pr-1"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		for _, userID := range []string{kept, authorID} {
+			w = performRequest(r, "POST", "/users/delete", map[string]interface{}{"user_id": userID})
+			assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+			assert.Equal(t, "USER_HAS_PULL_REQUESTS", errorOf(w)["code"])
+		}
+		tx.Model(&models.User{}).Where("user_id IN ?", []string{kept, authorID}).Count(&count)
+		assert.Equal(t, int64(2), count)
+		tx.Model(&models.PullRequestReviewer{}).Where("pull_request_id = ?", "pr-1").Count(&count)
+		assert.Equal(t, int64(2), count)
 	})
 }
 
+// createListedPullRequests creates three pull requests reviewed by the
+// same user, one a day starting on 2024-01-01. The second one is merged.
+// It returns the ID of the reviewer.
+func createListedPullRequests(t *testing.T, tx *gorm.DB) string {
+	team := models.Team{
+		ID:   uuid.New().String(),
+		Name: "listed",
+		Members: []models.User{
+			{ID: uuid.New().String(), Username: "author", IsActive: true},
+			{ID: uuid.New().String(), Username: "reviewer", IsActive: true},
+		},
+	}
+	require.NoError(t, tx.Create(&team).Error)
+	authorID, reviewerID := team.Members[0].ID, team.Members[1].ID
+
+	for i := 1; i <= 3; i++ {
+		id := fmt.Sprintf("pr-%d", i)
+		status := models.StatusOpen
+		if i == 2 {
+			status = models.StatusMerged
+		}
+		pr := models.PullRequest{
+			ID:        id,
+			Name:      fmt.Sprintf("change %d", i),
+			Status:    status,
+			AuthorID:  authorID,
+			CreatedAt: time.Date(2024, 1, i, 12, 0, 0, 0, time.UTC),
+			Reviewers: []models.PullRequestReviewer{{PullRequestID: id, UserID: reviewerID}},
+		}
+		require.NoError(t, tx.Create(&pr).Error)
+	}
+	return reviewerID
+}
+
+// errorOf returns the error of the response.
+func errorOf(w *httptest.ResponseRecorder) map[string]interface{} {
+	var resp map[string]map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp["error"]
+}
+
+func pullRequestIDs(resp map[string]interface{}) []string {
+	ids := []string{}
+	prs, _ := resp["pull_requests"].([]interface{})
+	for _, pr := range prs {
+		ids = append(ids, pr.(map[string]interface{})["pull_request_id"].(string))
+	}
+	return ids
+}
+
 var user models.User
 var team models.Team
 var pr models.PullRequest
@@ -2,22 +2,54 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"reviewers/internal/auth"
 	"reviewers/internal/config"
 	"reviewers/internal/db"
+	"reviewers/internal/events"
+	"reviewers/internal/grpcserver"
 	"reviewers/internal/handler"
+	"reviewers/internal/metrics"
+	"reviewers/internal/repository"
+	"reviewers/internal/tracing"
+	"slices"
 	"syscall"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	logger := slog.Default()
+	logger := slog.New(tracing.NewLogHandler(slog.Default().Handler()))
+
+	// "--storage=memory" keeps the data in memory instead of the database,
+	// "main migrate up|down|version" migrates the database and exits
+	// instead of starting the servers.
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: main [--storage=postgres|memory] [migrate up|down|version]")
+		flag.PrintDefaults()
+	}
+	storage := flag.String("storage", storagePostgres, "where to keep the data: postgres or memory")
+	flag.Parse()
+
+	args := flag.Args()
+	var migrateCommand string
+	if len(args) > 0 {
+		if len(args) != 2 || args[0] != "migrate" || !slices.Contains(migrateCommands, args[1]) {
+			flag.Usage()
+			os.Exit(2)
+		}
+		migrateCommand = args[1]
+	}
+	if *storage != storagePostgres && (*storage != storageMemory || migrateCommand != "") {
+		flag.Usage()
+		os.Exit(2)
+	}
 
 	cfg, err := config.Load()
 	if err != nil {
@@ -25,27 +57,79 @@ func main() {
 		os.Exit(1)
 	}
 
-	conn, err := db.Connect(cfg)
+	if migrateCommand != "" {
+		if err := migrateDB(context.Background(), logger, cfg, migrateCommand); err != nil {
+			logger.Error("Failed to migrate database", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracingExporter)
 	if err != nil {
-		logger.Error("Failed to connect to database")
+		logger.Error("Failed to set up tracing", "error", err)
 		os.Exit(1)
 	}
 	defer func() {
-		sqlDB, _ := conn.DB()
-		sqlDB.Close()
-		logger.Info("Databse connection closed")
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error("Failed to flush traces", "error", err)
+		}
 	}()
 
+	var stores *repository.Stores
+	if *storage == storageMemory {
+		logger.Warn("Keeping data in memory, it is lost on shutdown")
+		stores = repository.NewMemoryStores(repository.NewMemoryDB(), logger)
+	} else {
+		if cfg.MigrateOnStartup {
+			if err := migrateDB(context.Background(), logger, cfg, "up"); err != nil {
+				logger.Error("Failed to migrate database", "error", err)
+				os.Exit(1)
+			}
+		}
+
+		conn, err := db.Connect(cfg)
+		if err != nil {
+			logger.Error("Failed to connect to database")
+			os.Exit(1)
+		}
+		defer func() {
+			sqlDB, _ := conn.DB()
+			sqlDB.Close()
+			logger.Info("Databse connection closed")
+		}()
+
+		if sqlDB, err := conn.DB(); err == nil {
+			if err := metrics.RegisterDB(sqlDB); err != nil {
+				logger.Warn("Failed to register database metrics", "error", err)
+			}
+		}
+
+		stores = repository.NewStores(conn, logger)
+	}
+
+	broker := events.NewBroker(cfg.EventHistory)
+	authCfg := auth.Config{Tokens: cfg.APITokens, TrustOrganizationHeader: cfg.TrustOrganizationHeader}
+
 	router := gin.Default()
-	handler.InitHandlers(logger, conn, router)
+	handler.InitHandlers(logger, stores, router, broker, handler.Options{Auth: authCfg, Timeout: cfg.DbTimeout})
+
+	// Contexts of all requests derive from baseCtx, so cancelling it stops
+	// in-flight queries when the shutdown timeout runs out.
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
 
 	addr := fmt.Sprintf(":%d", cfg.Port)
 	server := &http.Server{
-		Addr:    addr,
-		Handler: router,
+		Addr:        addr,
+		Handler:     router,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
 	}
+	// Event streams never finish by themselves, closing the broker ends
+	// them so that the shutdown does not wait for the timeout.
+	server.RegisterOnShutdown(broker.Close)
 
-	serverErrors := make(chan error, 1)
+	serverErrors := make(chan error, 2)
 	go func() {
 		logger.Info("Starting HTTP server", "address", addr)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -53,6 +137,20 @@ func main() {
 		}
 	}()
 
+	grpcServer := grpcserver.New(logger, stores, authCfg, cfg.DbTimeout, broker)
+	grpcAddr := fmt.Sprintf(":%d", cfg.GrpcPort)
+	go func() {
+		listener, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			serverErrors <- err
+			return
+		}
+		logger.Info("Starting gRPC server", "address", grpcAddr)
+		if err := grpcServer.Serve(listener); err != nil {
+			serverErrors <- err
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
@@ -63,14 +161,66 @@ func main() {
 	case <-quit:
 		logger.Info("Shutting down")
 
-		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 		defer cancel()
 
+		grpcStopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(grpcStopped)
+		}()
+
 		if err := server.Shutdown(ctx); err != nil {
-			logger.Error("Shutdown failed", "error", err)
+			logger.Error("Shutdown failed, cancelling in-flight requests", "error", err)
+			cancelRequests()
 			server.Close()
 		} else {
 			logger.Info("Shutdown completed")
 		}
+
+		select {
+		case <-grpcStopped:
+		case <-ctx.Done():
+			grpcServer.Stop()
+		}
+	}
+}
+
+const (
+	storagePostgres = "postgres"
+	storageMemory   = "memory"
+)
+
+var migrateCommands = []string{"up", "down", "version"}
+
+// migrateDB applies all migrations for "up", reverts the last one for
+// "down" and only reports the version for "version". Other processes
+// migrating the same database are waited for.
+func migrateDB(ctx context.Context, logger *slog.Logger, cfg *config.Config, command string) error {
+	migrator, err := db.NewMigrator(ctx, db.DSN(cfg))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := migrator.Close(); err != nil {
+			logger.Warn("Failed to release migration lock", "error", err)
+		}
+	}()
+
+	switch command {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		err = migrator.Down()
+	}
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return err
 	}
+	logger.Info("Database schema version", "version", version, "dirty", dirty)
+	return nil
 }
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reviewers/pkg/api"
+	"strings"
+)
+
+var (
+	importHeader  = []string{"LINE", "ACTION", "TEAM", "USERNAME", "DETAILS"}
+	restoreHeader = []string{"RECORDS", "RESTORED"}
+)
+
+func adminImport(ctx context.Context, a *app, args []string) error {
+	flags := a.flags("admin import")
+	format := flags.String("format", "", "format of the roster: yaml or csv, by default from the file extension")
+	dryRun := flags.Bool("dry-run", false, "print the changes without applying them")
+	values, err := parseArgs(flags, args, "file")
+	if err != nil {
+		return err
+	}
+
+	if *format == "" {
+		switch strings.ToLower(filepath.Ext(values[0])) {
+		case ".yaml", ".yml":
+			*format = "yaml"
+		case ".csv":
+			*format = "csv"
+		default:
+			fmt.Fprintf(flags.Output(), "unknown format of %s, set it with --format\n", values[0])
+			return errUsage
+		}
+	}
+
+	roster, err := os.ReadFile(values[0])
+	if err != nil {
+		return err
+	}
+	result, err := a.client.Import(ctx, roster, *format, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(result.Changes))
+	for _, change := range result.Changes {
+		rows = append(rows, []string{
+			formatInt(int64(change.Line)),
+			change.Action,
+			change.TeamName,
+			change.Username,
+			importDetails(change),
+		})
+	}
+	return a.out.print(result, importHeader, rows)
+}
+
+func importDetails(change api.ImportChange) string {
+	switch {
+	case change.OldTeamName != "":
+		return "from " + change.OldTeamName
+	case change.ParentTeamName != "":
+		return "parent " + change.ParentTeamName
+	default:
+		return ""
+	}
+}
+
+// adminExport writes a backup to the file or, without one, to stdout. A
+// failed export removes the file, so that no incomplete backup is left.
+func adminExport(ctx context.Context, a *app, args []string) error {
+	flags := a.flags("admin export")
+	output := flags.String("output", "", "file to write the backup to instead of stdout")
+	if _, err := parseArgs(flags, args); err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return a.client.Export(ctx, a.out.w)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	err = a.client.Export(ctx, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Join(err, os.Remove(*output))
+	}
+	return a.out.message("exported to " + *output)
+}
+
+func adminRestore(ctx context.Context, a *app, args []string) error {
+	flags := a.flags("admin restore")
+	values, err := parseArgs(flags, args, "file")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(values[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	result, err := a.client.Restore(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{
+		{"teams", formatInt(int64(result.Teams))},
+		{"users", formatInt(int64(result.Users))},
+		{"repositories", formatInt(int64(result.Repositories))},
+		{"code owner rules", formatInt(int64(result.CodeOwnerRules))},
+		{"pull requests", formatInt(int64(result.PullRequests))},
+		{"events", formatInt(int64(result.Events))},
+	}
+	return a.out.print(result, restoreHeader, rows)
+}
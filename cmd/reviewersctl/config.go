@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/caarlos0/env/v11"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the settings of the tool. They are read from the config
+// file first, environment variables take precedence over it and command
+// line flags take precedence over both.
+type Config struct {
+	URL          string `yaml:"url" env:"REVIEWERS_URL"`
+	Organization string `yaml:"organization" env:"REVIEWERS_ORGANIZATION"`
+	Token        string `yaml:"token" env:"REVIEWERS_TOKEN"`
+	Output       string `yaml:"output" env:"REVIEWERS_OUTPUT"`
+}
+
+// defaultConfigPath returns the path of the config file in the user
+// config directory, e.g. ~/.config/reviewersctl/config.yaml.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "reviewersctl", "config.yaml")
+}
+
+// loadConfig reads the config file at path and the environment. A
+// missing file is not an error unless the path has been set explicitly.
+func loadConfig(path string, explicit bool) (*Config, error) {
+	cfg := Config{
+		URL:    "http://localhost:8080",
+		Output: outputTable,
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := yaml.Unmarshal(data, &cfg); err != nil {
+				return nil, err
+			}
+		case errors.Is(err, fs.ErrNotExist) && !explicit:
+		default:
+			return nil, err
+		}
+	}
+
+	if err := env.Parse(&cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,257 @@
+// Command reviewersctl manages a reviewers service through its HTTP API.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"reviewers/pkg/client"
+	"reviewers/pkg/errs"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const usage = `Usage: reviewersctl [flags] <command> [arguments]
+
+Commands:
+  team get <name> [--subteams]
+  team add <name> [--parent <name>] [--member <username>]... [--member-id <user id>]...
+  team deactivate <team id>
+  user get <user id>
+  user create <username> --team <name> [--email <address>] [--inactive]
+  user update <user id> [--username <username>] [--team <name>] [--email <address>]
+  user delete <user id>
+  user set-active <user id> <true|false>
+  pr create <id> --name <name> --author <user id> [--repo <name>] [--file <path>]...
+  pr merge <id> [--repo <name>]
+  pr reassign <id> --reviewer <user id> [--repo <name>]
+  pr get <id> [--repo <name>]
+  pr list [--author <user id>] [--team <name>] [--reviewer <user id>] [--name <substring>] [--status <status>]
+          [--min-age <duration>] [--max-age <duration>] [--sort <field>] [--limit <n>] [--from <time>] [--to <time>]
+  stats reviewers [--team <name>] [--from <time>] [--to <time>]
+  stats team <name> [--from <time>] [--to <time>]
+  admin import <file> [--format yaml|csv] [--dry-run]
+  admin export [--output <file>]
+  admin restore <file>
+
+Flags:
+`
+
+// errUsage is returned for invalid command lines.
+var errUsage = errors.New("invalid usage")
+
+// app is the state shared by the commands.
+type app struct {
+	client *client.Client
+	out    *printer
+	stderr io.Writer
+}
+
+// flags returns the flag set of the command with the given name.
+func (a *app) flags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	return flags
+}
+
+// command runs a subcommand with the arguments following its name.
+type command func(ctx context.Context, a *app, args []string) error
+
+var commands = map[string]map[string]command{
+	"team": {
+		"get":        teamGet,
+		"add":        teamAdd,
+		"deactivate": teamDeactivate,
+	},
+	"user": {
+		"get":        userGet,
+		"create":     userCreate,
+		"update":     userUpdate,
+		"delete":     userDelete,
+		"set-active": userSetActive,
+	},
+	"pr": {
+		"create":   prCreate,
+		"merge":    prMerge,
+		"reassign": prReassign,
+		"get":      prGet,
+		"list":     prList,
+	},
+	"stats": {
+		"reviewers": statsReviewers,
+		"team":      statsTeam,
+	},
+	"admin": {
+		"import":  adminImport,
+		"export":  adminExport,
+		"restore": adminRestore,
+	},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "reviewersctl: %v\n", err)
+		var validationErr *errs.ValidationError
+		if errors.As(err, &validationErr) {
+			for _, field := range validationErr.Fields {
+				if field.Line > 0 {
+					fmt.Fprintf(os.Stderr, "  line %d: %s: %s\n", field.Line, field.Field, field.Message)
+					continue
+				}
+				fmt.Fprintf(os.Stderr, "  %s: %s\n", field.Field, field.Message)
+			}
+		}
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("reviewersctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+
+	configPath := flags.String("config", defaultConfigPath(), "config file")
+	url := flags.String("url", "", "base URL of the service (env REVIEWERS_URL)")
+	organization := flags.String("org", "", "organization ID (env REVIEWERS_ORGANIZATION)")
+	output := flags.String("o", "", "output format: table or json (env REVIEWERS_OUTPUT)")
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout of a request")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	explicit := false
+	flags.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == "config" })
+	cfg, err := loadConfig(*configPath, explicit)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if *url != "" {
+		cfg.URL = *url
+	}
+	if *organization != "" {
+		cfg.Organization = *organization
+	}
+	if *output != "" {
+		cfg.Output = *output
+	}
+	if cfg.Output != outputTable && cfg.Output != outputJSON {
+		return fmt.Errorf("unknown output format %q", cfg.Output)
+	}
+
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return errUsage
+	}
+	group, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", flags.Arg(0))
+		return errUsage
+	}
+	cmd, ok := group[flags.Arg(1)]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q, expected one of: %s\n",
+			flags.Arg(0)+" "+flags.Arg(1), strings.Join(names(group), ", "))
+		return errUsage
+	}
+
+	opts := []client.Option{client.WithTimeout(*timeout)}
+	if cfg.Organization != "" {
+		opts = append(opts, client.WithOrganization(cfg.Organization))
+	}
+	if cfg.Token != "" {
+		opts = append(opts, client.WithToken(cfg.Token))
+	}
+
+	a := &app{
+		client: client.New(cfg.URL, opts...),
+		out:    &printer{w: stdout, format: cfg.Output},
+		stderr: stderr,
+	}
+	return cmd(ctx, a, flags.Args()[2:])
+}
+
+func names(group map[string]command) []string {
+	result := make([]string, 0, len(group))
+	for name := range group {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// parseArgs parses the flags of a command, which may be given before and
+// after its positional arguments, and checks the number of the latter.
+func parseArgs(flags *flag.FlagSet, args []string, positional ...string) ([]string, error) {
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: reviewersctl %s", flags.Name())
+		for _, name := range positional {
+			fmt.Fprintf(flags.Output(), " <%s>", name)
+		}
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+
+	var values []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, errUsage
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		values = append(values, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+
+	if len(values) != len(positional) {
+		flags.Usage()
+		return nil, errUsage
+	}
+	return values, nil
+}
+
+// required checks that the flags with the given names are set.
+func required(flags *flag.FlagSet, names ...string) error {
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, name := range names {
+		if !set[name] {
+			fmt.Fprintf(flags.Output(), "flag --%s is required\n", name)
+			flags.Usage()
+			return errUsage
+		}
+	}
+	return nil
+}
+
+// stringList is a flag that may be repeated.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
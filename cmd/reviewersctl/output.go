@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// printer writes results either as JSON or as a table with the given
+// header and rows.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func (p *printer) print(value any, header []string, rows [][]string) error {
+	if p.format == outputJSON {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(value)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// message prints the result of a command that returns no data.
+func (p *printer) message(text string) error {
+	return p.print(map[string]string{"message": text}, []string{"MESSAGE"}, [][]string{{text}})
+}
+
+func formatBool(b bool) string {
+	return strconv.FormatBool(b)
+}
+
+func formatInt(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
+
+func formatFloat(f *float64) string {
+	if f == nil {
+		return "-"
+	}
+	return strconv.FormatFloat(*f, 'f', 2, 64)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
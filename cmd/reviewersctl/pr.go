@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reviewers/pkg/api"
+	"strings"
+)
+
+var pullRequestHeader = []string{"REPOSITORY", "ID", "NAME", "AUTHOR", "STATUS", "REVIEWERS"}
+
+func prCreate(ctx context.Context, a *app, args []string) error {
+	flags := a.flags("pr create")
+	name := flags.String("name", "", "name of the pull request")
+	author := flags.String("author", "", "ID of the author")
+	repo := flags.String("repo", "", "repository, the default one if empty")
+	var files stringList
+	flags.Var(&files, "file", "path of a changed file, may be repeated")
+	values, err := parseArgs(flags, args, "id")
+	if err != nil {
+		return err
+	}
+	if err := required(flags, "name", "author"); err != nil {
+		return err
+	}
+
+	pr, err := a.client.CreatePullRequest(ctx, &api.PullRequest{
+		ID:           values[0],
+		Name:         *name,
+		AuthorID:     *author,
+		Repository:   *repo,
+		ChangedFiles: files,
+	})
+	if err != nil {
+		return err
+	}
+	return a.printPullRequest(pr)
+}
+
+func prMerge(ctx context.Context, a *app, args []string) error {
+	flags := a.flags("pr merge")
+	repo := flags.String("repo", "", "repository, the default one if empty")
+	values, err := parseArgs(flags, args, "id")
+	if err != nil {
+		return err
+	}
+
+	pr, err := a.client.MergePullRequest(ctx, *repo, values[0])
+	if err != nil {
+		return err
+	}
+	return a.printPullRequest(pr)
+}
+
+func prReassign(ctx context.Context, a *app, args []string) error {
+	flags := a.flags("pr reassign")
+	repo := flags.String("repo", "", "repository, the default one if empty")
+	reviewer := flags.String("reviewer", "", "ID of the reviewer to replace")
+	values, err := parseArgs(flags, args, "id")
+	if err != nil {
+		return err
+	}
+	if err := required(flags, "reviewer"); err != nil {
+		return err
+	}
+
+	pr, err := a.client.ReassignReviewer(ctx, *repo, values[0], *reviewer)
+	if err != nil {
+		return err
+	}
+	return a.printPullRequest(pr)
+}
+
+var reviewerHeader = []string{"USER ID", "USERNAME", "TEAM", "ACTIVE", "APPROVED"}
+
+// prGet prints the reviewers of the pull request, the JSON output holds
+// the pull request as well.
+func prGet(ctx context.Context, a *app, args []string) error {
+	flags := a.flags("pr get")
+	repo := flags.String("repo", "", "repository, the default one if empty")
+	values, err := parseArgs(flags, args, "id")
+	if err != nil {
+		return err
+	}
+
+	pr, err := a.client.GetPullRequest(ctx, *repo, values[0])
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(pr.Reviewers))
+	for _, reviewer := range pr.Reviewers {
+		rows = append(rows, []string{
+			reviewer.UserID, reviewer.Username, reviewer.TeamName,
+			formatBool(reviewer.IsActive), formatTime(reviewer.ApprovedAt),
+		})
+	}
+	return a.out.print(pr, reviewerHeader, rows)
+}
+
+// prList follows the cursors of the listing until the limit is reached or
+// the pages run out.
+func prList(ctx context.Context, a *app, args []string) error {
+	flags := a.flags("pr list")
+	author := flags.String("author", "", "list pull requests of the user")
+	team := flags.String("team", "", "list pull requests of the members of the team and its subteams")
+	reviewer := flags.String("reviewer", "", "list pull requests assigned to the user")
+	name := flags.String("name", "", "substring of the name")
+	minAge := flags.Duration("min-age", 0, "list pull requests created at least this long ago")
+	maxAge := flags.Duration("max-age", 0, "list pull requests created at most this long ago")
+	status := flags.String("status", "", "OPEN or MERGED")
+	sort := flags.String("sort", "", "created_at or name, prefixed with - for the descending order")
+	limit := flags.Int("limit", 0, "maximum number of pull requests, 0 lists all")
+	from, to := timeRange(flags)
+	if _, err := parseArgs(flags, args); err != nil {
+		return err
+	}
+
+	filter := api.PullRequestFilter{
+		AuthorID:   *author,
+		TeamName:   *team,
+		ReviewerID: *reviewer,
+		Name:       *name,
+		MinAge:     *minAge,
+		MaxAge:     *maxAge,
+		Status:     *status,
+		Sort:       *sort,
+	}
+	var err error
+	if filter.From, err = parseTime(*from); err != nil {
+		fmt.Fprintf(flags.Output(), "invalid --from %q\n", *from)
+		return errUsage
+	}
+	if filter.To, err = parseTime(*to); err != nil {
+		fmt.Fprintf(flags.Output(), "invalid --to %q\n", *to)
+		return errUsage
+	}
+
+	prs := []api.PullRequestShort{}
+	for {
+		if *limit > 0 {
+			filter.Limit = min(*limit-len(prs), 1000)
+		}
+
+		page, err := a.client.ListPullRequests(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		prs = append(prs, page.PullRequests...)
+		if page.NextCursor == "" || (*limit > 0 && len(prs) >= *limit) {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	rows := make([][]string, 0, len(prs))
+	for _, pr := range prs {
+		rows = append(rows, []string{pr.Repository, pr.ID, pr.Name, pr.AuthorID, pr.Status, formatTime(&pr.CreatedAt)})
+	}
+	header := append(pullRequestHeader[:5:5], "CREATED")
+	return a.out.print(prs, header, rows)
+}
+
+func (a *app) printPullRequest(pr *api.PullRequest) error {
+	row := []string{pr.Repository, pr.ID, pr.Name, pr.AuthorID, pr.Status, strings.Join(pr.AssignedReviewers, ",")}
+	return a.out.print(pr, pullRequestHeader, [][]string{row})
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"reviewers/pkg/api"
+	"strings"
+	"time"
+)
+
+func statsReviewers(ctx context.Context, a *app, args []string) error {
+	flags := a.flags("stats reviewers")
+	team := flags.String("team", "", "limit statistics to the team and its subteams")
+	from, to := timeRange(flags)
+	if _, err := parseArgs(flags, args); err != nil {
+		return err
+	}
+
+	filter, err := statsFilter(flags, *team, *from, *to)
+	if err != nil {
+		return err
+	}
+
+	stats, err := a.client.GetReviewerStats(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	header := []string{"USER_ID", "USERNAME", "TEAM", "ASSIGNED", "REASSIGNED_AWAY", "APPROVED", "OPEN", "MEDIAN_FIRST_REVIEW_S"}
+	rows := make([][]string, 0, len(stats))
+	for _, s := range stats {
+		rows = append(rows, []string{
+			s.UserID, s.Username, s.TeamName,
+			formatInt(s.Assigned), formatInt(s.ReassignedAway), formatInt(s.Approved), formatInt(s.OpenReviews),
+			formatFloat(s.MedianTimeToFirstReview),
+		})
+	}
+	return a.out.print(stats, header, rows)
+}
+
+func statsTeam(ctx context.Context, a *app, args []string) error {
+	flags := a.flags("stats team")
+	from, to := timeRange(flags)
+	values, err := parseArgs(flags, args, "name")
+	if err != nil {
+		return err
+	}
+
+	filter, err := statsFilter(flags, values[0], *from, *to)
+	if err != nil {
+		return err
+	}
+
+	report, err := a.client.GetTeamReport(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	withoutReviews := make([]string, 0, len(report.MembersWithoutReviews))
+	for _, user := range report.MembersWithoutReviews {
+		withoutReviews = append(withoutReviews, user.Username)
+	}
+
+	header := []string{"METRIC", "VALUE"}
+	rows := [][]string{
+		{"team_name", report.TeamName},
+		{"from", formatTime(report.From)},
+		{"to", formatTime(report.To)},
+		{"members", fmt.Sprint(report.Members)},
+		{"total_assigned", formatInt(report.TotalAssigned)},
+		{"gini", formatFloat(&report.Gini)},
+		{"max_min_ratio", formatFloat(report.MaxMinRatio)},
+		{"merged_pull_requests", formatInt(report.MergedPullRequests)},
+		{"mean_time_to_merge_seconds", formatFloat(report.MeanTimeToMerge)},
+		{"reassignment_rate", formatFloat(report.ReassignmentRate)},
+		{"members_without_reviews", strings.Join(withoutReviews, ",")},
+	}
+	return a.out.print(report, header, rows)
+}
+
+func timeRange(flags *flag.FlagSet) (*string, *string) {
+	from := flags.String("from", "", "start of the period, an RFC 3339 timestamp or a date")
+	to := flags.String("to", "", "end of the period, an RFC 3339 timestamp or a date")
+	return from, to
+}
+
+func statsFilter(flags *flag.FlagSet, team, from, to string) (api.StatsFilter, error) {
+	filter := api.StatsFilter{TeamName: team}
+
+	var err error
+	if filter.From, err = parseTime(from); err != nil {
+		fmt.Fprintf(flags.Output(), "invalid --from %q\n", from)
+		return filter, errUsage
+	}
+	if filter.To, err = parseTime(to); err != nil {
+		fmt.Fprintf(flags.Output(), "invalid --to %q\n", to)
+		return filter, errUsage
+	}
+	return filter, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"reviewers/pkg/api"
+)
+
+func teamGet(ctx context.Context, a *app, args []string) error {
+	flags := a.flags("team get")
+	subteams := flags.Bool("subteams", false, "include subteams")
+	values, err := parseArgs(flags, args, "name")
+	if err != nil {
+		return err
+	}
+
+	team, err := a.client.GetTeam(ctx, values[0], *subteams)
+	if err != nil {
+		return err
+	}
+	return a.out.print(team, []string{"TEAM", "USER_ID", "USERNAME", "ACTIVE"}, teamRows(team))
+}
+
+func teamAdd(ctx context.Context, a *app, args []string) error {
+	flags := a.flags("team add")
+	parent := flags.String("parent", "", "name of the parent team")
+	var usernames, userIDs stringList
+	flags.Var(&usernames, "member", "username of a new member, may be repeated")
+	flags.Var(&userIDs, "member-id", "ID of an existing user to move to the team, may be repeated")
+	values, err := parseArgs(flags, args, "name")
+	if err != nil {
+		return err
+	}
+
+	team := &api.Team{Name: values[0], ParentName: *parent, Members: []api.User{}}
+	for _, username := range usernames {
+		team.Members = append(team.Members, api.User{Username: username, IsActive: true})
+	}
+	for _, userID := range userIDs {
+		team.Members = append(team.Members, api.User{ID: userID, IsActive: true})
+	}
+
+	team, err = a.client.CreateTeam(ctx, team)
+	if err != nil {
+		return err
+	}
+	return a.out.print(team, []string{"TEAM", "USER_ID", "USERNAME", "ACTIVE"}, teamRows(team))
+}
+
+func teamDeactivate(ctx context.Context, a *app, args []string) error {
+	values, err := parseArgs(a.flags("team deactivate"), args, "team id")
+	if err != nil {
+		return err
+	}
+
+	if err := a.client.DeactivateTeam(ctx, values[0]); err != nil {
+		return err
+	}
+	return a.out.message("team has been deactivated")
+}
+
+// teamRows lists the members of the team and its subteams.
+func teamRows(team *api.Team) [][]string {
+	var rows [][]string
+	for _, user := range team.Members {
+		rows = append(rows, []string{team.Name, user.ID, user.Username, formatBool(user.IsActive)})
+	}
+	for i := range team.Subteams {
+		rows = append(rows, teamRows(&team.Subteams[i])...)
+	}
+	return rows
+}
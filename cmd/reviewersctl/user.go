@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"reviewers/pkg/api"
+	"reviewers/pkg/client"
+	"strconv"
+)
+
+func userSetActive(ctx context.Context, a *app, args []string) error {
+	flags := a.flags("user set-active")
+	values, err := parseArgs(flags, args, "user id", "true|false")
+	if err != nil {
+		return err
+	}
+	active, err := strconv.ParseBool(values[1])
+	if err != nil {
+		fmt.Fprintf(flags.Output(), "invalid activity %q\n", values[1])
+		return errUsage
+	}
+
+	if err := a.client.SetUserActive(ctx, values[0], active); err != nil {
+		return err
+	}
+	return a.out.message("status updated")
+}
+
+var userHeader = []string{"USER_ID", "USERNAME", "TEAM", "EMAIL", "ACTIVE"}
+
+func userGet(ctx context.Context, a *app, args []string) error {
+	values, err := parseArgs(a.flags("user get"), args, "user id")
+	if err != nil {
+		return err
+	}
+
+	user, err := a.client.GetUser(ctx, values[0])
+	if err != nil {
+		return err
+	}
+	return a.printUser(user)
+}
+
+func userCreate(ctx context.Context, a *app, args []string) error {
+	flags := a.flags("user create")
+	team := flags.String("team", "", "name of the team")
+	email := flags.String("email", "", "email address")
+	inactive := flags.Bool("inactive", false, "create the user deactivated")
+	values, err := parseArgs(flags, args, "username")
+	if err != nil {
+		return err
+	}
+	if err := required(flags, "team"); err != nil {
+		return err
+	}
+
+	active := !*inactive
+	req := client.UserRequest{Username: values[0], TeamName: *team, IsActive: &active}
+	if *email != "" {
+		req.Email = email
+	}
+	user, err := a.client.CreateUser(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.printUser(user)
+}
+
+func userUpdate(ctx context.Context, a *app, args []string) error {
+	flags := a.flags("user update")
+	username := flags.String("username", "", "new username")
+	team := flags.String("team", "", "name of the new team")
+	email := flags.String("email", "", "new email address, empty removes it")
+	values, err := parseArgs(flags, args, "user id")
+	if err != nil {
+		return err
+	}
+
+	req := client.UserRequest{UserID: values[0], Username: *username, TeamName: *team}
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "email" {
+			req.Email = email
+		}
+	})
+	user, err := a.client.UpdateUser(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.printUser(user)
+}
+
+func userDelete(ctx context.Context, a *app, args []string) error {
+	values, err := parseArgs(a.flags("user delete"), args, "user id")
+	if err != nil {
+		return err
+	}
+
+	if err := a.client.DeleteUser(ctx, values[0]); err != nil {
+		return err
+	}
+	return a.out.message("user has been deleted")
+}
+
+func (a *app) printUser(user *api.User) error {
+	row := []string{user.ID, user.Username, user.TeamName, user.Email, formatBool(user.IsActive)}
+	return a.out.print(user, userHeader, [][]string{row})
+}
@@ -0,0 +1,81 @@
+// Package auth decides which organization a caller acts for. Callers
+// authenticate with bearer tokens, every token acts for one organization.
+package auth
+
+import (
+	"crypto/subtle"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+	"strings"
+)
+
+// Config holds the tokens of the callers and how far callers without a
+// token are trusted.
+type Config struct {
+	// Tokens maps bearer tokens to the IDs of the organizations they act
+	// for.
+	Tokens map[string]string
+	// TrustOrganizationHeader lets callers without a token select any
+	// organization. It is meant for deployments behind a proxy that
+	// authenticates callers and selects the organization itself.
+	TrustOrganizationHeader bool
+}
+
+// Principal is a caller authenticated by a token.
+type Principal struct {
+	organizationID string
+}
+
+// OrganizationID returns the organization the token of the caller acts
+// for.
+func (p *Principal) OrganizationID() string {
+	return p.organizationID
+}
+
+// Authenticate returns the principal of the value of an Authorization
+// header. A caller without the header has no principal, an unknown token
+// or another scheme than Bearer is an error.
+func (c Config) Authenticate(authorization string) (*Principal, error) {
+	if authorization == "" {
+		return nil, nil
+	}
+
+	scheme, token, _ := strings.Cut(authorization, " ")
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, errs.Unauthorized.WithMessage("bearer token expected")
+	}
+
+	// Every token is compared, so the time of the check does not reveal
+	// how much of a token matches
+	var orgID string
+	for known, id := range c.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(known)) == 1 {
+			orgID = id
+		}
+	}
+	if orgID == "" {
+		return nil, errs.Unauthorized.WithMessage("invalid token")
+	}
+	return &Principal{organizationID: orgID}, nil
+}
+
+// Organization returns the organization a caller acts for. The principal
+// is nil for callers without a token, requested is the organization the
+// caller selects, if any. A principal acts only for its organization,
+// callers without a token act for the default one unless they are
+// trusted.
+func (c Config) Organization(principal *Principal, requested string) (string, error) {
+	switch {
+	case principal != nil:
+		if requested != "" && requested != principal.organizationID {
+			return "", errs.Forbidden
+		}
+		return principal.organizationID, nil
+	case requested == "" || requested == models.DefaultOrganizationID:
+		return models.DefaultOrganizationID, nil
+	case c.TrustOrganizationHeader:
+		return requested, nil
+	default:
+		return "", errs.Unauthorized.WithMessage("a token is required to select an organization")
+	}
+}
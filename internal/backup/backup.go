@@ -0,0 +1,227 @@
+// Package backup writes and reads logical backups of an organization as
+// newline-delimited JSON.
+//
+// The first line of a backup is its header with the version of the format,
+// every following line is a record of the type it names, and the last
+// line counts the records, so that a truncated backup is detected:
+//
+//	{"type":"header","version":1,"exported_at":"2025-01-02T03:04:05Z"}
+//	{"type":"team","data":{"team_id":"...","name":"backend"}}
+//	{"type":"user","data":{"user_id":"...","username":"alice","is_active":true,"team_name":"backend"}}
+//	{"type":"end","records":2}
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+	"time"
+)
+
+// Version is the version of the format. Backups of other versions are not
+// restored.
+const Version = 1
+
+// ContentType is the media type of backups.
+const ContentType = "application/x-ndjson"
+
+// maxLineSize limits the size of a record.
+const maxLineSize = 16 << 20
+
+const (
+	typeHeader = "header"
+	typeEnd    = "end"
+)
+
+// line is a line of a backup. Data is set for records, Version and
+// ExportedAt for the header and Records for the end.
+type line struct {
+	Type       string          `json:"type"`
+	Version    int             `json:"version,omitempty"`
+	ExportedAt *time.Time      `json:"exported_at,omitempty"`
+	Records    *int            `json:"records,omitempty"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+// Writer writes a backup. Close must be called after the last record to
+// complete it.
+type Writer struct {
+	w       *bufio.Writer
+	records int
+}
+
+// NewWriter starts a backup exported at the given time.
+func NewWriter(w io.Writer, exportedAt time.Time) (*Writer, error) {
+	bw := &Writer{w: bufio.NewWriter(w)}
+	if err := bw.write(line{Type: typeHeader, Version: Version, ExportedAt: &exportedAt}); err != nil {
+		return nil, err
+	}
+	return bw, nil
+}
+
+// Write writes the record.
+func (w *Writer) Write(record models.BackupRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s record: %w", record.RecordType(), err)
+	}
+
+	w.records++
+	return w.write(line{Type: record.RecordType(), Data: data})
+}
+
+// Close writes the end of the backup and flushes it.
+func (w *Writer) Close() error {
+	if err := w.write(line{Type: typeEnd, Records: &w.records}); err != nil {
+		return err
+	}
+	return w.w.Flush()
+}
+
+func (w *Writer) write(l line) error {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	if _, err := w.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+// newRecords returns an empty record of every type by its name.
+var newRecords = map[string]func() models.BackupRecord{
+	"team":            func() models.BackupRecord { return &models.BackupTeam{} },
+	"user":            func() models.BackupRecord { return &models.BackupUser{} },
+	"repository":      func() models.BackupRecord { return &models.BackupRepository{} },
+	"code_owner_rule": func() models.BackupRecord { return &models.BackupCodeOwnerRule{} },
+	"pull_request":    func() models.BackupRecord { return &models.BackupPullRequest{} },
+	"event":           func() models.BackupRecord { return &models.BackupEvent{} },
+}
+
+// Reader reads a backup record by record. Malformed records and broken
+// backups are reported as an *errs.ValidationError with the line of the
+// failure.
+type Reader struct {
+	scanner  *bufio.Scanner
+	validate func(any) error
+	line     int
+	records  int
+	done     bool
+
+	// ExportedAt is the time from the header of the backup
+	ExportedAt time.Time
+}
+
+// NewReader reads the header of the backup. Every record is checked with
+// validate, which returns an *errs.ValidationError for invalid ones.
+func NewReader(r io.Reader, validate func(any) error) (*Reader, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
+	br := &Reader{scanner: scanner, validate: validate}
+
+	header, err := br.readLine()
+	switch {
+	case errors.Is(err, io.EOF):
+		return nil, br.fail("type", "header is missing, the backup is empty")
+	case err != nil:
+		return nil, err
+	case header.Type != typeHeader:
+		return nil, br.fail("type", "must be header on the first line")
+	case header.Version != Version:
+		return nil, br.fail("version", fmt.Sprintf("must be %d", Version))
+	}
+	if header.ExportedAt != nil {
+		br.ExportedAt = *header.ExportedAt
+	}
+	return br, nil
+}
+
+// Line returns the line of the last read record.
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// Next returns the next record. After the last record io.EOF is
+// returned.
+func (r *Reader) Next() (models.BackupRecord, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+
+	l, err := r.readLine()
+	if errors.Is(err, io.EOF) {
+		r.line++
+		return nil, r.fail("type", "end is missing, the backup is truncated")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if l.Type == typeEnd {
+		if l.Records == nil || *l.Records != r.records {
+			return nil, r.fail("records", fmt.Sprintf("must be %d", r.records))
+		}
+		r.done = true
+		if _, err := r.readLine(); !errors.Is(err, io.EOF) {
+			return nil, r.fail("type", "must not follow the end")
+		}
+		return nil, io.EOF
+	}
+
+	newRecord, ok := newRecords[l.Type]
+	if !ok {
+		return nil, r.fail("type", "is not a known record type")
+	}
+	record := newRecord()
+	if err := json.Unmarshal(l.Data, record); err != nil {
+		return nil, r.fail("data", "is not a valid "+l.Type)
+	}
+	if err := r.validate(record); err != nil {
+		var validationErr *errs.ValidationError
+		if errors.As(err, &validationErr) {
+			for i := range validationErr.Fields {
+				validationErr.Fields[i].Line = r.line
+			}
+		}
+		return nil, err
+	}
+
+	r.records++
+	return record, nil
+}
+
+// readLine reads the next line that is not blank.
+func (r *Reader) readLine() (*line, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var l line
+		if err := json.Unmarshal(data, &l); err != nil {
+			return nil, r.fail("type", "line is not valid JSON")
+		}
+		return &l, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			r.line++
+			return nil, r.fail("data", "must be at most 16 MB")
+		}
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	return nil, io.EOF
+}
+
+func (r *Reader) fail(field, message string) error {
+	return errs.NewValidationError(errs.FieldError{Line: r.line, Field: field, Message: message})
+}
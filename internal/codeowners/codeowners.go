@@ -0,0 +1,72 @@
+// Package codeowners matches file paths against CODEOWNERS-style patterns.
+package codeowners
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Match reports whether the file path matches the pattern.
+//
+// Patterns follow the CODEOWNERS syntax: a leading slash or a slash in
+// the middle anchors the pattern to the repository root, a trailing slash
+// matches everything inside a directory, "*" and "?" match within a single
+// path segment and "**" matches any number of directories.
+func Match(pattern, path string) bool {
+	re, err := compile(pattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(strings.TrimPrefix(path, "/"))
+}
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	dir := strings.HasSuffix(pattern, "/")
+	trimmed := strings.Trim(pattern, "/")
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(trimmed, "/")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+
+	segments := strings.Split(trimmed, "/")
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		if segment == "**" {
+			if last {
+				sb.WriteString(".*")
+			} else {
+				sb.WriteString("(?:.*/)?")
+			}
+			continue
+		}
+
+		for _, r := range segment {
+			switch r {
+			case '*':
+				sb.WriteString("[^/]*")
+			case '?':
+				sb.WriteString("[^/]")
+			default:
+				sb.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		if !last {
+			sb.WriteString("/")
+		}
+	}
+
+	last := segments[len(segments)-1]
+	switch {
+	case dir:
+		sb.WriteString("/.*")
+	case !strings.Contains(last, "*"):
+		// A pattern naming a directory owns everything inside it
+		sb.WriteString("(?:/.*)?")
+	}
+	sb.WriteString("$")
+
+	return regexp.Compile(sb.String())
+}
@@ -1,23 +1,59 @@
 package config
 
 import (
+	"time"
+
 	"github.com/caarlos0/env/v11"
 )
 
 type Config struct {
 	Port       int    `env:"PORT"`
+	GrpcPort   int    `env:"GRPC_PORT"`
 	DbUser     string `env:"DB_USER,required"`
 	DbPassword string `env:"DB_PASSWORD,required"`
 	DbName     string `env:"DB_NAME,required"`
 	DbHost     string `env:"DB_HOST"`
 	DbPort     int    `env:"DB_PORT"`
+
+	// DbTimeout limits the time a request may spend on database queries.
+	// Zero disables the limit.
+	DbTimeout time.Duration `env:"DB_TIMEOUT"`
+	// ShutdownTimeout is how long in-flight requests may run after a
+	// shutdown signal before they are cancelled.
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT"`
+
+	// EventHistory is how many latest events are kept for replay to
+	// reconnecting subscribers of the event stream.
+	EventHistory int `env:"EVENT_HISTORY"`
+
+	TracingExporter string `env:"TRACING_EXPORTER"`
+
+	// APITokens maps bearer tokens to the organizations they act for,
+	// e.g. "token1:org-id1,token2:org-id2".
+	APITokens map[string]string `env:"API_TOKENS"`
+	// TrustOrganizationHeader lets requests without a token select any
+	// organization with the X-Organization-ID header. Enable it only
+	// behind a proxy that authenticates callers and sets the header.
+	TrustOrganizationHeader bool `env:"TRUST_ORGANIZATION_HEADER"`
+
+	// MigrateOnStartup applies the embedded migrations before the servers
+	// start. Replicas starting together wait for each other.
+	MigrateOnStartup bool `env:"MIGRATE_ON_STARTUP"`
 }
 
 func Load() (*Config, error) {
 	cfg := Config{
-		Port:   8080,
-		DbHost: "localhost",
-		DbPort: 5432,
+		Port:     8080,
+		GrpcPort: 9090,
+		DbHost:   "localhost",
+		DbPort:   5432,
+
+		DbTimeout:       5 * time.Second,
+		ShutdownTimeout: 20 * time.Second,
+
+		EventHistory: 1000,
+
+		TracingExporter: "none",
 	}
 
 	if err := env.Parse(&cfg); err != nil {
@@ -6,12 +6,26 @@ import (
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/plugin/opentelemetry/tracing"
 )
 
-func Connect(cfg *config.Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable",
+// DSN returns the connection string of the database from the config.
+func DSN(cfg *config.Config) string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable",
 		cfg.DbHost, cfg.DbUser, cfg.DbPassword, cfg.DbName, cfg.DbPort)
-	return gorm.Open(postgres.Open(dsn), &gorm.Config{
+}
+
+func Connect(cfg *config.Config) (*gorm.DB, error) {
+	conn, err := gorm.Open(postgres.Open(DSN(cfg)), &gorm.Config{
 		TranslateError: true,
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Use(tracing.NewPlugin(tracing.WithoutMetrics(), tracing.WithoutQueryVariables())); err != nil {
+		return nil, fmt.Errorf("failed to set up query tracing: %w", err)
+	}
+
+	return conn, nil
 }
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reviewers/migrations"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratepg "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// migrationLockID is the key of the advisory lock held while migrating.
+// It differs from the key migrate locks by itself, because that lock is
+// taken on another connection of the same process.
+const migrationLockID = 4185520733
+
+// Migrator applies the migrations embedded into the binary. It holds a
+// Postgres advisory lock from NewMigrator until Close, so that replicas
+// starting together migrate the database one after another.
+type Migrator struct {
+	db   *sql.DB
+	lock *sql.Conn
+	m    *migrate.Migrate
+}
+
+// NewMigrator connects to the database and waits for the migration lock.
+func NewMigrator(ctx context.Context, dsn string) (*Migrator, error) {
+	sqlDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := sqlDB.Conn(ctx)
+	if err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if _, err := lock.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		lock.Close()
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to lock migrations: %w", err)
+	}
+
+	m, err := newMigrate(sqlDB)
+	if err != nil {
+		lock.Close()
+		sqlDB.Close()
+		return nil, err
+	}
+
+	return &Migrator{db: sqlDB, lock: lock, m: m}, nil
+}
+
+func newMigrate(sqlDB *sql.DB) (*migrate.Migrate, error) {
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	driver, err := migratepg.WithInstance(sqlDB, &migratepg.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare migrations: %w", err)
+	}
+
+	return migrate.NewWithInstance("iofs", source, "postgres", driver)
+}
+
+// Up applies all migrations that are not applied yet.
+func (m *Migrator) Up() error {
+	if err := m.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Down reverts the last applied migration. Nothing is done when no
+// migration is applied.
+func (m *Migrator) Down() error {
+	err := m.m.Steps(-1)
+	var shortLimit migrate.ErrShortLimit
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) && !errors.As(err, &shortLimit) {
+		return err
+	}
+	return nil
+}
+
+// Version returns the version of the last applied migration and whether
+// it failed halfway. The version is zero when no migration is applied.
+func (m *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// Close releases the migration lock and disconnects from the database.
+func (m *Migrator) Close() error {
+	_, unlockErr := m.lock.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+	lockErr := m.lock.Close()
+	sourceErr, dbErr := m.m.Close()
+	return errors.Join(unlockErr, lockErr, sourceErr, dbErr)
+}
@@ -0,0 +1,234 @@
+// Package events delivers changes of pull requests to subscribers within
+// the process.
+package events
+
+import (
+	"reviewers/internal/models"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	PullRequestCreated = "pr.created"
+	ReviewerAssigned   = "reviewer.assigned"
+	ReviewerReassigned = "reviewer.reassigned"
+	PullRequestMerged  = "pr.merged"
+)
+
+// subscriberBuffer is how many events a subscriber may lag behind before
+// it is dropped.
+const subscriberBuffer = 64
+
+// Event is a change of a pull request. ReviewerID is the assigned reviewer
+// of reviewer.assigned and the new reviewer of reviewer.reassigned.
+type Event struct {
+	ID             uint64 `json:"-"`
+	Type           string `json:"-"`
+	OrganizationID string `json:"-"`
+
+	Time              time.Time `json:"time"`
+	Repository        string    `json:"repository"`
+	PullRequestID     string    `json:"pull_request_id"`
+	PullRequestName   string    `json:"pull_request_name"`
+	AuthorID          string    `json:"author_id"`
+	Status            string    `json:"status"`
+	AssignedReviewers []string  `json:"assigned_reviewers"`
+	ReviewerID        string    `json:"reviewer_id,omitempty"`
+	OldReviewerID     string    `json:"old_reviewer_id,omitempty"`
+}
+
+// NewEvent returns an event of the pull request happened now.
+func NewEvent(eventType string, pr *models.PullRequest) Event {
+	return Event{
+		Type:              eventType,
+		OrganizationID:    pr.OrganizationID,
+		Time:              time.Now(),
+		Repository:        pr.Repository,
+		PullRequestID:     pr.ID,
+		PullRequestName:   pr.Name,
+		AuthorID:          pr.AuthorID,
+		Status:            pr.Status,
+		AssignedReviewers: slices.Clone(pr.AssignedReviewers),
+	}
+}
+
+// involves reports whether the event is about any of the users: the
+// author, a reviewer or the replaced reviewer.
+func (e *Event) involves(users map[string]bool) bool {
+	if users[e.AuthorID] || users[e.OldReviewerID] {
+		return true
+	}
+	for _, reviewer := range e.AssignedReviewers {
+		if users[reviewer] {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter selects the events of a subscriber. Users limit the events to
+// the ones about any of them, nil Users match every event of the
+// organization.
+type Filter struct {
+	OrganizationID string
+	Users          []string
+}
+
+type Subscription struct {
+	organizationID string
+	users          map[string]bool
+	events         chan Event
+	broker         *Broker
+}
+
+// Events returns the events of the subscription. The channel is closed
+// when the subscription is closed, the broker shuts down or the
+// subscriber falls too far behind.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close stops the delivery of events.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
+
+func (s *Subscription) matches(e *Event) bool {
+	return e.OrganizationID == s.organizationID && (s.users == nil || e.involves(s.users))
+}
+
+// Broker fans out published events to the matching subscribers and keeps
+// the latest ones, so that a reconnecting subscriber gets the events it
+// missed. Event IDs grow and continue from the time the broker is
+// created in microseconds, so that IDs of an earlier lifetime of the
+// broker are lower than all IDs of the current one.
+type Broker struct {
+	mu sync.Mutex
+	// firstID is the ID of the first event of the lifetime
+	firstID uint64
+	lastID  uint64
+	// history is a ring buffer of the latest events, an event is stored
+	// at its ID modulo the size of the history
+	history     []Event
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+// NewBroker returns a broker remembering the last historySize events.
+func NewBroker(historySize int) *Broker {
+	startID := uint64(time.Now().UnixMicro())
+	return &Broker{
+		firstID:     startID + 1,
+		lastID:      startID,
+		history:     make([]Event, max(historySize, 0)),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish assigns IDs to the events and delivers them. A subscriber that
+// cannot keep up is dropped and has to resubscribe.
+func (b *Broker) Publish(events ...Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, event := range events {
+		b.lastID++
+		event.ID = b.lastID
+
+		if len(b.history) > 0 {
+			b.history[b.index(event.ID)] = event
+		}
+
+		for s := range b.subscribers {
+			if !s.matches(&event) {
+				continue
+			}
+			select {
+			case s.events <- event:
+			default:
+				b.remove(s)
+			}
+		}
+	}
+}
+
+// Replay holds what a reconnecting subscriber missed.
+type Replay struct {
+	// Events are the remembered events matching the filter published
+	// after the last event the subscriber received.
+	Events []Event
+	// Lost reports that the missed events cannot be replayed: the last
+	// event is older than the history or unknown to this lifetime of the
+	// broker. Events is empty then.
+	Lost bool
+	// LastID is the ID of the last event published before the
+	// subscription.
+	LastID uint64
+}
+
+// Subscribe starts the delivery of the events matching the filter. If
+// lastEventID is not zero, the replay holds the events published after
+// it, they precede the events of the subscription.
+func (b *Broker) Subscribe(filter Filter, lastEventID uint64) (Replay, *Subscription) {
+	s := &Subscription{
+		organizationID: filter.OrganizationID,
+		events:         make(chan Event, subscriberBuffer),
+		broker:         b,
+	}
+	if filter.Users != nil {
+		s.users = make(map[string]bool, len(filter.Users))
+		for _, user := range filter.Users {
+			s.users[user] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(s.events)
+		return Replay{}, s
+	}
+	b.subscribers[s] = struct{}{}
+
+	replay := Replay{LastID: b.lastID}
+	if lastEventID == 0 {
+		return replay, s
+	}
+	// The events after lastEventID must all be in the history
+	if lastEventID < b.firstID-1 || lastEventID > b.lastID || b.lastID-lastEventID > uint64(len(b.history)) {
+		replay.Lost = true
+		return replay, s
+	}
+	for id := lastEventID + 1; id <= b.lastID; id++ {
+		if event := b.history[b.index(id)]; s.matches(&event) {
+			replay.Events = append(replay.Events, event)
+		}
+	}
+	return replay, s
+}
+
+func (b *Broker) index(id uint64) uint64 {
+	return id % uint64(len(b.history))
+}
+
+// Close ends all subscriptions and rejects new ones.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for s := range b.subscribers {
+		b.remove(s)
+	}
+}
+
+func (b *Broker) remove(s *Subscription) {
+	if _, ok := b.subscribers[s]; ok {
+		delete(b.subscribers, s)
+		close(s.events)
+	}
+}
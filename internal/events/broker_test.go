@@ -0,0 +1,89 @@
+package events_test
+
+import (
+	"reviewers/internal/events"
+	"reviewers/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// publish publishes an event for every pull request and returns the ID of
+// the last one.
+func publish(t *testing.T, broker *events.Broker, prIDs ...string) uint64 {
+	subscription := subscribe(broker)
+	defer subscription.Close()
+
+	for _, id := range prIDs {
+		broker.Publish(events.NewEvent(events.PullRequestCreated, &models.PullRequest{
+			OrganizationID: models.DefaultOrganizationID,
+			ID:             id,
+		}))
+	}
+
+	var last events.Event
+	for range prIDs {
+		last = <-subscription.Events()
+	}
+	require.Equal(t, prIDs[len(prIDs)-1], last.PullRequestID)
+	return last.ID
+}
+
+func subscribe(broker *events.Broker) *events.Subscription {
+	_, subscription := broker.Subscribe(events.Filter{OrganizationID: models.DefaultOrganizationID}, 0)
+	return subscription
+}
+
+func replay(broker *events.Broker, lastEventID uint64) events.Replay {
+	replay, subscription := broker.Subscribe(events.Filter{OrganizationID: models.DefaultOrganizationID}, lastEventID)
+	subscription.Close()
+	return replay
+}
+
+func replayed(replay events.Replay) []string {
+	ids := []string{}
+	for _, event := range replay.Events {
+		ids = append(ids, event.PullRequestID)
+	}
+	return ids
+}
+
+func TestBroker_Replay(t *testing.T) {
+	broker := events.NewBroker(3)
+	start := replay(broker, 0).LastID
+	second := publish(t, broker, "pr-1", "pr-2")
+	first := second - 1
+	assert.Equal(t, start+1, first)
+
+	assert.Equal(t, []string{"pr-2"}, replayed(replay(broker, first)))
+	assert.Equal(t, events.Replay{LastID: second}, replay(broker, second))
+	assert.Equal(t, []string{"pr-1", "pr-2"}, replayed(replay(broker, start)))
+
+	// The history keeps only the last events once it wraps around
+	fifth := publish(t, broker, "pr-3", "pr-4", "pr-5")
+	assert.Equal(t, []string{"pr-3", "pr-4", "pr-5"}, replayed(replay(broker, second)))
+	assert.Equal(t, []string{"pr-5"}, replayed(replay(broker, fifth-1)))
+	assert.Equal(t, events.Replay{Lost: true, LastID: fifth}, replay(broker, first))
+}
+
+func TestBroker_ReplayLost(t *testing.T) {
+	earlier := events.NewBroker(3)
+	earlierID := publish(t, earlier, "pr-1")
+	// A restart takes longer than a microsecond per event
+	time.Sleep(time.Millisecond)
+
+	// IDs of an earlier lifetime and unknown IDs cannot be replayed
+	broker := events.NewBroker(3)
+	last := publish(t, broker, "pr-2")
+	assert.Greater(t, last, earlierID)
+	assert.True(t, replay(broker, earlierID).Lost)
+	assert.True(t, replay(broker, last+1).Lost)
+
+	// Without a history only the last event can be resumed from
+	broker = events.NewBroker(0)
+	last = publish(t, broker, "pr-1", "pr-2")
+	assert.False(t, replay(broker, last).Lost)
+	assert.True(t, replay(broker, last-1).Lost)
+}
@@ -0,0 +1,62 @@
+// Package graphql serves nested reads of teams, users and pull requests
+// in a single request.
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"log/slog"
+	"reviewers/pkg/errs"
+
+	gql "github.com/graph-gophers/graphql-go"
+)
+
+//go:embed schema.graphql
+var schema string
+
+// Request is a GraphQL request in the format of the GraphQL over HTTP
+// specification.
+type Request struct {
+	Query         string         `json:"query" binding:"required,max=100000"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type Server struct {
+	schema *gql.Schema
+	repos  Repositories
+	logger *slog.Logger
+}
+
+// NewServer parses the schema and panics if it does not match the
+// resolvers.
+func NewServer(logger *slog.Logger, repos Repositories) *Server {
+	s := gql.MustParseSchema(schema, &queryResolver{},
+		gql.MaxDepth(10),
+		gql.MaxParallelism(100),
+	)
+	return &Server{schema: s, repos: repos, logger: logger}
+}
+
+// Execute runs the request in the organization. Errors of resolvers are
+// reported with the message and code of the HTTP error response, the
+// code is in the "code" extension.
+func (s *Server) Execute(ctx context.Context, orgID string, req Request) *gql.Response {
+	ctx = withLoaders(ctx, newLoaders(s.repos, orgID))
+	resp := s.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+	for _, queryErr := range resp.Errors {
+		if queryErr.ResolverError == nil {
+			continue
+		}
+
+		status, response := errs.ToResponse(queryErr.ResolverError)
+		if status >= 500 {
+			s.logger.ErrorContext(ctx, "resolver failed", "error", queryErr.ResolverError, "path", queryErr.Path)
+		}
+		queryErr.Message = response.Error.Message
+		queryErr.Extensions = map[string]any{"code": response.Error.Code}
+	}
+
+	return resp
+}
@@ -0,0 +1,141 @@
+package graphql
+
+import (
+	"context"
+	"reviewers/internal/models"
+	"reviewers/internal/repository"
+	"sync"
+
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+// Repositories are the data sources of the resolvers.
+type Repositories struct {
+	Teams        repository.TeamStore
+	Users        repository.UserStore
+	Repositories repository.RepoStore
+	PullRequests repository.PRStore
+}
+
+// loaders batch the lookups of a single request, so resolving a field of
+// every item of a list takes one query instead of one per item. Queries
+// of a request run one at a time, so a request holds at most one
+// database connection.
+type loaders struct {
+	repos Repositories
+	orgID string
+	mu    sync.Mutex
+
+	teams        *dataloader.Loader[string, *models.Team]
+	subteams     *dataloader.Loader[string, []models.Team]
+	members      *dataloader.Loader[string, []models.User]
+	users        *dataloader.Loader[string, *models.User]
+	reviews      *dataloader.Loader[string, []models.PullRequest]
+	repositories *dataloader.Loader[string, *models.Repository]
+}
+
+type loadersKey struct{}
+
+func newLoaders(repos Repositories, orgID string) *loaders {
+	l := &loaders{repos: repos, orgID: orgID}
+
+	l.teams = dataloader.NewBatchedLoader(func(ctx context.Context, ids []string) []*dataloader.Result[*models.Team] {
+		var teams []models.Team
+		err := l.query(func() (err error) {
+			teams, err = repos.Teams.GetByIDs(ctx, orgID, ids)
+			return err
+		})
+		return byID(ids, teams, err, func(t *models.Team) string { return t.ID })
+	})
+
+	l.subteams = dataloader.NewBatchedLoader(func(ctx context.Context, ids []string) []*dataloader.Result[[]models.Team] {
+		var teams []models.Team
+		err := l.query(func() (err error) {
+			teams, err = repos.Teams.GetByParentIDs(ctx, orgID, ids)
+			return err
+		})
+		return groupBy(ids, teams, err, func(t *models.Team) []string { return []string{*t.ParentID} })
+	})
+
+	l.members = dataloader.NewBatchedLoader(func(ctx context.Context, ids []string) []*dataloader.Result[[]models.User] {
+		var users []models.User
+		err := l.query(func() (err error) {
+			users, err = repos.Users.GetByTeamIDs(ctx, orgID, ids)
+			return err
+		})
+		return groupBy(ids, users, err, func(u *models.User) []string { return []string{u.TeamID} })
+	})
+
+	l.users = dataloader.NewBatchedLoader(func(ctx context.Context, ids []string) []*dataloader.Result[*models.User] {
+		var users []models.User
+		err := l.query(func() (err error) {
+			users, err = repos.Users.GetByIDs(ctx, orgID, ids)
+			return err
+		})
+		return byID(ids, users, err, func(u *models.User) string { return u.ID })
+	})
+
+	l.reviews = dataloader.NewBatchedLoader(func(ctx context.Context, ids []string) []*dataloader.Result[[]models.PullRequest] {
+		var prs []models.PullRequest
+		err := l.query(func() (err error) {
+			prs, err = repos.PullRequests.GetByReviewerIDs(ctx, orgID, ids)
+			return err
+		})
+		return groupBy(ids, prs, err, func(pr *models.PullRequest) []string { return pr.AssignedReviewers })
+	})
+
+	l.repositories = dataloader.NewBatchedLoader(func(ctx context.Context, ids []string) []*dataloader.Result[*models.Repository] {
+		var repositories []models.Repository
+		err := l.query(func() (err error) {
+			repositories, err = repos.Repositories.GetByIDs(ctx, orgID, ids)
+			return err
+		})
+		return byID(ids, repositories, err, func(r *models.Repository) string { return r.ID })
+	})
+
+	return l
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// query runs a query of the request after the previous one finishes.
+func (l *loaders) query(fn func() error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return fn()
+}
+
+// byID returns the item with each key, or nil if there is none.
+func byID[V any](keys []string, items []V, err error, id func(*V) string) []*dataloader.Result[*V] {
+	results := make([]*dataloader.Result[*V], len(keys))
+	index := make(map[string]*V, len(items))
+	for i := range items {
+		index[id(&items[i])] = &items[i]
+	}
+	for i, key := range keys {
+		results[i] = &dataloader.Result[*V]{Data: index[key], Error: err}
+	}
+	return results
+}
+
+// groupBy returns the items of each key in the order of items. An item
+// may belong to several keys.
+func groupBy[V any](keys []string, items []V, err error, groups func(*V) []string) []*dataloader.Result[[]V] {
+	results := make([]*dataloader.Result[[]V], len(keys))
+	index := make(map[string][]V, len(keys))
+	for i := range items {
+		for _, key := range groups(&items[i]) {
+			index[key] = append(index[key], items[i])
+		}
+	}
+	for i, key := range keys {
+		results[i] = &dataloader.Result[[]V]{Data: index[key], Error: err}
+	}
+	return results
+}
@@ -0,0 +1,230 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+
+	"github.com/google/uuid"
+	gql "github.com/graph-gophers/graphql-go"
+)
+
+type queryResolver struct{}
+
+func (r *queryResolver) Team(ctx context.Context, args struct{ Name string }) (*teamResolver, error) {
+	l := loadersFrom(ctx)
+
+	var team *models.Team
+	err := l.query(func() (err error) {
+		team, err = l.repos.Teams.GetTeam(ctx, l.orgID, args.Name, false)
+		return err
+	})
+	if errors.Is(err, errs.ResourceNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &teamResolver{team}, nil
+}
+
+func (r *queryResolver) User(ctx context.Context, args struct{ ID gql.ID }) (*userResolver, error) {
+	if _, err := uuid.Parse(string(args.ID)); err != nil {
+		return nil, nil
+	}
+
+	user, err := loadersFrom(ctx).users.Load(ctx, string(args.ID))()
+	if err != nil || user == nil {
+		return nil, err
+	}
+	return &userResolver{user}, nil
+}
+
+func (r *queryResolver) PullRequest(ctx context.Context, args struct {
+	ID         string
+	Repository *string
+}) (*pullRequestResolver, error) {
+	l := loadersFrom(ctx)
+
+	name := models.DefaultRepository
+	if args.Repository != nil {
+		name = *args.Repository
+	}
+
+	var pr *models.PullRequest
+	err := l.query(func() error {
+		repo, err := l.repos.Repositories.GetByName(ctx, l.orgID, name)
+		if err != nil {
+			return err
+		}
+		pr, err = l.repos.PullRequests.Get(ctx, l.orgID, repo.ID, args.ID)
+		return err
+	})
+	if errors.Is(err, errs.ResourceNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	pr.Repository = name
+	return &pullRequestResolver{pr}, nil
+}
+
+type teamResolver struct {
+	team *models.Team
+}
+
+func (r *teamResolver) ID() gql.ID {
+	return gql.ID(r.team.ID)
+}
+
+func (r *teamResolver) Name() string {
+	return r.team.Name
+}
+
+func (r *teamResolver) Parent(ctx context.Context) (*teamResolver, error) {
+	if r.team.ParentID == nil {
+		return nil, nil
+	}
+	parent, err := loadersFrom(ctx).teams.Load(ctx, *r.team.ParentID)()
+	if err != nil || parent == nil {
+		return nil, err
+	}
+	return &teamResolver{parent}, nil
+}
+
+func (r *teamResolver) Members(ctx context.Context) ([]*userResolver, error) {
+	members, err := loadersFrom(ctx).members.Load(ctx, r.team.ID)()
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*userResolver, len(members))
+	for i := range members {
+		resolvers[i] = &userResolver{&members[i]}
+	}
+	return resolvers, nil
+}
+
+func (r *teamResolver) Subteams(ctx context.Context) ([]*teamResolver, error) {
+	subteams, err := loadersFrom(ctx).subteams.Load(ctx, r.team.ID)()
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*teamResolver, len(subteams))
+	for i := range subteams {
+		resolvers[i] = &teamResolver{&subteams[i]}
+	}
+	return resolvers, nil
+}
+
+type userResolver struct {
+	user *models.User
+}
+
+func (r *userResolver) ID() gql.ID {
+	return gql.ID(r.user.ID)
+}
+
+func (r *userResolver) Username() string {
+	return r.user.Username
+}
+
+func (r *userResolver) IsActive() bool {
+	return r.user.IsActive
+}
+
+func (r *userResolver) Team(ctx context.Context) (*teamResolver, error) {
+	team, err := loadersFrom(ctx).teams.Load(ctx, r.user.TeamID)()
+	if err != nil || team == nil {
+		return nil, err
+	}
+	return &teamResolver{team}, nil
+}
+
+func (r *userResolver) Reviews(ctx context.Context, args struct{ Status *string }) ([]*pullRequestResolver, error) {
+	prs, err := loadersFrom(ctx).reviews.Load(ctx, r.user.ID)()
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*pullRequestResolver, 0, len(prs))
+	for i := range prs {
+		if args.Status == nil || prs[i].Status == *args.Status {
+			resolvers = append(resolvers, &pullRequestResolver{&prs[i]})
+		}
+	}
+	return resolvers, nil
+}
+
+type pullRequestResolver struct {
+	pr *models.PullRequest
+}
+
+func (r *pullRequestResolver) ID() string {
+	return r.pr.ID
+}
+
+func (r *pullRequestResolver) Repository(ctx context.Context) (string, error) {
+	if r.pr.Repository != "" {
+		return r.pr.Repository, nil
+	}
+	repo, err := loadersFrom(ctx).repositories.Load(ctx, r.pr.RepositoryID)()
+	if err != nil {
+		return "", err
+	}
+	if repo == nil {
+		return "", errs.ResourceNotFound
+	}
+	return repo.Name, nil
+}
+
+func (r *pullRequestResolver) Name() string {
+	return r.pr.Name
+}
+
+func (r *pullRequestResolver) Status() string {
+	return r.pr.Status
+}
+
+func (r *pullRequestResolver) CreatedAt() gql.Time {
+	return gql.Time{Time: r.pr.CreatedAt}
+}
+
+func (r *pullRequestResolver) MergedAt() *gql.Time {
+	if r.pr.MergedAt == nil {
+		return nil
+	}
+	return &gql.Time{Time: *r.pr.MergedAt}
+}
+
+func (r *pullRequestResolver) Author(ctx context.Context) (*userResolver, error) {
+	author, err := loadersFrom(ctx).users.Load(ctx, r.pr.AuthorID)()
+	if err != nil || author == nil {
+		return nil, err
+	}
+	return &userResolver{author}, nil
+}
+
+func (r *pullRequestResolver) Reviewers(ctx context.Context) ([]*userResolver, error) {
+	users, loadErrs := loadersFrom(ctx).users.LoadMany(ctx, r.pr.AssignedReviewers)()
+	resolvers := make([]*userResolver, 0, len(users))
+	for i, user := range users {
+		if loadErrs != nil && loadErrs[i] != nil {
+			return nil, loadErrs[i]
+		}
+		if user != nil {
+			resolvers = append(resolvers, &userResolver{user})
+		}
+	}
+	return resolvers, nil
+}
+
+func (r *pullRequestResolver) ChangedFiles() []string {
+	if r.pr.ChangedFiles == nil {
+		return []string{}
+	}
+	return r.pr.ChangedFiles
+}
@@ -0,0 +1,108 @@
+package grpcserver
+
+import (
+	"reviewers/internal/models"
+	pb "reviewers/pkg/pb/reviewers/v1"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toTeam(team *models.Team) *pb.Team {
+	result := &pb.Team{
+		TeamName:       team.Name,
+		ParentTeamName: team.ParentName,
+		Members:        make([]*pb.User, 0, len(team.Members)),
+	}
+	for _, user := range team.Members {
+		result.Members = append(result.Members, &pb.User{
+			UserId:   user.ID,
+			Username: user.Username,
+			IsActive: user.IsActive,
+		})
+	}
+	for i := range team.Subteams {
+		result.Subteams = append(result.Subteams, toTeam(&team.Subteams[i]))
+	}
+	return result
+}
+
+func fromTeam(team *pb.Team) *models.Team {
+	result := &models.Team{
+		Name:       team.GetTeamName(),
+		ParentName: team.GetParentTeamName(),
+		Members:    make([]models.User, 0, len(team.GetMembers())),
+	}
+	for _, user := range team.GetMembers() {
+		result.Members = append(result.Members, models.User{
+			ID:       user.GetUserId(),
+			Username: user.GetUsername(),
+			IsActive: user.GetIsActive(),
+		})
+	}
+	return result
+}
+
+func toTeamStats(stats *models.TeamStats) *pb.TeamStats {
+	result := &pb.TeamStats{
+		TeamName:        stats.TeamName,
+		Members:         stats.Members,
+		ActiveMembers:   stats.ActiveMembers,
+		AssignedReviews: stats.AssignedReviews,
+		OpenReviews:     stats.OpenReviews,
+	}
+	for i := range stats.Subteams {
+		result.Subteams = append(result.Subteams, toTeamStats(&stats.Subteams[i]))
+	}
+	return result
+}
+
+func toPullRequestStatus(status string) pb.PullRequestStatus {
+	switch status {
+	case models.StatusOpen:
+		return pb.PullRequestStatus_PULL_REQUEST_STATUS_OPEN
+	case models.StatusMerged:
+		return pb.PullRequestStatus_PULL_REQUEST_STATUS_MERGED
+	default:
+		return pb.PullRequestStatus_PULL_REQUEST_STATUS_UNSPECIFIED
+	}
+}
+
+// fromPullRequestStatus returns an empty status for the unspecified one.
+func fromPullRequestStatus(status pb.PullRequestStatus) string {
+	switch status {
+	case pb.PullRequestStatus_PULL_REQUEST_STATUS_OPEN:
+		return models.StatusOpen
+	case pb.PullRequestStatus_PULL_REQUEST_STATUS_MERGED:
+		return models.StatusMerged
+	default:
+		return ""
+	}
+}
+
+func toPullRequest(pr *models.PullRequest) *pb.PullRequest {
+	result := &pb.PullRequest{
+		Repository:        pr.Repository,
+		PullRequestId:     pr.ID,
+		PullRequestName:   pr.Name,
+		AuthorId:          pr.AuthorID,
+		Status:            toPullRequestStatus(pr.Status),
+		CreatedAt:         timestamppb.New(pr.CreatedAt),
+		AssignedReviewers: pr.AssignedReviewers,
+		ChangedFiles:      pr.ChangedFiles,
+	}
+	if pr.MergedAt != nil {
+		result.MergedAt = timestamppb.New(*pr.MergedAt)
+	}
+	return result
+}
+
+func toPullRequestShort(pr models.PullRequestShort) *pb.PullRequestShort {
+	return &pb.PullRequestShort{
+		Repository:      pr.Repository,
+		PullRequestId:   pr.ID,
+		PullRequestName: pr.Name,
+		AuthorId:        pr.AuthorID,
+		Status:          toPullRequestStatus(pr.Status),
+		CreatedAt:       timestamppb.New(pr.CreatedAt),
+	}
+}
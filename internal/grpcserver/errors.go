@@ -0,0 +1,86 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reviewers/pkg/errs"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// ErrorDomain is the domain of the ErrorInfo details of returned
+// statuses. The reason of the details is the errs.ErrorCode of the error.
+const ErrorDomain = "reviewers"
+
+// ErrorInterceptor converts the errors of the services to gRPC statuses.
+func ErrorInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+
+		st := toStatus(err)
+		if st.Code() == codes.Internal {
+			logger.ErrorContext(ctx, "call failed", "error", err, "method", info.FullMethod)
+		}
+		return nil, st.Err()
+	}
+}
+
+// toStatus converts the error to a status with the same message as the
+// HTTP error response. The status details carry the error code and the
+// invalid fields.
+func toStatus(err error) *status.Status {
+	if errors.Is(err, context.Canceled) {
+		return status.New(codes.Canceled, err.Error())
+	}
+
+	_, response := errs.ToResponse(err)
+	code, _ := errs.ParseErrorCode(response.Error.Code)
+	st := status.New(grpcCode(code), response.Error.Message)
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: response.Error.Code, Domain: ErrorDomain}}
+	if len(response.Error.Fields) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, field := range response.Error.Fields {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       field.Field,
+				Description: field.Message,
+			})
+		}
+		details = append(details, badRequest)
+	}
+
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		return withDetails
+	}
+	return st
+}
+
+func grpcCode(code errs.ErrorCode) codes.Code {
+	switch code {
+	case errs.CodeNotFound:
+		return codes.NotFound
+	case errs.CodeTeamExists, errs.CodePRExists, errs.CodeRepositoryExists, errs.CodeOrganizationExists, errs.CodeUserExists:
+		return codes.AlreadyExists
+	case errs.CodePRMerged, errs.CodeNotAssigned, errs.CodeNoCandidate, errs.CodeInvalidHierarchy, errs.CodeNotEmpty,
+		errs.CodeUserHasPullRequests:
+		return codes.FailedPrecondition
+	case errs.CodeUnauthorized:
+		return codes.Unauthenticated
+	case errs.CodeForbidden:
+		return codes.PermissionDenied
+	case errs.CodeValidationFailed:
+		return codes.InvalidArgument
+	case errs.CodeTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
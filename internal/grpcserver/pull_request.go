@@ -0,0 +1,97 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reviewers/internal/models"
+	"reviewers/internal/service"
+	"reviewers/internal/validation"
+	"reviewers/pkg/errs"
+	pb "reviewers/pkg/pb/reviewers/v1"
+)
+
+type pullRequestServer struct {
+	pb.UnimplementedPullRequestServiceServer
+	service *service.PRService
+}
+
+func (s *pullRequestServer) CreatePullRequest(ctx context.Context, req *pb.CreatePullRequestRequest) (*pb.PullRequest, error) {
+	body := validation.CreatePRRequest{
+		ID:           req.GetPullRequestId(),
+		Name:         req.GetPullRequestName(),
+		AuthorID:     req.GetAuthorId(),
+		Repository:   req.GetRepository(),
+		ChangedFiles: req.GetChangedFiles(),
+	}
+	if err := validation.Validate(&body); err != nil {
+		return nil, err
+	}
+
+	pr := &models.PullRequest{
+		ID:           body.ID,
+		Name:         body.Name,
+		AuthorID:     body.AuthorID,
+		Repository:   body.Repository,
+		ChangedFiles: body.ChangedFiles,
+	}
+
+	if err := s.service.Create(ctx, organizationID(ctx), pr); err != nil {
+		if errors.Is(err, errs.PullRequestExists) {
+			err = errs.PullRequestExists.WithMessage(fmt.Sprintf("PR %s already exists", pr.ID))
+		}
+		return nil, err
+	}
+
+	return toPullRequest(pr), nil
+}
+
+func (s *pullRequestServer) MergePullRequest(ctx context.Context, req *pb.MergePullRequestRequest) (*pb.PullRequest, error) {
+	body := validation.MergePRRequest{Repository: req.GetRepository(), PullRequestID: req.GetPullRequestId()}
+	if err := validation.Validate(&body); err != nil {
+		return nil, err
+	}
+
+	pr, err := s.service.Merge(ctx, organizationID(ctx), body.Repository, body.PullRequestID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toPullRequest(pr), nil
+}
+
+func (s *pullRequestServer) ReassignReviewer(ctx context.Context, req *pb.ReassignReviewerRequest) (*pb.PullRequest, error) {
+	body := validation.ReassignReviewerRequest{
+		Repository:    req.GetRepository(),
+		PullRequestID: req.GetPullRequestId(),
+		OldReviewerID: req.GetOldReviewerId(),
+	}
+	if err := validation.Validate(&body); err != nil {
+		return nil, err
+	}
+
+	pr, err := s.service.Reassign(ctx, organizationID(ctx), body.Repository, body.PullRequestID, body.OldReviewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toPullRequest(pr), nil
+}
+
+func (s *pullRequestServer) ApprovePullRequest(ctx context.Context, req *pb.ApprovePullRequestRequest) (*pb.PullRequest, error) {
+	body := validation.ApprovePRRequest{
+		Repository:    req.GetRepository(),
+		PullRequestID: req.GetPullRequestId(),
+		ReviewerID:    req.GetReviewerId(),
+	}
+	if err := validation.Validate(&body); err != nil {
+		return nil, err
+	}
+
+	pr, err := s.service.Approve(ctx, organizationID(ctx), body.Repository, body.PullRequestID, body.ReviewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toPullRequest(pr), nil
+}
@@ -0,0 +1,62 @@
+// Package grpcserver exposes the team, user and pull request operations
+// over gRPC. It calls the same services as the HTTP handlers.
+package grpcserver
+
+import (
+	"context"
+	"log/slog"
+	"reviewers/internal/auth"
+	"reviewers/internal/events"
+	"reviewers/internal/repository"
+	"reviewers/internal/service"
+	pb "reviewers/pkg/pb/reviewers/v1"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// New returns a gRPC server with every service registered, served from
+// the stores. Calls are authenticated the same way as HTTP requests, the
+// timeout limits the time of a call the same way as
+// handler.TimeoutMiddleware, changes of pull requests are published to
+// the broker.
+func New(logger *slog.Logger, stores *repository.Stores, authCfg auth.Config, timeout time.Duration, broker *events.Broker) *grpc.Server {
+	orgService := service.NewOrganizationService(stores.Organizations)
+	userService := service.NewUserService(stores.Users)
+	teamService := service.NewTeamService(stores.Teams)
+	repoService := service.NewRepoService(stores.Repositories)
+	codeOwnerService := service.NewCodeOwnerService(stores.CodeOwners, repoService, userService)
+	prService := service.NewPRService(stores.PullRequests, repoService, teamService, userService, codeOwnerService, broker)
+
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			ErrorInterceptor(logger),
+			TimeoutInterceptor(timeout),
+			TenantInterceptor(orgService, authCfg),
+		),
+	)
+
+	pb.RegisterTeamServiceServer(server, &teamServer{service: teamService})
+	pb.RegisterUserServiceServer(server, &userServer{service: userService})
+	pb.RegisterPullRequestServiceServer(server, &pullRequestServer{service: prService})
+	reflection.Register(server)
+
+	return server
+}
+
+// TimeoutInterceptor limits the lifetime of the call context. A zero
+// timeout leaves the context as is.
+func TimeoutInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if timeout <= 0 {
+			return handler(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
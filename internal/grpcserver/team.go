@@ -0,0 +1,86 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reviewers/internal/service"
+	"reviewers/internal/validation"
+	"reviewers/pkg/errs"
+	pb "reviewers/pkg/pb/reviewers/v1"
+)
+
+type teamServer struct {
+	pb.UnimplementedTeamServiceServer
+	service *service.TeamService
+}
+
+func (s *teamServer) GetTeam(ctx context.Context, req *pb.GetTeamRequest) (*pb.Team, error) {
+	query := validation.GetTeamQuery{TeamName: req.GetTeamName(), IncludeSubteams: req.GetIncludeSubteams()}
+	if err := validation.Validate(&query); err != nil {
+		return nil, err
+	}
+
+	team, err := s.service.GetTeam(ctx, organizationID(ctx), query.TeamName, query.IncludeSubteams)
+	if err != nil {
+		return nil, err
+	}
+
+	return toTeam(team), nil
+}
+
+func (s *teamServer) CreateTeam(ctx context.Context, req *pb.CreateTeamRequest) (*pb.Team, error) {
+	team := fromTeam(req.GetTeam())
+	if err := validation.Validate(team); err != nil {
+		return nil, err
+	}
+
+	if err := s.service.CreateTeam(ctx, organizationID(ctx), team); err != nil {
+		if errors.Is(err, errs.TeamExists) {
+			err = errs.TeamExists.WithMessage(fmt.Sprintf("%s already exists", team.Name))
+		}
+		return nil, err
+	}
+
+	return toTeam(team), nil
+}
+
+func (s *teamServer) DeactivateTeam(ctx context.Context, req *pb.DeactivateTeamRequest) (*pb.DeactivateTeamResponse, error) {
+	query := validation.DeactivateTeamQuery{TeamID: req.GetTeamId()}
+	if err := validation.Validate(&query); err != nil {
+		return nil, err
+	}
+
+	if err := s.service.DeactivateTeam(ctx, organizationID(ctx), query.TeamID); err != nil {
+		return nil, err
+	}
+
+	return &pb.DeactivateTeamResponse{}, nil
+}
+
+func (s *teamServer) SetParent(ctx context.Context, req *pb.SetParentRequest) (*pb.SetParentResponse, error) {
+	body := validation.SetParentRequest{TeamName: req.GetTeamName(), ParentTeamName: req.GetParentTeamName()}
+	if err := validation.Validate(&body); err != nil {
+		return nil, err
+	}
+
+	if err := s.service.SetParent(ctx, organizationID(ctx), body.TeamName, body.ParentTeamName); err != nil {
+		return nil, err
+	}
+
+	return &pb.SetParentResponse{}, nil
+}
+
+func (s *teamServer) GetTeamStats(ctx context.Context, req *pb.GetTeamStatsRequest) (*pb.TeamStats, error) {
+	query := validation.GetTeamQuery{TeamName: req.GetTeamName()}
+	if err := validation.Validate(&query); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.service.GetStats(ctx, organizationID(ctx), query.TeamName)
+	if err != nil {
+		return nil, err
+	}
+
+	return toTeamStats(stats), nil
+}
@@ -0,0 +1,68 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"reviewers/internal/auth"
+	"reviewers/internal/service"
+	"reviewers/pkg/errs"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// OrganizationMetadata selects the organization of a call, like the
+// X-Organization-ID header of the HTTP API.
+const OrganizationMetadata = "x-organization-id"
+
+// AuthorizationMetadata carries the bearer token of a call, like the
+// Authorization header of the HTTP API.
+const AuthorizationMetadata = "authorization"
+
+type organizationKey struct{}
+
+// TenantInterceptor authenticates the token of the call and resolves its
+// organization, see auth.Config.Organization.
+func TenantInterceptor(orgService *service.OrganizationService, authCfg auth.Config) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		principal, err := authCfg.Authenticate(firstValue(md, AuthorizationMetadata))
+		if err != nil {
+			return nil, err
+		}
+
+		requested := firstValue(md, OrganizationMetadata)
+		if requested != "" {
+			if _, err := uuid.Parse(requested); err != nil {
+				return nil, errs.NewValidationError(errs.FieldError{Field: OrganizationMetadata, Message: "must be a UUID"})
+			}
+		}
+
+		orgID, err := authCfg.Organization(principal, requested)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := orgService.Get(ctx, orgID); err != nil {
+			if errors.Is(err, errs.ResourceNotFound) {
+				err = errs.ResourceNotFound.WithMessage("organization not found")
+			}
+			return nil, err
+		}
+
+		return handler(context.WithValue(ctx, organizationKey{}, orgID), req)
+	}
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func organizationID(ctx context.Context) string {
+	orgID, _ := ctx.Value(organizationKey{}).(string)
+	return orgID
+}
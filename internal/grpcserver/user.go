@@ -0,0 +1,56 @@
+package grpcserver
+
+import (
+	"context"
+	"reviewers/internal/service"
+	"reviewers/internal/validation"
+	pb "reviewers/pkg/pb/reviewers/v1"
+)
+
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+	service *service.UserService
+}
+
+func (s *userServer) SetIsActive(ctx context.Context, req *pb.SetIsActiveRequest) (*pb.SetIsActiveResponse, error) {
+	body := validation.SetActiveRequest{UserID: req.GetUserId(), IsActive: req.GetIsActive()}
+	if err := validation.Validate(&body); err != nil {
+		return nil, err
+	}
+
+	if err := s.service.SetActiveStatus(ctx, organizationID(ctx), body.UserID, body.IsActive); err != nil {
+		return nil, err
+	}
+
+	return &pb.SetIsActiveResponse{}, nil
+}
+
+func (s *userServer) GetReview(ctx context.Context, req *pb.GetReviewRequest) (*pb.GetReviewResponse, error) {
+	query := validation.GetReviewQuery{UserID: req.GetUserId()}
+	if err := validation.Validate(&query); err != nil {
+		return nil, err
+	}
+	list := validation.ListQuery{
+		Status: fromPullRequestStatus(req.GetStatus()),
+		Cursor: req.GetPageToken(),
+		Limit:  int(req.GetPageSize()),
+	}
+	if err := validation.Validate(&list); err != nil {
+		return nil, err
+	}
+
+	page, err := s.service.GetReview(ctx, organizationID(ctx), query.UserID, list.Filter())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.GetReviewResponse{
+		UserId:        query.UserID,
+		PullRequests:  make([]*pb.PullRequestShort, 0, len(page.PullRequests)),
+		NextPageToken: page.NextCursor,
+	}
+	for _, pr := range page.PullRequests {
+		resp.PullRequests = append(resp.PullRequests, toPullRequestShort(pr))
+	}
+	return resp, nil
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"io"
+	"net/http"
+	"reviewers/internal/backup"
+	"reviewers/internal/roster"
+	"reviewers/internal/service"
+	"reviewers/internal/validation"
+	"reviewers/pkg/errs"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRosterSize limits the size of an imported roster.
+const maxRosterSize = 10 << 20
+
+type AdminHandler struct {
+	teamService   *service.TeamService
+	backupService *service.BackupService
+}
+
+func NewAdminHandler(teamService *service.TeamService, backupService *service.BackupService) *AdminHandler {
+	return &AdminHandler{teamService, backupService}
+}
+
+// ImportQuery sets up an import. Without Format the format of the roster
+// comes from the Content-Type header.
+type ImportQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=yaml csv"`
+	DryRun bool   `form:"dry_run"`
+}
+
+// rosterFormats maps the content types of rosters to their formats.
+var rosterFormats = map[string]string{
+	"text/csv":           roster.FormatCSV,
+	"application/yaml":   roster.FormatYAML,
+	"application/x-yaml": roster.FormatYAML,
+	"text/yaml":          roster.FormatYAML,
+	"text/x-yaml":        roster.FormatYAML,
+}
+
+// Import applies a roster of teams and their members. Errors of every
+// entry are reported together with the lines of the entries.
+func (h *AdminHandler) Import(c *gin.Context) {
+	var query ImportQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	format := query.Format
+	if format == "" {
+		var ok bool
+		if format, ok = rosterFormats[c.ContentType()]; !ok {
+			c.Error(errs.NewValidationError(errs.FieldError{
+				Field:   "format",
+				Message: "must be set for the content type " + c.ContentType(),
+			}))
+			return
+		}
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxRosterSize))
+	if err != nil {
+		var sizeErr *http.MaxBytesError
+		if errors.As(err, &sizeErr) {
+			err = errs.NewValidationError(errs.FieldError{Field: "roster", Message: "must be at most 10 MB"})
+		}
+		c.Error(err)
+		return
+	}
+
+	parsed, err := roster.Parse(bytes.NewReader(data), format)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var fields []errs.FieldError
+	check := func(line int, entry any) {
+		var validationErr *errs.ValidationError
+		if errors.As(validation.Validate(entry), &validationErr) {
+			for _, field := range validationErr.Fields {
+				field.Line = line
+				fields = append(fields, field)
+			}
+		}
+	}
+	for _, team := range parsed.Teams {
+		check(team.Line, team)
+	}
+	for _, member := range parsed.Members {
+		check(member.Line, member)
+	}
+	if len(fields) > 0 {
+		slices.SortStableFunc(fields, func(a, b errs.FieldError) int { return cmp.Compare(a.Line, b.Line) })
+		c.Error(errs.NewValidationError(fields...))
+		return
+	}
+
+	result, err := h.teamService.Import(c.Request.Context(), organizationID(c), parsed, query.DryRun)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Export streams a backup of the organization. When the export fails
+// after the backup has started, the backup is left without its end, so
+// that it cannot be restored.
+func (h *AdminHandler) Export(c *gin.Context) {
+	c.Header("Content-Type", backup.ContentType)
+	c.Header("Content-Disposition", `attachment; filename="backup.ndjson"`)
+
+	w, err := backup.NewWriter(c.Writer, time.Now().UTC())
+	if err == nil {
+		err = h.backupService.Export(c.Request.Context(), organizationID(c), w)
+	}
+	if err != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+		}
+		c.Error(err)
+	}
+}
+
+// Restore loads a backup into the organization, which must be empty. The
+// backup is applied in one transaction, the first invalid record fails it
+// with its line.
+func (h *AdminHandler) Restore(c *gin.Context) {
+	r, err := backup.NewReader(c.Request.Body, validation.Validate)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	result, err := h.backupService.Restore(c.Request.Context(), organizationID(c), r)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
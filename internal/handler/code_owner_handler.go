@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"reviewers/internal/models"
+	"reviewers/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CodeOwnerHandler struct {
+	service *service.CodeOwnerService
+}
+
+func NewCodeOwnerHandler(service *service.CodeOwnerService) *CodeOwnerHandler {
+	return &CodeOwnerHandler{service}
+}
+
+type SetCodeOwnersRequest struct {
+	Repository string                 `json:"repository" binding:"required,identifier,max=100"`
+	Rules      []models.CodeOwnerRule `json:"rules" binding:"max=1000,dive"`
+}
+
+type GetCodeOwnersQuery struct {
+	Repository string `form:"repository" binding:"required,identifier,max=100"`
+}
+
+func (h *CodeOwnerHandler) GetRules(c *gin.Context) {
+	var query GetCodeOwnersQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	rules, err := h.service.GetRules(c.Request.Context(), organizationID(c), query.Repository)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"repository": query.Repository,
+		"rules":      rules,
+	})
+}
+
+func (h *CodeOwnerHandler) SetRules(c *gin.Context) {
+	var req SetCodeOwnersRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.service.SetRules(c.Request.Context(), organizationID(c), req.Repository, req.Rules); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"repository": req.Repository,
+		"rules":      req.Rules,
+	})
+}
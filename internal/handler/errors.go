@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"log/slog"
+	"reviewers/pkg/errs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID of the request. The ID is taken from the
+// request if the client sets it and generated otherwise.
+const RequestIDHeader = "X-Request-ID"
+
+const requestIDKey = "request_id"
+
+// RequestIDMiddleware assigns an ID to the request and returns it in the
+// response header.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+
+		c.Set(requestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// ErrorMiddleware writes the last error added to the context with
+// c.Error as the response unless the handler has already written one.
+func ErrorMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		status, response := errs.ToResponse(err)
+		response.Error.RequestID = c.GetString(requestIDKey)
+
+		if status >= 500 {
+			logger.ErrorContext(c.Request.Context(), "request failed",
+				"error", err,
+				"route", c.FullPath(),
+				"request_id", response.Error.RequestID,
+			)
+		}
+
+		c.AbortWithStatusJSON(status, response)
+	}
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"reviewers/internal/events"
+	"reviewers/internal/models"
+	"reviewers/internal/service"
+	"reviewers/pkg/errs"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// heartbeatInterval is how often an idle stream gets a comment, so that
+// proxies keep the connection open.
+const heartbeatInterval = 15 * time.Second
+
+// resetEvent tells a reconnecting client that the events it missed
+// cannot be replayed, so it has to reload the state it follows. Its ID
+// is the ID of the last event published before the subscription.
+const resetEvent = "stream.reset"
+
+type EventHandler struct {
+	broker      *events.Broker
+	teamService *service.TeamService
+}
+
+func NewEventHandler(broker *events.Broker, teamService *service.TeamService) *EventHandler {
+	return &EventHandler{broker, teamService}
+}
+
+type StreamQuery struct {
+	UserID   string `form:"user_id" binding:"omitempty,uuid"`
+	TeamName string `form:"team_name" binding:"omitempty,notblank,max=255"`
+}
+
+// Stream sends changes of pull requests as server-sent events. With
+// user_id only the events about the user are sent, with team_name the
+// events about members of the team and its subteams at the moment of
+// subscription.
+//
+// The Last-Event-ID header replays the missed events the broker still
+// remembers, see config.EventHistory. When the ID is older than the
+// history or comes from before a restart of the service, the stream
+// starts with a reset event instead.
+func (h *EventHandler) Stream(c *gin.Context) {
+	var query StreamQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	if query.UserID != "" && query.TeamName != "" {
+		c.Error(errs.NewValidationError(errs.FieldError{Field: "team_name", Message: "must not be set together with user_id"}))
+		return
+	}
+
+	filter := events.Filter{OrganizationID: organizationID(c)}
+	if query.UserID != "" {
+		filter.Users = []string{query.UserID}
+	}
+	if query.TeamName != "" {
+		team, err := h.teamService.GetTeam(c.Request.Context(), filter.OrganizationID, query.TeamName, true)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		filter.Users = teamMembers(team)
+	}
+
+	var lastEventID uint64
+	if header := c.GetHeader("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			c.Error(errs.NewValidationError(errs.FieldError{Field: "Last-Event-ID", Message: "must be an event ID"}))
+			return
+		}
+		lastEventID = id
+	}
+
+	replay, subscription := h.broker.Subscribe(filter, lastEventID)
+	defer subscription.Close()
+
+	c.Header("Content-Type", sse.ContentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	if replay.Lost {
+		c.Render(-1, sse.Event{
+			Id:    strconv.FormatUint(replay.LastID, 10),
+			Event: resetEvent,
+			Data:  gin.H{"message": "missed events are no longer available"},
+		})
+	}
+	for _, event := range replay.Events {
+		renderEvent(c, event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return false
+			}
+			renderEvent(c, event)
+		case <-heartbeat.C:
+			io.WriteString(w, ":\n\n")
+		case <-c.Request.Context().Done():
+			return false
+		}
+		return true
+	})
+}
+
+func renderEvent(c *gin.Context, event events.Event) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatUint(event.ID, 10),
+		Event: event.Type,
+		Data:  event,
+	})
+}
+
+// teamMembers returns IDs of the members of the team and its subteams.
+func teamMembers(team *models.Team) []string {
+	users := make([]string, 0, len(team.Members))
+	for _, member := range team.Members {
+		users = append(users, member.ID)
+	}
+	for i := range team.Subteams {
+		users = append(users, teamMembers(&team.Subteams[i])...)
+	}
+	return users
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+	"reviewers/internal/graphql"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GraphQLHandler struct {
+	server *graphql.Server
+}
+
+func NewGraphQLHandler(server *graphql.Server) *GraphQLHandler {
+	return &GraphQLHandler{server}
+}
+
+// Query executes the GraphQL request. Like in the GraphQL over HTTP
+// specification, errors of the query are reported in the body of a
+// successful response.
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	var req graphql.Request
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	resp := h.server.Execute(c.Request.Context(), organizationID(c), req)
+	c.JSON(http.StatusOK, resp)
+}
@@ -2,41 +2,162 @@ package handler
 
 import (
 	"log/slog"
+	"reviewers/internal/auth"
+	"reviewers/internal/events"
+	"reviewers/internal/graphql"
+	"reviewers/internal/metrics"
+	"reviewers/internal/openapi"
 	"reviewers/internal/repository"
+	"reviewers/internal/scim"
 	"reviewers/internal/service"
+	"reviewers/internal/tracing"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func InitHandlers(logger *slog.Logger, conn *gorm.DB, router *gin.Engine) {
-	// Users
-	userRepository := repository.NewUserRepository(conn, logger)
-	userService := service.NewUserService(userRepository)
-	userHandler := NewUserHandler(userService)
+// Options configure the routes registered by InitHandlers.
+type Options struct {
+	// Auth decides which organization a request acts for.
+	Auth auth.Config
+	// Timeout limits the time a request may spend on database queries,
+	// see TimeoutMiddleware.
+	Timeout time.Duration
+}
 
-	userRouter := router.Group("/users")
-	userRouter.POST("/setIsActive", userHandler.SetActiveStatus)
-	userRouter.GET("/getReview", userHandler.GetReview)
+// InitHandlers registers every route served from the stores. Changes of
+// pull requests are published to the broker and streamed from it.
+func InitHandlers(logger *slog.Logger, stores *repository.Stores, router *gin.Engine, broker *events.Broker, opts Options) {
+	router.Use(otelgin.Middleware(tracing.ServiceName))
+	// Metrics wrap the error middleware to count errors by their status
+	router.Use(RequestIDMiddleware(), metrics.Middleware(), ErrorMiddleware(logger))
+	router.Use(TimeoutMiddleware(opts.Timeout))
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	openapi.Register(router)
+
+	// Organizations
+	orgService := service.NewOrganizationService(stores.Organizations)
+	orgHandler := NewOrganizationHandler(orgService)
+
+	// Creating an organization is not scoped by one, but needs a token
+	router.POST("/organization/add", AuthMiddleware(opts.Auth), RequirePrincipal(), orgHandler.Create)
+
+	// Every other route is scoped by the organization of the request
+	tenantRouter := router.Group("/", AuthMiddleware(opts.Auth), TenantMiddleware(orgService, opts.Auth))
+	tenantRouter.GET("/organization/get", orgHandler.Get)
+
+	// Users
+	userService := service.NewUserService(stores.Users)
 
 	// Teams
-	teamRepository := repository.NewTeamRepository(conn, logger)
-	teamService := service.NewTeamService(teamRepository)
+	teamService := service.NewTeamService(stores.Teams)
 	teamHandler := NewTeamHandler(teamService)
 
-	teamRouter := router.Group("/team")
+	teamRouter := tenantRouter.Group("/team")
 	teamRouter.GET("/get", teamHandler.GetTeam)
 	teamRouter.POST("/add", teamHandler.CreateTeam)
 	teamRouter.POST("/deactivate", teamHandler.DeactivateTeam)
+	teamRouter.POST("/setParent", teamHandler.SetParent)
+	teamRouter.GET("/stats", teamHandler.GetStats)
+
+	// Repositories
+	repoService := service.NewRepoService(stores.Repositories)
+	repoHandler := NewRepoHandler(repoService)
+
+	repoRouter := tenantRouter.Group("/repository")
+	repoRouter.GET("/get", repoHandler.Get)
+	repoRouter.POST("/add", repoHandler.Create)
+	repoRouter.POST("/update", repoHandler.Update)
+
+	// Code owners
+	codeOwnerService := service.NewCodeOwnerService(stores.CodeOwners, repoService, userService)
+	codeOwnerHandler := NewCodeOwnerHandler(codeOwnerService)
+
+	codeOwnerRouter := tenantRouter.Group("/codeOwners")
+	codeOwnerRouter.GET("/get", codeOwnerHandler.GetRules)
+	codeOwnerRouter.POST("/set", codeOwnerHandler.SetRules)
 
 	// Pull requests
-	prRepository := repository.NewPRRepository(conn, logger)
-	prService := service.NewPRService(prRepository, teamService, userService)
+	prService := service.NewPRService(stores.PullRequests, repoService, teamService, userService, codeOwnerService, broker)
 	prHandler := NewPRHandler(prService)
 
-	prRouter := router.Group("/pullRequest")
+	prRouter := tenantRouter.Group("/pullRequest")
 	prRouter.POST("/create", prHandler.Create)
 	prRouter.POST("/merge", prHandler.Merge)
 	prRouter.POST("/reassign", prHandler.Reassign)
+	prRouter.POST("/approve", prHandler.Approve)
+	prRouter.GET("/get", prHandler.Get)
+	prRouter.GET("/list", prHandler.List)
+
+	// Users, deletion releases their reviews
+	userHandler := NewUserHandler(userService, prService)
+
+	userRouter := tenantRouter.Group("/users")
+	userRouter.GET("/get", userHandler.Get)
+	userRouter.POST("/create", userHandler.Create)
+	userRouter.POST("/update", userHandler.Update)
+	userRouter.POST("/delete", userHandler.Delete)
+	userRouter.POST("/setIsActive", userHandler.SetActiveStatus)
+	userRouter.GET("/getReview", userHandler.GetReview)
+
+	// Statistics
+	statsService := service.NewStatsService(stores.Stats, teamService)
+	statsHandler := NewStatsHandler(statsService)
+
+	statsRouter := tenantRouter.Group("/stats")
+	statsRouter.GET("/reviewers", statsHandler.GetReviewerStats)
+	statsRouter.GET("/teams/:name", statsHandler.GetTeamReport)
+
+	// GraphQL
+	graphqlServer := graphql.NewServer(logger, graphql.Repositories{
+		Teams:        stores.Teams,
+		Users:        stores.Users,
+		Repositories: stores.Repositories,
+		PullRequests: stores.PullRequests,
+	})
+	graphqlHandler := NewGraphQLHandler(graphqlServer)
+
+	tenantRouter.POST("/graphql", graphqlHandler.Query)
+
+	// Administration
+	backupService := service.NewBackupService(stores.Backups)
+	adminHandler := NewAdminHandler(teamService, backupService)
+
+	// Administration acts on a whole organization, so it needs a token
+	adminRouter := tenantRouter.Group("/admin", RequirePrincipal())
+	adminRouter.POST("/import", adminHandler.Import)
+	adminRouter.GET("/export", adminHandler.Export)
+	adminRouter.POST("/restore", adminHandler.Restore)
+
+	// SCIM, errors are reported in the format of SCIM. Identity providers
+	// authenticate with the token of their organization
+	directoryService := service.NewDirectoryService(userService, teamService, prService)
+	scimHandler := NewSCIMHandler(directoryService)
+
+	scimRouter := router.Group(scim.BasePath,
+		SCIMErrorMiddleware(logger),
+		AuthMiddleware(opts.Auth),
+		RequirePrincipal(),
+		TenantMiddleware(orgService, opts.Auth),
+	)
+	scimRouter.GET("/ServiceProviderConfig", scimHandler.ServiceProviderConfig)
+	scimRouter.GET("/ResourceTypes", scimHandler.ResourceTypes)
+	scimRouter.GET("/Users", scimHandler.ListUsers)
+	scimRouter.POST("/Users", scimHandler.CreateUser)
+	scimRouter.GET("/Users/:id", scimHandler.GetUser)
+	scimRouter.PUT("/Users/:id", scimHandler.ReplaceUser)
+	scimRouter.PATCH("/Users/:id", scimHandler.PatchUser)
+	scimRouter.DELETE("/Users/:id", scimHandler.DeleteUser)
+	scimRouter.GET("/Groups", scimHandler.ListGroups)
+	scimRouter.POST("/Groups", scimHandler.CreateGroup)
+	scimRouter.GET("/Groups/:id", scimHandler.GetGroup)
+	scimRouter.PUT("/Groups/:id", scimHandler.ReplaceGroup)
+	scimRouter.PATCH("/Groups/:id", scimHandler.PatchGroup)
+	scimRouter.DELETE("/Groups/:id", scimHandler.DeleteGroup)
+
+	// Events
+	eventHandler := NewEventHandler(broker, teamService)
 
+	tenantRouter.GET("/events/stream", eventHandler.Stream)
 }
@@ -0,0 +1,86 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reviewers/internal/events"
+	"reviewers/internal/handler"
+	"reviewers/internal/repository"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.DiscardHandler)
+	router := gin.New()
+	handler.InitHandlers(logger, repository.NewMemoryStores(repository.NewMemoryDB(), logger), router, events.NewBroker(100), handler.Options{})
+	return router
+}
+
+func performRequest(r *gin.Engine, method, target string, body any) *httptest.ResponseRecorder {
+	reqBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, target, bytes.NewBuffer(reqBody))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder) map[string]any {
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return resp
+}
+
+func TestHandlers_PullRequestWorkflow(t *testing.T) {
+	r := setupRouter()
+
+	w := performRequest(r, "POST", "/team/add", map[string]any{
+		"team_name": "backend",
+		"members": []map[string]any{
+			{"username": "author", "is_active": true},
+			{"username": "alice", "is_active": true},
+		},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	members := decode(t, w)["members"].([]any)
+	authorID := members[0].(map[string]any)["user_id"]
+	aliceID := members[1].(map[string]any)["user_id"]
+
+	w = performRequest(r, "POST", "/pullRequest/create", map[string]any{
+		"pull_request_id":   "pr-1",
+		"pull_request_name": "Add search",
+		"author_id":         authorID,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []any{aliceID}, decode(t, w)["pr"].(map[string]any)["assigned_reviewers"])
+
+	w = performRequest(r, "POST", "/pullRequest/create", map[string]any{
+		"pull_request_id":   "pr-1",
+		"pull_request_name": "Add search",
+		"author_id":         authorID,
+	})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "PR_EXISTS", decode(t, w)["error"].(map[string]any)["code"])
+
+	w = performRequest(r, "POST", "/pullRequest/approve", map[string]any{"pull_request_id": "pr-1", "reviewer_id": aliceID})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = performRequest(r, "POST", "/pullRequest/merge", map[string]any{"pull_request_id": "pr-1"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "MERGED", decode(t, w)["pr"].(map[string]any)["status"])
+
+	w = performRequest(r, "GET", "/users/getReview?user_id="+aliceID.(string), nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	prs := decode(t, w)["pull_requests"].([]any)
+	require.Len(t, prs, 1)
+	assert.Equal(t, "pr-1", prs[0].(map[string]any)["pull_request_id"])
+
+	w = performRequest(r, "GET", "/pullRequest/get?pull_request_id=pr-2", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reviewers/internal/models"
+	"reviewers/internal/service"
+	"reviewers/pkg/errs"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrganizationHandler struct {
+	service *service.OrganizationService
+}
+
+func NewOrganizationHandler(service *service.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{service}
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required,notblank,max=255"`
+}
+
+func (h *OrganizationHandler) Create(c *gin.Context) {
+	var req CreateOrganizationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	org := &models.Organization{Name: req.Name}
+	if err := h.service.Create(c.Request.Context(), org); err != nil {
+		if errors.Is(err, errs.OrganizationExists) {
+			err = errs.OrganizationExists.WithMessage(fmt.Sprintf("%s already exists", org.Name))
+		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+func (h *OrganizationHandler) Get(c *gin.Context) {
+	org, err := h.service.Get(c.Request.Context(), organizationID(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
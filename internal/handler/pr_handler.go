@@ -3,10 +3,15 @@ package handler
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
-	"reviewers/internal/errs"
 	"reviewers/internal/models"
 	"reviewers/internal/service"
+	"reviewers/internal/validation"
+	"reviewers/pkg/errs"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,45 +24,59 @@ func NewPRHandler(service *service.PRService) *PRHandler {
 	return &PRHandler{service}
 }
 
-type CreatePRRequest struct {
-	ID       string `json:"pull_request_id"`
-	Name     string `json:"pull_request_name"`
-	AuthorID string `json:"author_id"`
+// bindListFilter reads the parameters of a pull request listing. The from
+// and to parameters limit the creation time. On failure the error is
+// added to the context and false is returned.
+func bindListFilter(c *gin.Context) (models.PullRequestFilter, bool) {
+	var query validation.ListQuery
+	if !bindQuery(c, &query) {
+		return models.PullRequestFilter{}, false
+	}
+
+	filter := query.Filter()
+	var err error
+	if filter.From, filter.To, err = parseTimeRange(c); err != nil {
+		c.Error(err)
+		return filter, false
+	}
+	return filter, true
 }
 
-type MergePRRequest struct {
-	PullRequestID string `json:"pull_request_id"`
+// PRListQuery holds the filters of the pull request listing besides
+// the ones of validation.ListQuery.
+type PRListQuery struct {
+	AuthorID   string `form:"author_id" binding:"omitempty,uuid"`
+	TeamName   string `form:"team_name" binding:"omitempty,notblank,max=255"`
+	ReviewerID string `form:"reviewer_id" binding:"omitempty,uuid"`
+	Name       string `form:"name" binding:"max=255"`
+	MinAge     string `form:"min_age"`
+	MaxAge     string `form:"max_age"`
 }
 
-type ReassignReviewerRequest struct {
-	PullRequestID string `json:"pull_request_id"`
-	OldReviewerID string `json:"old_reviewer_id"`
+type GetPRQuery struct {
+	Repository    string `form:"repository" binding:"omitempty,identifier,max=100"`
+	PullRequestID string `form:"pull_request_id" binding:"required,identifier,max=64"`
 }
 
 func (h *PRHandler) Create(c *gin.Context) {
-	var req CreatePRRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+	var req validation.CreatePRRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	pr := &models.PullRequest{
-		ID:       req.ID,
-		Name:     req.Name,
-		AuthorID: req.AuthorID,
-	}
-
-	if err := h.service.Create(pr); err != nil {
-		if errors.Is(err, errs.ResourceNotFound) {
-			response := errs.NewErrorResponse(errs.CodeNotFound, err.Error())
-			c.JSON(http.StatusNotFound, response)
-			return
-		} else if errors.Is(err, errs.PullRequestExists) {
-			response := errs.NewErrorResponse(errs.CodePRExists, fmt.Sprintf("PR %s already exists", pr.ID))
-			c.JSON(http.StatusConflict, response)
-			return
+		ID:           req.ID,
+		Name:         req.Name,
+		AuthorID:     req.AuthorID,
+		Repository:   req.Repository,
+		ChangedFiles: req.ChangedFiles,
+	}
+
+	if err := h.service.Create(c.Request.Context(), organizationID(c), pr); err != nil {
+		if errors.Is(err, errs.PullRequestExists) {
+			err = errs.PullRequestExists.WithMessage(fmt.Sprintf("PR %s already exists", pr.ID))
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "something bad happened"})
+		c.Error(err)
 		return
 	}
 
@@ -65,20 +84,14 @@ func (h *PRHandler) Create(c *gin.Context) {
 }
 
 func (h *PRHandler) Merge(c *gin.Context) {
-	var req MergePRRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+	var req validation.MergePRRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	pr, err := h.service.Merge(req.PullRequestID)
+	pr, err := h.service.Merge(c.Request.Context(), organizationID(c), req.Repository, req.PullRequestID)
 	if err != nil {
-		if errors.Is(err, errs.ResourceNotFound) {
-			response := errs.NewErrorResponse(errs.CodeNotFound, err.Error())
-			c.JSON(http.StatusNotFound, response)
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "something bad happened"})
+		c.Error(err)
 		return
 	}
 
@@ -86,22 +99,113 @@ func (h *PRHandler) Merge(c *gin.Context) {
 }
 
 func (h *PRHandler) Reassign(c *gin.Context) {
-	var req ReassignReviewerRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+	var req validation.ReassignReviewerRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	pr, err := h.service.Reassign(req.PullRequestID, req.OldReviewerID)
+	pr, err := h.service.Reassign(c.Request.Context(), organizationID(c), req.Repository, req.PullRequestID, req.OldReviewerID)
 	if err != nil {
-		switch err.(type) {
-		case errs.ApiError:
-			err.(errs.ApiError).ReturnError(c, err.Error())
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "something bad happened"})
-		}
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"pr": pr})
 }
+
+func (h *PRHandler) Approve(c *gin.Context) {
+	var req validation.ApprovePRRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	pr, err := h.service.Approve(c.Request.Context(), organizationID(c), req.Repository, req.PullRequestID, req.ReviewerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pr": pr})
+}
+
+func (h *PRHandler) Get(c *gin.Context) {
+	var query GetPRQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	pr, err := h.service.Get(c.Request.Context(), organizationID(c), query.Repository, query.PullRequestID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pr": pr})
+}
+
+func (h *PRHandler) List(c *gin.Context) {
+	var query PRListQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	filter, ok := bindListFilter(c)
+	if !ok {
+		return
+	}
+
+	filter.AuthorID = query.AuthorID
+	filter.TeamName = query.TeamName
+	filter.ReviewerID = query.ReviewerID
+	filter.Name = query.Name
+
+	var err error
+	if filter.MinAge, err = parseAge(query.MinAge); err != nil {
+		c.Error(errs.NewValidationError(errs.FieldError{Field: "min_age", Message: ageFormat}))
+		return
+	}
+	if filter.MaxAge, err = parseAge(query.MaxAge); err != nil {
+		c.Error(errs.NewValidationError(errs.FieldError{Field: "max_age", Message: ageFormat}))
+		return
+	}
+
+	page, err := h.service.List(c.Request.Context(), organizationID(c), filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+const ageFormat = "must be a positive duration such as 36h or 7d"
+
+// maxAgeDays is the largest number of days a time.Duration holds.
+const maxAgeDays = int64(math.MaxInt64 / (24 * time.Hour))
+
+// parseAge reads a duration in the Go format or a number of days with the
+// "d" suffix.
+func parseAge(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.ParseInt(days, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		if n <= 0 || n > maxAgeDays {
+			return 0, errors.New("age is out of range")
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if age <= 0 {
+		return 0, errors.New("age must be positive")
+	}
+	return age, nil
+}
@@ -0,0 +1,42 @@
+package handler_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPRList_Age(t *testing.T) {
+	r := setupRouter()
+
+	tests := []struct {
+		age    string
+		status int
+	}{
+		{"36h", http.StatusOK},
+		{"7d", http.StatusOK},
+		{"106751d", http.StatusOK},
+		{"-5d", http.StatusBadRequest},
+		{"0d", http.StatusBadRequest},
+		{"106752d", http.StatusBadRequest},
+		{"200000d", http.StatusBadRequest},
+		{"300000d", http.StatusBadRequest},
+		{"99999999999999999999d", http.StatusBadRequest},
+		{"-36h", http.StatusBadRequest},
+		{"0s", http.StatusBadRequest},
+		{"week", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.age, func(t *testing.T) {
+			for _, param := range []string{"min_age", "max_age"} {
+				w := performRequest(r, "GET", "/pullRequest/list?"+param+"="+tt.age, nil)
+				assert.Equal(t, tt.status, w.Code, w.Body.String())
+				if tt.status == http.StatusBadRequest {
+					fields := decode(t, w)["error"].(map[string]any)["fields"].([]any)
+					assert.Equal(t, param, fields[0].(map[string]any)["field"])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reviewers/internal/models"
+	"reviewers/internal/service"
+	"reviewers/pkg/errs"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RepoHandler struct {
+	service *service.RepoService
+}
+
+func NewRepoHandler(service *service.RepoService) *RepoHandler {
+	return &RepoHandler{service}
+}
+
+type RepositoryRequest struct {
+	Name           string `json:"name" binding:"required,identifier,max=100"`
+	TeamName       string `json:"team_name" binding:"max=255"`
+	ReviewersCount *int   `json:"reviewers_count" binding:"omitempty,min=1,max=10"`
+	ClimbHierarchy *bool  `json:"climb_hierarchy"`
+}
+
+type GetRepositoryQuery struct {
+	Name string `form:"name" binding:"omitempty,identifier,max=100"`
+}
+
+func (req *RepositoryRequest) apply(repo *models.Repository) {
+	if req.TeamName != "" {
+		repo.TeamName = req.TeamName
+	}
+	if req.ReviewersCount != nil {
+		repo.ReviewersCount = *req.ReviewersCount
+	}
+	if req.ClimbHierarchy != nil {
+		repo.ClimbHierarchy = *req.ClimbHierarchy
+	}
+}
+
+func (h *RepoHandler) Get(c *gin.Context) {
+	var query GetRepositoryQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	repo, err := h.service.GetByName(c.Request.Context(), organizationID(c), query.Name)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, repo)
+}
+
+func (h *RepoHandler) Create(c *gin.Context) {
+	var req RepositoryRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	repo := &models.Repository{
+		Name:           req.Name,
+		ReviewersCount: 2,
+		ClimbHierarchy: true,
+	}
+	req.apply(repo)
+
+	if err := h.service.Create(c.Request.Context(), organizationID(c), repo); err != nil {
+		if errors.Is(err, errs.RepositoryExists) {
+			err = errs.RepositoryExists.WithMessage(fmt.Sprintf("%s already exists", repo.Name))
+		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, repo)
+}
+
+func (h *RepoHandler) Update(c *gin.Context) {
+	var req RepositoryRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	repo, err := h.service.GetByName(c.Request.Context(), organizationID(c), req.Name)
+	if err == nil {
+		req.apply(repo)
+		err = h.service.Update(c.Request.Context(), repo)
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, repo)
+}
@@ -0,0 +1,381 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reviewers/internal/scim"
+	"reviewers/internal/service"
+	"reviewers/internal/validation"
+	"reviewers/pkg/errs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SCIMHandler serves users and teams as SCIM 2.0 users and groups, so
+// that identity providers can provision them.
+type SCIMHandler struct {
+	service *service.DirectoryService
+}
+
+func NewSCIMHandler(service *service.DirectoryService) *SCIMHandler {
+	return &SCIMHandler{service}
+}
+
+// SCIMErrorMiddleware writes the last error added to the context as a
+// SCIM error response. It runs before ErrorMiddleware, which skips the
+// written response.
+func SCIMErrorMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		response := scim.ToError(err)
+		if response.StatusCode() >= 500 {
+			logger.ErrorContext(c.Request.Context(), "request failed",
+				"error", err,
+				"route", c.FullPath(),
+				"request_id", c.GetString(requestIDKey),
+			)
+		}
+
+		c.Header("Content-Type", scim.ContentType)
+		c.AbortWithStatusJSON(response.StatusCode(), response)
+	}
+}
+
+// scimJSON writes the response with the SCIM media type.
+func scimJSON(c *gin.Context, status int, obj any) {
+	c.Header("Content-Type", scim.ContentType)
+	c.JSON(status, obj)
+}
+
+// resourceID returns the ID from the path. IDs of users and teams are
+// UUIDs, so other IDs belong to no resource.
+func resourceID(c *gin.Context, resource string) (string, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.Error(errs.ResourceNotFound.WithMessage(resource + " not found"))
+		return "", false
+	}
+	return id, true
+}
+
+func (h *SCIMHandler) ServiceProviderConfig(c *gin.Context) {
+	scimJSON(c, http.StatusOK, scim.ServiceProviderConfig())
+}
+
+func (h *SCIMHandler) ResourceTypes(c *gin.Context) {
+	scimJSON(c, http.StatusOK, scim.ResourceTypes())
+}
+
+// ListUsers returns a page of users. The only supported filter is the
+// lookup by userName.
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	var query scim.ListQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	filter, err := scim.ParseFilter(query.Filter, "userName")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	startIndex, offset, limit := query.Page()
+
+	resources := []scim.User{}
+	if filter.Attribute != "" && filter.Value == "" {
+		scimJSON(c, http.StatusOK, scim.NewListResponse(resources, 0, startIndex))
+		return
+	}
+
+	users, total, err := h.service.ListUsers(c.Request.Context(), organizationID(c), filter.Value, offset, limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	for i := range users {
+		resources = append(resources, scim.NewUser(&users[i]))
+	}
+
+	scimJSON(c, http.StatusOK, scim.NewListResponse(resources, total, startIndex))
+}
+
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	id, ok := resourceID(c, "user")
+	if !ok {
+		return
+	}
+	h.respondUser(c, http.StatusOK, id)
+}
+
+// CreateUser provisions the user in the unassigned team, the user joins
+// a team when they are added to its group.
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	var req scim.User
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	user := req.Model()
+	if err := h.service.CreateUser(c.Request.Context(), organizationID(c), user); err != nil {
+		if errors.Is(err, errs.UserExists) {
+			err = errs.UserExists.WithMessage(fmt.Sprintf("%s already exists", user.Username))
+		}
+		c.Error(err)
+		return
+	}
+
+	resource := scim.NewUser(user)
+	c.Header("Location", resource.Meta.Location)
+	scimJSON(c, http.StatusCreated, resource)
+}
+
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	id, ok := resourceID(c, "user")
+	if !ok {
+		return
+	}
+	var req scim.User
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	req.ID = id
+	if !h.replaceUser(c, &req) {
+		return
+	}
+	h.respondUser(c, http.StatusOK, id)
+}
+
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	id, ok := resourceID(c, "user")
+	if !ok {
+		return
+	}
+	var req scim.PatchRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	user, err := h.service.GetUser(c.Request.Context(), organizationID(c), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	resource := scim.NewUser(user)
+	if err := resource.Apply(req.Operations); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := validation.Validate(&resource); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if !h.replaceUser(c, &resource) {
+		return
+	}
+	h.respondUser(c, http.StatusOK, id)
+}
+
+// DeleteUser deprovisions the user. The user is deactivated and their
+// reviews are reassigned, but the user is kept with their history.
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	id, ok := resourceID(c, "user")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeprovisionUser(c.Request.Context(), organizationID(c), id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *SCIMHandler) replaceUser(c *gin.Context, resource *scim.User) bool {
+	user := resource.Model()
+	if err := h.service.ReplaceUser(c.Request.Context(), organizationID(c), user); err != nil {
+		if errors.Is(err, errs.UserExists) {
+			err = errs.UserExists.WithMessage(fmt.Sprintf("%s already exists", user.Username))
+		}
+		c.Error(err)
+		return false
+	}
+	return true
+}
+
+func (h *SCIMHandler) respondUser(c *gin.Context, status int, id string) {
+	user, err := h.service.GetUser(c.Request.Context(), organizationID(c), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	scimJSON(c, status, scim.NewUser(user))
+}
+
+// ListGroups returns a page of groups. The only supported filter is the
+// lookup by displayName.
+func (h *SCIMHandler) ListGroups(c *gin.Context) {
+	var query scim.ListQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	filter, err := scim.ParseFilter(query.Filter, "displayName")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	startIndex, offset, limit := query.Page()
+
+	resources := []scim.Group{}
+	if filter.Attribute != "" && filter.Value == "" {
+		scimJSON(c, http.StatusOK, scim.NewListResponse(resources, 0, startIndex))
+		return
+	}
+
+	teams, total, err := h.service.ListGroups(c.Request.Context(), organizationID(c), filter.Value, offset, limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	withoutMembers := scim.Excludes(query.ExcludedAttributes, "members")
+	for i := range teams {
+		resource := scim.NewGroup(&teams[i])
+		if withoutMembers {
+			resource.Members = nil
+		}
+		resources = append(resources, resource)
+	}
+
+	scimJSON(c, http.StatusOK, scim.NewListResponse(resources, total, startIndex))
+}
+
+func (h *SCIMHandler) GetGroup(c *gin.Context) {
+	id, ok := resourceID(c, "team")
+	if !ok {
+		return
+	}
+	h.respondGroup(c, http.StatusOK, id)
+}
+
+// CreateGroup creates the team and moves its members to it.
+func (h *SCIMHandler) CreateGroup(c *gin.Context) {
+	var req scim.Group
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	team := req.Model()
+	if err := h.service.CreateGroup(c.Request.Context(), organizationID(c), team); err != nil {
+		if errors.Is(err, errs.TeamExists) {
+			err = errs.TeamExists.WithMessage(fmt.Sprintf("%s already exists", team.Name))
+		}
+		c.Error(err)
+		return
+	}
+
+	c.Header("Location", scim.BasePath+"/Groups/"+team.ID)
+	h.respondGroup(c, http.StatusCreated, team.ID)
+}
+
+// ReplaceGroup renames the team and sets its members, the users that
+// leave it are moved to the unassigned team.
+func (h *SCIMHandler) ReplaceGroup(c *gin.Context) {
+	id, ok := resourceID(c, "team")
+	if !ok {
+		return
+	}
+	var req scim.Group
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	req.ID = id
+	if !h.replaceGroup(c, &req) {
+		return
+	}
+	h.respondGroup(c, http.StatusOK, id)
+}
+
+func (h *SCIMHandler) PatchGroup(c *gin.Context) {
+	id, ok := resourceID(c, "team")
+	if !ok {
+		return
+	}
+	var req scim.PatchRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	team, err := h.service.GetGroup(c.Request.Context(), organizationID(c), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	resource := scim.NewGroup(team)
+	if err := resource.Apply(req.Operations); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := validation.Validate(&resource); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if !h.replaceGroup(c, &resource) {
+		return
+	}
+	h.respondGroup(c, http.StatusOK, id)
+}
+
+// DeleteGroup deletes the team and moves its members to the unassigned
+// team.
+func (h *SCIMHandler) DeleteGroup(c *gin.Context) {
+	id, ok := resourceID(c, "team")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteGroup(c.Request.Context(), organizationID(c), id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *SCIMHandler) replaceGroup(c *gin.Context, resource *scim.Group) bool {
+	team := resource.Model()
+	if err := h.service.ReplaceGroup(c.Request.Context(), organizationID(c), team); err != nil {
+		if errors.Is(err, errs.TeamExists) {
+			err = errs.TeamExists.WithMessage(fmt.Sprintf("%s already exists", team.Name))
+		}
+		c.Error(err)
+		return false
+	}
+	return true
+}
+
+func (h *SCIMHandler) respondGroup(c *gin.Context, status int, id string) {
+	team, err := h.service.GetGroup(c.Request.Context(), organizationID(c), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	resource := scim.NewGroup(team)
+	if scim.Excludes(c.Query("excludedAttributes"), "members") {
+		resource.Members = nil
+	}
+	scimJSON(c, status, resource)
+}
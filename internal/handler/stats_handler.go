@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"reviewers/internal/models"
+	"reviewers/internal/service"
+	"reviewers/pkg/errs"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatsHandler struct {
+	service *service.StatsService
+}
+
+func NewStatsHandler(service *service.StatsService) *StatsHandler {
+	return &StatsHandler{service}
+}
+
+func (h *StatsHandler) GetReviewerStats(c *gin.Context) {
+	filter, err := parseStatsFilter(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	stats, err := h.service.GetReviewerStats(c.Request.Context(), organizationID(c), filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reviewers": stats})
+}
+
+func (h *StatsHandler) GetTeamReport(c *gin.Context) {
+	filter, err := parseStatsFilter(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	filter.TeamName = c.Param("name")
+
+	csv, err := wantsCSV(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	report, err := h.service.GetTeamReport(c.Request.Context(), organizationID(c), filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if csv {
+		writeTeamReportCSV(c, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// wantsCSV reports whether the report is requested as CSV. The format
+// parameter takes precedence over the Accept header.
+func wantsCSV(c *gin.Context) (bool, error) {
+	switch c.Query("format") {
+	case "":
+		return c.NegotiateFormat(gin.MIMEJSON, "text/csv") == "text/csv", nil
+	case "json":
+		return false, nil
+	case "csv":
+		return true, nil
+	default:
+		return false, errs.NewValidationError(errs.FieldError{Field: "format", Message: "must be one of json, csv"})
+	}
+}
+
+// writeTeamReportCSV writes the report as a header row and a single data
+// row. Members without reviews are separated by semicolons.
+func writeTeamReportCSV(c *gin.Context, report *models.TeamReport) {
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	formatFloat := func(f *float64) string {
+		if f == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*f, 'f', -1, 64)
+	}
+
+	withoutReviews := make([]string, 0, len(report.MembersWithoutReviews))
+	for _, user := range report.MembersWithoutReviews {
+		withoutReviews = append(withoutReviews, user.Username)
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, report.TeamName))
+	c.Status(http.StatusOK)
+	c.Writer.Header().Set("Content-Type", "text/csv")
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{
+		"team_name", "from", "to", "members", "total_assigned", "gini", "max_min_ratio",
+		"merged_pull_requests", "mean_time_to_merge_seconds", "reassignment_rate", "members_without_reviews",
+	})
+	w.Write([]string{
+		report.TeamName,
+		formatTime(report.From),
+		formatTime(report.To),
+		strconv.Itoa(report.Members),
+		strconv.FormatInt(report.TotalAssigned, 10),
+		strconv.FormatFloat(report.Gini, 'f', -1, 64),
+		formatFloat(report.MaxMinRatio),
+		strconv.FormatInt(report.MergedPullRequests, 10),
+		formatFloat(report.MeanTimeToMerge),
+		formatFloat(report.ReassignmentRate),
+		strings.Join(withoutReviews, ";"),
+	})
+	w.Flush()
+}
+
+// parseStatsFilter reads the team_name, from and to query parameters.
+// Time bounds are RFC 3339 timestamps or dates.
+func parseStatsFilter(c *gin.Context) (models.StatsFilter, error) {
+	filter := models.StatsFilter{TeamName: c.Query("team_name")}
+	if len(filter.TeamName) > 255 {
+		return filter, errs.NewValidationError(errs.FieldError{Field: "team_name", Message: "must be at most 255 characters"})
+	}
+
+	var err error
+	filter.From, filter.To, err = parseTimeRange(c)
+	return filter, err
+}
+
+// parseTimeRange reads the from and to query parameters, which are RFC
+// 3339 timestamps or dates.
+func parseTimeRange(c *gin.Context) (from, to time.Time, err error) {
+	const timeFormat = "must be an RFC 3339 timestamp or a date"
+
+	if from, err = parseTime(c.Query("from")); err != nil {
+		return from, to, errs.NewValidationError(errs.FieldError{Field: "from", Message: timeFormat})
+	}
+	if to, err = parseTime(c.Query("to")); err != nil {
+		return from, to, errs.NewValidationError(errs.FieldError{Field: "to", Message: timeFormat})
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return from, to, errs.NewValidationError(errs.FieldError{Field: "to", Message: "must be after from"})
+	}
+
+	return from, to, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
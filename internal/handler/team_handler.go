@@ -1,11 +1,13 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
-	"reviewers/internal/errs"
 	"reviewers/internal/models"
 	"reviewers/internal/service"
+	"reviewers/internal/validation"
+	"reviewers/pkg/errs"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,16 +21,14 @@ func NewTeamHandler(service *service.TeamService) *TeamHandler {
 }
 
 func (h *TeamHandler) GetTeam(c *gin.Context) {
-	name := c.Query("team_name")
+	var query validation.GetTeamQuery
+	if !bindQuery(c, &query) {
+		return
+	}
 
-	team, err := h.service.GetTeam(name)
+	team, err := h.service.GetTeam(c.Request.Context(), organizationID(c), query.TeamName, query.IncludeSubteams)
 	if err != nil {
-		switch err.(type) {
-		case errs.ApiError:
-			err.(errs.ApiError).ReturnError(c, err.Error())
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "something bad happened"})
-		}
+		c.Error(err)
 		return
 	}
 
@@ -37,18 +37,15 @@ func (h *TeamHandler) GetTeam(c *gin.Context) {
 
 func (h *TeamHandler) CreateTeam(c *gin.Context) {
 	var team models.Team
-	if err := c.ShouldBindJSON(&team); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+	if !bindJSON(c, &team) {
 		return
 	}
 
-	if err := h.service.CreateTeam(&team); err != nil {
-		switch err.(type) {
-		case errs.ApiError:
-			err.(errs.ApiError).ReturnError(c, fmt.Sprintf("%s already exists", team.Name))
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "something bad happened"})
+	if err := h.service.CreateTeam(c.Request.Context(), organizationID(c), &team); err != nil {
+		if errors.Is(err, errs.TeamExists) {
+			err = errs.TeamExists.WithMessage(fmt.Sprintf("%s already exists", team.Name))
 		}
+		c.Error(err)
 		return
 	}
 
@@ -56,17 +53,45 @@ func (h *TeamHandler) CreateTeam(c *gin.Context) {
 }
 
 func (h *TeamHandler) DeactivateTeam(c *gin.Context) {
-	teamID := c.Query("team_id")
-	if teamID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+	var query validation.DeactivateTeamQuery
+	if !bindQuery(c, &query) {
 		return
 	}
 
-	err := h.service.DeactivateTeam(teamID)
+	err := h.service.DeactivateTeam(c.Request.Context(), organizationID(c), query.TeamID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "something bad happened"})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "team has been deactivated"})
 }
+
+func (h *TeamHandler) SetParent(c *gin.Context) {
+	var req validation.SetParentRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.service.SetParent(c.Request.Context(), organizationID(c), req.TeamName, req.ParentTeamName); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "parent team updated"})
+}
+
+func (h *TeamHandler) GetStats(c *gin.Context) {
+	var query validation.GetTeamQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	stats, err := h.service.GetStats(c.Request.Context(), organizationID(c), query.TeamName)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
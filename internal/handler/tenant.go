@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+	"reviewers/internal/auth"
+	"reviewers/internal/service"
+	"reviewers/pkg/errs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// OrganizationHeader selects the organization of a request. Requests
+// with a token may only select the organization of the token.
+const OrganizationHeader = "X-Organization-ID"
+
+// PrincipalKey is the gin context key under which AuthMiddleware stores
+// the *auth.Principal of the request.
+const PrincipalKey = "principal"
+
+const organizationKey = "organization_id"
+
+// AuthMiddleware authenticates the bearer token of the request. Requests
+// without a token go on without a principal.
+func AuthMiddleware(authCfg auth.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := authCfg.Authenticate(c.GetHeader("Authorization"))
+		if err != nil {
+			abortUnauthorized(c, err)
+			return
+		}
+
+		if principal != nil {
+			c.Set(PrincipalKey, principal)
+		}
+		c.Next()
+	}
+}
+
+// RequirePrincipal rejects requests without a token, whatever
+// organization they select. It guards routes that act on a whole
+// organization at once.
+func RequirePrincipal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal(c) == nil {
+			abortUnauthorized(c, errs.Unauthorized.WithMessage("a token is required"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// TenantMiddleware resolves the organization of the request from the
+// principal and the header, see auth.Config.Organization.
+func TenantMiddleware(orgService *service.OrganizationService, authCfg auth.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requested := c.GetHeader(OrganizationHeader)
+		if requested != "" {
+			if _, err := uuid.Parse(requested); err != nil {
+				c.Error(errs.NewValidationError(errs.FieldError{Field: OrganizationHeader, Message: "must be a UUID"}))
+				c.Abort()
+				return
+			}
+		}
+
+		orgID, err := authCfg.Organization(principal(c), requested)
+		if err != nil {
+			abortUnauthorized(c, err)
+			return
+		}
+
+		if _, err := orgService.Get(c.Request.Context(), orgID); err != nil {
+			if errors.Is(err, errs.ResourceNotFound) {
+				err = errs.ResourceNotFound.WithMessage("organization not found")
+			}
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		c.Set(organizationKey, orgID)
+		c.Next()
+	}
+}
+
+// abortUnauthorized aborts the request with the error, challenging the
+// client to authenticate if the error is errs.Unauthorized.
+func abortUnauthorized(c *gin.Context, err error) {
+	if errors.Is(err, errs.Unauthorized) {
+		c.Header("WWW-Authenticate", "Bearer")
+	}
+	c.Error(err)
+	c.Abort()
+}
+
+func principal(c *gin.Context) *auth.Principal {
+	value, _ := c.Get(PrincipalKey)
+	principal, _ := value.(*auth.Principal)
+	return principal
+}
+
+func organizationID(c *gin.Context) string {
+	return c.GetString(organizationKey)
+}
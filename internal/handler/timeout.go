@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamingRoutes live as long as the client stays connected or transfer
+// the whole data of an organization, so the timeout does not apply to
+// them.
+var streamingRoutes = map[string]bool{
+	"/events/stream": true,
+	"/admin/export":  true,
+	"/admin/restore": true,
+}
+
+// TimeoutMiddleware limits the lifetime of the request context, which
+// every database query of the request runs with. A zero timeout leaves
+// the context as is.
+func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 || streamingRoutes[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
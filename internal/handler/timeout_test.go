@@ -0,0 +1,84 @@
+package handler_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reviewers/internal/events"
+	"reviewers/internal/handler"
+	"reviewers/internal/models"
+	"reviewers/internal/repository"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTimeout = 50 * time.Millisecond
+
+// slowTeams is a team store whose queries last until the request context
+// ends, like a query stuck on a lock.
+type slowTeams struct {
+	repository.TeamStore
+}
+
+func (s slowTeams) GetTeam(ctx context.Context, orgID, name string, withSubteams bool) (*models.Team, error) {
+	<-ctx.Done()
+	return nil, fmt.Errorf("get team: %w", ctx.Err())
+}
+
+func setupTimeoutRouter(broker *events.Broker) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.DiscardHandler)
+	stores := repository.NewMemoryStores(repository.NewMemoryDB(), logger)
+	stores.Teams = slowTeams{stores.Teams}
+
+	router := gin.New()
+	handler.InitHandlers(logger, stores, router, broker, handler.Options{Timeout: testTimeout})
+	return router
+}
+
+func TestTimeout_SlowQuery(t *testing.T) {
+	r := setupTimeoutRouter(events.NewBroker(100))
+
+	w := performRequest(r, "GET", "/team/get?team_name=backend", nil)
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Equal(t, "TIMEOUT", decode(t, w)["error"].(map[string]any)["code"])
+}
+
+func TestTimeout_StreamingRoutesExempt(t *testing.T) {
+	broker := events.NewBroker(100)
+	server := httptest.NewServer(setupTimeoutRouter(broker))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/events/stream", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The stream outlives the timeout
+	time.Sleep(3 * testTimeout)
+	broker.Publish(events.NewEvent(events.PullRequestCreated, &models.PullRequest{
+		OrganizationID: models.DefaultOrganizationID,
+		ID:             "pr-1",
+	}))
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "event:") {
+			assert.Equal(t, "event:"+events.PullRequestCreated, strings.ReplaceAll(scanner.Text(), " ", ""))
+			return
+		}
+	}
+	t.Fatalf("stream ended without the event: %v", scanner.Err())
+}
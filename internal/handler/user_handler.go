@@ -1,41 +1,76 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
-	"reviewers/internal/errs"
+	"reviewers/internal/models"
 	"reviewers/internal/service"
+	"reviewers/internal/validation"
+	"reviewers/pkg/errs"
 
 	"github.com/gin-gonic/gin"
 )
 
 type UserHandler struct {
-	service *service.UserService
+	service   *service.UserService
+	prService *service.PRService
 }
 
-func NewUserHandler(service *service.UserService) *UserHandler {
-	return &UserHandler{service}
+func NewUserHandler(service *service.UserService, prService *service.PRService) *UserHandler {
+	return &UserHandler{service, prService}
 }
 
-type SetActiveRequest struct {
-	UserID   string `json:"user_id" binding:"required"`
-	IsActive bool   `json:"is_active"`
+type GetUserQuery struct {
+	UserID string `form:"user_id" binding:"required,uuid"`
+}
+
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required,notblank,max=255"`
+	TeamName string `json:"team_name" binding:"required,notblank,max=255"`
+	Email    string `json:"email" binding:"omitempty,email,max=255"`
+	IsActive *bool  `json:"is_active"`
+}
+
+// UpdateUserRequest changes the given fields of the user, an empty email
+// removes it.
+type UpdateUserRequest struct {
+	UserID   string  `json:"user_id" binding:"required,uuid"`
+	Username string  `json:"username" binding:"omitempty,notblank,max=255"`
+	TeamName string  `json:"team_name" binding:"omitempty,notblank,max=255"`
+	Email    *string `json:"email" binding:"omitempty,max=255"`
+}
+
+// updateEmail validates a new non-empty email of UpdateUserRequest.
+type updateEmail struct {
+	Email string `json:"email" binding:"email"`
+}
+
+type DeleteUserRequest struct {
+	UserID string `json:"user_id" binding:"required,uuid"`
+}
+
+func (req *UpdateUserRequest) apply(user *models.User) {
+	if req.Username != "" {
+		user.Username = req.Username
+	}
+	if req.TeamName != "" {
+		user.TeamName = req.TeamName
+	}
+	if req.Email != nil {
+		user.Email = *req.Email
+	}
 }
 
 func (h *UserHandler) SetActiveStatus(c *gin.Context) {
-	var req SetActiveRequest
+	var req validation.SetActiveRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	if err := h.service.SetActiveStatus(req.UserID, req.IsActive); err != nil {
-		switch err.(type) {
-		case errs.ApiError:
-			err.(errs.ApiError).ReturnError(c, err.Error())
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "something bad happened"})
-		}
+	if err := h.service.SetActiveStatus(c.Request.Context(), organizationID(c), req.UserID, req.IsActive); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -45,21 +80,117 @@ func (h *UserHandler) SetActiveStatus(c *gin.Context) {
 }
 
 func (h *UserHandler) GetReview(c *gin.Context) {
-	userId := c.Query("user_id")
+	var query validation.GetReviewQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	filter, ok := bindListFilter(c)
+	if !ok {
+		return
+	}
 
-	if userId == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user id is required"})
+	page, err := h.service.GetReview(c.Request.Context(), organizationID(c), query.UserID, filter)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	prs, err := h.service.GetReview(userId)
+	resp := gin.H{
+		"user_id":       query.UserID,
+		"pull_requests": page.PullRequests,
+	}
+	if page.NextCursor != "" {
+		resp["next_cursor"] = page.NextCursor
+	}
+	if page.Total != nil {
+		resp["total"] = *page.Total
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *UserHandler) Get(c *gin.Context) {
+	var query GetUserQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	user, err := h.service.GetWithTeam(c.Request.Context(), organizationID(c), query.UserID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "something bad happened"})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"user_id":       userId,
-		"pull_requests": prs,
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+func (h *UserHandler) Create(c *gin.Context) {
+	var req CreateUserRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	user := &models.User{
+		Username: req.Username,
+		TeamName: req.TeamName,
+		Email:    req.Email,
+		IsActive: req.IsActive == nil || *req.IsActive,
+	}
+	if err := h.service.Create(c.Request.Context(), organizationID(c), user); err != nil {
+		if errors.Is(err, errs.UserExists) {
+			err = errs.UserExists.WithMessage(fmt.Sprintf("%s already exists", user.Username))
+		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+func (h *UserHandler) Update(c *gin.Context) {
+	var req UpdateUserRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.Email != nil && *req.Email != "" {
+		if err := validation.Validate(updateEmail{*req.Email}); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	user, err := h.service.GetWithTeam(c.Request.Context(), organizationID(c), req.UserID)
+	if err == nil {
+		req.apply(user)
+		err = h.service.Update(c.Request.Context(), user)
+	}
+	if err != nil {
+		if errors.Is(err, errs.UserExists) {
+			err = errs.UserExists.WithMessage(fmt.Sprintf("%s already exists", user.Username))
+		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// Delete reassigns the open reviews of the user and removes the user. If
+// some review cannot be reassigned or the user has other pull requests,
+// the user is kept.
+func (h *UserHandler) Delete(c *gin.Context) {
+	var req DeleteUserRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, orgID := c.Request.Context(), organizationID(c)
+	err := h.prService.ReleaseReviews(ctx, orgID, req.UserID, func(reassignments []models.Reassignment) error {
+		return h.service.Delete(ctx, orgID, req.UserID, reassignments)
 	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user has been deleted"})
 }
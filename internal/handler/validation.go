@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"reviewers/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// structValidator checks the requests bound by gin with the rules of the
+// validation package.
+type structValidator struct{}
+
+func (structValidator) ValidateStruct(obj any) error {
+	return validation.Struct(obj)
+}
+
+func (structValidator) Engine() any {
+	return validation.Engine()
+}
+
+func init() {
+	binding.Validator = structValidator{}
+}
+
+// bindJSON decodes the request body into obj. On failure the validation
+// error is added to the context and false is returned.
+func bindJSON(c *gin.Context, obj any) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		c.Error(validation.Error(err))
+		return false
+	}
+	return true
+}
+
+// bindQuery decodes the query parameters into obj the same way as bindJSON.
+func bindQuery(c *gin.Context, obj any) bool {
+	if err := c.ShouldBindQuery(obj); err != nil {
+		c.Error(validation.Error(err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+// Package metrics exposes service metrics in the Prometheus format.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "reviewers"
+
+const (
+	OutcomeSuccess     = "success"
+	OutcomeNoCandidate = "NO_CANDIDATE"
+	OutcomeNotAssigned = "NOT_ASSIGNED"
+	OutcomeError       = "error"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	PullRequestsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pull_requests_created_total",
+		Help:      "Number of created pull requests.",
+	})
+
+	PullRequestsMerged = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pull_requests_merged_total",
+		Help:      "Number of merged pull requests.",
+	})
+
+	Reassignments = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reassignments_total",
+		Help:      "Number of reviewer reassignments by outcome.",
+	}, []string{"outcome"})
+
+	CandidatePoolSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "candidate_pool_size",
+		Help:      "Number of review candidates found in the author's team.",
+		Buckets:   []float64{0, 1, 2, 3, 5, 8, 13, 21},
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		PullRequestsCreated,
+		PullRequestsMerged,
+		Reassignments,
+		CandidatePoolSize,
+	)
+}
+
+// RegisterDB exposes connection pool statistics of the database.
+func RegisterDB(db *sql.DB) error {
+	return registry.Register(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// Handler serves the metrics.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts requests and measures their latency per route.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		httpRequests.WithLabelValues(route, c.Request.Method, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(route, c.Request.Method).Observe(time.Since(start).Seconds())
+	}
+}
@@ -1,25 +1,87 @@
 package models
 
 import (
+	"reviewers/pkg/api"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// Types that are stored as they are sent over the wire are the ones of
+// the API.
+type (
+	ReviewerStats     = api.ReviewerStats
+	TeamReport        = api.TeamReport
+	UserShort         = api.UserShort
+	StatsFilter       = api.StatsFilter
+	PullRequestFilter = api.PullRequestFilter
+	ReviewerDetails   = api.ReviewerDetails
+	ImportChange      = api.ImportChange
+	ImportResult      = api.ImportResult
+	RestoreResult     = api.RestoreResult
+)
+
+// Organization is a tenant. Data of different organizations is fully
+// isolated from each other.
+type Organization struct {
+	ID   string `json:"organization_id" gorm:"column:organization_id;primaryKey"`
+	Name string `json:"name" gorm:"column:name;unique;not null"`
+}
+
+const DefaultOrganizationID = api.DefaultOrganizationID
+
 type User struct {
-	ID       string `json:"user_id" gorm:"column:user_id;primaryKey"`
-	Username string `json:"username" gorm:"unique;not null"`
-	IsActive bool   `json:"is_active"`
-	TeamID   string `json:"-"`
+	ID             string `json:"user_id" gorm:"column:user_id;primaryKey" binding:"omitempty,uuid"`
+	OrganizationID string `json:"-" gorm:"column:organization_id;default:00000000-0000-0000-0000-000000000000"`
+	Username       string `json:"username" gorm:"not null" binding:"required_without=ID,omitempty,notblank,max=255"`
+	Email          string `json:"email,omitempty" gorm:"column:email" binding:"omitempty,email,max=255"`
+	IsActive       bool   `json:"is_active"`
+	TeamID         string `json:"-"`
+	TeamName       string `json:"team_name,omitempty" gorm:"-"`
 }
 
 type Team struct {
-	ID      string `json:"-" gorm:"column:team_id;primaryKey"`
-	Name    string `json:"team_name" gorm:"column:name;unique;not null"`
-	Members []User `json:"members" gorm:"foreignKey:TeamID"`
+	ID             string  `json:"-" gorm:"column:team_id;primaryKey"`
+	OrganizationID string  `json:"-" gorm:"column:organization_id;default:00000000-0000-0000-0000-000000000000"`
+	Name           string  `json:"team_name" gorm:"column:name;not null" binding:"required,notblank,max=255"`
+	ParentID       *string `json:"-" gorm:"column:parent_team_id"`
+	ParentName     string  `json:"parent_team_name,omitempty" gorm:"-" binding:"max=255"`
+	Members        []User  `json:"members" gorm:"foreignKey:TeamID" binding:"max=1000,dive"`
+	Subteams       []Team  `json:"subteams,omitempty" gorm:"foreignKey:ParentID"`
+}
+
+// TeamStats holds review counters of a team. Every counter includes
+// the counters of all subteams.
+type TeamStats struct {
+	TeamName        string      `json:"team_name" gorm:"-"`
+	TeamID          string      `json:"-" gorm:"column:team_id"`
+	Members         int64       `json:"members" gorm:"column:members"`
+	ActiveMembers   int64       `json:"active_members" gorm:"column:active_members"`
+	AssignedReviews int64       `json:"assigned_reviews" gorm:"column:assigned_reviews"`
+	OpenReviews     int64       `json:"open_reviews" gorm:"column:open_reviews"`
+	Subteams        []TeamStats `json:"subteams,omitempty" gorm:"-"`
 }
 
+// Repository groups pull requests and defines how reviewers are picked
+// for them. Pull request IDs are unique within a repository.
+type Repository struct {
+	ID             string  `json:"-" gorm:"column:repository_id;primaryKey"`
+	OrganizationID string  `json:"-" gorm:"column:organization_id;default:00000000-0000-0000-0000-000000000000"`
+	Name           string  `json:"name" gorm:"column:name;not null"`
+	TeamID         *string `json:"-" gorm:"column:team_id"`
+	TeamName       string  `json:"team_name,omitempty" gorm:"-"`
+	ReviewersCount int     `json:"reviewers_count" gorm:"column:reviewers_count"`
+	ClimbHierarchy bool    `json:"climb_hierarchy" gorm:"column:climb_hierarchy"`
+}
+
+const DefaultRepository = api.DefaultRepository
+const DefaultRepositoryID = "00000000-0000-0000-0000-000000000000"
+
 type PullRequest struct {
+	OrganizationID string `json:"-" gorm:"column:organization_id;default:00000000-0000-0000-0000-000000000000"`
+	RepositoryID   string `json:"-" gorm:"column:repository_id;primaryKey;default:00000000-0000-0000-0000-000000000000"`
+	Repository     string `json:"repository" gorm:"-"`
+
 	ID        string     `json:"pull_request_id" gorm:"column:pull_request_id;primaryKey"`
 	Name      string     `json:"pull_request_name" gorm:"column:pull_request_name"`
 	Status    string     `json:"status"`
@@ -29,8 +91,11 @@ type PullRequest struct {
 	AuthorID string `json:"author_id"`
 	Author   User   `json:"-" gorm:"foreignKey:AuthorID"`
 
-	Reviewers         []PullRequestReviewer `json:"-" gorm:"foreignKey:PullRequestID"`
+	Reviewers         []PullRequestReviewer `json:"-" gorm:"foreignKey:RepositoryID,PullRequestID;references:RepositoryID,ID"`
 	AssignedReviewers []string              `json:"assigned_reviewers" gorm:"-"`
+
+	Files        []PullRequestFile `json:"-" gorm:"foreignKey:RepositoryID,PullRequestID;references:RepositoryID,ID"`
+	ChangedFiles []string          `json:"changed_files,omitempty" gorm:"-"`
 }
 
 func (pr *PullRequest) AfterFind(tx *gorm.DB) (err error) {
@@ -40,20 +105,229 @@ func (pr *PullRequest) AfterFind(tx *gorm.DB) (err error) {
 			pr.AssignedReviewers = append(pr.AssignedReviewers, reviewer.UserID)
 		}
 	}
+	if len(pr.Files) > 0 {
+		pr.ChangedFiles = make([]string, 0, len(pr.Files))
+		for _, file := range pr.Files {
+			pr.ChangedFiles = append(pr.ChangedFiles, file.Path)
+		}
+	}
 	return nil
 }
 
+type PullRequestFile struct {
+	RepositoryID  string `gorm:"column:repository_id;primaryKey;default:00000000-0000-0000-0000-000000000000"`
+	PullRequestID string `gorm:"column:pull_request_id;primaryKey"`
+	Path          string `gorm:"column:path;primaryKey"`
+}
+
+// CodeOwnerRule assigns owners to the files matching the pattern.
+// When several rules of a repository match a file, the last one wins.
+type CodeOwnerRule struct {
+	ID           int64       `json:"-" gorm:"column:rule_id;primaryKey"`
+	RepositoryID string      `json:"-" gorm:"column:repository_id"`
+	Position     int         `json:"-"`
+	Pattern      string      `json:"pattern" binding:"required,max=1024"`
+	Owners       []CodeOwner `json:"-" gorm:"foreignKey:RuleID"`
+	OwnerIDs     []string    `json:"owners" gorm:"-" binding:"dive,uuid"`
+}
+
+func (r *CodeOwnerRule) AfterFind(tx *gorm.DB) (err error) {
+	r.OwnerIDs = make([]string, 0, len(r.Owners))
+	for _, owner := range r.Owners {
+		r.OwnerIDs = append(r.OwnerIDs, owner.UserID)
+	}
+	return nil
+}
+
+type CodeOwner struct {
+	RuleID int64  `gorm:"column:rule_id;primaryKey"`
+	UserID string `gorm:"column:user_id;primaryKey"`
+}
+
 type PullRequestReviewer struct {
+	RepositoryID  string `json:"-" gorm:"column:repository_id;primaryKey;default:00000000-0000-0000-0000-000000000000"`
 	PullRequestID string `json:"pull_request_id" gorm:"column:pull_request_id;primaryKey"`
 	UserID        string `json:"user_id" gorm:"column:user_id;primaryKey"`
 }
 
-const StatusOpen = "OPEN"
-const StatusMerged = "MERGED"
+const StatusOpen = api.StatusOpen
+const StatusMerged = api.StatusMerged
+
+// PullRequestEvent is an entry of the pull request history.
+type PullRequestEvent struct {
+	ID             int64     `json:"-" gorm:"column:event_id;primaryKey"`
+	OrganizationID string    `json:"-" gorm:"column:organization_id"`
+	RepositoryID   string    `json:"-" gorm:"column:repository_id"`
+	PullRequestID  string    `json:"pull_request_id" gorm:"column:pull_request_id"`
+	Type           string    `json:"event_type" gorm:"column:event_type"`
+	UserID         *string   `json:"user_id,omitempty" gorm:"column:user_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+const EventAssigned = "ASSIGNED"
+const EventUnassigned = "UNASSIGNED"
+const EventApproved = "APPROVED"
+const EventMerged = "MERGED"
+
+// NewPullRequestEvent returns an event of the pull request happened now.
+// The user is the reviewer the event is about, if any.
+func NewPullRequestEvent(pr *PullRequest, eventType string, userID string) PullRequestEvent {
+	event := PullRequestEvent{
+		OrganizationID: pr.OrganizationID,
+		RepositoryID:   pr.RepositoryID,
+		PullRequestID:  pr.ID,
+		Type:           eventType,
+		CreatedAt:      time.Now(),
+	}
+	if userID != "" {
+		event.UserID = &userID
+	}
+	return event
+}
+
+// Reassignment is a pull request with a replaced reviewer and the history
+// entries of the replacement.
+type Reassignment struct {
+	PullRequest *PullRequest
+	Events      []PullRequestEvent
+}
+
+// MergeStats holds how fast pull requests get merged.
+type MergeStats struct {
+	Merged          int64    `gorm:"column:merged"`
+	MeanTimeToMerge *float64 `gorm:"column:mean_time_to_merge"`
+}
 
 type PullRequestShort struct {
-	ID       string `json:"pull_request_id" gorm:"column:pull_request_id"`
-	Name     string `json:"pull_request_name" gorm:"column:pull_request_name"`
-	AuthorID string `json:"author_id" gorm:"column:author_id"`
-	Status   string `json:"status" gorm:"column:status"`
+	RepositoryID string    `json:"-" gorm:"column:repository_id"`
+	Repository   string    `json:"repository" gorm:"column:repository"`
+	ID           string    `json:"pull_request_id" gorm:"column:pull_request_id"`
+	Name         string    `json:"pull_request_name" gorm:"column:pull_request_name"`
+	AuthorID     string    `json:"author_id" gorm:"column:author_id"`
+	Status       string    `json:"status" gorm:"column:status"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at"`
 }
+
+const SortCreatedAt = api.SortCreatedAt
+const SortName = api.SortName
+
+// PullRequestDetails is a pull request with the details of its reviewers.
+type PullRequestDetails struct {
+	PullRequest
+	Reviewers []ReviewerDetails `json:"reviewers"`
+}
+
+// PullRequestPage is a page of a pull request listing. NextCursor is empty
+// on the last page, Total counts pull requests of all pages and is only
+// set on request.
+type PullRequestPage struct {
+	PullRequests []PullRequestShort `json:"pull_requests"`
+	NextCursor   string             `json:"next_cursor,omitempty"`
+	Total        *int64             `json:"total,omitempty"`
+}
+
+// Roster is a list of teams and their members to import. Line is the line
+// of an entry in the imported file.
+type Roster struct {
+	Teams   []RosterTeam
+	Members []RosterMember
+}
+
+// RosterTeam is a team of a roster. An empty ParentName keeps the parent
+// of an existing team.
+type RosterTeam struct {
+	Line       int    `json:"-"`
+	Name       string `json:"team_name" binding:"required,notblank,max=255"`
+	ParentName string `json:"parent_team_name" binding:"max=255"`
+}
+
+// RosterMember is a member of a team of a roster. An empty Email keeps the
+// email of an existing user.
+type RosterMember struct {
+	Line     int    `json:"-"`
+	TeamName string `json:"team_name"`
+	Username string `json:"username" binding:"required,notblank,max=255"`
+	Email    string `json:"email" binding:"omitempty,email,max=255"`
+	IsActive bool   `json:"is_active"`
+}
+
+const (
+	ImportCreateTeam     = api.ImportCreateTeam
+	ImportSetParent      = api.ImportSetParent
+	ImportCreateUser     = api.ImportCreateUser
+	ImportMoveUser       = api.ImportMoveUser
+	ImportUpdateEmail    = api.ImportUpdateEmail
+	ImportActivateUser   = api.ImportActivateUser
+	ImportDeactivateUser = api.ImportDeactivateUser
+)
+
+// BackupRecord is a record of a backup: a *BackupTeam, *BackupUser,
+// *BackupRepository, *BackupCodeOwnerRule, *BackupPullRequest or
+// *BackupEvent. Records refer to teams and repositories by their names and
+// to users by their IDs, a record follows the records it refers to.
+type BackupRecord interface {
+	// RecordType names the type of the record in a backup.
+	RecordType() string
+}
+
+// BackupTeam is a team of a backup. The ID is kept, so that references of
+// identity providers to the team stay valid.
+type BackupTeam struct {
+	ID         string `json:"team_id" binding:"required,uuid"`
+	Name       string `json:"name" binding:"required,notblank,max=255"`
+	ParentName string `json:"parent_team_name,omitempty" binding:"max=255"`
+}
+
+type BackupUser struct {
+	ID       string `json:"user_id" binding:"required,uuid"`
+	Username string `json:"username" binding:"required,notblank,max=255"`
+	Email    string `json:"email,omitempty" binding:"max=255"`
+	IsActive bool   `json:"is_active"`
+	TeamName string `json:"team_name" binding:"required,max=255"`
+}
+
+type BackupRepository struct {
+	Name           string `json:"name" binding:"required,notblank,max=255"`
+	TeamName       string `json:"team_name,omitempty" binding:"max=255"`
+	ReviewersCount int    `json:"reviewers_count" binding:"min=1"`
+	ClimbHierarchy bool   `json:"climb_hierarchy"`
+}
+
+// BackupCodeOwnerRule is a code owner rule of a backup. Rules of a
+// repository follow each other in their order.
+type BackupCodeOwnerRule struct {
+	Repository string   `json:"repository" binding:"required,max=255"`
+	Pattern    string   `json:"pattern" binding:"required,max=1024"`
+	Owners     []string `json:"owners" binding:"dive,uuid"`
+}
+
+// BackupPullRequest is a pull request of a backup with its reviewers and
+// changed files.
+type BackupPullRequest struct {
+	Repository   string     `json:"repository" binding:"required,max=255"`
+	ID           string     `json:"pull_request_id" binding:"required,max=255"`
+	Name         string     `json:"pull_request_name" binding:"max=255"`
+	Status       string     `json:"status" binding:"oneof=OPEN MERGED"`
+	CreatedAt    time.Time  `json:"created_at"`
+	MergedAt     *time.Time `json:"merged_at,omitempty"`
+	AuthorID     string     `json:"author_id" binding:"required,uuid"`
+	Reviewers    []string   `json:"reviewers" binding:"dive,uuid"`
+	ChangedFiles []string   `json:"changed_files,omitempty"`
+}
+
+// BackupEvent is an entry of the pull request history of a backup.
+// Events follow each other in the order they happened.
+type BackupEvent struct {
+	Repository    string    `json:"repository" binding:"required,max=255"`
+	PullRequestID string    `json:"pull_request_id" binding:"required,max=255"`
+	Type          string    `json:"event_type" binding:"oneof=ASSIGNED UNASSIGNED APPROVED MERGED"`
+	UserID        *string   `json:"user_id,omitempty" binding:"omitempty,uuid"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (*BackupTeam) RecordType() string          { return "team" }
+func (*BackupUser) RecordType() string          { return "user" }
+func (*BackupRepository) RecordType() string    { return "repository" }
+func (*BackupCodeOwnerRule) RecordType() string { return "code_owner_rule" }
+func (*BackupPullRequest) RecordType() string   { return "pull_request" }
+func (*BackupEvent) RecordType() string         { return "event" }
@@ -0,0 +1,63 @@
+// Package openapi holds the OpenAPI specification of the HTTP API.
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed openapi.yaml
+var spec []byte
+
+// Load parses and validates the specification.
+func Load() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OpenAPI spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+	return doc, nil
+}
+
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Reviewers API</title>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+  <redoc spec-url="openapi.json"></redoc>
+  <script src="https://cdn.redoc.ly/redoc/latest/bundles/redoc.standalone.js"></script>
+</body>
+</html>`
+
+// Register serves the specification at /openapi.json and its
+// documentation page at /docs. It panics if the embedded specification
+// is invalid.
+func Register(router gin.IRouter) {
+	doc, err := Load()
+	if err != nil {
+		panic(err)
+	}
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		panic(err)
+	}
+
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, gin.MIMEJSON, body)
+	})
+	router.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, gin.MIMEHTML, []byte(docsPage))
+	})
+}
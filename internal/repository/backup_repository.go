@@ -0,0 +1,695 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+	"slices"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// exportBatchSize is the number of rows an export reads at once.
+const exportBatchSize = 1000
+
+type BackupRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewBackupRepository(db *gorm.DB, logger *slog.Logger) *BackupRepository {
+	return &BackupRepository{db, logger}
+}
+
+// BackupSource yields the records of a backup. Next returns io.EOF after
+// the last record, Line returns the line of the last returned record.
+type BackupSource interface {
+	Next() (models.BackupRecord, error)
+	Line() int
+}
+
+// Export passes every team, user, repository, code owner rule, pull
+// request and event of the organization to write, in the order in which
+// Restore accepts them. The data is read from a single snapshot.
+func (r *BackupRepository) Export(ctx context.Context, orgID string, write func(models.BackupRecord) error) error {
+	logger := r.logger.With(
+		"method", "export_backup",
+		"organization_id", orgID,
+	)
+	logger.InfoContext(ctx, "exporting backup")
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		teamNames, err := exportTeams(tx, orgID, write)
+		if err != nil {
+			return err
+		}
+		if err := exportUsers(tx, orgID, teamNames, write); err != nil {
+			return err
+		}
+		repoNames, err := exportRepositories(tx, orgID, teamNames, write)
+		if err != nil {
+			return err
+		}
+		if err := exportPullRequests(tx, orgID, repoNames, write); err != nil {
+			return err
+		}
+		return exportEvents(tx, orgID, repoNames, write)
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to export backup", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// exportTeams writes the teams so that parents precede their subteams and
+// returns the names of the teams by their IDs.
+func exportTeams(tx *gorm.DB, orgID string, write func(models.BackupRecord) error) (map[string]string, error) {
+	var teams []models.Team
+	if err := tx.Where("organization_id = ?", orgID).Order("name").Find(&teams).Error; err != nil {
+		return nil, fmt.Errorf("failed to get teams: %w", err)
+	}
+
+	return writeTeams(teams, write)
+}
+
+// writeTeams writes the teams ordered by name so that parents precede
+// their subteams and returns the names of the teams by their IDs.
+func writeTeams(teams []models.Team, write func(models.BackupRecord) error) (map[string]string, error) {
+	names := make(map[string]string, len(teams))
+	subteams := make(map[string][]models.Team)
+	var roots []models.Team
+	for _, team := range teams {
+		names[team.ID] = team.Name
+		if team.ParentID == nil {
+			roots = append(roots, team)
+		} else {
+			subteams[*team.ParentID] = append(subteams[*team.ParentID], team)
+		}
+	}
+
+	var visit func(team models.Team, parentName string) error
+	visit = func(team models.Team, parentName string) error {
+		if err := write(&models.BackupTeam{ID: team.ID, Name: team.Name, ParentName: parentName}); err != nil {
+			return err
+		}
+		for _, subteam := range subteams[team.ID] {
+			if err := visit(subteam, team.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, team := range roots {
+		if err := visit(team, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return names, nil
+}
+
+func exportUsers(tx *gorm.DB, orgID string, teamNames map[string]string, write func(models.BackupRecord) error) error {
+	var users []models.User
+	result := tx.Where("organization_id = ?", orgID).FindInBatches(&users, exportBatchSize, func(_ *gorm.DB, _ int) error {
+		for _, user := range users {
+			if err := write(backupUser(&user, teamNames)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to export users: %w", result.Error)
+	}
+	return nil
+}
+
+// exportRepositories writes the repositories with their code owner rules
+// and returns the names of the repositories by their IDs.
+func exportRepositories(tx *gorm.DB, orgID string, teamNames map[string]string, write func(models.BackupRecord) error) (map[string]string, error) {
+	var repos []models.Repository
+	if err := tx.Where("organization_id = ?", orgID).Order("name").Find(&repos).Error; err != nil {
+		return nil, fmt.Errorf("failed to get repositories: %w", err)
+	}
+
+	names := make(map[string]string, len(repos))
+	for _, repo := range repos {
+		names[repo.ID] = repo.Name
+		if err := write(backupRepository(&repo, teamNames)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, repo := range repos {
+		var rules []models.CodeOwnerRule
+		err := tx.Preload("Owners", func(db *gorm.DB) *gorm.DB { return db.Order("user_id") }).
+			Where("repository_id = ?", repo.ID).
+			Order("position").
+			Find(&rules).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to get code owner rules of %s: %w", repo.Name, err)
+		}
+
+		for _, rule := range rules {
+			err := write(&models.BackupCodeOwnerRule{Repository: repo.Name, Pattern: rule.Pattern, Owners: rule.OwnerIDs})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return names, nil
+}
+
+// exportPullRequests writes the pull requests page by page. The pages
+// follow the primary key, which is composite, so FindInBatches does not
+// fit.
+func exportPullRequests(tx *gorm.DB, orgID string, repoNames map[string]string, write func(models.BackupRecord) error) error {
+	var last *models.PullRequest
+	for {
+		query := tx.Preload("Reviewers").
+			Preload("Files").
+			Where("organization_id = ?", orgID).
+			Order("repository_id, pull_request_id").
+			Limit(exportBatchSize)
+		if last != nil {
+			query = query.Where("(repository_id, pull_request_id) > (?, ?)", last.RepositoryID, last.ID)
+		}
+
+		var prs []models.PullRequest
+		if err := query.Find(&prs).Error; err != nil {
+			return fmt.Errorf("failed to get pull requests: %w", err)
+		}
+
+		for _, pr := range prs {
+			if err := write(backupPullRequest(&pr, repoNames)); err != nil {
+				return err
+			}
+		}
+
+		if len(prs) < exportBatchSize {
+			return nil
+		}
+		last = &prs[len(prs)-1]
+	}
+}
+
+func exportEvents(tx *gorm.DB, orgID string, repoNames map[string]string, write func(models.BackupRecord) error) error {
+	var events []models.PullRequestEvent
+	result := tx.Where("organization_id = ?", orgID).FindInBatches(&events, exportBatchSize, func(_ *gorm.DB, _ int) error {
+		for _, event := range events {
+			if err := write(backupEvent(&event, repoNames)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to export events: %w", result.Error)
+	}
+	return nil
+}
+
+func backupUser(user *models.User, teamNames map[string]string) *models.BackupUser {
+	return &models.BackupUser{
+		ID:       user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		IsActive: user.IsActive,
+		TeamName: teamNames[user.TeamID],
+	}
+}
+
+func backupRepository(repo *models.Repository, teamNames map[string]string) *models.BackupRepository {
+	record := &models.BackupRepository{
+		Name:           repo.Name,
+		ReviewersCount: repo.ReviewersCount,
+		ClimbHierarchy: repo.ClimbHierarchy,
+	}
+	if repo.TeamID != nil {
+		record.TeamName = teamNames[*repo.TeamID]
+	}
+	return record
+}
+
+// backupPullRequest returns the record of the pull request with its
+// reviewers and changed files.
+func backupPullRequest(pr *models.PullRequest, repoNames map[string]string) *models.BackupPullRequest {
+	reviewers := slices.Sorted(slices.Values(pr.AssignedReviewers))
+	if reviewers == nil {
+		reviewers = []string{}
+	}
+	return &models.BackupPullRequest{
+		Repository:   repoNames[pr.RepositoryID],
+		ID:           pr.ID,
+		Name:         pr.Name,
+		Status:       pr.Status,
+		CreatedAt:    pr.CreatedAt,
+		MergedAt:     pr.MergedAt,
+		AuthorID:     pr.AuthorID,
+		Reviewers:    reviewers,
+		ChangedFiles: slices.Sorted(slices.Values(pr.ChangedFiles)),
+	}
+}
+
+func backupEvent(event *models.PullRequestEvent, repoNames map[string]string) *models.BackupEvent {
+	return &models.BackupEvent{
+		Repository:    repoNames[event.RepositoryID],
+		PullRequestID: event.PullRequestID,
+		Type:          event.Type,
+		UserID:        event.UserID,
+		CreatedAt:     event.CreatedAt,
+	}
+}
+
+// restoreTarget saves the records of a restore. Creating a team or a
+// user with a taken ID fails with gorm.ErrDuplicatedKey.
+type restoreTarget interface {
+	createTeam(team *models.Team) error
+	createUser(user *models.User) error
+	createRepository(repo *models.Repository) error
+	updateRepository(repo *models.Repository) error
+	// createCodeOwnerRule creates the rule with its owners
+	createCodeOwnerRule(rule *models.CodeOwnerRule) error
+	// createPullRequest creates the pull request with its reviewers and
+	// changed files
+	createPullRequest(pr *models.PullRequest) error
+	createEvent(event *models.PullRequestEvent) error
+}
+
+// restore holds what a restore has saved so far, so that records are
+// checked to refer only to the records preceding them.
+type restore struct {
+	target restoreTarget
+	orgID  string
+	source BackupSource
+	result models.RestoreResult
+
+	teams     map[string]string // IDs by name
+	users     map[string]bool
+	usernames map[string]bool
+	repos     map[string]string // IDs by name
+	restored  map[string]bool   // names of the restored repositories
+	positions map[string]int    // next code owner rule position by repository ID
+	prs       map[string]bool   // by repository ID and pull request ID
+}
+
+// Restore saves the records of the backup in one transaction. The
+// organization must have no teams, repositories other than the default one
+// and code owner rules, otherwise errs.NotEmpty is returned. The default
+// repository takes the settings from the backup. Records referring to
+// records that do not precede them are reported as an
+// *errs.ValidationError with their line.
+func (r *BackupRepository) Restore(ctx context.Context, orgID string, source BackupSource) (*models.RestoreResult, error) {
+	logger := r.logger.With(
+		"method", "restore_backup",
+		"organization_id", orgID,
+	)
+	logger.InfoContext(ctx, "restoring backup")
+
+	var rs *restore
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing, err := restoredRepositories(tx, orgID)
+		if err != nil {
+			return err
+		}
+
+		rs = newRestore(dbRestoreTarget{tx}, orgID, source, existing)
+		return rs.run()
+	})
+	switch {
+	case errors.Is(err, errs.NotEmpty):
+		logger.WarnContext(ctx, "organization is not empty")
+		return nil, err
+	case errors.Is(err, errs.ValidationFailed):
+		logger.WarnContext(ctx, "invalid backup", "error", err, "line", source.Line())
+		return nil, err
+	case err != nil:
+		logger.ErrorContext(ctx, "failed to restore backup", "error", err, "line", source.Line())
+		return nil, fmt.Errorf("failed to restore backup: %w", err)
+	}
+
+	return &rs.result, nil
+}
+
+// restoredRepositories checks that the organization is empty and returns
+// its repositories.
+func restoredRepositories(tx *gorm.DB, orgID string) ([]models.Repository, error) {
+	var teams, repos, rules int64
+	if err := tx.Model(&models.Team{}).Where("organization_id = ?", orgID).Count(&teams).Error; err != nil {
+		return nil, fmt.Errorf("failed to count teams: %w", err)
+	}
+	err := tx.Model(&models.Repository{}).
+		Where("organization_id = ? AND name <> ?", orgID, models.DefaultRepository).
+		Count(&repos).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count repositories: %w", err)
+	}
+	err = tx.Model(&models.CodeOwnerRule{}).
+		Joins("JOIN repositories ON repositories.repository_id = code_owner_rules.repository_id").
+		Where("repositories.organization_id = ?", orgID).
+		Count(&rules).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count code owner rules: %w", err)
+	}
+	// Users belong to teams and pull requests to users, so they are
+	// counted along with the teams
+	if teams > 0 || repos > 0 || rules > 0 {
+		return nil, errNotEmpty
+	}
+
+	var existing []models.Repository
+	if err := tx.Where("organization_id = ?", orgID).Find(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to get repositories: %w", err)
+	}
+	return existing, nil
+}
+
+var errNotEmpty = errs.NotEmpty.WithMessage("organization must have no teams, repositories and code owner rules to restore a backup")
+
+// newRestore starts the restore into the organization with the given
+// repositories.
+func newRestore(target restoreTarget, orgID string, source BackupSource, existing []models.Repository) *restore {
+	repoIDs := make(map[string]string, len(existing))
+	for _, repo := range existing {
+		repoIDs[repo.Name] = repo.ID
+	}
+
+	return &restore{
+		target:    target,
+		orgID:     orgID,
+		source:    source,
+		teams:     map[string]string{},
+		users:     map[string]bool{},
+		usernames: map[string]bool{},
+		repos:     repoIDs,
+		restored:  map[string]bool{},
+		positions: map[string]int{},
+		prs:       map[string]bool{},
+	}
+}
+
+// run saves every record of the source.
+func (rs *restore) run() error {
+	for {
+		record, err := rs.source.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := rs.save(record); err != nil {
+			return err
+		}
+	}
+}
+
+// fail reports the field of the current record.
+func (rs *restore) fail(field, message string) error {
+	return errs.NewValidationError(errs.FieldError{Line: rs.source.Line(), Field: field, Message: message})
+}
+
+func (rs *restore) save(record models.BackupRecord) error {
+	switch record := record.(type) {
+	case *models.BackupTeam:
+		return rs.saveTeam(record)
+	case *models.BackupUser:
+		return rs.saveUser(record)
+	case *models.BackupRepository:
+		return rs.saveRepository(record)
+	case *models.BackupCodeOwnerRule:
+		return rs.saveCodeOwnerRule(record)
+	case *models.BackupPullRequest:
+		return rs.savePullRequest(record)
+	case *models.BackupEvent:
+		return rs.saveEvent(record)
+	default:
+		return fmt.Errorf("unknown record type %s", record.RecordType())
+	}
+}
+
+func (rs *restore) saveTeam(record *models.BackupTeam) error {
+	if _, ok := rs.teams[record.Name]; ok {
+		return rs.fail("name", "is already restored")
+	}
+
+	team := models.Team{ID: record.ID, OrganizationID: rs.orgID, Name: record.Name}
+	if record.ParentName != "" {
+		parentID, ok := rs.teams[record.ParentName]
+		if !ok {
+			return rs.fail("parent_team_name", "team not found, the parent must precede its subteams")
+		}
+		team.ParentID = &parentID
+	}
+
+	if err := rs.target.createTeam(&team); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return rs.fail("team_id", "is already used")
+		}
+		return fmt.Errorf("failed to create team %s: %w", team.Name, err)
+	}
+
+	rs.teams[team.Name] = team.ID
+	rs.result.Teams++
+	return nil
+}
+
+func (rs *restore) saveUser(record *models.BackupUser) error {
+	if rs.usernames[record.Username] {
+		return rs.fail("username", "is already restored")
+	}
+	teamID, ok := rs.teams[record.TeamName]
+	if !ok {
+		return rs.fail("team_name", "team not found, teams must precede their members")
+	}
+
+	user := models.User{
+		ID:             record.ID,
+		OrganizationID: rs.orgID,
+		Username:       record.Username,
+		Email:          record.Email,
+		IsActive:       record.IsActive,
+		TeamID:         teamID,
+	}
+	if err := rs.target.createUser(&user); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return rs.fail("user_id", "is already used")
+		}
+		return fmt.Errorf("failed to create user %s: %w", user.Username, err)
+	}
+
+	rs.users[user.ID] = true
+	rs.usernames[user.Username] = true
+	rs.result.Users++
+	return nil
+}
+
+// saveRepository creates the repository or, for the default one, updates
+// its settings.
+func (rs *restore) saveRepository(record *models.BackupRepository) error {
+	if rs.restored[record.Name] {
+		return rs.fail("name", "is already restored")
+	}
+
+	repo := models.Repository{
+		OrganizationID: rs.orgID,
+		Name:           record.Name,
+		ReviewersCount: record.ReviewersCount,
+		ClimbHierarchy: record.ClimbHierarchy,
+	}
+	if record.TeamName != "" {
+		teamID, ok := rs.teams[record.TeamName]
+		if !ok {
+			return rs.fail("team_name", "team not found, teams must precede repositories")
+		}
+		repo.TeamID = &teamID
+	}
+
+	if id, ok := rs.repos[record.Name]; ok {
+		repo.ID = id
+		if err := rs.target.updateRepository(&repo); err != nil {
+			return fmt.Errorf("failed to update repository %s: %w", repo.Name, err)
+		}
+	} else {
+		repo.ID = uuid.New().String()
+		if err := rs.target.createRepository(&repo); err != nil {
+			return fmt.Errorf("failed to create repository %s: %w", repo.Name, err)
+		}
+	}
+
+	rs.repos[repo.Name] = repo.ID
+	rs.restored[repo.Name] = true
+	rs.result.Repositories++
+	return nil
+}
+
+func (rs *restore) saveCodeOwnerRule(record *models.BackupCodeOwnerRule) error {
+	repoID, ok := rs.repository(record.Repository)
+	if !ok {
+		return rs.fail("repository", "repository not found, repositories must precede their rules")
+	}
+	for _, owner := range record.Owners {
+		if !rs.users[owner] {
+			return rs.fail("owners", fmt.Sprintf("user %s not found, users must precede the rules they own", owner))
+		}
+	}
+
+	rule := models.CodeOwnerRule{RepositoryID: repoID, Position: rs.positions[repoID], Pattern: record.Pattern}
+	for _, owner := range slices.Compact(slices.Sorted(slices.Values(record.Owners))) {
+		rule.Owners = append(rule.Owners, models.CodeOwner{UserID: owner})
+	}
+	if err := rs.target.createCodeOwnerRule(&rule); err != nil {
+		return err
+	}
+	rs.positions[repoID]++
+
+	rs.result.CodeOwnerRules++
+	return nil
+}
+
+func (rs *restore) savePullRequest(record *models.BackupPullRequest) error {
+	repoID, ok := rs.repository(record.Repository)
+	if !ok {
+		return rs.fail("repository", "repository not found, repositories must precede their pull requests")
+	}
+	key := repoID + "/" + record.ID
+	if rs.prs[key] {
+		return rs.fail("pull_request_id", "is already restored")
+	}
+	if !rs.users[record.AuthorID] {
+		return rs.fail("author_id", "user not found, users must precede their pull requests")
+	}
+	for _, reviewer := range record.Reviewers {
+		if !rs.users[reviewer] {
+			return rs.fail("reviewers", fmt.Sprintf("user %s not found, users must precede their reviews", reviewer))
+		}
+	}
+	if (record.Status == models.StatusMerged) != (record.MergedAt != nil) {
+		return rs.fail("merged_at", "must be set for merged pull requests only")
+	}
+
+	pr := models.PullRequest{
+		OrganizationID: rs.orgID,
+		RepositoryID:   repoID,
+		ID:             record.ID,
+		Name:           record.Name,
+		Status:         record.Status,
+		CreatedAt:      record.CreatedAt,
+		MergedAt:       record.MergedAt,
+		AuthorID:       record.AuthorID,
+	}
+	for _, reviewer := range slices.Compact(slices.Sorted(slices.Values(record.Reviewers))) {
+		pr.Reviewers = append(pr.Reviewers, models.PullRequestReviewer{RepositoryID: repoID, PullRequestID: pr.ID, UserID: reviewer})
+	}
+	for _, path := range slices.Compact(slices.Sorted(slices.Values(record.ChangedFiles))) {
+		pr.Files = append(pr.Files, models.PullRequestFile{RepositoryID: repoID, PullRequestID: pr.ID, Path: path})
+	}
+	if err := rs.target.createPullRequest(&pr); err != nil {
+		return err
+	}
+
+	rs.prs[key] = true
+	rs.result.PullRequests++
+	return nil
+}
+
+func (rs *restore) saveEvent(record *models.BackupEvent) error {
+	repoID, ok := rs.repository(record.Repository)
+	if !ok || !rs.prs[repoID+"/"+record.PullRequestID] {
+		return rs.fail("pull_request_id", "pull request not found, pull requests must precede their events")
+	}
+	if record.UserID != nil && !rs.users[*record.UserID] {
+		return rs.fail("user_id", "user not found, users must precede their events")
+	}
+
+	event := models.PullRequestEvent{
+		OrganizationID: rs.orgID,
+		RepositoryID:   repoID,
+		PullRequestID:  record.PullRequestID,
+		Type:           record.Type,
+		UserID:         record.UserID,
+		CreatedAt:      record.CreatedAt,
+	}
+	if err := rs.target.createEvent(&event); err != nil {
+		return fmt.Errorf("failed to create event of %s: %w", event.PullRequestID, err)
+	}
+
+	rs.result.Events++
+	return nil
+}
+
+// repository returns the ID of a restored repository. The default
+// repository exists before the restore, so it needs no record.
+func (rs *restore) repository(name string) (string, bool) {
+	id, ok := rs.repos[name]
+	return id, ok
+}
+
+// dbRestoreTarget saves the records of a restore in the transaction.
+type dbRestoreTarget struct {
+	tx *gorm.DB
+}
+
+func (t dbRestoreTarget) createTeam(team *models.Team) error {
+	return t.tx.Select("ID", "OrganizationID", "Name", "ParentID").Create(team).Error
+}
+
+func (t dbRestoreTarget) createUser(user *models.User) error {
+	return t.tx.Create(user).Error
+}
+
+func (t dbRestoreTarget) createRepository(repo *models.Repository) error {
+	return t.tx.Create(repo).Error
+}
+
+func (t dbRestoreTarget) updateRepository(repo *models.Repository) error {
+	return t.tx.Model(repo).Select("TeamID", "ReviewersCount", "ClimbHierarchy").Updates(repo).Error
+}
+
+func (t dbRestoreTarget) createCodeOwnerRule(rule *models.CodeOwnerRule) error {
+	if err := t.tx.Omit("Owners").Create(rule).Error; err != nil {
+		return fmt.Errorf("failed to create code owner rule %s: %w", rule.Pattern, err)
+	}
+	if len(rule.Owners) == 0 {
+		return nil
+	}
+
+	for i := range rule.Owners {
+		rule.Owners[i].RuleID = rule.ID
+	}
+	if err := t.tx.Create(&rule.Owners).Error; err != nil {
+		return fmt.Errorf("failed to create code owners of %s: %w", rule.Pattern, err)
+	}
+	return nil
+}
+
+func (t dbRestoreTarget) createPullRequest(pr *models.PullRequest) error {
+	if err := t.tx.Omit(clause.Associations).Create(pr).Error; err != nil {
+		return fmt.Errorf("failed to create pull request %s: %w", pr.ID, err)
+	}
+	if len(pr.Reviewers) > 0 {
+		if err := t.tx.Create(&pr.Reviewers).Error; err != nil {
+			return fmt.Errorf("failed to create reviewers of %s: %w", pr.ID, err)
+		}
+	}
+	if len(pr.Files) > 0 {
+		if err := t.tx.CreateInBatches(&pr.Files, exportBatchSize).Error; err != nil {
+			return fmt.Errorf("failed to create files of %s: %w", pr.ID, err)
+		}
+	}
+	return nil
+}
+
+func (t dbRestoreTarget) createEvent(event *models.PullRequestEvent) error {
+	return t.tx.Create(event).Error
+}
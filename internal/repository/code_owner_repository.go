@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+type CodeOwnerRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewCodeOwnerRepository(db *gorm.DB, logger *slog.Logger) *CodeOwnerRepository {
+	return &CodeOwnerRepository{db, logger}
+}
+
+// SetRules replaces all code owner rules of the repository.
+func (r *CodeOwnerRepository) SetRules(ctx context.Context, repositoryID string, rules []models.CodeOwnerRule) error {
+	logger := r.logger.With(
+		"method", "set_code_owner_rules",
+		"repository_id", repositoryID,
+	)
+	logger.InfoContext(ctx, "setting code owner rules")
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repository_id = ?", repositoryID).Delete(&models.CodeOwnerRule{}).Error; err != nil {
+			logger.ErrorContext(ctx, "failed to delete code owner rules", "error", err)
+			return fmt.Errorf("failed to delete code owner rules: %w", err)
+		}
+
+		var ownerIDs []string
+		for _, rule := range rules {
+			ownerIDs = append(ownerIDs, rule.OwnerIDs...)
+		}
+		if err := r.checkOwners(tx, repositoryID, ownerIDs); err != nil {
+			logger.WarnContext(ctx, "owner not found", "error", err)
+			return err
+		}
+
+		for i := range rules {
+			rule := &rules[i]
+			rule.RepositoryID = repositoryID
+			rule.Position = i
+
+			if err := tx.Omit("Owners").Create(rule).Error; err != nil {
+				logger.ErrorContext(ctx, "failed to create code owner rule", "error", err, "pattern", rule.Pattern)
+				return fmt.Errorf("failed to create code owner rule %s: %w", rule.Pattern, err)
+			}
+
+			rule.Owners = make([]models.CodeOwner, 0, len(rule.OwnerIDs))
+			for _, userID := range rule.OwnerIDs {
+				rule.Owners = append(rule.Owners, models.CodeOwner{RuleID: rule.ID, UserID: userID})
+			}
+			if len(rule.Owners) == 0 {
+				continue
+			}
+
+			if err := tx.Create(&rule.Owners).Error; err != nil {
+				if errors.Is(err, gorm.ErrForeignKeyViolated) {
+					logger.WarnContext(ctx, "owner not found", "error", err, "pattern", rule.Pattern)
+					return errs.ResourceNotFound
+				}
+				logger.ErrorContext(ctx, "failed to create code owners", "error", err, "pattern", rule.Pattern)
+				return fmt.Errorf("failed to create code owners of %s: %w", rule.Pattern, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetRules returns code owner rules of the repository in their order.
+func (r *CodeOwnerRepository) GetRules(ctx context.Context, repositoryID string) ([]models.CodeOwnerRule, error) {
+	logger := r.logger.With(
+		"method", "get_code_owner_rules",
+		"repository_id", repositoryID,
+	)
+	logger.InfoContext(ctx, "getting code owner rules")
+
+	var rules []models.CodeOwnerRule
+	err := r.db.WithContext(ctx).Where("repository_id = ?", repositoryID).Preload("Owners").Order("position").Find(&rules).Error
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get code owner rules", "error", err)
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// checkOwners makes sure that all owners belong to the organization of
+// the repository.
+func (r *CodeOwnerRepository) checkOwners(tx *gorm.DB, repositoryID string, ownerIDs []string) error {
+	if len(ownerIDs) == 0 {
+		return nil
+	}
+
+	var count int64
+	err := tx.Model(&models.User{}).
+		Where("user_id IN ?", ownerIDs).
+		Where("organization_id = (?)", tx.Model(&models.Repository{}).
+			Select("organization_id").
+			Where("repository_id = ?", repositoryID),
+		).
+		Distinct("user_id").
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+
+	if count != int64(len(slices.Compact(slices.Sorted(slices.Values(ownerIDs))))) {
+		return errs.ResourceNotFound
+	}
+	return nil
+}
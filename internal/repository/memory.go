@@ -0,0 +1,550 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"reviewers/internal/models"
+	"slices"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// MemoryDB keeps the data of the memory stores. Every write works on a
+// copy of the data, which replaces the data only if the write succeeds,
+// so a write is applied completely or not at all, like a transaction.
+// Reads see the data as of their start. Writes run one at a time.
+//
+// The data follows the constraints of the database schema: writes that
+// break a unique constraint fail with gorm.ErrDuplicatedKey and writes
+// referring to missing rows fail with gorm.ErrForeignKeyViolated, so the
+// memory stores translate them the same way as the database ones.
+// Deletions cascade like in the database.
+type MemoryDB struct {
+	mu   sync.Mutex
+	data *memoryData
+}
+
+// memoryData holds the rows of every table. Rows are stored by value, so
+// a copy of the maps is a copy of the data, slices of the rows are never
+// changed in place.
+type memoryData struct {
+	organizations map[string]models.Organization
+	teams         map[string]models.Team       // without members and subteams
+	users         map[string]models.User       // without team names
+	repositories  map[string]models.Repository // without team names
+	rules         map[string][]models.CodeOwnerRule
+	pullRequests  map[prKey]models.PullRequest // with reviewers and files
+	events        []models.PullRequestEvent
+
+	nextRuleID  int64
+	nextEventID int64
+}
+
+type prKey struct {
+	repositoryID  string
+	pullRequestID string
+}
+
+// NewMemoryDB returns a database with the default organization and its
+// default repository, which the migrations create in the database.
+func NewMemoryDB() *MemoryDB {
+	data := &memoryData{
+		organizations: map[string]models.Organization{},
+		teams:         map[string]models.Team{},
+		users:         map[string]models.User{},
+		repositories:  map[string]models.Repository{},
+		rules:         map[string][]models.CodeOwnerRule{},
+		pullRequests:  map[prKey]models.PullRequest{},
+		nextRuleID:    1,
+		nextEventID:   1,
+	}
+	data.organizations[models.DefaultOrganizationID] = models.Organization{
+		ID:   models.DefaultOrganizationID,
+		Name: "default",
+	}
+	data.repositories[models.DefaultRepositoryID] = models.Repository{
+		ID:             models.DefaultRepositoryID,
+		OrganizationID: models.DefaultOrganizationID,
+		Name:           models.DefaultRepository,
+		ReviewersCount: 2,
+		ClimbHierarchy: true,
+	}
+
+	return &MemoryDB{data: data}
+}
+
+// NewMemoryStores returns the stores kept in the memory database.
+func NewMemoryStores(db *MemoryDB, logger *slog.Logger) *Stores {
+	return &Stores{
+		Organizations: NewMemoryOrganizationRepository(db, logger),
+		Users:         NewMemoryUserRepository(db, logger),
+		Teams:         NewMemoryTeamRepository(db, logger),
+		Repositories:  NewMemoryRepoRepository(db, logger),
+		CodeOwners:    NewMemoryCodeOwnerRepository(db, logger),
+		PullRequests:  NewMemoryPRRepository(db, logger),
+		Stats:         NewMemoryStatsRepository(db, logger),
+		Backups:       NewMemoryBackupRepository(db, logger),
+	}
+}
+
+// read calls fn with the current data, which fn must not change.
+func (db *MemoryDB) read(ctx context.Context, fn func(data *memoryData) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	data := db.data
+	db.mu.Unlock()
+
+	return fn(data)
+}
+
+// write calls fn with a copy of the data and keeps the copy unless fn
+// fails.
+func (db *MemoryDB) write(ctx context.Context, fn func(data *memoryData) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	data := db.data.clone()
+	if err := fn(data); err != nil {
+		return err
+	}
+	db.data = data
+	return nil
+}
+
+func (d *memoryData) clone() *memoryData {
+	return &memoryData{
+		organizations: maps.Clone(d.organizations),
+		teams:         maps.Clone(d.teams),
+		users:         maps.Clone(d.users),
+		repositories:  maps.Clone(d.repositories),
+		rules:         maps.Clone(d.rules),
+		pullRequests:  maps.Clone(d.pullRequests),
+		events:        slices.Clip(d.events),
+		nextRuleID:    d.nextRuleID,
+		nextEventID:   d.nextEventID,
+	}
+}
+
+// Organizations
+
+func (d *memoryData) insertOrganization(org models.Organization) error {
+	if _, ok := d.organizations[org.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	for _, other := range d.organizations {
+		if other.Name == org.Name {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+
+	d.organizations[org.ID] = org
+	return nil
+}
+
+// Teams
+
+func (d *memoryData) teamByName(orgID, name string) (models.Team, bool) {
+	for _, team := range d.teams {
+		if team.OrganizationID == orgID && team.Name == name {
+			return team, true
+		}
+	}
+	return models.Team{}, false
+}
+
+// teamsOf returns the teams of the organization matching the filter
+// ordered by name.
+func (d *memoryData) teamsOf(orgID string, filter func(team *models.Team) bool) []models.Team {
+	teams := []models.Team{}
+	for _, team := range d.teams {
+		if team.OrganizationID == orgID && (filter == nil || filter(&team)) {
+			teams = append(teams, team)
+		}
+	}
+	slices.SortFunc(teams, func(a, b models.Team) int { return cmp.Compare(a.Name, b.Name) })
+	return teams
+}
+
+func (d *memoryData) insertTeam(team models.Team) error {
+	if _, ok := d.teams[team.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	return d.saveTeam(team)
+}
+
+func (d *memoryData) updateTeam(team models.Team) error {
+	if _, ok := d.teams[team.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	return d.saveTeam(team)
+}
+
+func (d *memoryData) saveTeam(team models.Team) error {
+	if _, ok := d.organizations[team.OrganizationID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if other, ok := d.teamByName(team.OrganizationID, team.Name); ok && other.ID != team.ID {
+		return gorm.ErrDuplicatedKey
+	}
+	if team.ParentID != nil {
+		if _, ok := d.teams[*team.ParentID]; !ok {
+			return gorm.ErrForeignKeyViolated
+		}
+	}
+
+	team.Members, team.Subteams, team.ParentName = nil, nil, ""
+	d.teams[team.ID] = team
+	return nil
+}
+
+// subtree returns the IDs of all descendants of the team.
+func (d *memoryData) subtree(teamID string) []string {
+	var ids []string
+	queue := []string{teamID}
+	for len(queue) > 0 {
+		parentID := queue[0]
+		queue = queue[1:]
+		for _, team := range d.teams {
+			if team.ParentID != nil && *team.ParentID == parentID {
+				ids = append(ids, team.ID)
+				queue = append(queue, team.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// ancestors returns the IDs of all teams above the team, starting from
+// the closest one.
+func (d *memoryData) ancestors(teamID string) []string {
+	var ids []string
+	for team, ok := d.teams[teamID]; ok && team.ParentID != nil; team, ok = d.teams[*team.ParentID] {
+		ids = append(ids, *team.ParentID)
+	}
+	return ids
+}
+
+// deleteTeam deletes the team with its members. Its subteams and the
+// repositories it owns are left without a team.
+func (d *memoryData) deleteTeam(teamID string) {
+	for _, user := range d.users {
+		if user.TeamID == teamID {
+			d.deleteUser(user.ID)
+		}
+	}
+	for id, team := range d.teams {
+		if team.ParentID != nil && *team.ParentID == teamID {
+			team.ParentID = nil
+			d.teams[id] = team
+		}
+	}
+	for id, repo := range d.repositories {
+		if repo.TeamID != nil && *repo.TeamID == teamID {
+			repo.TeamID = nil
+			d.repositories[id] = repo
+		}
+	}
+	delete(d.teams, teamID)
+}
+
+// Users
+
+func (d *memoryData) userByName(orgID, username string) (models.User, bool) {
+	for _, user := range d.users {
+		if user.OrganizationID == orgID && user.Username == username {
+			return user, true
+		}
+	}
+	return models.User{}, false
+}
+
+// usersOf returns the users of the organization matching the filter
+// ordered by username.
+func (d *memoryData) usersOf(orgID string, filter func(user *models.User) bool) []models.User {
+	users := []models.User{}
+	for _, user := range d.users {
+		if user.OrganizationID == orgID && (filter == nil || filter(&user)) {
+			users = append(users, user)
+		}
+	}
+	slices.SortFunc(users, func(a, b models.User) int { return cmp.Compare(a.Username, b.Username) })
+	return users
+}
+
+func (d *memoryData) insertUser(user models.User) error {
+	if _, ok := d.users[user.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	return d.saveUser(user)
+}
+
+func (d *memoryData) updateUser(user models.User) error {
+	if _, ok := d.users[user.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	return d.saveUser(user)
+}
+
+func (d *memoryData) saveUser(user models.User) error {
+	if _, ok := d.organizations[user.OrganizationID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if _, ok := d.teams[user.TeamID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if other, ok := d.userByName(user.OrganizationID, user.Username); ok && other.ID != user.ID {
+		return gorm.ErrDuplicatedKey
+	}
+
+	user.TeamName = ""
+	d.users[user.ID] = user
+	return nil
+}
+
+// deleteUser deletes the user with the pull requests they authored, their
+// reviews, code ownership and history.
+func (d *memoryData) deleteUser(userID string) {
+	for key, pr := range d.pullRequests {
+		if pr.AuthorID == userID {
+			d.deletePullRequest(key)
+			continue
+		}
+		if slices.ContainsFunc(pr.Reviewers, func(r models.PullRequestReviewer) bool { return r.UserID == userID }) {
+			pr.Reviewers = without(pr.Reviewers, func(r models.PullRequestReviewer) bool { return r.UserID == userID })
+			d.pullRequests[key] = pr
+		}
+	}
+
+	for repoID, rules := range d.rules {
+		changed := make([]models.CodeOwnerRule, len(rules))
+		for i, rule := range rules {
+			rule.Owners = without(rule.Owners, func(o models.CodeOwner) bool { return o.UserID == userID })
+			changed[i] = rule
+		}
+		d.rules[repoID] = changed
+	}
+
+	events := make([]models.PullRequestEvent, len(d.events))
+	for i, event := range d.events {
+		if event.UserID != nil && *event.UserID == userID {
+			event.UserID = nil
+		}
+		events[i] = event
+	}
+	d.events = events
+	delete(d.users, userID)
+}
+
+// Repositories
+
+func (d *memoryData) repositoryByName(orgID, name string) (models.Repository, bool) {
+	for _, repo := range d.repositories {
+		if repo.OrganizationID == orgID && repo.Name == name {
+			return repo, true
+		}
+	}
+	return models.Repository{}, false
+}
+
+func (d *memoryData) insertRepository(repo models.Repository) error {
+	if _, ok := d.repositories[repo.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	return d.saveRepository(repo)
+}
+
+func (d *memoryData) updateRepository(repo models.Repository) error {
+	if _, ok := d.repositories[repo.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	return d.saveRepository(repo)
+}
+
+func (d *memoryData) saveRepository(repo models.Repository) error {
+	if _, ok := d.organizations[repo.OrganizationID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if repo.TeamID != nil {
+		if _, ok := d.teams[*repo.TeamID]; !ok {
+			return gorm.ErrForeignKeyViolated
+		}
+	}
+	if other, ok := d.repositoryByName(repo.OrganizationID, repo.Name); ok && other.ID != repo.ID {
+		return gorm.ErrDuplicatedKey
+	}
+	if repo.ReviewersCount <= 0 {
+		return fmt.Errorf("reviewers count of repository %s must be positive", repo.Name)
+	}
+
+	repo.TeamName = ""
+	d.repositories[repo.ID] = repo
+	return nil
+}
+
+// Code owner rules
+
+// insertCodeOwnerRule appends the rule with its owners to the rules of
+// its repository and sets the IDs of the rule.
+func (d *memoryData) insertCodeOwnerRule(rule *models.CodeOwnerRule) error {
+	if _, ok := d.repositories[rule.RepositoryID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	rules := d.rules[rule.RepositoryID]
+	if slices.ContainsFunc(rules, func(r models.CodeOwnerRule) bool { return r.Position == rule.Position }) {
+		return gorm.ErrDuplicatedKey
+	}
+
+	owners := make([]models.CodeOwner, 0, len(rule.Owners))
+	for _, owner := range rule.Owners {
+		if _, ok := d.users[owner.UserID]; !ok {
+			return gorm.ErrForeignKeyViolated
+		}
+		if slices.ContainsFunc(owners, func(o models.CodeOwner) bool { return o.UserID == owner.UserID }) {
+			return gorm.ErrDuplicatedKey
+		}
+		owners = append(owners, owner)
+	}
+
+	rule.ID = d.nextRuleID
+	d.nextRuleID++
+	for i := range owners {
+		owners[i].RuleID = rule.ID
+	}
+	rule.Owners = slices.Clone(owners)
+
+	stored := *rule
+	stored.Owners, stored.OwnerIDs = owners, nil
+	d.rules[rule.RepositoryID] = append(slices.Clip(rules), stored)
+	return nil
+}
+
+// Pull requests
+
+// pullRequest returns a copy of the pull request with the IDs of its
+// reviewers and its changed files.
+func (d *memoryData) pullRequest(key prKey) (models.PullRequest, bool) {
+	pr, ok := d.pullRequests[key]
+	if !ok {
+		return pr, false
+	}
+
+	pr.Reviewers = slices.Clone(pr.Reviewers)
+	pr.Files = slices.Clone(pr.Files)
+	pr.AssignedReviewers, pr.ChangedFiles = nil, nil
+	pr.AfterFind(nil)
+	return pr, true
+}
+
+// savePullRequest creates the pull request or replaces it. The reviewers
+// and files of the pull request replace the stored ones.
+func (d *memoryData) savePullRequest(pr *models.PullRequest) error {
+	if _, ok := d.organizations[pr.OrganizationID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if _, ok := d.repositories[pr.RepositoryID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if _, ok := d.users[pr.AuthorID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+
+	stored := *pr
+	stored.Author = models.User{}
+	stored.AssignedReviewers, stored.ChangedFiles = nil, nil
+	stored.Reviewers = make([]models.PullRequestReviewer, 0, len(pr.Reviewers))
+	for _, reviewer := range pr.Reviewers {
+		if _, ok := d.users[reviewer.UserID]; !ok {
+			return gorm.ErrForeignKeyViolated
+		}
+		reviewer.RepositoryID, reviewer.PullRequestID = pr.RepositoryID, pr.ID
+		if slices.Contains(stored.Reviewers, reviewer) {
+			return gorm.ErrDuplicatedKey
+		}
+		stored.Reviewers = append(stored.Reviewers, reviewer)
+	}
+	stored.Files = make([]models.PullRequestFile, 0, len(pr.Files))
+	for _, file := range pr.Files {
+		file.RepositoryID, file.PullRequestID = pr.RepositoryID, pr.ID
+		if slices.Contains(stored.Files, file) {
+			return gorm.ErrDuplicatedKey
+		}
+		stored.Files = append(stored.Files, file)
+	}
+
+	d.pullRequests[prKey{pr.RepositoryID, pr.ID}] = stored
+	return nil
+}
+
+// deletePullRequest deletes the pull request with its history.
+func (d *memoryData) deletePullRequest(key prKey) {
+	d.events = without(d.events, func(e models.PullRequestEvent) bool {
+		return e.RepositoryID == key.repositoryID && e.PullRequestID == key.pullRequestID
+	})
+	delete(d.pullRequests, key)
+}
+
+// addEvents appends the events to the history and sets their IDs.
+func (d *memoryData) addEvents(events ...models.PullRequestEvent) error {
+	for _, event := range events {
+		if _, ok := d.pullRequests[prKey{event.RepositoryID, event.PullRequestID}]; !ok {
+			return fmt.Errorf("failed to save pull request history: %w", gorm.ErrForeignKeyViolated)
+		}
+		if event.UserID != nil {
+			if _, ok := d.users[*event.UserID]; !ok {
+				return fmt.Errorf("failed to save pull request history: %w", gorm.ErrForeignKeyViolated)
+			}
+		}
+
+		event.ID = d.nextEventID
+		d.nextEventID++
+		d.events = append(d.events, event)
+	}
+	return nil
+}
+
+// without returns a copy of the items except the ones matching remove.
+func without[T any](items []T, remove func(T) bool) []T {
+	kept := make([]T, 0, len(items))
+	for _, item := range items {
+		if !remove(item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// distinct returns the items without repetitions, in the order of their
+// first occurrence.
+func distinct[T comparable](items []T) []T {
+	seen := make(map[T]bool, len(items))
+	kept := make([]T, 0, len(items))
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// paginate returns the items at the offset, at most limit of them. A
+// negative limit means no limit.
+func paginate[T any](items []T, offset, limit int) []T {
+	offset = min(max(offset, 0), len(items))
+	items = items[offset:]
+	if limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return slices.Clone(items)
+}
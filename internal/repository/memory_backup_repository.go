@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// MemoryBackupRepository exports and restores the data of the memory
+// database.
+type MemoryBackupRepository struct {
+	db     *MemoryDB
+	logger *slog.Logger
+}
+
+func NewMemoryBackupRepository(db *MemoryDB, logger *slog.Logger) *MemoryBackupRepository {
+	return &MemoryBackupRepository{db, logger}
+}
+
+// Export passes every team, user, repository, code owner rule, pull
+// request and event of the organization to write, in the same order as
+// BackupRepository.Export.
+func (r *MemoryBackupRepository) Export(ctx context.Context, orgID string, write func(models.BackupRecord) error) error {
+	logger := r.logger.With(
+		"method", "export_backup",
+		"organization_id", orgID,
+	)
+	logger.InfoContext(ctx, "exporting backup")
+
+	err := r.db.read(ctx, func(data *memoryData) error {
+		teamNames, err := writeTeams(data.teamsOf(orgID, nil), write)
+		if err != nil {
+			return err
+		}
+
+		users := data.usersOf(orgID, nil)
+		slices.SortFunc(users, func(a, b models.User) int { return cmp.Compare(a.ID, b.ID) })
+		for _, user := range users {
+			if err := write(backupUser(&user, teamNames)); err != nil {
+				return err
+			}
+		}
+
+		var repos []models.Repository
+		for _, repo := range data.repositories {
+			if repo.OrganizationID == orgID {
+				repos = append(repos, repo)
+			}
+		}
+		slices.SortFunc(repos, func(a, b models.Repository) int { return cmp.Compare(a.Name, b.Name) })
+		repoNames := make(map[string]string, len(repos))
+		for _, repo := range repos {
+			repoNames[repo.ID] = repo.Name
+			if err := write(backupRepository(&repo, teamNames)); err != nil {
+				return err
+			}
+		}
+		for _, repo := range repos {
+			for _, rule := range data.sortedRules(repo.ID) {
+				owners := make([]string, 0, len(rule.Owners))
+				for _, owner := range rule.Owners {
+					owners = append(owners, owner.UserID)
+				}
+				slices.Sort(owners)
+				if err := write(&models.BackupCodeOwnerRule{Repository: repo.Name, Pattern: rule.Pattern, Owners: owners}); err != nil {
+					return err
+				}
+			}
+		}
+
+		var keys []prKey
+		for key, pr := range data.pullRequests {
+			if pr.OrganizationID == orgID {
+				keys = append(keys, key)
+			}
+		}
+		slices.SortFunc(keys, func(a, b prKey) int {
+			return cmp.Or(cmp.Compare(a.repositoryID, b.repositoryID), cmp.Compare(a.pullRequestID, b.pullRequestID))
+		})
+		for _, key := range keys {
+			pr, _ := data.pullRequest(key)
+			if err := write(backupPullRequest(&pr, repoNames)); err != nil {
+				return err
+			}
+		}
+
+		for _, event := range data.events {
+			if event.OrganizationID != orgID {
+				continue
+			}
+			if err := write(backupEvent(&event, repoNames)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to export backup", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// Restore saves the records of the backup at once, like
+// BackupRepository.Restore.
+func (r *MemoryBackupRepository) Restore(ctx context.Context, orgID string, source BackupSource) (*models.RestoreResult, error) {
+	logger := r.logger.With(
+		"method", "restore_backup",
+		"organization_id", orgID,
+	)
+	logger.InfoContext(ctx, "restoring backup")
+
+	var rs *restore
+	err := r.db.write(ctx, func(data *memoryData) error {
+		var existing []models.Repository
+		for _, repo := range data.repositories {
+			if repo.OrganizationID != orgID {
+				continue
+			}
+			if repo.Name != models.DefaultRepository || len(data.rules[repo.ID]) > 0 {
+				return errNotEmpty
+			}
+			existing = append(existing, repo)
+		}
+		// Users belong to teams and pull requests to users, so they are
+		// checked along with the teams
+		if len(data.teamsOf(orgID, nil)) > 0 {
+			return errNotEmpty
+		}
+
+		rs = newRestore(memoryRestoreTarget{data}, orgID, source, existing)
+		return rs.run()
+	})
+	switch {
+	case errors.Is(err, errs.NotEmpty):
+		logger.WarnContext(ctx, "organization is not empty")
+		return nil, err
+	case errors.Is(err, errs.ValidationFailed):
+		logger.WarnContext(ctx, "invalid backup", "error", err, "line", source.Line())
+		return nil, err
+	case err != nil:
+		logger.ErrorContext(ctx, "failed to restore backup", "error", err, "line", source.Line())
+		return nil, fmt.Errorf("failed to restore backup: %w", err)
+	}
+
+	return &rs.result, nil
+}
+
+// sortedRules returns the code owner rules of the repository ordered by
+// position.
+func (d *memoryData) sortedRules(repositoryID string) []models.CodeOwnerRule {
+	rules := slices.Clone(d.rules[repositoryID])
+	slices.SortFunc(rules, func(a, b models.CodeOwnerRule) int { return cmp.Compare(a.Position, b.Position) })
+	return rules
+}
+
+// memoryRestoreTarget saves the records of a restore in the memory
+// database.
+type memoryRestoreTarget struct {
+	data *memoryData
+}
+
+func (t memoryRestoreTarget) createTeam(team *models.Team) error {
+	return t.data.insertTeam(*team)
+}
+
+func (t memoryRestoreTarget) createUser(user *models.User) error {
+	return t.data.insertUser(*user)
+}
+
+func (t memoryRestoreTarget) createRepository(repo *models.Repository) error {
+	return t.data.insertRepository(*repo)
+}
+
+func (t memoryRestoreTarget) updateRepository(repo *models.Repository) error {
+	stored := t.data.repositories[repo.ID]
+	stored.TeamID, stored.ReviewersCount, stored.ClimbHierarchy = repo.TeamID, repo.ReviewersCount, repo.ClimbHierarchy
+	return t.data.updateRepository(stored)
+}
+
+func (t memoryRestoreTarget) createCodeOwnerRule(rule *models.CodeOwnerRule) error {
+	if err := t.data.insertCodeOwnerRule(rule); err != nil {
+		return fmt.Errorf("failed to create code owner rule %s: %w", rule.Pattern, err)
+	}
+	return nil
+}
+
+func (t memoryRestoreTarget) createPullRequest(pr *models.PullRequest) error {
+	if _, ok := t.data.pullRequests[prKey{pr.RepositoryID, pr.ID}]; ok {
+		return fmt.Errorf("failed to create pull request %s: %w", pr.ID, gorm.ErrDuplicatedKey)
+	}
+	if err := t.data.savePullRequest(pr); err != nil {
+		return fmt.Errorf("failed to create pull request %s: %w", pr.ID, err)
+	}
+	return nil
+}
+
+func (t memoryRestoreTarget) createEvent(event *models.PullRequestEvent) error {
+	return t.data.addEvents(*event)
+}
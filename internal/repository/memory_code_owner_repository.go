@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// MemoryCodeOwnerRepository keeps code owner rules in the memory
+// database.
+type MemoryCodeOwnerRepository struct {
+	db     *MemoryDB
+	logger *slog.Logger
+}
+
+func NewMemoryCodeOwnerRepository(db *MemoryDB, logger *slog.Logger) *MemoryCodeOwnerRepository {
+	return &MemoryCodeOwnerRepository{db, logger}
+}
+
+// SetRules replaces all code owner rules of the repository.
+func (r *MemoryCodeOwnerRepository) SetRules(ctx context.Context, repositoryID string, rules []models.CodeOwnerRule) error {
+	logger := r.logger.With(
+		"method", "set_code_owner_rules",
+		"repository_id", repositoryID,
+	)
+	logger.InfoContext(ctx, "setting code owner rules")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		delete(data.rules, repositoryID)
+
+		// Owners must belong to the organization of the repository
+		repo := data.repositories[repositoryID]
+		for _, rule := range rules {
+			for _, ownerID := range rule.OwnerIDs {
+				if owner, ok := data.users[ownerID]; !ok || owner.OrganizationID != repo.OrganizationID {
+					logger.WarnContext(ctx, "owner not found", "user_id", ownerID)
+					return errs.ResourceNotFound
+				}
+			}
+		}
+
+		for i := range rules {
+			rule := &rules[i]
+			rule.RepositoryID = repositoryID
+			rule.Position = i
+
+			rule.Owners = make([]models.CodeOwner, 0, len(rule.OwnerIDs))
+			for _, userID := range rule.OwnerIDs {
+				rule.Owners = append(rule.Owners, models.CodeOwner{UserID: userID})
+			}
+
+			if err := data.insertCodeOwnerRule(rule); err != nil {
+				if errors.Is(err, gorm.ErrForeignKeyViolated) {
+					logger.WarnContext(ctx, "owner not found", "error", err, "pattern", rule.Pattern)
+					return errs.ResourceNotFound
+				}
+				logger.ErrorContext(ctx, "failed to create code owner rule", "error", err, "pattern", rule.Pattern)
+				return fmt.Errorf("failed to create code owner rule %s: %w", rule.Pattern, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetRules returns code owner rules of the repository in their order.
+func (r *MemoryCodeOwnerRepository) GetRules(ctx context.Context, repositoryID string) ([]models.CodeOwnerRule, error) {
+	logger := r.logger.With(
+		"method", "get_code_owner_rules",
+		"repository_id", repositoryID,
+	)
+	logger.InfoContext(ctx, "getting code owner rules")
+
+	var rules []models.CodeOwnerRule
+	err := r.db.read(ctx, func(data *memoryData) error {
+		for _, rule := range data.sortedRules(repositoryID) {
+			rule.Owners = slices.Clone(rule.Owners)
+			rule.AfterFind(nil)
+			rules = append(rules, rule)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
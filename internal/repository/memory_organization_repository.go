@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MemoryOrganizationRepository keeps organizations in the memory
+// database.
+type MemoryOrganizationRepository struct {
+	db     *MemoryDB
+	logger *slog.Logger
+}
+
+func NewMemoryOrganizationRepository(db *MemoryDB, logger *slog.Logger) *MemoryOrganizationRepository {
+	return &MemoryOrganizationRepository{db, logger}
+}
+
+// Create creates the organization together with its default repository.
+func (r *MemoryOrganizationRepository) Create(ctx context.Context, org *models.Organization) error {
+	logger := r.logger.With(
+		"method", "create_organization",
+		"organization_name", org.Name,
+	)
+	logger.InfoContext(ctx, "creating organization")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		org.ID = uuid.New().String()
+		if err := data.insertOrganization(*org); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				logger.WarnContext(ctx, "organization already exists", "error", err)
+				return errs.OrganizationExists
+			}
+			logger.ErrorContext(ctx, "failed to create organization", "error", err)
+			return fmt.Errorf("failed to create organization %s: %w", org.Name, err)
+		}
+
+		repo := models.Repository{
+			ID:             uuid.New().String(),
+			OrganizationID: org.ID,
+			Name:           models.DefaultRepository,
+			ReviewersCount: 2,
+			ClimbHierarchy: true,
+		}
+		if err := data.insertRepository(repo); err != nil {
+			logger.ErrorContext(ctx, "failed to create default repository", "error", err)
+			return fmt.Errorf("failed to create default repository: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *MemoryOrganizationRepository) Get(ctx context.Context, orgID string) (*models.Organization, error) {
+	logger := r.logger.With(
+		"method", "get_organization",
+		"organization_id", orgID,
+	)
+	logger.InfoContext(ctx, "getting organization")
+
+	var org models.Organization
+	err := r.db.read(ctx, func(data *memoryData) error {
+		var ok bool
+		if org, ok = data.organizations[orgID]; !ok {
+			logger.WarnContext(ctx, "organization not found")
+			return errs.ResourceNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &org, nil
+}
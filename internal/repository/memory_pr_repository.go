@@ -0,0 +1,495 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MemoryPRRepository keeps pull requests and their history in the memory
+// database.
+type MemoryPRRepository struct {
+	db     *MemoryDB
+	logger *slog.Logger
+}
+
+func NewMemoryPRRepository(db *MemoryDB, logger *slog.Logger) *MemoryPRRepository {
+	return &MemoryPRRepository{db, logger}
+}
+
+func (r *MemoryPRRepository) Create(ctx context.Context, pr *models.PullRequest) error {
+	logger := r.logger.With(
+		"method", "create_pull_request",
+		"organization_id", pr.OrganizationID,
+		"repository_id", pr.RepositoryID,
+		"pull_request_id", pr.ID,
+		"pull_request_name", pr.Name,
+	)
+	logger.InfoContext(ctx, "creating pull request")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		if _, ok := data.pullRequests[prKey{pr.RepositoryID, pr.ID}]; ok {
+			logger.WarnContext(ctx, "pull request already exists")
+			return errs.PullRequestExists
+		}
+		if err := data.savePullRequest(pr); err != nil {
+			if errors.Is(err, gorm.ErrForeignKeyViolated) {
+				logger.WarnContext(ctx, "author not found", "error", err)
+				return errs.ResourceNotFound
+			}
+			logger.ErrorContext(ctx, "failed to create pull request", "error", err)
+			return err
+		}
+
+		events := make([]models.PullRequestEvent, 0, len(pr.Reviewers))
+		for _, reviewer := range pr.Reviewers {
+			events = append(events, models.NewPullRequestEvent(pr, models.EventAssigned, reviewer.UserID))
+		}
+		if err := data.addEvents(events...); err != nil {
+			logger.ErrorContext(ctx, "failed to save history", "error", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Save updates the pull request and its reviewers and appends the events
+// to its history.
+func (r *MemoryPRRepository) Save(ctx context.Context, pr *models.PullRequest, events ...models.PullRequestEvent) error {
+	logger := r.logger.With(
+		"method", "update_pull_request",
+		"organization_id", pr.OrganizationID,
+		"repository_id", pr.RepositoryID,
+		"pull_request_id", pr.ID,
+		"pull_request_name", pr.Name,
+	)
+	logger.InfoContext(ctx, "updating pull request")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		if err := data.updatePullRequest(pr, events...); err != nil {
+			logger.ErrorContext(ctx, "failed to update pull request", "error", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// updatePullRequest saves the pull request with its reviewers but without
+// its changed files, which never change, and adds the events to its
+// history.
+func (d *memoryData) updatePullRequest(pr *models.PullRequest, events ...models.PullRequestEvent) error {
+	saved := *pr
+	saved.Files = d.pullRequests[prKey{pr.RepositoryID, pr.ID}].Files
+	if err := d.savePullRequest(&saved); err != nil {
+		return fmt.Errorf("failed to update pull request %s: %w", pr.Name, err)
+	}
+	return d.addEvents(events...)
+}
+
+// saveReassignments replaces the reviewers of the reassigned pull requests
+// and adds the events to their history. A pull request merged after the
+// reassignments were planned keeps its reviewers.
+func (d *memoryData) saveReassignments(reassignments []models.Reassignment) error {
+	for _, reassignment := range reassignments {
+		key := prKey{reassignment.PullRequest.RepositoryID, reassignment.PullRequest.ID}
+		pr, ok := d.pullRequests[key]
+		if !ok || pr.Status != models.StatusOpen {
+			continue
+		}
+
+		pr.Reviewers = reassignment.PullRequest.Reviewers
+		if err := d.updatePullRequest(&pr, reassignment.Events...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openReviews returns the IDs of the open pull requests the user reviews.
+func (d *memoryData) openReviews(userID string) []string {
+	var ids []string
+	for _, pr := range d.pullRequests {
+		if pr.Status == models.StatusOpen && slices.ContainsFunc(pr.Reviewers, func(r models.PullRequestReviewer) bool {
+			return r.UserID == userID
+		}) {
+			ids = append(ids, pr.ID)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// pullRequestsOf returns the IDs of the pull requests authored or
+// reviewed by the user.
+func (d *memoryData) pullRequestsOf(userID string) []string {
+	var ids []string
+	for _, pr := range d.pullRequests {
+		if pr.AuthorID == userID || slices.ContainsFunc(pr.Reviewers, func(r models.PullRequestReviewer) bool {
+			return r.UserID == userID
+		}) {
+			ids = append(ids, pr.ID)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func (r *MemoryPRRepository) Get(ctx context.Context, orgID, repositoryID, pullRequestID string) (*models.PullRequest, error) {
+	logger := r.logger.With(
+		"method", "get_pull_request",
+		"organization_id", orgID,
+		"repository_id", repositoryID,
+		"pull_request_id", pullRequestID,
+	)
+	logger.InfoContext(ctx, "getting pull request")
+
+	var pr models.PullRequest
+	err := r.db.read(ctx, func(data *memoryData) error {
+		var ok bool
+		if pr, ok = data.pullRequest(prKey{repositoryID, pullRequestID}); !ok || pr.OrganizationID != orgID {
+			logger.WarnContext(ctx, "pull request not found")
+			return errs.ResourceNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pr, nil
+}
+
+// GetDetails returns the pull request with its changed files and the
+// details of its reviewers.
+func (r *MemoryPRRepository) GetDetails(ctx context.Context, orgID, repositoryID, pullRequestID string) (*models.PullRequestDetails, error) {
+	logger := r.logger.With(
+		"method", "get_pull_request_details",
+		"organization_id", orgID,
+		"repository_id", repositoryID,
+		"pull_request_id", pullRequestID,
+	)
+	logger.InfoContext(ctx, "getting pull request details")
+
+	var details models.PullRequestDetails
+	err := r.db.read(ctx, func(data *memoryData) error {
+		pr, ok := data.pullRequest(prKey{repositoryID, pullRequestID})
+		if !ok || pr.OrganizationID != orgID {
+			logger.WarnContext(ctx, "pull request not found")
+			return errs.ResourceNotFound
+		}
+		details.PullRequest = pr
+		details.PullRequest.Reviewers = nil
+
+		details.Reviewers = []models.ReviewerDetails{}
+		for _, reviewer := range pr.Reviewers {
+			user := data.users[reviewer.UserID]
+			details.Reviewers = append(details.Reviewers, models.ReviewerDetails{
+				UserID:     user.ID,
+				Username:   user.Username,
+				TeamName:   data.teams[user.TeamID].Name,
+				IsActive:   user.IsActive,
+				ApprovedAt: data.approvedAt(pr.RepositoryID, pr.ID, user.ID, time.Time{}),
+			})
+		}
+		slices.SortFunc(details.Reviewers, func(a, b models.ReviewerDetails) int {
+			return cmp.Compare(a.Username, b.Username)
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	details.AssignedReviewers = make([]string, 0, len(details.Reviewers))
+	for _, reviewer := range details.Reviewers {
+		details.AssignedReviewers = append(details.AssignedReviewers, reviewer.UserID)
+	}
+
+	return &details, nil
+}
+
+func (r *MemoryPRRepository) Merge(ctx context.Context, orgID, repositoryID, pullRequestID string) error {
+	logger := r.logger.With(
+		"method", "merge_pull_request",
+		"organization_id", orgID,
+		"repository_id", repositoryID,
+		"pull_request_id", pullRequestID,
+	)
+	logger.InfoContext(ctx, "merging pull request")
+
+	now := time.Now()
+	return r.db.write(ctx, func(data *memoryData) error {
+		key := prKey{repositoryID, pullRequestID}
+		pr, ok := data.pullRequests[key]
+		if !ok || pr.OrganizationID != orgID {
+			logger.WarnContext(ctx, "pull request not found")
+			return errs.ResourceNotFound
+		}
+		pr.Status = models.StatusMerged
+		pr.MergedAt = &now
+		data.pullRequests[key] = pr
+
+		event := models.NewPullRequestEvent(&pr, models.EventMerged, "")
+		event.CreatedAt = now
+		if err := data.addEvents(event); err != nil {
+			logger.ErrorContext(ctx, "failed to save history", "error", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Approve records the approval of the pull request by the reviewer.
+// Repeated approvals by the same reviewer are ignored.
+func (r *MemoryPRRepository) Approve(ctx context.Context, pr *models.PullRequest, reviewerID string) error {
+	logger := r.logger.With(
+		"method", "approve_pull_request",
+		"organization_id", pr.OrganizationID,
+		"repository_id", pr.RepositoryID,
+		"pull_request_id", pr.ID,
+		"reviewer_id", reviewerID,
+	)
+	logger.InfoContext(ctx, "approving pull request")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		if data.approvedAt(pr.RepositoryID, pr.ID, reviewerID, time.Time{}) != nil {
+			return nil
+		}
+
+		if err := data.addEvents(models.NewPullRequestEvent(pr, models.EventApproved, reviewerID)); err != nil {
+			logger.ErrorContext(ctx, "failed to save history", "error", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// GetByReviewerIDs returns the pull requests any of the given users
+// reviews, with their reviewers and changed files.
+func (r *MemoryPRRepository) GetByReviewerIDs(ctx context.Context, orgID string, userIDs []string) ([]models.PullRequest, error) {
+	logger := r.logger.With(
+		"method", "get_pull_requests_by_reviewer_ids",
+		"organization_id", orgID,
+		"user_ids", userIDs,
+	)
+	logger.InfoContext(ctx, "getting pull requests")
+
+	var prs []models.PullRequest
+	err := r.db.read(ctx, func(data *memoryData) error {
+		for key, stored := range data.pullRequests {
+			reviewed := slices.ContainsFunc(stored.Reviewers, func(reviewer models.PullRequestReviewer) bool {
+				return slices.Contains(userIDs, reviewer.UserID)
+			})
+			if stored.OrganizationID != orgID || !reviewed {
+				continue
+			}
+			pr, _ := data.pullRequest(key)
+			prs = append(prs, pr)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(prs, func(a, b models.PullRequest) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return prs, nil
+}
+
+// List returns a page of the pull requests of the organization.
+func (r *MemoryPRRepository) List(ctx context.Context, orgID string, filter models.PullRequestFilter) (*models.PullRequestPage, error) {
+	logger := r.logger.With(
+		"method", "list_pull_requests",
+		"organization_id", orgID,
+		"status", filter.Status,
+		"sort", filter.Sort,
+	)
+	logger.InfoContext(ctx, "listing pull requests")
+
+	var page *models.PullRequestPage
+	err := r.db.read(ctx, func(data *memoryData) (err error) {
+		page, err = data.listPullRequests(orgID, filter)
+		return err
+	})
+	if errors.Is(err, errs.ValidationFailed) {
+		logger.WarnContext(ctx, "invalid listing", "error", err)
+	} else if err != nil {
+		logger.ErrorContext(ctx, "failed to list pull requests", "error", err)
+	}
+
+	return page, err
+}
+
+// approvedAt returns the time of the first approval of the pull request
+// by the user made at or after the given time, if any.
+func (d *memoryData) approvedAt(repositoryID, pullRequestID, userID string, after time.Time) *time.Time {
+	var approvedAt *time.Time
+	for _, event := range d.events {
+		if event.RepositoryID != repositoryID || event.PullRequestID != pullRequestID ||
+			event.Type != models.EventApproved || event.UserID == nil || *event.UserID != userID {
+			continue
+		}
+		if event.CreatedAt.Before(after) {
+			continue
+		}
+		if approvedAt == nil || event.CreatedAt.Before(*approvedAt) {
+			approvedAt = &event.CreatedAt
+		}
+	}
+	return approvedAt
+}
+
+// teamTree returns the IDs of the team with the given name and of its
+// subteams.
+func (d *memoryData) teamTree(orgID, name string) []string {
+	team, ok := d.teamByName(orgID, name)
+	if !ok {
+		return nil
+	}
+	return append([]string{team.ID}, d.subtree(team.ID)...)
+}
+
+// listPullRequests returns a page of pull requests of the organization
+// matching the filter, in the order of the database listing.
+func (d *memoryData) listPullRequests(orgID string, filter models.PullRequestFilter) (*models.PullRequestPage, error) {
+	sort := filter.Sort
+	if sort == "" {
+		sort = "-" + models.SortCreatedAt
+	}
+	field, descending := strings.CutPrefix(sort, "-")
+	if _, ok := sortColumns[field]; !ok {
+		return nil, errs.NewValidationError(errs.FieldError{Field: "sort", Message: "is not supported"})
+	}
+
+	limit := filter.Limit
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+
+	var teamIDs []string
+	if filter.TeamName != "" {
+		teamIDs = d.teamTree(orgID, filter.TeamName)
+	}
+	now := time.Now()
+	matches := func(pr *models.PullRequest) bool {
+		switch {
+		case pr.OrganizationID != orgID:
+			return false
+		case filter.AuthorID != "" && pr.AuthorID != filter.AuthorID:
+			return false
+		case filter.TeamName != "" && !slices.Contains(teamIDs, d.users[pr.AuthorID].TeamID):
+			return false
+		case filter.ReviewerID != "" && !slices.ContainsFunc(pr.Reviewers, func(reviewer models.PullRequestReviewer) bool {
+			return reviewer.UserID == filter.ReviewerID
+		}):
+			return false
+		case filter.Status != "" && pr.Status != filter.Status:
+			return false
+		case filter.Name != "" && !strings.Contains(strings.ToLower(pr.Name), strings.ToLower(filter.Name)):
+			return false
+		case !filter.From.IsZero() && pr.CreatedAt.Before(filter.From):
+			return false
+		case !filter.To.IsZero() && !pr.CreatedAt.Before(filter.To):
+			return false
+		case filter.MinAge > 0 && pr.CreatedAt.After(now.Add(-filter.MinAge)):
+			return false
+		case filter.MaxAge > 0 && pr.CreatedAt.Before(now.Add(-filter.MaxAge)):
+			return false
+		}
+		return true
+	}
+
+	var position func(pr *models.PullRequestShort) int
+	if filter.Cursor != "" {
+		cursor, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != sort {
+			return nil, errs.NewValidationError(errs.FieldError{Field: "cursor", Message: "belongs to another sort order"})
+		}
+
+		last := models.PullRequestShort{Name: cursor.Value, RepositoryID: cursor.RepositoryID, ID: cursor.PullRequestID}
+		if field == models.SortCreatedAt {
+			if last.CreatedAt, err = time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+				return nil, invalidCursor
+			}
+		}
+		position = func(pr *models.PullRequestShort) int { return comparePullRequests(field, pr, &last) }
+	}
+
+	var matching []models.PullRequestShort
+	for _, pr := range d.pullRequests {
+		if !matches(&pr) {
+			continue
+		}
+		matching = append(matching, models.PullRequestShort{
+			RepositoryID: pr.RepositoryID,
+			Repository:   d.repositories[pr.RepositoryID].Name,
+			ID:           pr.ID,
+			Name:         pr.Name,
+			AuthorID:     pr.AuthorID,
+			Status:       pr.Status,
+			CreatedAt:    pr.CreatedAt,
+		})
+	}
+	slices.SortFunc(matching, func(a, b models.PullRequestShort) int {
+		if descending {
+			return comparePullRequests(field, &b, &a)
+		}
+		return comparePullRequests(field, &a, &b)
+	})
+
+	page := models.PullRequestPage{PullRequests: []models.PullRequestShort{}}
+	for _, pr := range matching {
+		if position != nil {
+			if p := position(&pr); descending && p >= 0 || !descending && p <= 0 {
+				continue
+			}
+		}
+		page.PullRequests = append(page.PullRequests, pr)
+		if limit > 0 && len(page.PullRequests) > limit {
+			break
+		}
+	}
+
+	if limit > 0 && len(page.PullRequests) > limit {
+		page.PullRequests = page.PullRequests[:limit]
+		last := page.PullRequests[limit-1]
+		cursor := listCursor{Sort: sort, Value: last.Name, RepositoryID: last.RepositoryID, PullRequestID: last.ID}
+		if field == models.SortCreatedAt {
+			cursor.Value = last.CreatedAt.Format(time.RFC3339Nano)
+		}
+		page.NextCursor = encodeCursor(cursor)
+	}
+
+	if filter.IncludeTotal {
+		total := int64(len(matching))
+		page.Total = &total
+	}
+
+	return &page, nil
+}
+
+// comparePullRequests compares pull requests by the sort field, breaking
+// ties by the primary key.
+func comparePullRequests(field string, a, b *models.PullRequestShort) int {
+	var c int
+	if field == models.SortCreatedAt {
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	} else {
+		c = cmp.Compare(a.Name, b.Name)
+	}
+	return cmp.Or(c, cmp.Compare(a.RepositoryID, b.RepositoryID), cmp.Compare(a.ID, b.ID))
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MemoryRepoRepository keeps repositories in the memory database.
+type MemoryRepoRepository struct {
+	db     *MemoryDB
+	logger *slog.Logger
+}
+
+func NewMemoryRepoRepository(db *MemoryDB, logger *slog.Logger) *MemoryRepoRepository {
+	return &MemoryRepoRepository{db, logger}
+}
+
+func (r *MemoryRepoRepository) Create(ctx context.Context, repo *models.Repository) error {
+	logger := r.logger.With(
+		"method", "create_repository",
+		"organization_id", repo.OrganizationID,
+		"repository", repo.Name,
+	)
+	logger.InfoContext(ctx, "creating repository")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		if err := resolveRepositoryTeam(data, repo); err != nil {
+			logger.WarnContext(ctx, "owning team not found", "error", err, "team_name", repo.TeamName)
+			return err
+		}
+
+		repo.ID = uuid.New().String()
+		if err := data.insertRepository(*repo); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				logger.WarnContext(ctx, "repository already exists", "error", err)
+				return errs.RepositoryExists
+			}
+			logger.ErrorContext(ctx, "failed to create repository", "error", err)
+			return fmt.Errorf("failed to create repository %s: %w", repo.Name, err)
+		}
+
+		return nil
+	})
+}
+
+func (r *MemoryRepoRepository) Update(ctx context.Context, repo *models.Repository) error {
+	logger := r.logger.With(
+		"method", "update_repository",
+		"organization_id", repo.OrganizationID,
+		"repository", repo.Name,
+	)
+	logger.InfoContext(ctx, "updating repository")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		if err := resolveRepositoryTeam(data, repo); err != nil {
+			logger.WarnContext(ctx, "owning team not found", "error", err, "team_name", repo.TeamName)
+			return err
+		}
+
+		stored, ok := data.repositories[repo.ID]
+		if !ok || stored.OrganizationID != repo.OrganizationID {
+			return nil
+		}
+		stored.TeamID = repo.TeamID
+		stored.ReviewersCount = repo.ReviewersCount
+		stored.ClimbHierarchy = repo.ClimbHierarchy
+		if err := data.updateRepository(stored); err != nil {
+			logger.ErrorContext(ctx, "failed to update repository", "error", err)
+			return fmt.Errorf("failed to update repository %s: %w", repo.Name, err)
+		}
+
+		return nil
+	})
+}
+
+func (r *MemoryRepoRepository) GetByName(ctx context.Context, orgID, name string) (*models.Repository, error) {
+	logger := r.logger.With(
+		"method", "get_repository",
+		"organization_id", orgID,
+		"repository", name,
+	)
+	logger.InfoContext(ctx, "getting repository")
+
+	var repo models.Repository
+	err := r.db.read(ctx, func(data *memoryData) error {
+		var ok bool
+		if repo, ok = data.repositoryByName(orgID, name); !ok {
+			logger.WarnContext(ctx, "repository not found")
+			return errs.ResourceNotFound
+		}
+
+		if repo.TeamID != nil {
+			repo.TeamName = data.teams[*repo.TeamID].Name
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &repo, nil
+}
+
+// GetByIDs returns the repositories with the given IDs. Their owning
+// teams are not resolved.
+func (r *MemoryRepoRepository) GetByIDs(ctx context.Context, orgID string, repositoryIDs []string) ([]models.Repository, error) {
+	logger := r.logger.With(
+		"method", "get_repositories_by_ids",
+		"organization_id", orgID,
+		"repository_ids", repositoryIDs,
+	)
+	logger.InfoContext(ctx, "getting repositories")
+
+	var repos []models.Repository
+	err := r.db.read(ctx, func(data *memoryData) error {
+		for _, id := range distinct(repositoryIDs) {
+			if repo, ok := data.repositories[id]; ok && repo.OrganizationID == orgID {
+				repos = append(repos, repo)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repos, nil
+}
+
+// resolveRepositoryTeam sets the ID of the owning team of the repository
+// from its name, if any.
+func resolveRepositoryTeam(data *memoryData, repo *models.Repository) error {
+	if repo.TeamName == "" {
+		return nil
+	}
+
+	team, ok := data.teamByName(repo.OrganizationID, repo.TeamName)
+	if !ok {
+		return errs.ResourceNotFound
+	}
+
+	repo.TeamID = &team.ID
+	return nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"reviewers/internal/models"
+	"slices"
+	"time"
+)
+
+// MemoryStatsRepository computes review statistics from the memory
+// database.
+type MemoryStatsRepository struct {
+	db     *MemoryDB
+	logger *slog.Logger
+}
+
+func NewMemoryStatsRepository(db *MemoryDB, logger *slog.Logger) *MemoryStatsRepository {
+	return &MemoryStatsRepository{db, logger}
+}
+
+// GetReviewerStats returns review counters of every user of the
+// organization. The team filter includes subteams of the team, the time
+// range applies to the history of pull requests.
+func (r *MemoryStatsRepository) GetReviewerStats(ctx context.Context, orgID string, filter models.StatsFilter) ([]models.ReviewerStats, error) {
+	logger := r.logger.With(
+		"method", "get_reviewer_stats",
+		"organization_id", orgID,
+		"team_name", filter.TeamName,
+		"from", filter.From,
+		"to", filter.To,
+	)
+	logger.InfoContext(ctx, "getting reviewer stats")
+
+	stats := []models.ReviewerStats{}
+	err := r.db.read(ctx, func(data *memoryData) error {
+		var tree []string
+		if filter.TeamName != "" {
+			tree = data.teamTree(orgID, filter.TeamName)
+		} else {
+			for _, team := range data.teamsOf(orgID, nil) {
+				tree = append(tree, team.ID)
+			}
+		}
+
+		inRange := func(at time.Time) bool {
+			return (filter.From.IsZero() || !at.Before(filter.From)) && (filter.To.IsZero() || at.Before(filter.To))
+		}
+
+		for _, user := range data.usersOf(orgID, func(user *models.User) bool { return slices.Contains(tree, user.TeamID) }) {
+			row := models.ReviewerStats{
+				UserID:   user.ID,
+				Username: user.Username,
+				TeamName: data.teams[user.TeamID].Name,
+			}
+
+			var firstReviews []float64
+			for _, event := range data.events {
+				if event.OrganizationID != orgID || event.UserID == nil || *event.UserID != user.ID || !inRange(event.CreatedAt) {
+					continue
+				}
+				switch event.Type {
+				case models.EventAssigned:
+					row.Assigned++
+					if approvedAt := data.approvedAt(event.RepositoryID, event.PullRequestID, user.ID, event.CreatedAt); approvedAt != nil {
+						firstReviews = append(firstReviews, approvedAt.Sub(event.CreatedAt).Seconds())
+					}
+				case models.EventUnassigned:
+					row.ReassignedAway++
+				case models.EventApproved:
+					row.Approved++
+				}
+			}
+			row.MedianTimeToFirstReview = median(firstReviews)
+
+			for _, pr := range data.pullRequests {
+				if pr.OrganizationID == orgID && pr.Status == models.StatusOpen &&
+					slices.ContainsFunc(pr.Reviewers, func(reviewer models.PullRequestReviewer) bool { return reviewer.UserID == user.ID }) {
+					row.OpenReviews++
+				}
+			}
+
+			stats = append(stats, row)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(stats, func(a, b models.ReviewerStats) int { return cmp.Compare(a.TeamName, b.TeamName) })
+	return stats, nil
+}
+
+// GetMergeStats returns how fast pull requests authored by members of the
+// team and its subteams get merged. The time range applies to merge time.
+func (r *MemoryStatsRepository) GetMergeStats(ctx context.Context, orgID string, filter models.StatsFilter) (*models.MergeStats, error) {
+	logger := r.logger.With(
+		"method", "get_merge_stats",
+		"organization_id", orgID,
+		"team_name", filter.TeamName,
+		"from", filter.From,
+		"to", filter.To,
+	)
+	logger.InfoContext(ctx, "getting merge stats")
+
+	var stats models.MergeStats
+	err := r.db.read(ctx, func(data *memoryData) error {
+		tree := data.teamTree(orgID, filter.TeamName)
+
+		var total float64
+		for _, pr := range data.pullRequests {
+			if pr.OrganizationID != orgID || pr.MergedAt == nil || !slices.Contains(tree, data.users[pr.AuthorID].TeamID) {
+				continue
+			}
+			if !filter.From.IsZero() && pr.MergedAt.Before(filter.From) || !filter.To.IsZero() && !pr.MergedAt.Before(filter.To) {
+				continue
+			}
+			stats.Merged++
+			total += pr.MergedAt.Sub(pr.CreatedAt).Seconds()
+		}
+
+		if stats.Merged > 0 {
+			mean := total / float64(stats.Merged)
+			stats.MeanTimeToMerge = &mean
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// median returns the median of the values interpolated like
+// percentile_cont(0.5), or nil if there are no values.
+func median(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+
+	slices.Sort(values)
+	m := values[len(values)/2]
+	if len(values)%2 == 0 {
+		m = (values[len(values)/2-1] + m) / 2
+	}
+	return &m
+}
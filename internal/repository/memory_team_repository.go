@@ -0,0 +1,687 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reviewers/internal/metrics"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MemoryTeamRepository keeps teams in the memory database.
+type MemoryTeamRepository struct {
+	db     *MemoryDB
+	logger *slog.Logger
+}
+
+func NewMemoryTeamRepository(db *MemoryDB, logger *slog.Logger) *MemoryTeamRepository {
+	return &MemoryTeamRepository{db, logger}
+}
+
+func (r *MemoryTeamRepository) GetTeam(ctx context.Context, orgID, name string, withSubteams bool) (*models.Team, error) {
+	logger := r.logger.With(
+		"method", "get_team",
+		"organization_id", orgID,
+		"team_name", name,
+	)
+	logger.InfoContext(ctx, "getting team")
+
+	var team models.Team
+	err := r.db.read(ctx, func(data *memoryData) error {
+		var ok bool
+		if team, ok = data.teamByName(orgID, name); !ok {
+			logger.WarnContext(ctx, "team not found")
+			return errs.ResourceNotFound
+		}
+
+		team.Members = data.members(team.ID)
+		if team.ParentID != nil {
+			team.ParentName = data.teams[*team.ParentID].Name
+		}
+		if withSubteams {
+			data.attachSubteams(&team)
+		}
+		return nil
+	})
+
+	return &team, err
+}
+
+// members returns the members of the team ordered by username.
+func (d *memoryData) members(teamID string) []models.User {
+	team := d.teams[teamID]
+	return d.usersOf(team.OrganizationID, func(user *models.User) bool { return user.TeamID == teamID })
+}
+
+// attachSubteams fills Subteams of the team with all its descendants
+// (including their members) down to the leaves.
+func (d *memoryData) attachSubteams(team *models.Team) {
+	subteams := d.teamsOf(team.OrganizationID, func(t *models.Team) bool {
+		return t.ParentID != nil && *t.ParentID == team.ID
+	})
+	if len(subteams) == 0 {
+		return
+	}
+
+	team.Subteams = subteams
+	for i := range team.Subteams {
+		team.Subteams[i].ParentName = team.Name
+		team.Subteams[i].Members = d.members(team.Subteams[i].ID)
+		d.attachSubteams(&team.Subteams[i])
+	}
+}
+
+func (r *MemoryTeamRepository) CreateTeam(ctx context.Context, orgID string, team *models.Team) error {
+	logger := r.logger.With(
+		"method", "create_team",
+		"organization_id", orgID,
+		"team_name", team.Name,
+	)
+	logger.InfoContext(ctx, "creating team")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		// Resolve parent team
+		if team.ParentName != "" {
+			parent, ok := data.teamByName(orgID, team.ParentName)
+			if !ok {
+				logger.WarnContext(ctx, "parent team not found", "parent_team_name", team.ParentName)
+				return errs.ResourceNotFound
+			}
+			team.ParentID = &parent.ID
+		}
+
+		// Create team
+		team.ID = uuid.New().String()
+		team.OrganizationID = orgID
+		if err := data.insertTeam(*team); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				logger.WarnContext(ctx, "team already exists", "error", err)
+				return errs.TeamExists
+			}
+			logger.ErrorContext(ctx, "failed to create team", "error", err)
+			return fmt.Errorf("failed to create team %s: %w", team.Name, err)
+		}
+
+		// Create users. Existing users get the non-zero fields of their
+		// members.
+		for i := range team.Members {
+			user := &team.Members[i]
+			user.TeamID = team.ID
+
+			if user.ID != "" {
+				stored, ok := data.users[user.ID]
+				if !ok || stored.OrganizationID != orgID {
+					logger.WarnContext(ctx, "user not found", "user_id", user.ID)
+					return errs.ResourceNotFound
+				}
+				stored.Username = cmp.Or(user.Username, stored.Username)
+				stored.Email = cmp.Or(user.Email, stored.Email)
+				stored.IsActive = user.IsActive || stored.IsActive
+				stored.TeamID = team.ID
+				if err := data.updateUser(stored); err != nil {
+					if errors.Is(err, gorm.ErrDuplicatedKey) {
+						logger.WarnContext(ctx, "username is taken", "error", err, "user_id", user.ID)
+						return errs.UserExists
+					}
+					logger.ErrorContext(ctx, "failed to update user", "error", err, "user_id", user.ID)
+					return fmt.Errorf("failed to update user %s: %w", user.ID, err)
+				}
+				continue
+			}
+
+			user.ID = uuid.New().String()
+			user.OrganizationID = orgID
+			if err := data.insertUser(*user); err != nil {
+				if errors.Is(err, gorm.ErrDuplicatedKey) {
+					logger.WarnContext(ctx, "users already exist", "error", err)
+					return errs.UserExists
+				}
+				logger.ErrorContext(ctx, "failed to create users", "error", err)
+				return fmt.Errorf("failed to create users: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *MemoryTeamRepository) GetReviewerIdsFromUserTeam(ctx context.Context, orgID, userID string, excludedUsers ...string) ([]*models.User, error) {
+	logger := r.logger.With(
+		"method", "get_reviewers_from_same_team",
+		"organization_id", orgID,
+		"user_id", userID,
+	)
+	logger.InfoContext(ctx, "getting reviewers from the same team")
+
+	var reviewers []*models.User
+	err := r.db.read(ctx, func(data *memoryData) error {
+		user, ok := data.users[userID]
+		if !ok || user.OrganizationID != orgID {
+			return nil
+		}
+
+		for _, member := range data.members(user.TeamID) {
+			if member.IsActive && member.ID != userID && !slices.Contains(excludedUsers, member.ID) {
+				reviewers = append(reviewers, &member)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.CandidatePoolSize.Observe(float64(len(reviewers)))
+	return reviewers, nil
+}
+
+// GetAncestorTeamIDs returns IDs of all teams above the team of the user,
+// starting from the closest one.
+func (r *MemoryTeamRepository) GetAncestorTeamIDs(ctx context.Context, orgID, userID string) ([]string, error) {
+	logger := r.logger.With(
+		"method", "get_ancestor_teams",
+		"organization_id", orgID,
+		"user_id", userID,
+	)
+	logger.InfoContext(ctx, "getting ancestor teams")
+
+	var ids []string
+	err := r.db.read(ctx, func(data *memoryData) error {
+		user, ok := data.users[userID]
+		if !ok || user.OrganizationID != orgID {
+			logger.WarnContext(ctx, "user not found")
+			return errs.ResourceNotFound
+		}
+
+		ids = data.ancestors(user.TeamID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// GetReviewersFromTeamTree returns active users of the team and all its
+// subteams except the excluded ones.
+func (r *MemoryTeamRepository) GetReviewersFromTeamTree(ctx context.Context, orgID, teamID string, excludedUsers ...string) ([]*models.User, error) {
+	logger := r.logger.With(
+		"method", "get_reviewers_from_team_tree",
+		"organization_id", orgID,
+		"team_id", teamID,
+	)
+	logger.InfoContext(ctx, "getting reviewers from team tree")
+
+	var reviewers []*models.User
+	err := r.db.read(ctx, func(data *memoryData) error {
+		tree := append([]string{teamID}, data.subtree(teamID)...)
+		for _, user := range data.usersOf(orgID, nil) {
+			if user.IsActive && slices.Contains(tree, user.TeamID) && !slices.Contains(excludedUsers, user.ID) {
+				reviewers = append(reviewers, &user)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reviewers, nil
+}
+
+func (r *MemoryTeamRepository) DeactivateTeam(ctx context.Context, orgID, teamID string) error {
+	logger := r.logger.With(
+		"method", "deactivate_team",
+		"organization_id", orgID,
+		"team_id", teamID,
+	)
+	logger.InfoContext(ctx, "deactivating team")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		for id, user := range data.users {
+			if user.OrganizationID == orgID && user.TeamID == teamID {
+				user.IsActive = false
+				data.users[id] = user
+			}
+		}
+		return nil
+	})
+}
+
+func (r *MemoryTeamRepository) SetParent(ctx context.Context, orgID, teamName, parentName string) error {
+	logger := r.logger.With(
+		"method", "set_parent_team",
+		"organization_id", orgID,
+		"team_name", teamName,
+		"parent_team_name", parentName,
+	)
+	logger.InfoContext(ctx, "setting parent team")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		team, ok := data.teamByName(orgID, teamName)
+		if !ok {
+			logger.WarnContext(ctx, "team not found")
+			return errs.ResourceNotFound
+		}
+
+		team.ParentID = nil
+		if parentName != "" {
+			parent, ok := data.teamByName(orgID, parentName)
+			if !ok {
+				logger.WarnContext(ctx, "parent team not found")
+				return errs.ResourceNotFound
+			}
+			if parent.ID == team.ID || slices.Contains(data.subtree(team.ID), parent.ID) {
+				logger.WarnContext(ctx, "parent team is a subteam of the team")
+				return errs.InvalidHierarchy
+			}
+			team.ParentID = &parent.ID
+		}
+
+		if err := data.updateTeam(team); err != nil {
+			logger.ErrorContext(ctx, "failed to set parent team", "error", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// GetStats returns review counters of the team and all its subteams.
+// Counters of every team are rolled up from its subteams.
+func (r *MemoryTeamRepository) GetStats(ctx context.Context, team *models.Team) (*models.TeamStats, error) {
+	logger := r.logger.With(
+		"method", "get_team_stats",
+		"organization_id", team.OrganizationID,
+		"team_name", team.Name,
+	)
+	logger.InfoContext(ctx, "getting team stats")
+
+	own := map[string]models.TeamStats{}
+	err := r.db.read(ctx, func(data *memoryData) error {
+		for _, user := range data.usersOf(team.OrganizationID, nil) {
+			stats := own[user.TeamID]
+			stats.Members++
+			if user.IsActive {
+				stats.ActiveMembers++
+			}
+			for _, pr := range data.pullRequests {
+				if !slices.ContainsFunc(pr.Reviewers, func(reviewer models.PullRequestReviewer) bool {
+					return reviewer.UserID == user.ID
+				}) {
+					continue
+				}
+				stats.AssignedReviews++
+				if pr.OrganizationID == team.OrganizationID && pr.Status == models.StatusOpen {
+					stats.OpenReviews++
+				}
+			}
+			own[user.TeamID] = stats
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var rollUp func(t *models.Team) models.TeamStats
+	rollUp = func(t *models.Team) models.TeamStats {
+		stats := own[t.ID]
+		stats.TeamID = t.ID
+		stats.TeamName = t.Name
+		for i := range t.Subteams {
+			sub := rollUp(&t.Subteams[i])
+			stats.Members += sub.Members
+			stats.ActiveMembers += sub.ActiveMembers
+			stats.AssignedReviews += sub.AssignedReviews
+			stats.OpenReviews += sub.OpenReviews
+			stats.Subteams = append(stats.Subteams, sub)
+		}
+		return stats
+	}
+	stats := rollUp(team)
+
+	return &stats, nil
+}
+
+// GetByIDs returns the teams with the given IDs without their members.
+func (r *MemoryTeamRepository) GetByIDs(ctx context.Context, orgID string, teamIDs []string) ([]models.Team, error) {
+	logger := r.logger.With(
+		"method", "get_teams_by_ids",
+		"organization_id", orgID,
+		"team_ids", teamIDs,
+	)
+	logger.InfoContext(ctx, "getting teams")
+
+	var teams []models.Team
+	err := r.db.read(ctx, func(data *memoryData) error {
+		for _, id := range distinct(teamIDs) {
+			if team, ok := data.teams[id]; ok && team.OrganizationID == orgID {
+				teams = append(teams, team)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return teams, nil
+}
+
+// GetByParentIDs returns the direct subteams of the given teams without
+// their members.
+func (r *MemoryTeamRepository) GetByParentIDs(ctx context.Context, orgID string, parentIDs []string) ([]models.Team, error) {
+	logger := r.logger.With(
+		"method", "get_teams_by_parent_ids",
+		"organization_id", orgID,
+		"parent_team_ids", parentIDs,
+	)
+	logger.InfoContext(ctx, "getting subteams")
+
+	var teams []models.Team
+	err := r.db.read(ctx, func(data *memoryData) error {
+		teams = data.teamsOf(orgID, func(team *models.Team) bool {
+			return team.ParentID != nil && slices.Contains(parentIDs, *team.ParentID)
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return teams, nil
+}
+
+// GetTeamByID returns the team with its members.
+func (r *MemoryTeamRepository) GetTeamByID(ctx context.Context, orgID, teamID string) (*models.Team, error) {
+	logger := r.logger.With(
+		"method", "get_team_by_id",
+		"organization_id", orgID,
+		"team_id", teamID,
+	)
+	logger.InfoContext(ctx, "getting team")
+
+	var team models.Team
+	err := r.db.read(ctx, func(data *memoryData) error {
+		var ok bool
+		if team, ok = data.teams[teamID]; !ok || team.OrganizationID != orgID {
+			logger.WarnContext(ctx, "team not found")
+			return errs.ResourceNotFound
+		}
+
+		team.Members = data.members(teamID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &team, nil
+}
+
+// ListTeams returns a page of teams with their members ordered by name
+// and the number of teams on all pages. A non-empty name selects the
+// team with it regardless of case.
+func (r *MemoryTeamRepository) ListTeams(ctx context.Context, orgID, name string, offset, limit int) ([]models.Team, int64, error) {
+	logger := r.logger.With(
+		"method", "list_teams",
+		"organization_id", orgID,
+		"team_name", name,
+	)
+	logger.InfoContext(ctx, "listing teams")
+
+	var teams []models.Team
+	var total int64
+	err := r.db.read(ctx, func(data *memoryData) error {
+		matching := data.teamsOf(orgID, func(team *models.Team) bool {
+			return name == "" || strings.EqualFold(team.Name, name)
+		})
+		total = int64(len(matching))
+
+		teams = paginate(matching, offset, limit)
+		for i := range teams {
+			teams[i].Members = data.members(teams[i].ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return teams, total, nil
+}
+
+// EnsureTeam creates the team unless it exists.
+func (r *MemoryTeamRepository) EnsureTeam(ctx context.Context, orgID, name string) error {
+	logger := r.logger.With(
+		"method", "ensure_team",
+		"organization_id", orgID,
+		"team_name", name,
+	)
+	logger.InfoContext(ctx, "ensuring team")
+
+	err := r.db.write(ctx, func(data *memoryData) error {
+		_, err := data.ensureTeam(orgID, name)
+		return err
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to create team", "error", err)
+		return err
+	}
+	return nil
+}
+
+// ReplaceTeam renames the team and makes the given users its only
+// members. Users that leave the team are moved to the fallback team,
+// which is created if needed.
+func (r *MemoryTeamRepository) ReplaceTeam(ctx context.Context, orgID string, team *models.Team, fallbackName string) error {
+	logger := r.logger.With(
+		"method", "replace_team",
+		"organization_id", orgID,
+		"team_id", team.ID,
+		"team_name", team.Name,
+	)
+	logger.InfoContext(ctx, "replacing team")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		stored, ok := data.teams[team.ID]
+		if !ok || stored.OrganizationID != orgID {
+			logger.WarnContext(ctx, "team not found")
+			return errs.ResourceNotFound
+		}
+		stored.Name = team.Name
+		if err := data.updateTeam(stored); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				logger.WarnContext(ctx, "team already exists", "error", err)
+				return errs.TeamExists
+			}
+			logger.ErrorContext(ctx, "failed to rename team", "error", err)
+			return fmt.Errorf("failed to rename team %s: %w", team.ID, err)
+		}
+
+		memberIDs := make([]string, 0, len(team.Members))
+		for _, member := range team.Members {
+			memberIDs = append(memberIDs, member.ID)
+		}
+		if err := data.moveUsers(orgID, memberIDs, team.ID); err != nil {
+			logger.WarnContext(ctx, "member not found", "error", err)
+			return err
+		}
+
+		var leavingIDs []string
+		for _, user := range data.members(team.ID) {
+			if !slices.Contains(memberIDs, user.ID) {
+				leavingIDs = append(leavingIDs, user.ID)
+			}
+		}
+		if len(leavingIDs) == 0 {
+			return nil
+		}
+
+		fallbackID, err := data.ensureTeam(orgID, fallbackName)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to create fallback team", "error", err)
+			return err
+		}
+		if err := data.moveUsers(orgID, leavingIDs, fallbackID); err != nil {
+			logger.ErrorContext(ctx, "failed to move leaving members", "error", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// DeleteTeam deletes the team after moving its members to the fallback
+// team, which is created if needed. Subteams of the team become top-level
+// teams. The fallback team itself cannot be deleted.
+func (r *MemoryTeamRepository) DeleteTeam(ctx context.Context, orgID, teamID, fallbackName string) error {
+	logger := r.logger.With(
+		"method", "delete_team",
+		"organization_id", orgID,
+		"team_id", teamID,
+	)
+	logger.InfoContext(ctx, "deleting team")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		fallbackID, err := data.ensureTeam(orgID, fallbackName)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to create fallback team", "error", err)
+			return err
+		}
+		if fallbackID == teamID {
+			logger.WarnContext(ctx, "deleting fallback team")
+			return errs.NewValidationError(errs.FieldError{
+				Field:   "id",
+				Message: fmt.Sprintf("is the team %s, which cannot be deleted", fallbackName),
+			})
+		}
+
+		if team, ok := data.teams[teamID]; !ok || team.OrganizationID != orgID {
+			logger.WarnContext(ctx, "team not found")
+			return errs.ResourceNotFound
+		}
+
+		for id, user := range data.users {
+			if user.TeamID == teamID {
+				user.TeamID = fallbackID
+				data.users[id] = user
+			}
+		}
+		data.deleteTeam(teamID)
+
+		return nil
+	})
+}
+
+// Import creates the teams and users of the roster, moves users between
+// teams and updates their parents, emails and activity at once. Users and
+// teams missing from the roster are kept as they are. With dryRun the
+// changes are returned but not saved.
+func (r *MemoryTeamRepository) Import(ctx context.Context, orgID string, roster *models.Roster, dryRun bool) ([]models.ImportChange, error) {
+	logger := r.logger.With(
+		"method", "import_roster",
+		"organization_id", orgID,
+		"teams", len(roster.Teams),
+		"members", len(roster.Members),
+		"dry_run", dryRun,
+	)
+	logger.InfoContext(ctx, "importing roster")
+
+	var plan *importPlan
+	err := r.db.write(ctx, func(data *memoryData) error {
+		usernames := rosterUsernames(roster)
+		users := data.usersOf(orgID, func(user *models.User) bool { return slices.Contains(usernames, user.Username) })
+		plan = newImportPlan(orgID, roster, data.teamsOf(orgID, nil), users)
+		if len(plan.fields) > 0 {
+			logger.WarnContext(ctx, "invalid roster", "errors", len(plan.fields))
+			return errs.NewValidationError(plan.fields...)
+		}
+		if dryRun {
+			return nil
+		}
+
+		if err := data.applyImport(plan); err != nil {
+			logger.ErrorContext(ctx, "failed to import roster", "error", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.changes, nil
+}
+
+// applyImport saves the changes of the import plan.
+func (d *memoryData) applyImport(plan *importPlan) error {
+	// Teams are created without parents, as a parent may be created
+	// after its subteam
+	for _, team := range plan.newTeams {
+		created := models.Team{ID: team.ID, OrganizationID: team.OrganizationID, Name: team.Name}
+		if err := d.insertTeam(created); err != nil {
+			return fmt.Errorf("failed to create teams: %w", err)
+		}
+	}
+	for _, team := range plan.reparented {
+		stored := d.teams[team.ID]
+		stored.ParentID = team.ParentID
+		if err := d.updateTeam(stored); err != nil {
+			return fmt.Errorf("failed to set parent of team %s: %w", team.Name, err)
+		}
+	}
+
+	for _, user := range plan.newUsers {
+		if err := d.insertUser(*user); err != nil {
+			return fmt.Errorf("failed to create users: %w", err)
+		}
+	}
+	for _, user := range plan.changedUser {
+		stored := d.users[user.ID]
+		stored.TeamID, stored.Email, stored.IsActive = user.TeamID, user.Email, user.IsActive
+		if err := d.updateUser(stored); err != nil {
+			return fmt.Errorf("failed to update user %s: %w", user.Username, err)
+		}
+	}
+
+	return nil
+}
+
+// ensureTeam returns the ID of the team, creating the team if it does not
+// exist.
+func (d *memoryData) ensureTeam(orgID, name string) (string, error) {
+	if team, ok := d.teamByName(orgID, name); ok {
+		return team.ID, nil
+	}
+
+	team := models.Team{ID: uuid.New().String(), OrganizationID: orgID, Name: name}
+	if err := d.insertTeam(team); err != nil {
+		return "", fmt.Errorf("failed to create team %s: %w", name, err)
+	}
+	return team.ID, nil
+}
+
+// moveUsers moves the users to the team. If any of the users does not
+// exist, errs.ResourceNotFound is returned.
+func (d *memoryData) moveUsers(orgID string, userIDs []string, teamID string) error {
+	for _, id := range distinct(userIDs) {
+		user, ok := d.users[id]
+		if !ok || user.OrganizationID != orgID {
+			return errs.ResourceNotFound.WithMessage("user not found")
+		}
+		user.TeamID = teamID
+		if err := d.updateUser(user); err != nil {
+			return fmt.Errorf("failed to move users to team %s: %w", teamID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,324 @@
+package repository_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reviewers/internal/models"
+	"reviewers/internal/repository"
+	"reviewers/pkg/errs"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const orgID = models.DefaultOrganizationID
+
+func newStores() *repository.Stores {
+	return repository.NewMemoryStores(repository.NewMemoryDB(), slog.New(slog.DiscardHandler))
+}
+
+// createTeam creates a team with active members of the given usernames
+// and returns it with their IDs.
+func createTeam(t *testing.T, stores *repository.Stores, name, parentName string, usernames ...string) *models.Team {
+	team := &models.Team{Name: name, ParentName: parentName}
+	for _, username := range usernames {
+		team.Members = append(team.Members, models.User{Username: username, IsActive: true})
+	}
+	require.NoError(t, stores.Teams.CreateTeam(context.Background(), orgID, team))
+	return team
+}
+
+func createPullRequest(t *testing.T, stores *repository.Stores, id string, createdAt time.Time, authorID string, reviewerIDs ...string) {
+	pr := &models.PullRequest{
+		OrganizationID: orgID,
+		RepositoryID:   models.DefaultRepositoryID,
+		ID:             id,
+		Name:           "Change " + id,
+		Status:         models.StatusOpen,
+		CreatedAt:      createdAt,
+		AuthorID:       authorID,
+	}
+	for _, reviewerID := range reviewerIDs {
+		pr.Reviewers = append(pr.Reviewers, models.PullRequestReviewer{RepositoryID: pr.RepositoryID, PullRequestID: id, UserID: reviewerID})
+	}
+	require.NoError(t, stores.PullRequests.Create(context.Background(), pr))
+}
+
+func mustGetPullRequest(t *testing.T, stores *repository.Stores, id string) *models.PullRequest {
+	pr, err := stores.PullRequests.Get(context.Background(), orgID, models.DefaultRepositoryID, id)
+	require.NoError(t, err)
+	return pr
+}
+
+func TestMemory_DuplicatesAndNotFound(t *testing.T) {
+	stores := newStores()
+	ctx := context.Background()
+	team := createTeam(t, stores, "backend", "", "alice")
+
+	err := stores.Teams.CreateTeam(ctx, orgID, &models.Team{Name: "backend"})
+	assert.ErrorIs(t, err, errs.TeamExists)
+
+	err = stores.Users.Create(ctx, &models.User{OrganizationID: orgID, Username: "alice", TeamName: "backend"})
+	assert.ErrorIs(t, err, errs.UserExists)
+
+	err = stores.Repositories.Create(ctx, &models.Repository{OrganizationID: orgID, Name: models.DefaultRepository, ReviewersCount: 2})
+	assert.ErrorIs(t, err, errs.RepositoryExists)
+
+	err = stores.Organizations.Create(ctx, &models.Organization{Name: "default"})
+	assert.ErrorIs(t, err, errs.OrganizationExists)
+
+	createPullRequest(t, stores, "pr-1", time.Now(), team.Members[0].ID)
+	err = stores.PullRequests.Create(ctx, &models.PullRequest{
+		OrganizationID: orgID,
+		RepositoryID:   models.DefaultRepositoryID,
+		ID:             "pr-1",
+		AuthorID:       team.Members[0].ID,
+	})
+	assert.ErrorIs(t, err, errs.PullRequestExists)
+
+	_, err = stores.Users.Get(ctx, orgID, uuid.New().String())
+	assert.ErrorIs(t, err, errs.ResourceNotFound)
+	_, err = stores.Teams.GetTeam(ctx, orgID, "frontend", false)
+	assert.ErrorIs(t, err, errs.ResourceNotFound)
+	assert.ErrorIs(t, stores.Users.SetActiveStatus(ctx, orgID, uuid.New().String(), false), errs.ResourceNotFound)
+	assert.ErrorIs(t, stores.PullRequests.Merge(ctx, orgID, models.DefaultRepositoryID, "pr-2"), errs.ResourceNotFound)
+
+	// Data of other organizations is not found
+	_, err = stores.Users.Get(ctx, uuid.New().String(), team.Members[0].ID)
+	assert.ErrorIs(t, err, errs.ResourceNotFound)
+}
+
+func TestMemory_FailedWriteChangesNothing(t *testing.T) {
+	stores := newStores()
+	ctx := context.Background()
+	createTeam(t, stores, "backend", "", "alice")
+
+	// The second member is missing, so the team and the first member are
+	// not created either
+	err := stores.Teams.CreateTeam(ctx, orgID, &models.Team{
+		Name: "frontend",
+		Members: []models.User{
+			{Username: "bob", IsActive: true},
+			{ID: uuid.New().String(), Username: "carol"},
+		},
+	})
+	assert.ErrorIs(t, err, errs.ResourceNotFound)
+
+	_, err = stores.Teams.GetTeam(ctx, orgID, "frontend", false)
+	assert.ErrorIs(t, err, errs.ResourceNotFound)
+	users, total, err := stores.Users.List(ctx, orgID, "", 0, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	assert.Equal(t, "alice", users[0].Username)
+
+	err = stores.Teams.SetParent(ctx, orgID, "backend", "backend")
+	assert.ErrorIs(t, err, errs.InvalidHierarchy)
+}
+
+func TestMemory_DeletionsCascade(t *testing.T) {
+	stores := newStores()
+	ctx := context.Background()
+	platform := createTeam(t, stores, "platform", "", "lead")
+	backend := createTeam(t, stores, "backend", "platform", "alice", "bob")
+	alice, bob := backend.Members[0].ID, backend.Members[1].ID
+
+	createPullRequest(t, stores, "pr-1", time.Now(), alice, bob)
+	createPullRequest(t, stores, "pr-2", time.Now(), bob, alice)
+	createPullRequest(t, stores, "pr-3", time.Now(), bob, alice)
+	require.NoError(t, stores.PullRequests.Merge(ctx, orgID, models.DefaultRepositoryID, "pr-3"))
+
+	// Open reviews of the user have to be reassigned in the same
+	// transaction
+	assert.ErrorIs(t, stores.Users.Delete(ctx, orgID, alice, nil), errs.NoCandidate)
+	pr, err := stores.PullRequests.Get(ctx, orgID, models.DefaultRepositoryID, "pr-2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{alice}, pr.AssignedReviewers)
+
+	lead := platform.Members[0].ID
+	pr.Reviewers[0].UserID = lead
+	reassignment := models.Reassignment{PullRequest: pr, Events: []models.PullRequestEvent{
+		models.NewPullRequestEvent(pr, models.EventUnassigned, alice),
+		models.NewPullRequestEvent(pr, models.EventAssigned, lead),
+	}}
+	// Pull requests authored or reviewed by the user keep them, the
+	// reassignments are rolled back
+	err = stores.Users.Delete(ctx, orgID, alice, []models.Reassignment{reassignment})
+	assert.ErrorIs(t, err, errs.UserHasPullRequests)
+	assert.ErrorContains(t, err, "pr-1, pr-3")
+	pr, err = stores.PullRequests.Get(ctx, orgID, models.DefaultRepositoryID, "pr-2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{alice}, pr.AssignedReviewers)
+
+	// A user with open reviews only is deleted, their events stay in the
+	// history without the user
+	carolTeam := createTeam(t, stores, "frontend", "platform", "carol")
+	carol := carolTeam.Members[0].ID
+	createPullRequest(t, stores, "pr-4", time.Now(), bob, carol)
+	pr = mustGetPullRequest(t, stores, "pr-4")
+	pr.Reviewers[0].UserID = lead
+	reassignment = models.Reassignment{PullRequest: pr, Events: []models.PullRequestEvent{
+		models.NewPullRequestEvent(pr, models.EventUnassigned, carol),
+		models.NewPullRequestEvent(pr, models.EventAssigned, lead),
+	}}
+	require.NoError(t, stores.Users.Delete(ctx, orgID, carol, []models.Reassignment{reassignment}))
+	assert.Equal(t, []string{lead}, mustGetPullRequest(t, stores, "pr-4").AssignedReviewers)
+	assert.ErrorIs(t, stores.Users.Delete(ctx, orgID, carol, nil), errs.ResourceNotFound)
+
+	var events []*models.BackupEvent
+	require.NoError(t, stores.Backups.Export(ctx, orgID, func(record models.BackupRecord) error {
+		if event, ok := record.(*models.BackupEvent); ok && event.PullRequestID == "pr-4" {
+			events = append(events, event)
+		}
+		return nil
+	}))
+	var users []*string
+	for _, event := range events {
+		users = append(users, event.UserID)
+	}
+	assert.Equal(t, []*string{nil, nil, &lead}, users)
+
+	// Members of a deleted team move to the fallback team, subteams become
+	// top-level teams
+	require.NoError(t, stores.Teams.DeleteTeam(ctx, orgID, platform.ID, "unassigned"))
+	team, err := stores.Teams.GetTeam(ctx, orgID, "backend", false)
+	require.NoError(t, err)
+	assert.Nil(t, team.ParentID)
+	user, err := stores.Users.GetWithTeam(ctx, orgID, lead)
+	require.NoError(t, err)
+	assert.Equal(t, "unassigned", user.TeamName)
+
+	fallback, err := stores.Teams.GetTeam(ctx, orgID, "unassigned", false)
+	require.NoError(t, err)
+	var validationErr *errs.ValidationError
+	assert.ErrorAs(t, stores.Teams.DeleteTeam(ctx, orgID, fallback.ID, "unassigned"), &validationErr)
+}
+
+func TestMemory_ListPullRequests(t *testing.T) {
+	stores := newStores()
+	ctx := context.Background()
+	team := createTeam(t, stores, "backend", "", "alice", "bob")
+	alice, bob := team.Members[0].ID, team.Members[1].ID
+
+	start := time.Now().Add(-time.Hour)
+	for i, id := range []string{"pr-1", "pr-2", "pr-3", "pr-4", "pr-5"} {
+		createPullRequest(t, stores, id, start.Add(time.Duration(i)*time.Minute), alice, bob)
+	}
+	require.NoError(t, stores.PullRequests.Merge(ctx, orgID, models.DefaultRepositoryID, "pr-2"))
+
+	var ids []string
+	filter := models.PullRequestFilter{Limit: 2, IncludeTotal: true}
+	for {
+		page, err := stores.PullRequests.List(ctx, orgID, filter)
+		require.NoError(t, err)
+		assert.EqualValues(t, 5, *page.Total)
+		for _, pr := range page.PullRequests {
+			ids = append(ids, pr.ID)
+			assert.Equal(t, models.DefaultRepository, pr.Repository)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []string{"pr-5", "pr-4", "pr-3", "pr-2", "pr-1"}, ids)
+
+	page, err := stores.Users.GetReview(ctx, orgID, bob, models.PullRequestFilter{Status: models.StatusMerged})
+	require.NoError(t, err)
+	require.Len(t, page.PullRequests, 1)
+	assert.Equal(t, "pr-2", page.PullRequests[0].ID)
+
+	page, err = stores.PullRequests.List(ctx, orgID, models.PullRequestFilter{Sort: models.SortName, Name: "CHANGE PR-4"})
+	require.NoError(t, err)
+	require.Len(t, page.PullRequests, 1)
+	assert.Equal(t, "pr-4", page.PullRequests[0].ID)
+
+	_, err = stores.PullRequests.List(ctx, orgID, models.PullRequestFilter{Sort: "author"})
+	assert.ErrorIs(t, err, errs.ValidationFailed)
+	_, err = stores.PullRequests.List(ctx, orgID, models.PullRequestFilter{Sort: models.SortName, Cursor: filter.Cursor})
+	assert.ErrorIs(t, err, errs.ValidationFailed)
+}
+
+func TestMemory_ReviewerStats(t *testing.T) {
+	stores := newStores()
+	ctx := context.Background()
+	team := createTeam(t, stores, "backend", "", "alice", "bob")
+	alice, bob := team.Members[0].ID, team.Members[1].ID
+
+	for _, id := range []string{"pr-1", "pr-2"} {
+		createPullRequest(t, stores, id, time.Now(), alice, bob)
+		pr, err := stores.PullRequests.Get(ctx, orgID, models.DefaultRepositoryID, id)
+		require.NoError(t, err)
+		require.NoError(t, stores.PullRequests.Approve(ctx, pr, bob))
+		require.NoError(t, stores.PullRequests.Approve(ctx, pr, bob))
+	}
+	require.NoError(t, stores.PullRequests.Merge(ctx, orgID, models.DefaultRepositoryID, "pr-1"))
+
+	stats, err := stores.Stats.GetReviewerStats(ctx, orgID, models.StatsFilter{TeamName: "backend"})
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, "alice", stats[0].Username)
+	assert.Nil(t, stats[0].MedianTimeToFirstReview)
+	assert.Equal(t, "bob", stats[1].Username)
+	assert.EqualValues(t, 2, stats[1].Assigned)
+	assert.EqualValues(t, 2, stats[1].Approved)
+	assert.EqualValues(t, 1, stats[1].OpenReviews)
+	assert.NotNil(t, stats[1].MedianTimeToFirstReview)
+
+	merges, err := stores.Stats.GetMergeStats(ctx, orgID, models.StatsFilter{TeamName: "backend"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, merges.Merged)
+	assert.NotNil(t, merges.MeanTimeToMerge)
+}
+
+// backupRecords replays exported records as a backup source.
+type backupRecords struct {
+	records []models.BackupRecord
+	line    int
+}
+
+func (b *backupRecords) Next() (models.BackupRecord, error) {
+	if b.line == len(b.records) {
+		return nil, io.EOF
+	}
+	b.line++
+	return b.records[b.line-1], nil
+}
+
+func (b *backupRecords) Line() int { return b.line }
+
+func TestMemory_ExportRestore(t *testing.T) {
+	stores := newStores()
+	ctx := context.Background()
+	createTeam(t, stores, "platform", "", "lead")
+	team := createTeam(t, stores, "backend", "platform", "alice", "bob")
+	createPullRequest(t, stores, "pr-1", time.Now(), team.Members[0].ID, team.Members[1].ID)
+	require.NoError(t, stores.CodeOwners.SetRules(ctx, models.DefaultRepositoryID, []models.CodeOwnerRule{
+		{Pattern: "*.go", OwnerIDs: []string{team.Members[1].ID}},
+	}))
+
+	source := &backupRecords{}
+	require.NoError(t, stores.Backups.Export(ctx, orgID, func(record models.BackupRecord) error {
+		source.records = append(source.records, record)
+		return nil
+	}))
+
+	// Restoring into a non-empty organization changes nothing
+	_, err := stores.Backups.Restore(ctx, orgID, source)
+	assert.ErrorIs(t, err, errs.NotEmpty)
+
+	restored := newStores()
+	result, err := restored.Backups.Restore(ctx, orgID, source)
+	require.NoError(t, err)
+	assert.Equal(t, models.RestoreResult{Teams: 2, Users: 3, Repositories: 1, CodeOwnerRules: 1, PullRequests: 1, Events: 1}, *result)
+
+	var exported []models.BackupRecord
+	require.NoError(t, restored.Backups.Export(ctx, orgID, func(record models.BackupRecord) error {
+		exported = append(exported, record)
+		return nil
+	}))
+	assert.Equal(t, source.records, exported)
+}
@@ -0,0 +1,414 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MemoryUserRepository keeps users in the memory database.
+type MemoryUserRepository struct {
+	db     *MemoryDB
+	logger *slog.Logger
+}
+
+func NewMemoryUserRepository(db *MemoryDB, logger *slog.Logger) *MemoryUserRepository {
+	return &MemoryUserRepository{db, logger}
+}
+
+func (r *MemoryUserRepository) SetActiveStatus(ctx context.Context, orgID, userID string, active bool) error {
+	logger := r.logger.With(
+		"method", "set_active_status",
+		"organization_id", orgID,
+		"user_id", userID,
+	)
+	logger.InfoContext(ctx, "setting active status")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		user, ok := data.users[userID]
+		if !ok || user.OrganizationID != orgID {
+			logger.WarnContext(ctx, "user not found")
+			return errs.ResourceNotFound
+		}
+
+		user.IsActive = active
+		data.users[userID] = user
+		return nil
+	})
+}
+
+// GetReview returns a page of the pull requests the user reviews.
+func (r *MemoryUserRepository) GetReview(
+	ctx context.Context,
+	orgID, userID string,
+	filter models.PullRequestFilter,
+) (*models.PullRequestPage, error) {
+	logger := r.logger.With(
+		"method", "get_reviews",
+		"organization_id", orgID,
+		"user_id", userID,
+		"status", filter.Status,
+		"sort", filter.Sort,
+	)
+	logger.InfoContext(ctx, "getting reviews")
+
+	filter.ReviewerID = userID
+	var page *models.PullRequestPage
+	err := r.db.read(ctx, func(data *memoryData) (err error) {
+		page, err = data.listPullRequests(orgID, filter)
+		return err
+	})
+	if errors.Is(err, errs.ValidationFailed) {
+		logger.WarnContext(ctx, "invalid listing", "error", err)
+	} else if err != nil {
+		logger.ErrorContext(ctx, "failed to get reviews", "error", err)
+	}
+
+	return page, err
+}
+
+func (r *MemoryUserRepository) Get(ctx context.Context, orgID, userID string) (*models.User, error) {
+	logger := r.logger.With(
+		"method", "get_user",
+		"organization_id", orgID,
+		"user_id", userID,
+	)
+	logger.InfoContext(ctx, "getting user")
+
+	var user models.User
+	err := r.db.read(ctx, func(data *memoryData) error {
+		var ok bool
+		if user, ok = data.users[userID]; !ok || user.OrganizationID != orgID {
+			logger.WarnContext(ctx, "user not found")
+			return errs.ResourceNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetWithTeam returns the user with the name of their team.
+func (r *MemoryUserRepository) GetWithTeam(ctx context.Context, orgID, userID string) (*models.User, error) {
+	logger := r.logger.With(
+		"method", "get_user_with_team",
+		"organization_id", orgID,
+		"user_id", userID,
+	)
+	logger.InfoContext(ctx, "getting user")
+
+	var user models.User
+	err := r.db.read(ctx, func(data *memoryData) error {
+		var ok bool
+		if user, ok = data.users[userID]; !ok || user.OrganizationID != orgID {
+			logger.WarnContext(ctx, "user not found")
+			return errs.ResourceNotFound
+		}
+
+		user.TeamName = data.teams[user.TeamID].Name
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	logger := r.logger.With(
+		"method", "create_user",
+		"organization_id", user.OrganizationID,
+		"username", user.Username,
+	)
+	logger.InfoContext(ctx, "creating user")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		if err := resolveUserTeam(data, user); err != nil {
+			logger.WarnContext(ctx, "team not found", "error", err, "team_name", user.TeamName)
+			return err
+		}
+
+		user.ID = uuid.New().String()
+		if err := data.insertUser(*user); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				logger.WarnContext(ctx, "user already exists", "error", err)
+				return errs.UserExists
+			}
+			logger.ErrorContext(ctx, "failed to create user", "error", err)
+			return fmt.Errorf("failed to create user %s: %w", user.Username, err)
+		}
+
+		return nil
+	})
+}
+
+// Update saves the username, email and team of the user.
+func (r *MemoryUserRepository) Update(ctx context.Context, user *models.User) error {
+	logger := r.logger.With(
+		"method", "update_user",
+		"organization_id", user.OrganizationID,
+		"user_id", user.ID,
+	)
+	logger.InfoContext(ctx, "updating user")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		return data.saveUserChanges(ctx, logger, user)
+	})
+}
+
+// saveUserChanges saves the username, email and team of the user.
+func (d *memoryData) saveUserChanges(ctx context.Context, logger *slog.Logger, user *models.User) error {
+	if err := resolveUserTeam(d, user); err != nil {
+		logger.WarnContext(ctx, "team not found", "error", err, "team_name", user.TeamName)
+		return err
+	}
+
+	stored, ok := d.users[user.ID]
+	if !ok || stored.OrganizationID != user.OrganizationID {
+		logger.WarnContext(ctx, "user not found")
+		return errs.ResourceNotFound
+	}
+	stored.Username = user.Username
+	stored.Email = user.Email
+	stored.TeamID = user.TeamID
+	if err := d.updateUser(stored); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			logger.WarnContext(ctx, "username is taken", "error", err, "username", user.Username)
+			return errs.UserExists
+		}
+		logger.ErrorContext(ctx, "failed to update user", "error", err)
+		return fmt.Errorf("failed to update user %s: %w", user.ID, err)
+	}
+
+	return nil
+}
+
+// Delete saves the reassignments of the open reviews of the user and
+// removes the user in one transaction. If the user still reviews an open
+// pull request then, nothing is changed and errs.NoCandidate is returned.
+// A user who authored or reviewed the kept pull requests is not deleted
+// either, errs.UserHasPullRequests is returned. Events of the user stay
+// in the history of pull requests without the user.
+func (r *MemoryUserRepository) Delete(ctx context.Context, orgID, userID string, reassignments []models.Reassignment) error {
+	logger := r.logger.With(
+		"method", "delete_user",
+		"organization_id", orgID,
+		"user_id", userID,
+		"reassignments", len(reassignments),
+	)
+	logger.InfoContext(ctx, "deleting user")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		if err := data.releaseReviews(ctx, logger, orgID, userID, reassignments); err != nil {
+			return err
+		}
+
+		if pullRequests := data.pullRequestsOf(userID); len(pullRequests) > 0 {
+			logger.WarnContext(ctx, "user has pull requests", "pull_request_ids", pullRequests)
+			return pullRequestsLeft(pullRequests)
+		}
+
+		data.deleteUser(userID)
+		return nil
+	})
+}
+
+// Deactivate saves the reassignments of the open reviews of the user and
+// deactivates the user in one transaction. If the user still reviews an
+// open pull request then, nothing is changed and errs.NoCandidate is
+// returned.
+func (r *MemoryUserRepository) Deactivate(ctx context.Context, orgID, userID string, reassignments []models.Reassignment) error {
+	logger := r.logger.With(
+		"method", "deactivate_user",
+		"organization_id", orgID,
+		"user_id", userID,
+		"reassignments", len(reassignments),
+	)
+	logger.InfoContext(ctx, "deactivating user")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		if err := data.releaseReviews(ctx, logger, orgID, userID, reassignments); err != nil {
+			return err
+		}
+
+		data.deactivateUser(userID)
+		return nil
+	})
+}
+
+// UpdateAndDeactivate saves the username, email and team of the user
+// together with the deactivation, see Deactivate. If the user cannot be
+// deactivated, the user is not changed either.
+func (r *MemoryUserRepository) UpdateAndDeactivate(ctx context.Context, user *models.User, reassignments []models.Reassignment) error {
+	logger := r.logger.With(
+		"method", "update_and_deactivate_user",
+		"organization_id", user.OrganizationID,
+		"user_id", user.ID,
+		"reassignments", len(reassignments),
+	)
+	logger.InfoContext(ctx, "updating and deactivating user")
+
+	return r.db.write(ctx, func(data *memoryData) error {
+		if err := data.releaseReviews(ctx, logger, user.OrganizationID, user.ID, reassignments); err != nil {
+			return err
+		}
+		if err := data.saveUserChanges(ctx, logger, user); err != nil {
+			return err
+		}
+
+		data.deactivateUser(user.ID)
+		return nil
+	})
+}
+
+func (d *memoryData) deactivateUser(userID string) {
+	user := d.users[userID]
+	user.IsActive = false
+	d.users[userID] = user
+}
+
+// releaseReviews saves the reassignments of the open reviews of the user.
+// It fails if the user still reviews an open pull request then.
+func (d *memoryData) releaseReviews(ctx context.Context, logger *slog.Logger, orgID, userID string, reassignments []models.Reassignment) error {
+	if user, ok := d.users[userID]; !ok || user.OrganizationID != orgID {
+		logger.WarnContext(ctx, "user not found")
+		return errs.ResourceNotFound
+	}
+
+	if err := d.saveReassignments(reassignments); err != nil {
+		logger.ErrorContext(ctx, "failed to reassign reviews", "error", err)
+		return err
+	}
+
+	if openReviews := d.openReviews(userID); len(openReviews) > 0 {
+		logger.WarnContext(ctx, "user still reviews open pull requests", "pull_request_ids", openReviews)
+		return openReviewsLeft(openReviews)
+	}
+	return nil
+}
+
+// GetActive returns active users among the given ones.
+func (r *MemoryUserRepository) GetActive(ctx context.Context, orgID string, userIDs ...string) ([]*models.User, error) {
+	logger := r.logger.With(
+		"method", "get_active_users",
+		"organization_id", orgID,
+		"user_ids", userIDs,
+	)
+	logger.InfoContext(ctx, "getting active users")
+
+	var users []*models.User
+	err := r.db.read(ctx, func(data *memoryData) error {
+		for _, id := range distinct(userIDs) {
+			if user, ok := data.users[id]; ok && user.OrganizationID == orgID && user.IsActive {
+				users = append(users, &user)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// GetByIDs returns the users with the given IDs.
+func (r *MemoryUserRepository) GetByIDs(ctx context.Context, orgID string, userIDs []string) ([]models.User, error) {
+	logger := r.logger.With(
+		"method", "get_users_by_ids",
+		"organization_id", orgID,
+		"user_ids", userIDs,
+	)
+	logger.InfoContext(ctx, "getting users")
+
+	var users []models.User
+	err := r.db.read(ctx, func(data *memoryData) error {
+		for _, id := range distinct(userIDs) {
+			if user, ok := data.users[id]; ok && user.OrganizationID == orgID {
+				users = append(users, user)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// GetByTeamIDs returns the members of the given teams.
+func (r *MemoryUserRepository) GetByTeamIDs(ctx context.Context, orgID string, teamIDs []string) ([]models.User, error) {
+	logger := r.logger.With(
+		"method", "get_users_by_team_ids",
+		"organization_id", orgID,
+		"team_ids", teamIDs,
+	)
+	logger.InfoContext(ctx, "getting team members")
+
+	var users []models.User
+	err := r.db.read(ctx, func(data *memoryData) error {
+		users = data.usersOf(orgID, func(user *models.User) bool { return slices.Contains(teamIDs, user.TeamID) })
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// List returns a page of users ordered by username with the names of
+// their teams and the number of users on all pages. A non-empty username
+// selects the user with it regardless of case.
+func (r *MemoryUserRepository) List(ctx context.Context, orgID, username string, offset, limit int) ([]models.User, int64, error) {
+	logger := r.logger.With(
+		"method", "list_users",
+		"organization_id", orgID,
+		"username", username,
+	)
+	logger.InfoContext(ctx, "listing users")
+
+	var users []models.User
+	var total int64
+	err := r.db.read(ctx, func(data *memoryData) error {
+		matching := data.usersOf(orgID, func(user *models.User) bool {
+			return username == "" || strings.EqualFold(user.Username, username)
+		})
+		total = int64(len(matching))
+
+		users = paginate(matching, offset, limit)
+		for i := range users {
+			users[i].TeamName = data.teams[users[i].TeamID].Name
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// resolveUserTeam sets the ID of the team of the user from its name.
+func resolveUserTeam(data *memoryData, user *models.User) error {
+	team, ok := data.teamByName(user.OrganizationID, user.TeamName)
+	if !ok {
+		return errs.ResourceNotFound
+	}
+
+	user.TeamID = team.ID
+	return nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OrganizationRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewOrganizationRepository(db *gorm.DB, logger *slog.Logger) *OrganizationRepository {
+	return &OrganizationRepository{db, logger}
+}
+
+// Create creates the organization together with its default repository.
+func (r *OrganizationRepository) Create(ctx context.Context, org *models.Organization) error {
+	logger := r.logger.With(
+		"method", "create_organization",
+		"organization_name", org.Name,
+	)
+	logger.InfoContext(ctx, "creating organization")
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		org.ID = uuid.New().String()
+		if err := tx.Create(org).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				logger.WarnContext(ctx, "organization already exists", "error", err)
+				return errs.OrganizationExists
+			}
+			logger.ErrorContext(ctx, "failed to create organization", "error", err)
+			return fmt.Errorf("failed to create organization %s: %w", org.Name, err)
+		}
+
+		repo := models.Repository{
+			ID:             uuid.New().String(),
+			OrganizationID: org.ID,
+			Name:           models.DefaultRepository,
+			ReviewersCount: 2,
+			ClimbHierarchy: true,
+		}
+		if err := tx.Create(&repo).Error; err != nil {
+			logger.ErrorContext(ctx, "failed to create default repository", "error", err)
+			return fmt.Errorf("failed to create default repository: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *OrganizationRepository) Get(ctx context.Context, orgID string) (*models.Organization, error) {
+	logger := r.logger.With(
+		"method", "get_organization",
+		"organization_id", orgID,
+	)
+	logger.InfoContext(ctx, "getting organization")
+
+	var org models.Organization
+
+	err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).First(&org).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.WarnContext(ctx, "organization not found", "error", err)
+			return nil, errs.ResourceNotFound
+		}
+		logger.ErrorContext(ctx, "failed to get organization", "error", err)
+		return nil, err
+	}
+
+	return &org, nil
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const defaultPageSize = 100
+
+// sortColumns are the columns listings may be ordered by. The primary key
+// breaks ties, so the order is total.
+var sortColumns = map[string]string{
+	models.SortCreatedAt: "pull_requests.created_at",
+	models.SortName:      "pull_requests.pull_request_name",
+}
+
+// teamTreeQuery selects the team with the given name and its subteams.
+const teamTreeQuery = `
+WITH RECURSIVE tree AS (
+  SELECT team_id FROM teams WHERE organization_id = @organization_id AND name = @team_name
+  UNION ALL
+  SELECT t.team_id FROM teams t JOIN tree ON t.parent_team_id = tree.team_id
+)
+SELECT team_id FROM tree`
+
+// likeEscaper escapes the wildcards of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// listCursor is the position of the last pull request of a page in the
+// order of the listing.
+type listCursor struct {
+	Sort          string `json:"s"`
+	Value         string `json:"v"`
+	RepositoryID  string `json:"r"`
+	PullRequestID string `json:"p"`
+}
+
+var invalidCursor = errs.NewValidationError(errs.FieldError{Field: "cursor", Message: "is invalid"})
+
+func encodeCursor(cursor listCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (listCursor, error) {
+	var cursor listCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, invalidCursor
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, invalidCursor
+	}
+	if _, err := uuid.Parse(cursor.RepositoryID); err != nil {
+		return cursor, invalidCursor
+	}
+	return cursor, nil
+}
+
+// listPullRequests returns a page of pull requests of the organization
+// matching the filter.
+func listPullRequests(db *gorm.DB, orgID string, filter models.PullRequestFilter) (*models.PullRequestPage, error) {
+	sort := filter.Sort
+	if sort == "" {
+		sort = "-" + models.SortCreatedAt
+	}
+	field, descending := strings.CutPrefix(sort, "-")
+	column, ok := sortColumns[field]
+	if !ok {
+		return nil, errs.NewValidationError(errs.FieldError{Field: "sort", Message: "is not supported"})
+	}
+
+	limit := filter.Limit
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+
+	now := time.Now()
+	matching := func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Where("pull_requests.organization_id = ?", orgID)
+		if filter.AuthorID != "" {
+			tx = tx.Where("pull_requests.author_id = ?", filter.AuthorID)
+		}
+		if filter.TeamName != "" {
+			tx = tx.Where("pull_requests.author_id IN (SELECT user_id FROM users WHERE team_id IN ("+teamTreeQuery+"))",
+				sql.Named("organization_id", orgID), sql.Named("team_name", filter.TeamName))
+		}
+		if filter.ReviewerID != "" {
+			tx = tx.Where("EXISTS (SELECT 1 FROM pull_request_reviewers prr "+
+				"WHERE prr.repository_id = pull_requests.repository_id "+
+				"AND prr.pull_request_id = pull_requests.pull_request_id AND prr.user_id = ?)", filter.ReviewerID)
+		}
+		if filter.Status != "" {
+			tx = tx.Where("pull_requests.status = ?", filter.Status)
+		}
+		if filter.Name != "" {
+			tx = tx.Where("pull_requests.pull_request_name ILIKE ?", "%"+likeEscaper.Replace(filter.Name)+"%")
+		}
+		if !filter.From.IsZero() {
+			tx = tx.Where("pull_requests.created_at >= ?", filter.From)
+		}
+		if !filter.To.IsZero() {
+			tx = tx.Where("pull_requests.created_at < ?", filter.To)
+		}
+		if filter.MinAge > 0 {
+			tx = tx.Where("pull_requests.created_at <= ?", now.Add(-filter.MinAge))
+		}
+		if filter.MaxAge > 0 {
+			tx = tx.Where("pull_requests.created_at >= ?", now.Add(-filter.MaxAge))
+		}
+		return tx
+	}
+
+	direction, comparison := "ASC", ">"
+	if descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	query := db.Model(&models.PullRequest{}).
+		Select("pull_requests.repository_id, repositories.name AS repository, pull_requests.pull_request_id, " +
+			"pull_requests.pull_request_name, pull_requests.author_id, pull_requests.status, pull_requests.created_at").
+		Joins("JOIN repositories ON repositories.repository_id = pull_requests.repository_id").
+		Scopes(matching).
+		Order(fmt.Sprintf("%[1]s %[2]s, pull_requests.repository_id %[2]s, pull_requests.pull_request_id %[2]s", column, direction))
+	if limit > 0 {
+		query = query.Limit(limit + 1)
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != sort {
+			return nil, errs.NewValidationError(errs.FieldError{Field: "cursor", Message: "belongs to another sort order"})
+		}
+
+		var value any = cursor.Value
+		if field == models.SortCreatedAt {
+			if value, err = time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+				return nil, invalidCursor
+			}
+		}
+		query = query.Where(
+			fmt.Sprintf("(%s, pull_requests.repository_id, pull_requests.pull_request_id) %s (?, ?, ?)", column, comparison),
+			value, cursor.RepositoryID, cursor.PullRequestID,
+		)
+	}
+
+	page := models.PullRequestPage{PullRequests: []models.PullRequestShort{}}
+	if err := query.Find(&page.PullRequests).Error; err != nil {
+		return nil, err
+	}
+
+	if limit > 0 && len(page.PullRequests) > limit {
+		page.PullRequests = page.PullRequests[:limit]
+		last := page.PullRequests[limit-1]
+		cursor := listCursor{Sort: sort, Value: last.Name, RepositoryID: last.RepositoryID, PullRequestID: last.ID}
+		if field == models.SortCreatedAt {
+			cursor.Value = last.CreatedAt.Format(time.RFC3339Nano)
+		}
+		page.NextCursor = encodeCursor(cursor)
+	}
+
+	if filter.IncludeTotal {
+		var total int64
+		if err := db.Model(&models.PullRequest{}).Scopes(matching).Count(&total).Error; err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	return &page, nil
+}
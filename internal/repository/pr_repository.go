@@ -1,11 +1,12 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
-	"reviewers/internal/errs"
 	"reviewers/internal/models"
+	"reviewers/pkg/errs"
 	"time"
 
 	"gorm.io/gorm"
@@ -20,24 +21,26 @@ func NewPRRepository(db *gorm.DB, logger *slog.Logger) *PRRepository {
 	return &PRRepository{db, logger}
 }
 
-func (r *PRRepository) Create(pr *models.PullRequest) error {
+func (r *PRRepository) Create(ctx context.Context, pr *models.PullRequest) error {
 	logger := r.logger.With(
 		"method", "create_pull_request",
+		"organization_id", pr.OrganizationID,
+		"repository_id", pr.RepositoryID,
 		"pull_request_id", pr.ID,
 		"pull_request_name", pr.Name,
 	)
-	logger.Info("creating pull request")
+	logger.InfoContext(ctx, "creating pull request")
 
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Omit("Author", "Reviewers").Create(pr).Error; err != nil {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Author", "Reviewers", "Files").Create(pr).Error; err != nil {
 			if errors.Is(err, gorm.ErrDuplicatedKey) {
-				logger.Warn("pull request already exists", "error", err)
+				logger.WarnContext(ctx, "pull request already exists", "error", err)
 				return errs.PullRequestExists
 			} else if errors.Is(err, gorm.ErrForeignKeyViolated) {
-				logger.Warn("author not found", "error", err)
+				logger.WarnContext(ctx, "author not found", "error", err)
 				return errs.ResourceNotFound
 			}
-			logger.Error("failed to create pull request", "error", err)
+			logger.ErrorContext(ctx, "failed to create pull request", "error", err)
 			return err
 		}
 
@@ -45,76 +48,269 @@ func (r *PRRepository) Create(pr *models.PullRequest) error {
 			return fmt.Errorf("failed to associate reviewers with pr %s: %w", pr.Name, err)
 		}
 
+		if len(pr.Files) > 0 {
+			if err := tx.Create(&pr.Files).Error; err != nil {
+				return fmt.Errorf("failed to save changed files of pr %s: %w", pr.Name, err)
+			}
+		}
+
+		events := make([]models.PullRequestEvent, 0, len(pr.Reviewers))
+		for _, reviewer := range pr.Reviewers {
+			events = append(events, models.NewPullRequestEvent(pr, models.EventAssigned, reviewer.UserID))
+		}
+		if err := addEvents(tx, events...); err != nil {
+			logger.ErrorContext(ctx, "failed to save history", "error", err)
+			return err
+		}
+
 		return nil
 	})
 }
 
-func (r *PRRepository) Save(pr *models.PullRequest) error {
+// Save updates the pull request and its reviewers and appends the events
+// to its history.
+func (r *PRRepository) Save(ctx context.Context, pr *models.PullRequest, events ...models.PullRequestEvent) error {
 	logger := r.logger.With(
 		"method", "update_pull_request",
+		"organization_id", pr.OrganizationID,
+		"repository_id", pr.RepositoryID,
 		"pull_request_id", pr.ID,
 		"pull_request_name", pr.Name,
 	)
-	logger.Info("updating pull request")
+	logger.InfoContext(ctx, "updating pull request")
 
-	err := r.db.Save(&pr).Error
-	if err != nil {
-		logger.Error("failed to update pull request", "error", err)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := savePullRequest(tx, pr, events...); err != nil {
+			logger.ErrorContext(ctx, "failed to update pull request", "error", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// savePullRequest saves the pull request with its reviewers but without
+// its changed files, which never change, and adds the events to its
+// history.
+func savePullRequest(tx *gorm.DB, pr *models.PullRequest, events ...models.PullRequestEvent) error {
+	if err := tx.Omit("Files").Save(&pr).Error; err != nil {
 		return err
 	}
 
-	if err := r.db.Model(pr).Association("Reviewers").Replace(pr.Reviewers); err != nil {
-		logger.Error("failed to update pull request", "error", err)
+	if err := tx.Model(pr).Association("Reviewers").Replace(pr.Reviewers); err != nil {
 		return fmt.Errorf("failed to associate reviewers with pr %s: %w", pr.Name, err)
 	}
 
-	return nil
+	return addEvents(tx, events...)
 }
 
-func (r *PRRepository) Get(pullRequestID string) (*models.PullRequest, error) {
+func (r *PRRepository) Get(ctx context.Context, orgID, repositoryID, pullRequestID string) (*models.PullRequest, error) {
 	logger := r.logger.With(
 		"method", "get_pull_request",
+		"organization_id", orgID,
+		"repository_id", repositoryID,
 		"pull_request_id", pullRequestID,
 	)
-	logger.Info("getting pull request")
+	logger.InfoContext(ctx, "getting pull request")
 
 	var pr models.PullRequest
-	err := r.db.Where("pull_request_id = ?", pullRequestID).Preload("Reviewers").First(&pr).Error
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND repository_id = ? AND pull_request_id = ?", orgID, repositoryID, pullRequestID).
+		Preload("Reviewers").
+		Preload("Files").
+		First(&pr).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			logger.Warn("pull request not found", "error", err)
+			logger.WarnContext(ctx, "pull request not found", "error", err)
 			return nil, errs.ResourceNotFound
 		}
-		logger.Error("failed to get pull request", "error", err)
+		logger.ErrorContext(ctx, "failed to get pull request", "error", err)
 		return nil, err
 	}
 
 	return &pr, nil
 }
 
-func (r *PRRepository) Merge(pullRequestID string) error {
+// GetDetails returns the pull request with its changed files and the
+// details of its reviewers. Reviewers are read in one query together with
+// their users, teams and approvals.
+func (r *PRRepository) GetDetails(ctx context.Context, orgID, repositoryID, pullRequestID string) (*models.PullRequestDetails, error) {
+	logger := r.logger.With(
+		"method", "get_pull_request_details",
+		"organization_id", orgID,
+		"repository_id", repositoryID,
+		"pull_request_id", pullRequestID,
+	)
+	logger.InfoContext(ctx, "getting pull request details")
+
+	var details models.PullRequestDetails
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND repository_id = ? AND pull_request_id = ?", orgID, repositoryID, pullRequestID).
+		Preload("Files").
+		First(&details.PullRequest).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.WarnContext(ctx, "pull request not found", "error", err)
+			return nil, errs.ResourceNotFound
+		}
+		logger.ErrorContext(ctx, "failed to get pull request", "error", err)
+		return nil, err
+	}
+
+	approvals := r.db.Model(&models.PullRequestEvent{}).
+		Select("user_id, min(created_at) AS approved_at").
+		Where("repository_id = ? AND pull_request_id = ? AND event_type = ?", repositoryID, pullRequestID, models.EventApproved).
+		Group("user_id")
+
+	details.Reviewers = []models.ReviewerDetails{}
+	err = r.db.WithContext(ctx).Table("pull_request_reviewers prr").
+		Select("prr.user_id, users.username, COALESCE(teams.name, '') AS team_name, users.is_active, approvals.approved_at").
+		Joins("JOIN users ON users.user_id = prr.user_id").
+		Joins("LEFT JOIN teams ON teams.team_id = users.team_id").
+		Joins("LEFT JOIN (?) approvals ON approvals.user_id = prr.user_id", approvals).
+		Where("prr.repository_id = ? AND prr.pull_request_id = ?", repositoryID, pullRequestID).
+		Order("users.username").
+		Scan(&details.Reviewers).Error
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get reviewers", "error", err)
+		return nil, err
+	}
+
+	details.AssignedReviewers = make([]string, 0, len(details.Reviewers))
+	for _, reviewer := range details.Reviewers {
+		details.AssignedReviewers = append(details.AssignedReviewers, reviewer.UserID)
+	}
+
+	return &details, nil
+}
+
+func (r *PRRepository) Merge(ctx context.Context, orgID, repositoryID, pullRequestID string) error {
 	logger := r.logger.With(
 		"method", "merge_pull_request",
+		"organization_id", orgID,
+		"repository_id", repositoryID,
 		"pull_request_id", pullRequestID,
 	)
-	logger.Info("merging pull request")
+	logger.InfoContext(ctx, "merging pull request")
 
 	now := time.Now()
 	pr := models.PullRequest{
-		ID:       pullRequestID,
-		Status:   models.StatusMerged,
-		MergedAt: &now,
+		RepositoryID: repositoryID,
+		ID:           pullRequestID,
+		Status:       models.StatusMerged,
+		MergedAt:     &now,
 	}
 
-	err := r.db.Model(&pr).Updates(pr).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			logger.Warn("pull request not found", "error", err)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&pr).Where("organization_id = ?", orgID).Updates(pr)
+		if result.Error != nil {
+			logger.ErrorContext(ctx, "failed to merge pull request", "error", result.Error)
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			logger.WarnContext(ctx, "pull request not found")
 			return errs.ResourceNotFound
 		}
-		logger.Error("failed to merge pull request", "error", err)
-		return err
-	}
 
+		pr.OrganizationID = orgID
+		event := models.NewPullRequestEvent(&pr, models.EventMerged, "")
+		event.CreatedAt = now
+		if err := addEvents(tx, event); err != nil {
+			logger.ErrorContext(ctx, "failed to save history", "error", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Approve records the approval of the pull request by the reviewer.
+// Repeated approvals by the same reviewer are ignored.
+func (r *PRRepository) Approve(ctx context.Context, pr *models.PullRequest, reviewerID string) error {
+	logger := r.logger.With(
+		"method", "approve_pull_request",
+		"organization_id", pr.OrganizationID,
+		"repository_id", pr.RepositoryID,
+		"pull_request_id", pr.ID,
+		"reviewer_id", reviewerID,
+	)
+	logger.InfoContext(ctx, "approving pull request")
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var approvals int64
+		err := tx.Model(&models.PullRequestEvent{}).
+			Where("repository_id = ? AND pull_request_id = ?", pr.RepositoryID, pr.ID).
+			Where("event_type = ? AND user_id = ?", models.EventApproved, reviewerID).
+			Count(&approvals).Error
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to check approvals", "error", err)
+			return err
+		}
+		if approvals > 0 {
+			return nil
+		}
+
+		if err := addEvents(tx, models.NewPullRequestEvent(pr, models.EventApproved, reviewerID)); err != nil {
+			logger.ErrorContext(ctx, "failed to save history", "error", err)
+			return err
+		}
+		return nil
+	})
+}
+
+func addEvents(tx *gorm.DB, events ...models.PullRequestEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if err := tx.Create(&events).Error; err != nil {
+		return fmt.Errorf("failed to save pull request history: %w", err)
+	}
 	return nil
 }
+
+// GetByReviewerIDs returns the pull requests any of the given users
+// reviews, with their reviewers and changed files.
+func (r *PRRepository) GetByReviewerIDs(ctx context.Context, orgID string, userIDs []string) ([]models.PullRequest, error) {
+	logger := r.logger.With(
+		"method", "get_pull_requests_by_reviewer_ids",
+		"organization_id", orgID,
+		"user_ids", userIDs,
+	)
+	logger.InfoContext(ctx, "getting pull requests")
+
+	var prs []models.PullRequest
+
+	reviewed := r.db.Model(&models.PullRequestReviewer{}).
+		Select("repository_id, pull_request_id").
+		Where("user_id IN ?", userIDs)
+
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ? AND (repository_id, pull_request_id) IN (?)", orgID, reviewed).
+		Preload("Reviewers").
+		Preload("Files").
+		Order("created_at").
+		Find(&prs).Error
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get pull requests", "error", err)
+		return nil, err
+	}
+
+	return prs, nil
+}
+
+// List returns a page of the pull requests of the organization.
+func (r *PRRepository) List(ctx context.Context, orgID string, filter models.PullRequestFilter) (*models.PullRequestPage, error) {
+	logger := r.logger.With(
+		"method", "list_pull_requests",
+		"organization_id", orgID,
+		"status", filter.Status,
+		"sort", filter.Sort,
+	)
+	logger.InfoContext(ctx, "listing pull requests")
+
+	page, err := listPullRequests(r.db.WithContext(ctx), orgID, filter)
+	if errors.Is(err, errs.ValidationFailed) {
+		logger.WarnContext(ctx, "invalid listing", "error", err)
+	} else if err != nil {
+		logger.ErrorContext(ctx, "failed to list pull requests", "error", err)
+	}
+
+	return page, err
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+
+	"gorm.io/gorm"
+)
+
+type RepoRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewRepoRepository(db *gorm.DB, logger *slog.Logger) *RepoRepository {
+	return &RepoRepository{db, logger}
+}
+
+func (r *RepoRepository) Create(ctx context.Context, repo *models.Repository) error {
+	logger := r.logger.With(
+		"method", "create_repository",
+		"organization_id", repo.OrganizationID,
+		"repository", repo.Name,
+	)
+	logger.InfoContext(ctx, "creating repository")
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.resolveTeam(tx, repo); err != nil {
+			logger.WarnContext(ctx, "owning team not found", "error", err, "team_name", repo.TeamName)
+			return err
+		}
+
+		if err := tx.Omit("ID").Create(repo).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				logger.WarnContext(ctx, "repository already exists", "error", err)
+				return errs.RepositoryExists
+			}
+			logger.ErrorContext(ctx, "failed to create repository", "error", err)
+			return fmt.Errorf("failed to create repository %s: %w", repo.Name, err)
+		}
+
+		return nil
+	})
+}
+
+func (r *RepoRepository) Update(ctx context.Context, repo *models.Repository) error {
+	logger := r.logger.With(
+		"method", "update_repository",
+		"organization_id", repo.OrganizationID,
+		"repository", repo.Name,
+	)
+	logger.InfoContext(ctx, "updating repository")
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.resolveTeam(tx, repo); err != nil {
+			logger.WarnContext(ctx, "owning team not found", "error", err, "team_name", repo.TeamName)
+			return err
+		}
+
+		err := tx.Model(repo).
+			Where("organization_id = ?", repo.OrganizationID).
+			Select("TeamID", "ReviewersCount", "ClimbHierarchy").
+			Updates(repo).Error
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to update repository", "error", err)
+			return fmt.Errorf("failed to update repository %s: %w", repo.Name, err)
+		}
+
+		return nil
+	})
+}
+
+func (r *RepoRepository) GetByName(ctx context.Context, orgID, name string) (*models.Repository, error) {
+	logger := r.logger.With(
+		"method", "get_repository",
+		"organization_id", orgID,
+		"repository", name,
+	)
+	logger.InfoContext(ctx, "getting repository")
+
+	var repo models.Repository
+
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND name = ?", orgID, name).First(&repo).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.WarnContext(ctx, "repository not found", "error", err)
+			return nil, errs.ResourceNotFound
+		}
+		logger.ErrorContext(ctx, "failed to get repository", "error", err)
+		return nil, err
+	}
+
+	if repo.TeamID != nil {
+		err := r.db.WithContext(ctx).Model(&models.Team{}).
+			Select("name").
+			Where("organization_id = ? AND team_id = ?", orgID, *repo.TeamID).
+			Scan(&repo.TeamName).Error
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to get owning team", "error", err)
+			return nil, err
+		}
+	}
+
+	return &repo, nil
+}
+
+func (r *RepoRepository) resolveTeam(tx *gorm.DB, repo *models.Repository) error {
+	if repo.TeamName == "" {
+		return nil
+	}
+
+	var teamID string
+	err := tx.Model(&models.Team{}).
+		Select("team_id").
+		Where("organization_id = ? AND name = ?", repo.OrganizationID, repo.TeamName).
+		Scan(&teamID).Error
+	if err != nil {
+		return err
+	}
+	if teamID == "" {
+		return errs.ResourceNotFound
+	}
+
+	repo.TeamID = &teamID
+	return nil
+}
+
+// GetByIDs returns the repositories with the given IDs. Their owning
+// teams are not resolved.
+func (r *RepoRepository) GetByIDs(ctx context.Context, orgID string, repositoryIDs []string) ([]models.Repository, error) {
+	logger := r.logger.With(
+		"method", "get_repositories_by_ids",
+		"organization_id", orgID,
+		"repository_ids", repositoryIDs,
+	)
+	logger.InfoContext(ctx, "getting repositories")
+
+	var repos []models.Repository
+
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND repository_id IN ?", orgID, repositoryIDs).
+		Find(&repos).Error
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get repositories", "error", err)
+		return nil, err
+	}
+
+	return repos, nil
+}
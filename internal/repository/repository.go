@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"reviewers/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// OrganizationStore keeps organizations.
+type OrganizationStore interface {
+	Create(ctx context.Context, org *models.Organization) error
+	Get(ctx context.Context, orgID string) (*models.Organization, error)
+}
+
+// UserStore keeps users of organizations.
+type UserStore interface {
+	SetActiveStatus(ctx context.Context, orgID, userID string, active bool) error
+	GetReview(ctx context.Context, orgID, userID string, filter models.PullRequestFilter) (*models.PullRequestPage, error)
+	Get(ctx context.Context, orgID, userID string) (*models.User, error)
+	GetWithTeam(ctx context.Context, orgID, userID string) (*models.User, error)
+	Create(ctx context.Context, user *models.User) error
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, orgID, userID string, reassignments []models.Reassignment) error
+	Deactivate(ctx context.Context, orgID, userID string, reassignments []models.Reassignment) error
+	UpdateAndDeactivate(ctx context.Context, user *models.User, reassignments []models.Reassignment) error
+	GetActive(ctx context.Context, orgID string, userIDs ...string) ([]*models.User, error)
+	GetByIDs(ctx context.Context, orgID string, userIDs []string) ([]models.User, error)
+	GetByTeamIDs(ctx context.Context, orgID string, teamIDs []string) ([]models.User, error)
+	List(ctx context.Context, orgID, username string, offset, limit int) ([]models.User, int64, error)
+}
+
+// TeamStore keeps teams of organizations and their hierarchy.
+type TeamStore interface {
+	GetTeam(ctx context.Context, orgID, name string, withSubteams bool) (*models.Team, error)
+	CreateTeam(ctx context.Context, orgID string, team *models.Team) error
+	GetReviewerIdsFromUserTeam(ctx context.Context, orgID, userID string, excludedUsers ...string) ([]*models.User, error)
+	GetAncestorTeamIDs(ctx context.Context, orgID, userID string) ([]string, error)
+	GetReviewersFromTeamTree(ctx context.Context, orgID, teamID string, excludedUsers ...string) ([]*models.User, error)
+	DeactivateTeam(ctx context.Context, orgID, teamID string) error
+	SetParent(ctx context.Context, orgID, teamName, parentName string) error
+	GetStats(ctx context.Context, team *models.Team) (*models.TeamStats, error)
+	GetByIDs(ctx context.Context, orgID string, teamIDs []string) ([]models.Team, error)
+	GetByParentIDs(ctx context.Context, orgID string, parentIDs []string) ([]models.Team, error)
+	GetTeamByID(ctx context.Context, orgID, teamID string) (*models.Team, error)
+	ListTeams(ctx context.Context, orgID, name string, offset, limit int) ([]models.Team, int64, error)
+	EnsureTeam(ctx context.Context, orgID, name string) error
+	ReplaceTeam(ctx context.Context, orgID string, team *models.Team, fallbackName string) error
+	DeleteTeam(ctx context.Context, orgID, teamID, fallbackName string) error
+	Import(ctx context.Context, orgID string, roster *models.Roster, dryRun bool) ([]models.ImportChange, error)
+}
+
+// RepoStore keeps repositories of organizations.
+type RepoStore interface {
+	Create(ctx context.Context, repo *models.Repository) error
+	Update(ctx context.Context, repo *models.Repository) error
+	GetByName(ctx context.Context, orgID, name string) (*models.Repository, error)
+	GetByIDs(ctx context.Context, orgID string, repositoryIDs []string) ([]models.Repository, error)
+}
+
+// CodeOwnerStore keeps code owner rules of repositories.
+type CodeOwnerStore interface {
+	SetRules(ctx context.Context, repositoryID string, rules []models.CodeOwnerRule) error
+	GetRules(ctx context.Context, repositoryID string) ([]models.CodeOwnerRule, error)
+}
+
+// PRStore keeps pull requests with their reviewers, changed files and
+// history.
+type PRStore interface {
+	Create(ctx context.Context, pr *models.PullRequest) error
+	Save(ctx context.Context, pr *models.PullRequest, events ...models.PullRequestEvent) error
+	Get(ctx context.Context, orgID, repositoryID, pullRequestID string) (*models.PullRequest, error)
+	GetDetails(ctx context.Context, orgID, repositoryID, pullRequestID string) (*models.PullRequestDetails, error)
+	Merge(ctx context.Context, orgID, repositoryID, pullRequestID string) error
+	Approve(ctx context.Context, pr *models.PullRequest, reviewerID string) error
+	GetByReviewerIDs(ctx context.Context, orgID string, userIDs []string) ([]models.PullRequest, error)
+	List(ctx context.Context, orgID string, filter models.PullRequestFilter) (*models.PullRequestPage, error)
+}
+
+// StatsStore computes review statistics from the history of pull
+// requests.
+type StatsStore interface {
+	GetReviewerStats(ctx context.Context, orgID string, filter models.StatsFilter) ([]models.ReviewerStats, error)
+	GetMergeStats(ctx context.Context, orgID string, filter models.StatsFilter) (*models.MergeStats, error)
+}
+
+// BackupStore exports and restores all data of an organization.
+type BackupStore interface {
+	Export(ctx context.Context, orgID string, write func(models.BackupRecord) error) error
+	Restore(ctx context.Context, orgID string, source BackupSource) (*models.RestoreResult, error)
+}
+
+// Stores holds a store of every kind, all backed by the same storage.
+type Stores struct {
+	Organizations OrganizationStore
+	Users         UserStore
+	Teams         TeamStore
+	Repositories  RepoStore
+	CodeOwners    CodeOwnerStore
+	PullRequests  PRStore
+	Stats         StatsStore
+	Backups       BackupStore
+}
+
+// NewStores returns the stores kept in the database.
+func NewStores(db *gorm.DB, logger *slog.Logger) *Stores {
+	return &Stores{
+		Organizations: NewOrganizationRepository(db, logger),
+		Users:         NewUserRepository(db, logger),
+		Teams:         NewTeamRepository(db, logger),
+		Repositories:  NewRepoRepository(db, logger),
+		CodeOwners:    NewCodeOwnerRepository(db, logger),
+		PullRequests:  NewPRRepository(db, logger),
+		Stats:         NewStatsRepository(db, logger),
+		Backups:       NewBackupRepository(db, logger),
+	}
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"reviewers/internal/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type StatsRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewStatsRepository(db *gorm.DB, logger *slog.Logger) *StatsRepository {
+	return &StatsRepository{db, logger}
+}
+
+const reviewerStatsQuery = `
+WITH RECURSIVE tree AS (
+  SELECT team_id FROM teams WHERE organization_id = @organization_id %[1]s
+  UNION ALL
+  SELECT t.team_id FROM teams t JOIN tree ON t.parent_team_id = tree.team_id
+),
+members AS (
+  SELECT u.user_id, u.username, t.name AS team_name
+  FROM users u
+  JOIN teams t ON t.team_id = u.team_id
+  WHERE u.organization_id = @organization_id AND u.team_id IN (SELECT team_id FROM tree)
+),
+counters AS (
+  SELECT e.user_id,
+    COUNT(*) FILTER (WHERE e.event_type = 'ASSIGNED') AS assigned,
+    COUNT(*) FILTER (WHERE e.event_type = 'UNASSIGNED') AS reassigned_away,
+    COUNT(*) FILTER (WHERE e.event_type = 'APPROVED') AS approved
+  FROM pull_request_events e
+  WHERE e.organization_id = @organization_id
+    AND e.user_id IN (SELECT user_id FROM members) %[2]s
+  GROUP BY e.user_id
+),
+first_reviews AS (
+  SELECT e.user_id, EXTRACT(EPOCH FROM MIN(a.created_at) - e.created_at)::float8 AS seconds
+  FROM pull_request_events e
+  JOIN pull_request_events a ON a.repository_id = e.repository_id
+    AND a.pull_request_id = e.pull_request_id
+    AND a.user_id = e.user_id
+    AND a.event_type = 'APPROVED'
+    AND a.created_at >= e.created_at
+  WHERE e.organization_id = @organization_id
+    AND e.event_type = 'ASSIGNED'
+    AND e.user_id IN (SELECT user_id FROM members) %[2]s
+  GROUP BY e.event_id, e.user_id, e.created_at
+),
+medians AS (
+  SELECT user_id, percentile_cont(0.5) WITHIN GROUP (ORDER BY seconds) AS median_time_to_first_review
+  FROM first_reviews
+  GROUP BY user_id
+),
+open_reviews AS (
+  SELECT prr.user_id, COUNT(*) AS open_reviews
+  FROM pull_request_reviewers prr
+  JOIN pull_requests pr ON pr.repository_id = prr.repository_id AND pr.pull_request_id = prr.pull_request_id
+  WHERE pr.organization_id = @organization_id
+    AND pr.status = 'OPEN'
+    AND prr.user_id IN (SELECT user_id FROM members)
+  GROUP BY prr.user_id
+)
+SELECT m.user_id, m.username, m.team_name,
+  COALESCE(c.assigned, 0) AS assigned,
+  COALESCE(c.reassigned_away, 0) AS reassigned_away,
+  COALESCE(c.approved, 0) AS approved,
+  COALESCE(o.open_reviews, 0) AS open_reviews,
+  md.median_time_to_first_review
+FROM members m
+LEFT JOIN counters c USING (user_id)
+LEFT JOIN medians md USING (user_id)
+LEFT JOIN open_reviews o USING (user_id)
+ORDER BY m.team_name, m.username`
+
+// GetReviewerStats returns review counters of every user of the
+// organization. The team filter includes subteams of the team, the time
+// range applies to the history of pull requests.
+func (r *StatsRepository) GetReviewerStats(ctx context.Context, orgID string, filter models.StatsFilter) ([]models.ReviewerStats, error) {
+	logger := r.logger.With(
+		"method", "get_reviewer_stats",
+		"organization_id", orgID,
+		"team_name", filter.TeamName,
+		"from", filter.From,
+		"to", filter.To,
+	)
+	logger.InfoContext(ctx, "getting reviewer stats")
+
+	args := []any{sql.Named("organization_id", orgID)}
+
+	teamCondition := "AND parent_team_id IS NULL"
+	if filter.TeamName != "" {
+		teamCondition = "AND name = @team_name"
+		args = append(args, sql.Named("team_name", filter.TeamName))
+	}
+
+	var rangeConditions []string
+	if !filter.From.IsZero() {
+		rangeConditions = append(rangeConditions, "AND e.created_at >= @from")
+		args = append(args, sql.Named("from", filter.From))
+	}
+	if !filter.To.IsZero() {
+		rangeConditions = append(rangeConditions, "AND e.created_at < @to")
+		args = append(args, sql.Named("to", filter.To))
+	}
+
+	query := fmt.Sprintf(reviewerStatsQuery, teamCondition, strings.Join(rangeConditions, " "))
+
+	stats := []models.ReviewerStats{}
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&stats).Error; err != nil {
+		logger.ErrorContext(ctx, "failed to get reviewer stats", "error", err)
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// GetMergeStats returns how fast pull requests authored by members of the
+// team and its subteams get merged. The time range applies to merge time.
+func (r *StatsRepository) GetMergeStats(ctx context.Context, orgID string, filter models.StatsFilter) (*models.MergeStats, error) {
+	logger := r.logger.With(
+		"method", "get_merge_stats",
+		"organization_id", orgID,
+		"team_name", filter.TeamName,
+		"from", filter.From,
+		"to", filter.To,
+	)
+	logger.InfoContext(ctx, "getting merge stats")
+
+	args := []any{
+		sql.Named("organization_id", orgID),
+		sql.Named("team_name", filter.TeamName),
+	}
+
+	var rangeConditions []string
+	if !filter.From.IsZero() {
+		rangeConditions = append(rangeConditions, "AND pr.merged_at >= @from")
+		args = append(args, sql.Named("from", filter.From))
+	}
+	if !filter.To.IsZero() {
+		rangeConditions = append(rangeConditions, "AND pr.merged_at < @to")
+		args = append(args, sql.Named("to", filter.To))
+	}
+
+	query := fmt.Sprintf(`
+WITH RECURSIVE tree AS (
+  SELECT team_id FROM teams WHERE organization_id = @organization_id AND name = @team_name
+  UNION ALL
+  SELECT t.team_id FROM teams t JOIN tree ON t.parent_team_id = tree.team_id
+)
+SELECT COUNT(*) AS merged,
+  AVG(EXTRACT(EPOCH FROM pr.merged_at - pr.created_at))::float8 AS mean_time_to_merge
+FROM pull_requests pr
+JOIN users u ON u.user_id = pr.author_id
+WHERE pr.organization_id = @organization_id
+  AND pr.merged_at IS NOT NULL
+  AND u.team_id IN (SELECT team_id FROM tree) %s`, strings.Join(rangeConditions, " "))
+
+	var stats models.MergeStats
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&stats).Error; err != nil {
+		logger.ErrorContext(ctx, "failed to get merge stats", "error", err)
+		return nil, err
+	}
+
+	return &stats, nil
+}
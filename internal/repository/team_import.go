@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+	"slices"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// importPlan holds the changes of an import before they are saved.
+type importPlan struct {
+	changes []models.ImportChange
+	fields  []errs.FieldError
+
+	teams       map[string]*models.Team // by name
+	teamsByID   map[string]*models.Team
+	newTeams    []*models.Team
+	reparented  []*models.Team
+	newUsers    []*models.User
+	changedUser []*models.User
+}
+
+// Import creates the teams and users of the roster, moves users between
+// teams and updates their parents, emails and activity in one
+// transaction. Users and teams missing from the roster are kept as they
+// are. With dryRun the changes are returned but not saved.
+func (r *TeamRepository) Import(ctx context.Context, orgID string, roster *models.Roster, dryRun bool) ([]models.ImportChange, error) {
+	logger := r.logger.With(
+		"method", "import_roster",
+		"organization_id", orgID,
+		"teams", len(roster.Teams),
+		"members", len(roster.Members),
+		"dry_run", dryRun,
+	)
+	logger.InfoContext(ctx, "importing roster")
+
+	var plan *importPlan
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if plan, err = planImport(tx, orgID, roster); err != nil {
+			logger.ErrorContext(ctx, "failed to plan import", "error", err)
+			return err
+		}
+		if len(plan.fields) > 0 {
+			logger.WarnContext(ctx, "invalid roster", "errors", len(plan.fields))
+			return errs.NewValidationError(plan.fields...)
+		}
+		if dryRun {
+			return nil
+		}
+
+		if err := plan.apply(tx); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				logger.WarnContext(ctx, "team or user created concurrently", "error", err)
+				return errs.UserExists.WithMessage("a team or user of the roster has been created concurrently")
+			}
+			logger.ErrorContext(ctx, "failed to import roster", "error", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.changes, nil
+}
+
+func planImport(tx *gorm.DB, orgID string, roster *models.Roster) (*importPlan, error) {
+	var teams []models.Team
+	if err := tx.Where("organization_id = ?", orgID).Find(&teams).Error; err != nil {
+		return nil, fmt.Errorf("failed to get teams: %w", err)
+	}
+
+	usernames := rosterUsernames(roster)
+	var users []models.User
+	if len(usernames) > 0 {
+		if err := tx.Where("organization_id = ? AND username IN ?", orgID, usernames).Find(&users).Error; err != nil {
+			return nil, fmt.Errorf("failed to get users: %w", err)
+		}
+	}
+
+	return newImportPlan(orgID, roster, teams, users), nil
+}
+
+func rosterUsernames(roster *models.Roster) []string {
+	usernames := make([]string, 0, len(roster.Members))
+	for _, member := range roster.Members {
+		usernames = append(usernames, member.Username)
+	}
+	return usernames
+}
+
+// newImportPlan plans the import of the roster into the organization
+// with the given teams and the users of the roster that already exist.
+// The plan takes the teams and users over and changes them.
+func newImportPlan(orgID string, roster *models.Roster, teams []models.Team, users []models.User) *importPlan {
+	plan := &importPlan{
+		changes:   []models.ImportChange{},
+		teams:     map[string]*models.Team{},
+		teamsByID: map[string]*models.Team{},
+	}
+	for i := range teams {
+		plan.teams[teams[i].Name] = &teams[i]
+		plan.teamsByID[teams[i].ID] = &teams[i]
+	}
+
+	usersByName := make(map[string]*models.User, len(users))
+	for i := range users {
+		usersByName[users[i].Username] = &users[i]
+	}
+
+	created := map[string]int{} // indices of the create_team changes by team name
+	for _, entry := range roster.Teams {
+		if _, ok := plan.teams[entry.Name]; ok {
+			continue
+		}
+		team := &models.Team{ID: uuid.New().String(), OrganizationID: orgID, Name: entry.Name}
+		plan.teams[team.Name] = team
+		plan.teamsByID[team.ID] = team
+		plan.newTeams = append(plan.newTeams, team)
+
+		created[team.Name] = len(plan.changes)
+		plan.changes = append(plan.changes, models.ImportChange{Line: entry.Line, Action: models.ImportCreateTeam, TeamName: team.Name})
+	}
+
+	for _, entry := range roster.Teams {
+		if entry.ParentName == "" {
+			continue
+		}
+		parent, ok := plan.teams[entry.ParentName]
+		if !ok {
+			plan.fail(entry.Line, "parent_team_name", "team not found")
+			continue
+		}
+
+		team := plan.teams[entry.Name]
+		if team.ParentID != nil && *team.ParentID == parent.ID {
+			continue
+		}
+		team.ParentID = &parent.ID
+		plan.reparented = append(plan.reparented, team)
+
+		if i, ok := created[team.Name]; ok {
+			plan.changes[i].ParentTeamName = parent.Name
+		} else {
+			plan.changes = append(plan.changes, models.ImportChange{
+				Line:           entry.Line,
+				Action:         models.ImportSetParent,
+				TeamName:       team.Name,
+				ParentTeamName: parent.Name,
+			})
+		}
+	}
+
+	// The parents form a tree only if no team is its own ancestor
+	for _, team := range plan.reparented {
+		ancestor := team.ParentID
+		for steps := 0; ancestor != nil && steps <= len(plan.teamsByID); steps++ {
+			if *ancestor == team.ID {
+				line := slices.IndexFunc(roster.Teams, func(entry models.RosterTeam) bool { return entry.Name == team.Name })
+				plan.fail(roster.Teams[line].Line, "parent_team_name", errs.InvalidHierarchy.Message)
+				break
+			}
+			ancestor = plan.teamsByID[*ancestor].ParentID
+		}
+	}
+
+	for _, member := range roster.Members {
+		team := plan.teams[member.TeamName]
+		user, ok := usersByName[member.Username]
+		if !ok {
+			user = &models.User{
+				ID:             uuid.New().String(),
+				OrganizationID: orgID,
+				Username:       member.Username,
+				Email:          member.Email,
+				IsActive:       member.IsActive,
+				TeamID:         team.ID,
+			}
+			plan.newUsers = append(plan.newUsers, user)
+			plan.changes = append(plan.changes, models.ImportChange{
+				Line:     member.Line,
+				Action:   models.ImportCreateUser,
+				TeamName: team.Name,
+				Username: user.Username,
+			})
+			continue
+		}
+
+		change := models.ImportChange{Line: member.Line, TeamName: team.Name, Username: user.Username}
+		changed := false
+		if user.TeamID != team.ID {
+			change.Action = models.ImportMoveUser
+			if oldTeam, ok := plan.teamsByID[user.TeamID]; ok {
+				change.OldTeamName = oldTeam.Name
+			}
+			plan.changes = append(plan.changes, change)
+			user.TeamID = team.ID
+			changed = true
+		}
+		if member.Email != "" && member.Email != user.Email {
+			change.Action, change.OldTeamName = models.ImportUpdateEmail, ""
+			plan.changes = append(plan.changes, change)
+			user.Email = member.Email
+			changed = true
+		}
+		if member.IsActive != user.IsActive {
+			change.Action, change.OldTeamName = models.ImportDeactivateUser, ""
+			if member.IsActive {
+				change.Action = models.ImportActivateUser
+			}
+			plan.changes = append(plan.changes, change)
+			user.IsActive = member.IsActive
+			changed = true
+		}
+		if changed {
+			plan.changedUser = append(plan.changedUser, user)
+		}
+	}
+
+	slices.SortStableFunc(plan.changes, func(a, b models.ImportChange) int {
+		return cmp.Compare(a.Line, b.Line)
+	})
+	slices.SortStableFunc(plan.fields, func(a, b errs.FieldError) int {
+		return cmp.Compare(a.Line, b.Line)
+	})
+	return plan
+}
+
+func (p *importPlan) fail(line int, field, message string) {
+	p.fields = append(p.fields, errs.FieldError{Line: line, Field: field, Message: message})
+}
+
+func (p *importPlan) apply(tx *gorm.DB) error {
+	// Teams are created without parents, as a parent may be created
+	// after its subteam
+	if len(p.newTeams) > 0 {
+		if err := tx.Select("ID", "OrganizationID", "Name").Create(p.newTeams).Error; err != nil {
+			return fmt.Errorf("failed to create teams: %w", err)
+		}
+	}
+	for _, team := range p.reparented {
+		err := tx.Model(&models.Team{}).
+			Where("team_id = ?", team.ID).
+			Update("parent_team_id", team.ParentID).Error
+		if err != nil {
+			return fmt.Errorf("failed to set parent of team %s: %w", team.Name, err)
+		}
+	}
+
+	if len(p.newUsers) > 0 {
+		if err := tx.Create(p.newUsers).Error; err != nil {
+			return fmt.Errorf("failed to create users: %w", err)
+		}
+	}
+	for _, user := range p.changedUser {
+		err := tx.Model(user).
+			Select("TeamID", "Email", "IsActive").
+			Updates(user).Error
+		if err != nil {
+			return fmt.Errorf("failed to update user %s: %w", user.Username, err)
+		}
+	}
+
+	return nil
+}
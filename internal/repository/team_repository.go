@@ -1,14 +1,19 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
-	"reviewers/internal/errs"
+	"reviewers/internal/metrics"
 	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+	"slices"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TeamRepository struct {
@@ -20,41 +25,129 @@ func NewTeamRepository(db *gorm.DB, logger *slog.Logger) *TeamRepository {
 	return &TeamRepository{db, logger}
 }
 
-func (r *TeamRepository) GetTeam(name string) (*models.Team, error) {
+func (r *TeamRepository) GetTeam(ctx context.Context, orgID, name string, withSubteams bool) (*models.Team, error) {
 	logger := r.logger.With(
 		"method", "get_team",
+		"organization_id", orgID,
 		"team_name", name,
 	)
-	logger.Info("getting team")
+	logger.InfoContext(ctx, "getting team")
 
 	var team models.Team
 
-	err := r.db.Where("name = ?", name).Preload("Members").First(&team).Error
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND name = ?", orgID, name).Preload("Members").First(&team).Error
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		logger.Warn("team not found", "error", err)
+		logger.WarnContext(ctx, "team not found", "error", err)
 		return &team, errs.ResourceNotFound
+	} else if err != nil {
+		logger.ErrorContext(ctx, "failed to get team", "error", err)
+		return &team, err
 	}
 
-	return &team, err
+	if team.ParentID != nil {
+		err := r.db.WithContext(ctx).Model(&models.Team{}).
+			Select("name").
+			Where("organization_id = ? AND team_id = ?", orgID, *team.ParentID).
+			Scan(&team.ParentName).Error
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to get parent team", "error", err)
+			return &team, err
+		}
+	}
+
+	if withSubteams {
+		if err := r.loadSubteams(ctx, &team); err != nil {
+			logger.ErrorContext(ctx, "failed to get subteams", "error", err)
+			return &team, err
+		}
+	}
+
+	return &team, nil
+}
+
+const subtreeQuery = `
+WITH RECURSIVE tree AS (
+  SELECT team_id FROM teams WHERE parent_team_id = @team_id
+  UNION ALL
+  SELECT t.team_id FROM teams t JOIN tree ON t.parent_team_id = tree.team_id
+)
+SELECT team_id FROM tree`
+
+const ancestorsQuery = `
+WITH RECURSIVE tree AS (
+  SELECT parent_team_id AS team_id, 1 AS depth FROM teams WHERE team_id = @team_id
+  UNION ALL
+  SELECT t.parent_team_id, tree.depth + 1 FROM teams t JOIN tree ON t.team_id = tree.team_id
+)
+SELECT team_id FROM tree WHERE team_id IS NOT NULL ORDER BY depth`
+
+// loadSubteams fills Subteams of the team with all its descendants
+// (including their members) down to the leaves.
+func (r *TeamRepository) loadSubteams(ctx context.Context, team *models.Team) error {
+	var ids []string
+	if err := r.db.WithContext(ctx).Raw(subtreeQuery, sql.Named("team_id", team.ID)).Scan(&ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var descendants []models.Team
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND team_id IN ?", team.OrganizationID, ids).
+		Preload("Members").
+		Order("name").
+		Find(&descendants).Error
+	if err != nil {
+		return err
+	}
+
+	children := make(map[string][]models.Team)
+	for _, t := range descendants {
+		children[*t.ParentID] = append(children[*t.ParentID], t)
+	}
+
+	var attach func(t *models.Team)
+	attach = func(t *models.Team) {
+		t.Subteams = children[t.ID]
+		for i := range t.Subteams {
+			t.Subteams[i].ParentName = t.Name
+			attach(&t.Subteams[i])
+		}
+	}
+	attach(team)
+
+	return nil
 }
 
-func (r *TeamRepository) CreateTeam(team *models.Team) error {
+func (r *TeamRepository) CreateTeam(ctx context.Context, orgID string, team *models.Team) error {
 	logger := r.logger.With(
 		"method", "create_team",
+		"organization_id", orgID,
 		"team_name", team.Name,
 	)
-	logger.Info("creating team")
+	logger.InfoContext(ctx, "creating team")
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Resolve parent team
+		if team.ParentName != "" {
+			parentID, err := r.getTeamID(tx, orgID, team.ParentName)
+			if err != nil {
+				logger.WarnContext(ctx, "parent team not found", "error", err, "parent_team_name", team.ParentName)
+				return err
+			}
+			team.ParentID = &parentID
+		}
 
-	return r.db.Transaction(func(tx *gorm.DB) error {
 		// Create team
 		team.ID = uuid.New().String()
-		if err := tx.Select("ID", "Name").Create(team).Error; err != nil {
+		team.OrganizationID = orgID
+		if err := tx.Select("ID", "OrganizationID", "Name", "ParentID").Create(team).Error; err != nil {
 			if errors.Is(err, gorm.ErrDuplicatedKey) {
-				logger.Warn("team already exists", "error", err)
+				logger.WarnContext(ctx, "team already exists", "error", err)
 				return errs.TeamExists
 			}
-			logger.Error("failed to create team", "error", err)
+			logger.ErrorContext(ctx, "failed to create team", "error", err)
 			return fmt.Errorf("failed to create team %s: %w", team.Name, err)
 		}
 
@@ -65,19 +158,33 @@ func (r *TeamRepository) CreateTeam(team *models.Team) error {
 			user.TeamID = team.ID
 
 			if user.ID != "" {
-				if err := tx.Model(&user).Updates(&user).Error; err != nil {
-					logger.Error("failed to update user", "error", err, "user_id", user.ID)
-					return fmt.Errorf("failed to update user %s: %w", user.ID, err)
+				result := tx.Model(&user).Where("organization_id = ?", orgID).Updates(&user)
+				if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+					logger.WarnContext(ctx, "username is taken", "error", result.Error, "user_id", user.ID)
+					return errs.UserExists
+				}
+				if result.Error != nil {
+					logger.ErrorContext(ctx, "failed to update user", "error", result.Error, "user_id", user.ID)
+					return fmt.Errorf("failed to update user %s: %w", user.ID, result.Error)
+				}
+				if result.RowsAffected == 0 {
+					logger.WarnContext(ctx, "user not found", "user_id", user.ID)
+					return errs.ResourceNotFound
 				}
 			} else {
 				user.ID = uuid.New().String()
+				user.OrganizationID = orgID
 				newUsers = append(newUsers, user)
 			}
 		}
 
 		if len(newUsers) > 0 {
 			if err := tx.Create(newUsers).Error; err != nil {
-				logger.Error("failed to create users", "error", err)
+				if errors.Is(err, gorm.ErrDuplicatedKey) {
+					logger.WarnContext(ctx, "users already exist", "error", err)
+					return errs.UserExists
+				}
+				logger.ErrorContext(ctx, "failed to create users", "error", err)
 				return fmt.Errorf("failed to create users: %w", err)
 			}
 		}
@@ -86,12 +193,13 @@ func (r *TeamRepository) CreateTeam(team *models.Team) error {
 	})
 }
 
-func (r *TeamRepository) GetReviewerIdsFromUserTeam(userID string, excludedUsers ...string) ([]*models.User, error) {
+func (r *TeamRepository) GetReviewerIdsFromUserTeam(ctx context.Context, orgID, userID string, excludedUsers ...string) ([]*models.User, error) {
 	logger := r.logger.With(
 		"method", "get_reviewers_from_same_team",
+		"organization_id", orgID,
 		"user_id", userID,
 	)
-	logger.Info("getting reviewers from the same team")
+	logger.InfoContext(ctx, "getting reviewers from the same team")
 
 	var reviewers []*models.User
 
@@ -99,39 +207,501 @@ func (r *TeamRepository) GetReviewerIdsFromUserTeam(userID string, excludedUsers
 	excludedIds = append(excludedIds, excludedUsers...)
 	excludedIds = append(excludedIds, userID)
 
-	query := r.db.Model(&models.User{}).
+	query := r.db.WithContext(ctx).Model(&models.User{}).
 		Where("team_id = (?)", r.db.Model(&models.User{}).
 			Select("team_id").
-			Where("user_id = ?", userID).
+			Where("organization_id = ? AND user_id = ?", orgID, userID).
 			Limit(1),
 		).
+		Where("organization_id = ?", orgID).
 		Where("is_active = true").
 		Where("user_id NOT IN ?", excludedIds)
 
 	err := query.Find(&reviewers).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			logger.Warn("team not found", "error", err)
+			logger.WarnContext(ctx, "team not found", "error", err)
 			return nil, errs.ResourceNotFound
 		}
-		logger.Error("failed to find users from team", "error", err)
+		logger.ErrorContext(ctx, "failed to find users from team", "error", err)
 		return nil, fmt.Errorf("failed to find users from team: %s", err.Error())
 	}
 
+	metrics.CandidatePoolSize.Observe(float64(len(reviewers)))
 	return reviewers, nil
 }
 
-func (r *TeamRepository) DeactivateTeam(teamID string) error {
+// GetAncestorTeamIDs returns IDs of all teams above the team of the user,
+// starting from the closest one.
+func (r *TeamRepository) GetAncestorTeamIDs(ctx context.Context, orgID, userID string) ([]string, error) {
+	logger := r.logger.With(
+		"method", "get_ancestor_teams",
+		"organization_id", orgID,
+		"user_id", userID,
+	)
+	logger.InfoContext(ctx, "getting ancestor teams")
+
+	var teamID string
+	err := r.db.WithContext(ctx).Model(&models.User{}).
+		Select("team_id").
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Scan(&teamID).Error
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get user team", "error", err)
+		return nil, err
+	}
+	if teamID == "" {
+		logger.WarnContext(ctx, "user not found")
+		return nil, errs.ResourceNotFound
+	}
+
+	var ids []string
+	if err := r.db.WithContext(ctx).Raw(ancestorsQuery, sql.Named("team_id", teamID)).Scan(&ids).Error; err != nil {
+		logger.ErrorContext(ctx, "failed to get ancestor teams", "error", err)
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// GetReviewersFromTeamTree returns active users of the team and all its
+// subteams except the excluded ones.
+func (r *TeamRepository) GetReviewersFromTeamTree(ctx context.Context, orgID, teamID string, excludedUsers ...string) ([]*models.User, error) {
+	logger := r.logger.With(
+		"method", "get_reviewers_from_team_tree",
+		"organization_id", orgID,
+		"team_id", teamID,
+	)
+	logger.InfoContext(ctx, "getting reviewers from team tree")
+
+	var reviewers []*models.User
+
+	query := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("team_id = ? OR team_id IN (?)", teamID, r.db.Raw(subtreeQuery, sql.Named("team_id", teamID))).
+		Where("organization_id = ?", orgID).
+		Where("is_active = true")
+	if len(excludedUsers) > 0 {
+		query = query.Where("user_id NOT IN ?", excludedUsers)
+	}
+
+	if err := query.Find(&reviewers).Error; err != nil {
+		logger.ErrorContext(ctx, "failed to find users from team tree", "error", err)
+		return nil, fmt.Errorf("failed to find users from team tree: %w", err)
+	}
+
+	return reviewers, nil
+}
+
+func (r *TeamRepository) DeactivateTeam(ctx context.Context, orgID, teamID string) error {
 	logger := r.logger.With(
 		"method", "deactivate_team",
+		"organization_id", orgID,
 		"team_id", teamID,
 	)
-	logger.Info("deactivating team")
+	logger.InfoContext(ctx, "deactivating team")
 
-	err := r.db.Model(&models.User{}).Where("team_id = ?", teamID).Update("is_active", false).Error
+	err := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("organization_id = ? AND team_id = ?", orgID, teamID).
+		Update("is_active", false).Error
 	if err != nil {
-		logger.Error("failed to deactivate team", "error", err)
+		logger.ErrorContext(ctx, "failed to deactivate team", "error", err)
 	}
 
 	return err
 }
+
+func (r *TeamRepository) SetParent(ctx context.Context, orgID, teamName, parentName string) error {
+	logger := r.logger.With(
+		"method", "set_parent_team",
+		"organization_id", orgID,
+		"team_name", teamName,
+		"parent_team_name", parentName,
+	)
+	logger.InfoContext(ctx, "setting parent team")
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		teamID, err := r.getTeamID(tx, orgID, teamName)
+		if err != nil {
+			logger.WarnContext(ctx, "team not found", "error", err)
+			return err
+		}
+
+		var parentID *string
+		if parentName != "" {
+			id, err := r.getTeamID(tx, orgID, parentName)
+			if err != nil {
+				logger.WarnContext(ctx, "parent team not found", "error", err)
+				return err
+			}
+
+			var subtree []string
+			if err := tx.Raw(subtreeQuery, sql.Named("team_id", teamID)).Scan(&subtree).Error; err != nil {
+				logger.ErrorContext(ctx, "failed to get subteams", "error", err)
+				return err
+			}
+			if id == teamID || slices.Contains(subtree, id) {
+				logger.WarnContext(ctx, "parent team is a subteam of the team")
+				return errs.InvalidHierarchy
+			}
+			parentID = &id
+		}
+
+		err = tx.Model(&models.Team{}).
+			Where("organization_id = ? AND team_id = ?", orgID, teamID).
+			Update("parent_team_id", parentID).Error
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to set parent team", "error", err)
+		}
+		return err
+	})
+}
+
+// GetStats returns review counters of the team and all its subteams.
+// Counters of every team are rolled up from its subteams.
+func (r *TeamRepository) GetStats(ctx context.Context, team *models.Team) (*models.TeamStats, error) {
+	logger := r.logger.With(
+		"method", "get_team_stats",
+		"organization_id", team.OrganizationID,
+		"team_name", team.Name,
+	)
+	logger.InfoContext(ctx, "getting team stats")
+
+	var rows []models.TeamStats
+	err := r.db.WithContext(ctx).Raw(`
+WITH RECURSIVE tree AS (
+  SELECT team_id FROM teams WHERE team_id = @team_id
+  UNION ALL
+  SELECT t.team_id FROM teams t JOIN tree ON t.parent_team_id = tree.team_id
+)
+SELECT tree.team_id,
+  COUNT(DISTINCT u.user_id) AS members,
+  COUNT(DISTINCT u.user_id) FILTER (WHERE u.is_active) AS active_members,
+  COUNT(prr.pull_request_id) AS assigned_reviews,
+  COUNT(prr.pull_request_id) FILTER (WHERE pr.status = 'OPEN') AS open_reviews
+FROM tree
+LEFT JOIN users u ON u.team_id = tree.team_id AND u.organization_id = @organization_id
+LEFT JOIN pull_request_reviewers prr ON prr.user_id = u.user_id
+LEFT JOIN pull_requests pr ON pr.repository_id = prr.repository_id AND pr.pull_request_id = prr.pull_request_id
+  AND pr.organization_id = @organization_id
+GROUP BY tree.team_id`,
+		sql.Named("team_id", team.ID),
+		sql.Named("organization_id", team.OrganizationID),
+	).Scan(&rows).Error
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get team stats", "error", err)
+		return nil, err
+	}
+
+	own := make(map[string]models.TeamStats, len(rows))
+	for _, row := range rows {
+		own[row.TeamID] = row
+	}
+
+	var rollUp func(t *models.Team) models.TeamStats
+	rollUp = func(t *models.Team) models.TeamStats {
+		stats := own[t.ID]
+		stats.TeamID = t.ID
+		stats.TeamName = t.Name
+		for i := range t.Subteams {
+			sub := rollUp(&t.Subteams[i])
+			stats.Members += sub.Members
+			stats.ActiveMembers += sub.ActiveMembers
+			stats.AssignedReviews += sub.AssignedReviews
+			stats.OpenReviews += sub.OpenReviews
+			stats.Subteams = append(stats.Subteams, sub)
+		}
+		return stats
+	}
+	stats := rollUp(team)
+
+	return &stats, nil
+}
+
+func (r *TeamRepository) getTeamID(tx *gorm.DB, orgID, name string) (string, error) {
+	var teamID string
+	err := tx.Model(&models.Team{}).
+		Select("team_id").
+		Where("organization_id = ? AND name = ?", orgID, name).
+		Scan(&teamID).Error
+	if err != nil {
+		return "", err
+	}
+	if teamID == "" {
+		return "", errs.ResourceNotFound
+	}
+	return teamID, nil
+}
+
+// GetByIDs returns the teams with the given IDs without their members.
+func (r *TeamRepository) GetByIDs(ctx context.Context, orgID string, teamIDs []string) ([]models.Team, error) {
+	logger := r.logger.With(
+		"method", "get_teams_by_ids",
+		"organization_id", orgID,
+		"team_ids", teamIDs,
+	)
+	logger.InfoContext(ctx, "getting teams")
+
+	var teams []models.Team
+
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND team_id IN ?", orgID, teamIDs).
+		Find(&teams).Error
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get teams", "error", err)
+		return nil, err
+	}
+
+	return teams, nil
+}
+
+// GetByParentIDs returns the direct subteams of the given teams without
+// their members.
+func (r *TeamRepository) GetByParentIDs(ctx context.Context, orgID string, parentIDs []string) ([]models.Team, error) {
+	logger := r.logger.With(
+		"method", "get_teams_by_parent_ids",
+		"organization_id", orgID,
+		"parent_team_ids", parentIDs,
+	)
+	logger.InfoContext(ctx, "getting subteams")
+
+	var teams []models.Team
+
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND parent_team_id IN ?", orgID, parentIDs).
+		Order("name").
+		Find(&teams).Error
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get subteams", "error", err)
+		return nil, err
+	}
+
+	return teams, nil
+}
+
+// GetTeamByID returns the team with its members.
+func (r *TeamRepository) GetTeamByID(ctx context.Context, orgID, teamID string) (*models.Team, error) {
+	logger := r.logger.With(
+		"method", "get_team_by_id",
+		"organization_id", orgID,
+		"team_id", teamID,
+	)
+	logger.InfoContext(ctx, "getting team")
+
+	var team models.Team
+
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND team_id = ?", orgID, teamID).
+		Preload("Members", func(tx *gorm.DB) *gorm.DB { return tx.Order("username") }).
+		First(&team).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.WarnContext(ctx, "team not found", "error", err)
+		return nil, errs.ResourceNotFound
+	} else if err != nil {
+		logger.ErrorContext(ctx, "failed to get team", "error", err)
+		return nil, err
+	}
+
+	return &team, nil
+}
+
+// ListTeams returns a page of teams with their members ordered by name
+// and the number of teams on all pages. A non-empty name selects the
+// team with it regardless of case.
+func (r *TeamRepository) ListTeams(ctx context.Context, orgID, name string, offset, limit int) ([]models.Team, int64, error) {
+	logger := r.logger.With(
+		"method", "list_teams",
+		"organization_id", orgID,
+		"team_name", name,
+	)
+	logger.InfoContext(ctx, "listing teams")
+
+	matching := func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Where("organization_id = ?", orgID)
+		if name != "" {
+			tx = tx.Where("LOWER(name) = LOWER(?)", name)
+		}
+		return tx
+	}
+
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.Team{}).Scopes(matching).Count(&total).Error; err != nil {
+		logger.ErrorContext(ctx, "failed to count teams", "error", err)
+		return nil, 0, err
+	}
+
+	teams := []models.Team{}
+	err := r.db.WithContext(ctx).Scopes(matching).
+		Preload("Members", func(tx *gorm.DB) *gorm.DB { return tx.Order("username") }).
+		Order("name").
+		Offset(offset).
+		Limit(limit).
+		Find(&teams).Error
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to list teams", "error", err)
+		return nil, 0, err
+	}
+
+	return teams, total, nil
+}
+
+// EnsureTeam creates the team unless it exists.
+func (r *TeamRepository) EnsureTeam(ctx context.Context, orgID, name string) error {
+	logger := r.logger.With(
+		"method", "ensure_team",
+		"organization_id", orgID,
+		"team_name", name,
+	)
+	logger.InfoContext(ctx, "ensuring team")
+
+	if _, err := r.ensureTeam(r.db.WithContext(ctx), orgID, name); err != nil {
+		logger.ErrorContext(ctx, "failed to create team", "error", err)
+		return err
+	}
+	return nil
+}
+
+// ReplaceTeam renames the team and makes the given users its only
+// members. Users that leave the team are moved to the fallback team,
+// which is created if needed.
+func (r *TeamRepository) ReplaceTeam(ctx context.Context, orgID string, team *models.Team, fallbackName string) error {
+	logger := r.logger.With(
+		"method", "replace_team",
+		"organization_id", orgID,
+		"team_id", team.ID,
+		"team_name", team.Name,
+	)
+	logger.InfoContext(ctx, "replacing team")
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Team{}).
+			Where("organization_id = ? AND team_id = ?", orgID, team.ID).
+			Update("name", team.Name)
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			logger.WarnContext(ctx, "team already exists", "error", result.Error)
+			return errs.TeamExists
+		}
+		if result.Error != nil {
+			logger.ErrorContext(ctx, "failed to rename team", "error", result.Error)
+			return fmt.Errorf("failed to rename team %s: %w", team.ID, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			logger.WarnContext(ctx, "team not found")
+			return errs.ResourceNotFound
+		}
+
+		memberIDs := make([]string, 0, len(team.Members))
+		for _, member := range team.Members {
+			memberIDs = append(memberIDs, member.ID)
+		}
+		if err := moveUsers(tx, orgID, memberIDs, team.ID); err != nil {
+			if errors.Is(err, errs.ResourceNotFound) {
+				logger.WarnContext(ctx, "member not found", "error", err)
+			} else {
+				logger.ErrorContext(ctx, "failed to move members", "error", err)
+			}
+			return err
+		}
+
+		leaving := tx.Model(&models.User{}).Where("organization_id = ? AND team_id = ?", orgID, team.ID)
+		if len(memberIDs) > 0 {
+			leaving = leaving.Where("user_id NOT IN ?", memberIDs)
+		}
+		var leavingIDs []string
+		if err := leaving.Pluck("user_id", &leavingIDs).Error; err != nil {
+			logger.ErrorContext(ctx, "failed to get leaving members", "error", err)
+			return err
+		}
+		if len(leavingIDs) == 0 {
+			return nil
+		}
+
+		fallbackID, err := r.ensureTeam(tx, orgID, fallbackName)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to create fallback team", "error", err)
+			return err
+		}
+		if err := moveUsers(tx, orgID, leavingIDs, fallbackID); err != nil {
+			logger.ErrorContext(ctx, "failed to move leaving members", "error", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// DeleteTeam deletes the team after moving its members to the fallback
+// team, which is created if needed. Subteams of the team become top-level
+// teams. The fallback team itself cannot be deleted.
+func (r *TeamRepository) DeleteTeam(ctx context.Context, orgID, teamID, fallbackName string) error {
+	logger := r.logger.With(
+		"method", "delete_team",
+		"organization_id", orgID,
+		"team_id", teamID,
+	)
+	logger.InfoContext(ctx, "deleting team")
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		fallbackID, err := r.ensureTeam(tx, orgID, fallbackName)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to create fallback team", "error", err)
+			return err
+		}
+		if fallbackID == teamID {
+			logger.WarnContext(ctx, "deleting fallback team")
+			return errs.NewValidationError(errs.FieldError{
+				Field:   "id",
+				Message: fmt.Sprintf("is the team %s, which cannot be deleted", fallbackName),
+			})
+		}
+
+		err = tx.Model(&models.User{}).
+			Where("organization_id = ? AND team_id = ?", orgID, teamID).
+			Update("team_id", fallbackID).Error
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to move members", "error", err)
+			return fmt.Errorf("failed to move members of team %s: %w", teamID, err)
+		}
+
+		result := tx.Where("organization_id = ? AND team_id = ?", orgID, teamID).Delete(&models.Team{})
+		if result.Error != nil {
+			logger.ErrorContext(ctx, "failed to delete team", "error", result.Error)
+			return fmt.Errorf("failed to delete team %s: %w", teamID, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			logger.WarnContext(ctx, "team not found")
+			return errs.ResourceNotFound
+		}
+
+		return nil
+	})
+}
+
+// ensureTeam returns the ID of the team, creating the team if it does not
+// exist.
+func (r *TeamRepository) ensureTeam(tx *gorm.DB, orgID, name string) (string, error) {
+	team := models.Team{ID: uuid.New().String(), OrganizationID: orgID, Name: name}
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Select("ID", "OrganizationID", "Name").
+		Create(&team).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to create team %s: %w", name, err)
+	}
+	return r.getTeamID(tx, orgID, name)
+}
+
+// moveUsers moves the users to the team. If any of the users does not
+// exist, errs.ResourceNotFound is returned.
+func moveUsers(tx *gorm.DB, orgID string, userIDs []string, teamID string) error {
+	slices.Sort(userIDs)
+	userIDs = slices.Compact(userIDs)
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	result := tx.Model(&models.User{}).
+		Where("organization_id = ? AND user_id IN ?", orgID, userIDs).
+		Update("team_id", teamID)
+	if result.Error != nil {
+		return fmt.Errorf("failed to move users to team %s: %w", teamID, result.Error)
+	}
+	if result.RowsAffected != int64(len(userIDs)) {
+		return errs.ResourceNotFound.WithMessage("user not found")
+	}
+	return nil
+}
@@ -1,12 +1,17 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log/slog"
-	"reviewers/internal/errs"
 	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+	"strings"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository struct {
@@ -18,69 +23,507 @@ func NewUserRepository(db *gorm.DB, logger *slog.Logger) *UserRepository {
 	return &UserRepository{db, logger}
 }
 
-func (r *UserRepository) SetActiveStatus(userID string, active bool) error {
+func (r *UserRepository) SetActiveStatus(ctx context.Context, orgID, userID string, active bool) error {
 	logger := r.logger.With(
 		"method", "set_active_status",
+		"organization_id", orgID,
 		"user_id", userID,
 	)
-	logger.Info("setting active status")
+	logger.InfoContext(ctx, "setting active status")
 
-	result := r.db.Model(&models.User{}).
-		Where("user_id = ?", userID).
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
 		Update("is_active", active)
 
 	if result.Error != nil {
-		logger.Error("failed to set status", "error", result.Error)
+		logger.ErrorContext(ctx, "failed to set status", "error", result.Error)
 		return result.Error
 	}
 
 	if result.RowsAffected == 0 {
-		logger.Warn("user not found", "error", result.Error)
+		logger.WarnContext(ctx, "user not found", "error", result.Error)
 		return errs.ResourceNotFound
 	}
 
 	return nil
 }
 
-func (r *UserRepository) GetReview(userID string) ([]models.PullRequestShort, error) {
+// GetReview returns a page of the pull requests the user reviews.
+func (r *UserRepository) GetReview(
+	ctx context.Context,
+	orgID, userID string,
+	filter models.PullRequestFilter,
+) (*models.PullRequestPage, error) {
 	logger := r.logger.With(
 		"method", "get_reviews",
+		"organization_id", orgID,
 		"user_id", userID,
+		"status", filter.Status,
+		"sort", filter.Sort,
 	)
-	logger.Info("getting reviews")
+	logger.InfoContext(ctx, "getting reviews")
 
-	var prs []models.PullRequestShort
+	filter.ReviewerID = userID
+	page, err := listPullRequests(r.db.WithContext(ctx), orgID, filter)
+	if errors.Is(err, errs.ValidationFailed) {
+		logger.WarnContext(ctx, "invalid listing", "error", err)
+	} else if err != nil {
+		logger.ErrorContext(ctx, "failed to get reviews", "error", err)
+	}
+
+	return page, err
+}
 
-	err := r.db.Model(&models.PullRequest{}).
-		Select("pull_requests.pull_request_id", "Name", "AuthorID", "Status").
-		Joins("JOIN pull_request_reviewers prr ON prr.pull_request_id = pull_requests.pull_request_id").
-		Where("prr.user_id = ?", userID).
-		Find(&prs).Error
+func (r *UserRepository) Get(ctx context.Context, orgID, userID string) (*models.User, error) {
+	logger := r.logger.With(
+		"method", "get_user",
+		"organization_id", orgID,
+		"user_id", userID,
+	)
+	logger.InfoContext(ctx, "getting user")
+
+	var user models.User
+
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND user_id = ?", orgID, userID).First(&user).Error
 	if err != nil {
-		logger.Error("failed to get reviews", "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.WarnContext(ctx, "user not found", "error", err)
+			return nil, errs.ResourceNotFound
+		}
+		logger.ErrorContext(ctx, "failed to get user", "error", err)
+		return nil, err
 	}
 
-	return prs, err
+	return &user, nil
 }
 
-func (r *UserRepository) Get(userID string) (*models.User, error) {
+// GetWithTeam returns the user with the name of their team.
+func (r *UserRepository) GetWithTeam(ctx context.Context, orgID, userID string) (*models.User, error) {
 	logger := r.logger.With(
-		"method", "get_user",
+		"method", "get_user_with_team",
+		"organization_id", orgID,
 		"user_id", userID,
 	)
-	logger.Info("getting user")
+	logger.InfoContext(ctx, "getting user")
 
 	var user models.User
 
-	err := r.db.Where("user_id = ?", userID).First(&user).Error
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND user_id = ?", orgID, userID).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			logger.Warn("user not found", "error", err)
+			logger.WarnContext(ctx, "user not found", "error", err)
 			return nil, errs.ResourceNotFound
 		}
-		logger.Error("failed to get user", "error", err)
+		logger.ErrorContext(ctx, "failed to get user", "error", err)
+		return nil, err
+	}
+
+	err = r.db.WithContext(ctx).Model(&models.Team{}).
+		Select("name").
+		Where("organization_id = ? AND team_id = ?", orgID, user.TeamID).
+		Scan(&user.TeamName).Error
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get team", "error", err)
 		return nil, err
 	}
 
 	return &user, nil
 }
+
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	logger := r.logger.With(
+		"method", "create_user",
+		"organization_id", user.OrganizationID,
+		"username", user.Username,
+	)
+	logger.InfoContext(ctx, "creating user")
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.resolveTeam(tx, user); err != nil {
+			logger.WarnContext(ctx, "team not found", "error", err, "team_name", user.TeamName)
+			return err
+		}
+
+		user.ID = uuid.New().String()
+		if err := tx.Create(user).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				logger.WarnContext(ctx, "user already exists", "error", err)
+				return errs.UserExists
+			}
+			logger.ErrorContext(ctx, "failed to create user", "error", err)
+			return fmt.Errorf("failed to create user %s: %w", user.Username, err)
+		}
+
+		return nil
+	})
+}
+
+// Update saves the username, email and team of the user.
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	logger := r.logger.With(
+		"method", "update_user",
+		"organization_id", user.OrganizationID,
+		"user_id", user.ID,
+	)
+	logger.InfoContext(ctx, "updating user")
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return r.updateUser(ctx, tx, logger, user)
+	})
+}
+
+func (r *UserRepository) updateUser(ctx context.Context, tx *gorm.DB, logger *slog.Logger, user *models.User) error {
+	if err := r.resolveTeam(tx, user); err != nil {
+		logger.WarnContext(ctx, "team not found", "error", err, "team_name", user.TeamName)
+		return err
+	}
+
+	result := tx.Model(user).
+		Where("organization_id = ?", user.OrganizationID).
+		Select("Username", "Email", "TeamID").
+		Updates(user)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			logger.WarnContext(ctx, "username is taken", "error", result.Error, "username", user.Username)
+			return errs.UserExists
+		}
+		logger.ErrorContext(ctx, "failed to update user", "error", result.Error)
+		return fmt.Errorf("failed to update user %s: %w", user.ID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		logger.WarnContext(ctx, "user not found")
+		return errs.ResourceNotFound
+	}
+
+	return nil
+}
+
+// Delete saves the reassignments of the open reviews of the user and
+// removes the user in one transaction. If the user still reviews an open
+// pull request then, nothing is changed and errs.NoCandidate is returned.
+// A user who authored or reviewed the kept pull requests is not deleted
+// either, errs.UserHasPullRequests is returned. Events of the user stay
+// in the history of pull requests without the user.
+func (r *UserRepository) Delete(ctx context.Context, orgID, userID string, reassignments []models.Reassignment) error {
+	logger := r.logger.With(
+		"method", "delete_user",
+		"organization_id", orgID,
+		"user_id", userID,
+		"reassignments", len(reassignments),
+	)
+	logger.InfoContext(ctx, "deleting user")
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := releaseReviews(ctx, tx, logger, orgID, userID, reassignments); err != nil {
+			return err
+		}
+
+		var pullRequests []string
+		err := tx.Model(&models.PullRequest{}).
+			Where("author_id = ?", userID).
+			Or("(repository_id, pull_request_id) IN (?)",
+				tx.Model(&models.PullRequestReviewer{}).Select("repository_id, pull_request_id").Where("user_id = ?", userID)).
+			Order("pull_request_id").
+			Pluck("pull_request_id", &pullRequests).Error
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to check pull requests of user", "error", err)
+			return err
+		}
+		if len(pullRequests) > 0 {
+			logger.WarnContext(ctx, "user has pull requests", "pull_request_ids", pullRequests)
+			return pullRequestsLeft(pullRequests)
+		}
+
+		err = tx.Where("organization_id = ? AND user_id = ?", orgID, userID).Delete(&models.User{}).Error
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to delete user", "error", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// Deactivate saves the reassignments of the open reviews of the user and
+// deactivates the user in one transaction. If the user still reviews an
+// open pull request then, nothing is changed and errs.NoCandidate is
+// returned.
+func (r *UserRepository) Deactivate(ctx context.Context, orgID, userID string, reassignments []models.Reassignment) error {
+	logger := r.logger.With(
+		"method", "deactivate_user",
+		"organization_id", orgID,
+		"user_id", userID,
+		"reassignments", len(reassignments),
+	)
+	logger.InfoContext(ctx, "deactivating user")
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := releaseReviews(ctx, tx, logger, orgID, userID, reassignments); err != nil {
+			return err
+		}
+		return deactivateUser(ctx, tx, logger, orgID, userID)
+	})
+}
+
+// UpdateAndDeactivate saves the username, email and team of the user
+// together with the deactivation, see Deactivate. If the user cannot be
+// deactivated, the user is not changed either.
+func (r *UserRepository) UpdateAndDeactivate(ctx context.Context, user *models.User, reassignments []models.Reassignment) error {
+	logger := r.logger.With(
+		"method", "update_and_deactivate_user",
+		"organization_id", user.OrganizationID,
+		"user_id", user.ID,
+		"reassignments", len(reassignments),
+	)
+	logger.InfoContext(ctx, "updating and deactivating user")
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := releaseReviews(ctx, tx, logger, user.OrganizationID, user.ID, reassignments); err != nil {
+			return err
+		}
+		if err := r.updateUser(ctx, tx, logger, user); err != nil {
+			return err
+		}
+		return deactivateUser(ctx, tx, logger, user.OrganizationID, user.ID)
+	})
+}
+
+func deactivateUser(ctx context.Context, tx *gorm.DB, logger *slog.Logger, orgID, userID string) error {
+	err := tx.Model(&models.User{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Update("is_active", false).Error
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to deactivate user", "error", err)
+		return err
+	}
+	return nil
+}
+
+// releaseReviews locks the user and saves the reassignments of their open
+// reviews within the transaction. It fails if the user still reviews an
+// open pull request then.
+func releaseReviews(ctx context.Context, tx *gorm.DB, logger *slog.Logger, orgID, userID string, reassignments []models.Reassignment) error {
+	// Assigning a review takes a key share lock of the reviewer, so no
+	// review is assigned to the locked user until the transaction ends
+	var user models.User
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.WarnContext(ctx, "user not found")
+		return errs.ResourceNotFound
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to lock user", "error", err)
+		return err
+	}
+
+	if err := saveReassignments(tx, reassignments); err != nil {
+		logger.ErrorContext(ctx, "failed to reassign reviews", "error", err)
+		return err
+	}
+
+	var openReviews []string
+	err = tx.Model(&models.PullRequestReviewer{}).
+		Joins("JOIN pull_requests pr ON pr.repository_id = pull_request_reviewers.repository_id "+
+			"AND pr.pull_request_id = pull_request_reviewers.pull_request_id").
+		Where("pull_request_reviewers.user_id = ? AND pr.status = ?", userID, models.StatusOpen).
+		Pluck("pr.pull_request_id", &openReviews).Error
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to check open reviews", "error", err)
+		return err
+	}
+	if len(openReviews) > 0 {
+		logger.WarnContext(ctx, "user still reviews open pull requests", "pull_request_ids", openReviews)
+		return openReviewsLeft(openReviews)
+	}
+	return nil
+}
+
+// saveReassignments replaces the reviewers of the reassigned pull requests
+// and adds the events to their history. The other columns are not written
+// back. A pull request merged after the reassignments were planned keeps
+// its reviewers.
+func saveReassignments(tx *gorm.DB, reassignments []models.Reassignment) error {
+	for _, reassignment := range reassignments {
+		pr := reassignment.PullRequest
+		var status string
+		err := tx.Model(&models.PullRequest{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("status").
+			Where("repository_id = ? AND pull_request_id = ?", pr.RepositoryID, pr.ID).
+			Scan(&status).Error
+		if err != nil {
+			return err
+		}
+		if status != models.StatusOpen {
+			continue
+		}
+
+		if err := tx.Model(pr).Association("Reviewers").Replace(pr.Reviewers); err != nil {
+			return fmt.Errorf("failed to associate reviewers with pr %s: %w", pr.Name, err)
+		}
+		if err := addEvents(tx, reassignment.Events...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openReviewsLeft is the error of releasing a user who still reviews the
+// open pull requests, e.g. assigned to them after the reassignments were
+// planned.
+func openReviewsLeft(pullRequestIDs []string) error {
+	return errs.NoCandidate.WithMessage(fmt.Sprintf("reviews of PR %s are not reassigned", strings.Join(pullRequestIDs, ", ")))
+}
+
+// pullRequestsLeft is the error of deleting a user who authored or
+// reviewed the pull requests, deleting them would change their history.
+func pullRequestsLeft(pullRequestIDs []string) error {
+	return errs.UserHasPullRequests.WithMessage(fmt.Sprintf(
+		"user authored or reviewed PR %s, deactivate the user instead", strings.Join(pullRequestIDs, ", ")))
+}
+
+func (r *UserRepository) resolveTeam(tx *gorm.DB, user *models.User) error {
+	var teamID string
+	err := tx.Model(&models.Team{}).
+		Select("team_id").
+		Where("organization_id = ? AND name = ?", user.OrganizationID, user.TeamName).
+		Scan(&teamID).Error
+	if err != nil {
+		return err
+	}
+	if teamID == "" {
+		return errs.ResourceNotFound
+	}
+
+	user.TeamID = teamID
+	return nil
+}
+
+// GetActive returns active users among the given ones.
+func (r *UserRepository) GetActive(ctx context.Context, orgID string, userIDs ...string) ([]*models.User, error) {
+	logger := r.logger.With(
+		"method", "get_active_users",
+		"organization_id", orgID,
+		"user_ids", userIDs,
+	)
+	logger.InfoContext(ctx, "getting active users")
+
+	var users []*models.User
+
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND user_id IN ?", orgID, userIDs).
+		Where("is_active = true").
+		Find(&users).Error
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get active users", "error", err)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// GetByIDs returns the users with the given IDs.
+func (r *UserRepository) GetByIDs(ctx context.Context, orgID string, userIDs []string) ([]models.User, error) {
+	logger := r.logger.With(
+		"method", "get_users_by_ids",
+		"organization_id", orgID,
+		"user_ids", userIDs,
+	)
+	logger.InfoContext(ctx, "getting users")
+
+	var users []models.User
+
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND user_id IN ?", orgID, userIDs).
+		Find(&users).Error
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get users", "error", err)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// GetByTeamIDs returns the members of the given teams.
+func (r *UserRepository) GetByTeamIDs(ctx context.Context, orgID string, teamIDs []string) ([]models.User, error) {
+	logger := r.logger.With(
+		"method", "get_users_by_team_ids",
+		"organization_id", orgID,
+		"team_ids", teamIDs,
+	)
+	logger.InfoContext(ctx, "getting team members")
+
+	var users []models.User
+
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND team_id IN ?", orgID, teamIDs).
+		Order("username").
+		Find(&users).Error
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get team members", "error", err)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// List returns a page of users ordered by username with the names of
+// their teams and the number of users on all pages. A non-empty username
+// selects the user with it regardless of case.
+func (r *UserRepository) List(ctx context.Context, orgID, username string, offset, limit int) ([]models.User, int64, error) {
+	logger := r.logger.With(
+		"method", "list_users",
+		"organization_id", orgID,
+		"username", username,
+	)
+	logger.InfoContext(ctx, "listing users")
+
+	matching := func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Where("organization_id = ?", orgID)
+		if username != "" {
+			tx = tx.Where("LOWER(username) = LOWER(?)", username)
+		}
+		return tx
+	}
+
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Scopes(matching).Count(&total).Error; err != nil {
+		logger.ErrorContext(ctx, "failed to count users", "error", err)
+		return nil, 0, err
+	}
+
+	users := []models.User{}
+	err := r.db.WithContext(ctx).Scopes(matching).
+		Order("username").
+		Offset(offset).
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to list users", "error", err)
+		return nil, 0, err
+	}
+	if len(users) == 0 {
+		return users, total, nil
+	}
+
+	teamIDs := make([]string, 0, len(users))
+	for _, user := range users {
+		teamIDs = append(teamIDs, user.TeamID)
+	}
+	var teams []models.Team
+	err = r.db.WithContext(ctx).Select("team_id", "name").
+		Where("organization_id = ? AND team_id IN ?", orgID, teamIDs).
+		Find(&teams).Error
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get teams", "error", err)
+		return nil, 0, err
+	}
+
+	names := make(map[string]string, len(teams))
+	for _, team := range teams {
+		names[team.ID] = team.Name
+	}
+	for i := range users {
+		users[i].TeamName = names[users[i].TeamID]
+	}
+
+	return users, total, nil
+}
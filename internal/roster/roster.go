@@ -0,0 +1,290 @@
+// Package roster reads lists of teams and their members in YAML or CSV.
+//
+// A YAML roster lists teams with their members:
+//
+//	teams:
+//	  - name: backend
+//	    parent: engineering
+//	    members:
+//	      - username: alice
+//	        email: alice@example.com
+//	        is_active: false
+//
+// A CSV roster has a header row naming its columns: team, parent,
+// username, email and is_active. Only the team column is required. Every
+// row with a username is a member of the team, a team may span several
+// rows.
+//
+// Members are active unless is_active is false.
+package roster
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatYAML = "yaml"
+	FormatCSV  = "csv"
+)
+
+// Parse reads a roster in the given format. Malformed entries, members
+// listed twice and teams listed with different parents are reported as an
+// *errs.ValidationError with the lines of the failing entries.
+func Parse(r io.Reader, format string) (*models.Roster, error) {
+	b := builder{teams: map[string]int{}, members: map[string]int{}}
+
+	var err error
+	switch format {
+	case FormatYAML:
+		err = b.readYAML(r)
+	case FormatCSV:
+		err = b.readCSV(r)
+	default:
+		return nil, errs.NewValidationError(errs.FieldError{Field: "format", Message: "must be one of yaml, csv"})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b.fields) > 0 {
+		return nil, errs.NewValidationError(b.fields...)
+	}
+	if len(b.roster.Teams) == 0 {
+		return nil, errs.NewValidationError(errs.FieldError{Field: "teams", Message: "must not be empty"})
+	}
+	return &b.roster, nil
+}
+
+// builder collects the entries of a roster and the errors found in them.
+type builder struct {
+	roster models.Roster
+	fields []errs.FieldError
+	// Indices of the listed teams and members by their names
+	teams   map[string]int
+	members map[string]int
+}
+
+func (b *builder) fail(line int, field, message string) {
+	b.fields = append(b.fields, errs.FieldError{Line: line, Field: field, Message: message})
+}
+
+// addTeam adds the team unless it is already listed. A team listed again
+// must have the same parent or none.
+func (b *builder) addTeam(line int, name, parentName string) {
+	i, ok := b.teams[name]
+	if !ok {
+		b.teams[name] = len(b.roster.Teams)
+		b.roster.Teams = append(b.roster.Teams, models.RosterTeam{Line: line, Name: name, ParentName: parentName})
+		return
+	}
+
+	team := &b.roster.Teams[i]
+	switch {
+	case parentName == "" || parentName == team.ParentName:
+	case team.ParentName == "":
+		team.ParentName = parentName
+	default:
+		b.fail(line, "parent_team_name", fmt.Sprintf("conflicts with %s on line %d", team.ParentName, team.Line))
+	}
+}
+
+func (b *builder) addMember(member models.RosterMember) {
+	if i, ok := b.members[member.Username]; ok && member.Username != "" {
+		b.fail(member.Line, "username", fmt.Sprintf("is already listed on line %d", b.roster.Members[i].Line))
+		return
+	}
+	b.members[member.Username] = len(b.roster.Members)
+	b.roster.Members = append(b.roster.Members, member)
+}
+
+type yamlRoster struct {
+	Teams []yamlTeam `yaml:"teams"`
+}
+
+type yamlTeam struct {
+	Name    string       `yaml:"name"`
+	Parent  string       `yaml:"parent"`
+	Members []yamlMember `yaml:"members"`
+}
+
+type yamlMember struct {
+	Username string `yaml:"username"`
+	Email    string `yaml:"email"`
+	IsActive *bool  `yaml:"is_active"`
+}
+
+func (b *builder) readYAML(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	// The document is decoded twice: strictly to reject unknown keys and
+	// as nodes to know the lines of the entries
+	var doc yamlRoster
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return yamlError(err)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+
+	teamNodes := items(mappingValue(&root, "teams"))
+	for i, team := range doc.Teams {
+		b.addTeam(teamNodes[i].Line, team.Name, team.Parent)
+
+		memberNodes := items(mappingValue(teamNodes[i], "members"))
+		for j, member := range team.Members {
+			b.addMember(models.RosterMember{
+				Line:     memberNodes[j].Line,
+				TeamName: team.Name,
+				Username: member.Username,
+				Email:    member.Email,
+				IsActive: member.IsActive == nil || *member.IsActive,
+			})
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value of the key of a mapping node, the
+// document node stands for its content.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// items returns the items of a sequence node.
+func items(node *yaml.Node) []*yaml.Node {
+	if node == nil {
+		return nil
+	}
+	return node.Content
+}
+
+var (
+	linePattern         = regexp.MustCompile(`^line (\d+): (.*)$`)
+	unknownFieldPattern = regexp.MustCompile(`^field (\S+) not found in type `)
+)
+
+// yamlError converts a decoding error to a validation error with a field
+// error for every failing line.
+func yamlError(err error) error {
+	messages := []string{strings.TrimPrefix(err.Error(), "yaml: ")}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+
+	fields := make([]errs.FieldError, 0, len(messages))
+	for _, message := range messages {
+		field := errs.FieldError{Field: "roster", Message: message}
+		if m := linePattern.FindStringSubmatch(message); m != nil {
+			field.Line, _ = strconv.Atoi(m[1])
+			field.Message = m[2]
+		}
+		if m := unknownFieldPattern.FindStringSubmatch(field.Message); m != nil {
+			field.Field = m[1]
+			field.Message = "is not a known key"
+		}
+		fields = append(fields, field)
+	}
+	return errs.NewValidationError(fields...)
+}
+
+var csvColumns = []string{"team", "parent", "username", "email", "is_active"}
+
+func (b *builder) readCSV(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return csvError(err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(csvColumns, name) {
+			return errs.NewValidationError(errs.FieldError{Line: 1, Field: name, Message: "is not a known column"})
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["team"]; !ok {
+		return errs.NewValidationError(errs.FieldError{Line: 1, Field: "team", Message: "column is required"})
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return csvError(err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		value := func(column string) string {
+			if i, ok := columns[column]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		team := value("team")
+		b.addTeam(line, team, value("parent"))
+
+		username := value("username")
+		if username == "" {
+			continue
+		}
+		active := true
+		if v := value("is_active"); v != "" {
+			if active, err = strconv.ParseBool(v); err != nil {
+				b.fail(line, "is_active", "must be true or false")
+				continue
+			}
+		}
+		b.addMember(models.RosterMember{
+			Line:     line,
+			TeamName: team,
+			Username: username,
+			Email:    value("email"),
+			IsActive: active,
+		})
+	}
+}
+
+func csvError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return errs.NewValidationError(errs.FieldError{Line: parseErr.Line, Field: "roster", Message: parseErr.Err.Error()})
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package scim
+
+// ServiceProviderConfig describes the features of the protocol the
+// service supports.
+func ServiceProviderConfig() map[string]any {
+	unsupported := map[string]any{"supported": false}
+	return map[string]any{
+		"schemas":        []string{ServiceProviderConfigSchema},
+		"patch":          map[string]any{"supported": true},
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": MaxResults},
+		"changePassword": unsupported,
+		"sort":           unsupported,
+		"etag":           unsupported,
+		// Requests carry a token of API_TOKENS, it selects the organization
+		"authenticationSchemes": []any{map[string]any{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Authentication with a bearer token of the organization",
+			"primary":     true,
+		}},
+		"meta": Meta{ResourceType: "ServiceProviderConfig", Location: BasePath + "/ServiceProviderConfig"},
+	}
+}
+
+// ResourceType describes a type of resources served by the service.
+type ResourceType struct {
+	Schemas  []string `json:"schemas"`
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Endpoint string   `json:"endpoint"`
+	Schema   string   `json:"schema"`
+	Meta     Meta     `json:"meta"`
+}
+
+// ResourceTypes returns the types of users and groups.
+func ResourceTypes() ListResponse[ResourceType] {
+	types := []ResourceType{
+		{
+			Schemas:  []string{ResourceTypeSchema},
+			ID:       "User",
+			Name:     "User",
+			Endpoint: "/Users",
+			Schema:   UserSchema,
+			Meta:     Meta{ResourceType: "ResourceType", Location: BasePath + "/ResourceTypes/User"},
+		},
+		{
+			Schemas:  []string{ResourceTypeSchema},
+			ID:       "Group",
+			Name:     "Group",
+			Endpoint: "/Groups",
+			Schema:   GroupSchema,
+			Meta:     Meta{ResourceType: "ResourceType", Location: BasePath + "/ResourceTypes/Group"},
+		},
+	}
+	return NewListResponse(types, int64(len(types)), 1)
+}
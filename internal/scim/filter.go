@@ -0,0 +1,44 @@
+package scim
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Filter selects resources whose attribute equals the value. It is the
+// only kind of filter identity providers use to look up resources.
+type Filter struct {
+	Attribute string
+	Value     string
+}
+
+var filterPattern = regexp.MustCompile(`^\s*([A-Za-z][\w.]*)\s+(?i:eq)\s+("(?:[^"\\]|\\.)*")\s*$`)
+
+// ParseFilter parses a filter of the form `attribute eq "value"`. An
+// empty filter selects every resource and returns a zero Filter. The
+// attribute must be one of the given ones, it is returned in the case it
+// is given.
+func ParseFilter(filter string, attributes ...string) (Filter, error) {
+	if strings.TrimSpace(filter) == "" {
+		return Filter{}, nil
+	}
+
+	m := filterPattern.FindStringSubmatch(filter)
+	if m == nil {
+		return Filter{}, NewError(http.StatusBadRequest, InvalidFilter, `only filters of the form attribute eq "value" are supported`)
+	}
+	value, err := strconv.Unquote(m[2])
+	if err != nil {
+		return Filter{}, NewError(http.StatusBadRequest, InvalidFilter, "invalid value "+m[2])
+	}
+
+	for _, attribute := range attributes {
+		if strings.EqualFold(m[1], attribute) {
+			return Filter{Attribute: attribute, Value: value}, nil
+		}
+	}
+	return Filter{}, NewError(http.StatusBadRequest, InvalidFilter,
+		"filtering is supported by "+strings.Join(attributes, ", ")+" only")
+}
@@ -0,0 +1,204 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PatchRequest changes attributes of a resource.
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations" binding:"required,min=1,max=1000,dive"`
+}
+
+// PatchOperation is an operation of a patch. Identity providers differ in
+// the case of the operations, so they are matched regardless of it.
+type PatchOperation struct {
+	Op    string          `json:"op" binding:"required"`
+	Path  string          `json:"path" binding:"max=1000"`
+	Value json.RawMessage `json:"value"`
+}
+
+const (
+	opAdd     = "add"
+	opRemove  = "remove"
+	opReplace = "replace"
+)
+
+// memberPathPattern matches a path selecting a member by its ID.
+var memberPathPattern = regexp.MustCompile(`^(?i:members)\[\s*(?i:value)\s+(?i:eq)\s+("(?:[^"\\]|\\.)*")\s*\]$`)
+
+// emailPathPattern matches a path selecting the value of an email.
+var emailPathPattern = regexp.MustCompile(`^(?i:emails)(\[[^\]]*\])?\.(?i:value)$`)
+
+// Apply applies the operations to the user. Attributes the service does
+// not store are ignored.
+func (u *User) Apply(ops []PatchOperation) error {
+	for _, op := range ops {
+		switch strings.ToLower(op.Op) {
+		case opAdd, opReplace:
+			if op.Path == "" {
+				var values map[string]json.RawMessage
+				if err := json.Unmarshal(op.Value, &values); err != nil {
+					return invalidValue("value must be an object without a path")
+				}
+				for path, value := range values {
+					if err := u.set(path, value); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := u.set(op.Path, op.Value); err != nil {
+				return err
+			}
+		case opRemove:
+			switch {
+			case strings.EqualFold(op.Path, "emails"), emailPathPattern.MatchString(op.Path):
+				u.Emails = nil
+			case strings.EqualFold(op.Path, "userName"), strings.EqualFold(op.Path, "active"):
+				return NewError(http.StatusBadRequest, Mutability, op.Path+" cannot be removed")
+			case op.Path == "":
+				return NewError(http.StatusBadRequest, NoTarget, "remove requires a path")
+			}
+		default:
+			return invalidValue("unknown operation " + op.Op)
+		}
+	}
+	return nil
+}
+
+func (u *User) set(path string, value json.RawMessage) error {
+	switch {
+	case strings.EqualFold(path, "userName"):
+		if err := json.Unmarshal(value, &u.UserName); err != nil {
+			return invalidValue("userName must be a string")
+		}
+	case strings.EqualFold(path, "active"):
+		active, err := parseBool(value)
+		if err != nil {
+			return invalidValue("active must be a boolean")
+		}
+		u.Active = &active
+	case strings.EqualFold(path, "emails"):
+		if err := json.Unmarshal(value, &u.Emails); err != nil {
+			return invalidValue("emails must be a list of emails")
+		}
+	case emailPathPattern.MatchString(path):
+		var email string
+		if err := json.Unmarshal(value, &email); err != nil {
+			return invalidValue("the value of an email must be a string")
+		}
+		u.Emails = []Email{{Value: email, Type: "work", Primary: true}}
+	}
+	return nil
+}
+
+// parseBool parses a boolean that some identity providers send as a
+// string.
+func parseBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(s)
+}
+
+// Apply applies the operations to the group. Attributes the service does
+// not store are ignored.
+func (g *Group) Apply(ops []PatchOperation) error {
+	for _, op := range ops {
+		switch name := strings.ToLower(op.Op); name {
+		case opAdd, opReplace:
+			if op.Path == "" {
+				var values map[string]json.RawMessage
+				if err := json.Unmarshal(op.Value, &values); err != nil {
+					return invalidValue("value must be an object without a path")
+				}
+				for path, value := range values {
+					if err := g.set(name, path, value); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := g.set(name, op.Path, op.Value); err != nil {
+				return err
+			}
+		case opRemove:
+			if err := g.remove(op.Path, op.Value); err != nil {
+				return err
+			}
+		default:
+			return invalidValue("unknown operation " + op.Op)
+		}
+	}
+	return nil
+}
+
+func (g *Group) set(op, path string, value json.RawMessage) error {
+	switch {
+	case strings.EqualFold(path, "displayName"):
+		if err := json.Unmarshal(value, &g.DisplayName); err != nil {
+			return invalidValue("displayName must be a string")
+		}
+	case strings.EqualFold(path, "members"):
+		var members []Member
+		if err := json.Unmarshal(value, &members); err != nil {
+			return invalidValue("members must be a list of members")
+		}
+		if op == opReplace {
+			g.Members = nil
+		}
+		for _, member := range members {
+			if !slices.ContainsFunc(g.Members, func(m Member) bool { return m.Value == member.Value }) {
+				g.Members = append(g.Members, member)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *Group) remove(path string, value json.RawMessage) error {
+	var removed []string
+	switch {
+	case strings.EqualFold(path, "members") && (len(value) == 0 || string(value) == "null"):
+		g.Members = nil
+		return nil
+	case strings.EqualFold(path, "members"):
+		var members []Member
+		if err := json.Unmarshal(value, &members); err != nil {
+			return invalidValue("members must be a list of members")
+		}
+		for _, member := range members {
+			removed = append(removed, member.Value)
+		}
+	case memberPathPattern.MatchString(path):
+		id, err := strconv.Unquote(memberPathPattern.FindStringSubmatch(path)[1])
+		if err != nil {
+			return NewError(http.StatusBadRequest, InvalidPath, "invalid path "+path)
+		}
+		removed = append(removed, id)
+	case strings.EqualFold(path, "displayName"):
+		return NewError(http.StatusBadRequest, Mutability, "displayName cannot be removed")
+	case path == "":
+		return NewError(http.StatusBadRequest, NoTarget, "remove requires a path")
+	default:
+		return nil
+	}
+
+	g.Members = slices.DeleteFunc(g.Members, func(m Member) bool { return slices.Contains(removed, m.Value) })
+	return nil
+}
+
+func invalidValue(detail string) *Error {
+	return NewError(http.StatusBadRequest, InvalidValue, detail)
+}
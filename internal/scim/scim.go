@@ -0,0 +1,275 @@
+// Package scim holds the resources and messages of SCIM 2.0 (RFC 7643
+// and RFC 7644), through which identity providers push their users and
+// groups.
+//
+// Users map to users of the service and groups to teams. Only the
+// attributes the service stores are kept: userName, the primary email and
+// active of users, displayName and members of groups. Other attributes
+// are accepted and ignored.
+package scim
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+	"strings"
+)
+
+const (
+	UserSchema                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	GroupSchema                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	ServiceProviderConfigSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	ResourceTypeSchema          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	ListResponseSchema          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	PatchOpSchema               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	ErrorSchema                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// ContentType is the media type of SCIM messages.
+const ContentType = "application/scim+json"
+
+// BasePath is the path the resources are served under.
+const BasePath = "/scim/v2"
+
+// MaxResults limits the number of resources in a list response.
+const MaxResults = 1000
+
+type Meta struct {
+	ResourceType string `json:"resourceType"`
+	Location     string `json:"location,omitempty"`
+}
+
+type User struct {
+	Schemas  []string `json:"schemas"`
+	ID       string   `json:"id,omitempty"`
+	UserName string   `json:"userName" binding:"required,notblank,max=255"`
+	// Active defaults to true
+	Active *bool      `json:"active,omitempty"`
+	Emails []Email    `json:"emails,omitempty" binding:"max=10,dive"`
+	Groups []GroupRef `json:"groups,omitempty"`
+	Meta   *Meta      `json:"meta,omitempty"`
+}
+
+type Email struct {
+	Value   string `json:"value" binding:"omitempty,email,max=255"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// GroupRef is a group of a user. Groups of a user are read-only, users
+// join groups through the members of the groups.
+type GroupRef struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type Group struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	DisplayName string   `json:"displayName" binding:"required,notblank,max=255"`
+	Members     []Member `json:"members,omitempty" binding:"max=1000,dive"`
+	Meta        *Meta    `json:"meta,omitempty"`
+}
+
+// Member is a user in a group, Value is the ID of the user.
+type Member struct {
+	Value   string `json:"value" binding:"required,uuid"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// ListResponse is a page of resources.
+type ListResponse[T any] struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int64    `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []T      `json:"Resources"`
+}
+
+// NewListResponse returns the page of resources starting from the
+// 1-based startIndex.
+func NewListResponse[T any](resources []T, total int64, startIndex int) ListResponse[T] {
+	return ListResponse[T]{
+		Schemas:      []string{ListResponseSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+// ListQuery is the query of a list request. StartIndex is 1-based, Count
+// defaults to 100 and is capped at MaxResults.
+type ListQuery struct {
+	Filter             string `form:"filter" binding:"max=1000"`
+	StartIndex         int    `form:"startIndex"`
+	Count              *int   `form:"count"`
+	ExcludedAttributes string `form:"excludedAttributes"`
+}
+
+// Page returns the offset and limit of the query.
+func (q ListQuery) Page() (startIndex, offset, limit int) {
+	startIndex = max(q.StartIndex, 1)
+	limit = 100
+	if q.Count != nil {
+		limit = min(max(*q.Count, 0), MaxResults)
+	}
+	return startIndex, startIndex - 1, limit
+}
+
+// Excludes reports whether the attribute is in the comma-separated list
+// of excludedAttributes of a request.
+func Excludes(excludedAttributes, attribute string) bool {
+	for _, excluded := range strings.Split(excludedAttributes, ",") {
+		if strings.EqualFold(strings.TrimSpace(excluded), attribute) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewUser returns the resource of the user.
+func NewUser(user *models.User) User {
+	active := user.IsActive
+	resource := User{
+		Schemas:  []string{UserSchema},
+		ID:       user.ID,
+		UserName: user.Username,
+		Active:   &active,
+		Meta:     &Meta{ResourceType: "User", Location: BasePath + "/Users/" + user.ID},
+	}
+	if user.Email != "" {
+		resource.Emails = []Email{{Value: user.Email, Type: "work", Primary: true}}
+	}
+	if user.TeamID != "" {
+		resource.Groups = []GroupRef{{Value: user.TeamID, Display: user.TeamName, Ref: BasePath + "/Groups/" + user.TeamID}}
+	}
+	return resource
+}
+
+// Model returns the user with the attributes of the resource. The email
+// is the primary one or the first one if none is primary.
+func (u *User) Model() *models.User {
+	user := &models.User{
+		ID:       u.ID,
+		Username: u.UserName,
+		IsActive: u.Active == nil || *u.Active,
+	}
+	for _, email := range u.Emails {
+		if email.Primary {
+			user.Email = email.Value
+			break
+		}
+	}
+	if user.Email == "" && len(u.Emails) > 0 {
+		user.Email = u.Emails[0].Value
+	}
+	return user
+}
+
+// NewGroup returns the resource of the team.
+func NewGroup(team *models.Team) Group {
+	resource := Group{
+		Schemas:     []string{GroupSchema},
+		ID:          team.ID,
+		DisplayName: team.Name,
+		Members:     make([]Member, 0, len(team.Members)),
+		Meta:        &Meta{ResourceType: "Group", Location: BasePath + "/Groups/" + team.ID},
+	}
+	for _, member := range team.Members {
+		resource.Members = append(resource.Members, Member{
+			Value:   member.ID,
+			Display: member.Username,
+			Ref:     BasePath + "/Users/" + member.ID,
+		})
+	}
+	return resource
+}
+
+// Model returns the team with the name and members of the resource. The
+// members have only their IDs.
+func (g *Group) Model() *models.Team {
+	team := &models.Team{ID: g.ID, Name: g.DisplayName, Members: make([]models.User, 0, len(g.Members))}
+	for _, member := range g.Members {
+		team.Members = append(team.Members, models.User{ID: member.Value})
+	}
+	return team
+}
+
+// Error is an error response. It is also returned as an error for
+// requests that break the protocol.
+type Error struct {
+	status int
+
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// Types of errors from RFC 7644, section 3.12.
+const (
+	InvalidFilter = "invalidFilter"
+	InvalidSyntax = "invalidSyntax"
+	InvalidPath   = "invalidPath"
+	NoTarget      = "noTarget"
+	InvalidValue  = "invalidValue"
+	Uniqueness    = "uniqueness"
+	Mutability    = "mutability"
+)
+
+// NewError returns an error response with the status, type and detail.
+func NewError(status int, scimType, detail string) *Error {
+	return &Error{
+		status:   status,
+		Schemas:  []string{ErrorSchema},
+		Status:   fmt.Sprint(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+// StatusCode returns the HTTP status of the error.
+func (e *Error) StatusCode() int {
+	return e.status
+}
+
+// ToError converts an error of the service to an error response. Errors
+// that are not known are reported as internal ones.
+func ToError(err error) *Error {
+	var scimErr *Error
+	if errors.As(err, &scimErr) {
+		return scimErr
+	}
+
+	var validationErr *errs.ValidationError
+	if errors.As(err, &validationErr) {
+		details := make([]string, 0, len(validationErr.Fields))
+		for _, field := range validationErr.Fields {
+			details = append(details, field.Field+" "+field.Message)
+		}
+		detail := validationErr.Message
+		if len(details) > 0 {
+			detail += ": " + strings.Join(details, ", ")
+		}
+		return NewError(http.StatusBadRequest, InvalidValue, detail)
+	}
+
+	status, response := errs.ToResponse(err)
+	switch {
+	case errors.Is(err, errs.UserExists), errors.Is(err, errs.TeamExists):
+		return NewError(http.StatusConflict, Uniqueness, response.Error.Message)
+	case errors.Is(err, errs.NoCandidate):
+		return NewError(http.StatusConflict, "", response.Error.Message)
+	default:
+		return NewError(status, "", response.Error.Message)
+	}
+}
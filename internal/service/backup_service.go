@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"reviewers/internal/backup"
+	"reviewers/internal/models"
+	"reviewers/internal/repository"
+	"reviewers/internal/tracing"
+)
+
+type BackupService struct {
+	repo repository.BackupStore
+}
+
+func NewBackupService(repo repository.BackupStore) *BackupService {
+	return &BackupService{repo}
+}
+
+// Export writes every record of the organization to the backup and
+// completes it. A failed export leaves the backup incomplete, so that it
+// cannot be restored.
+func (s *BackupService) Export(ctx context.Context, orgID string, w *backup.Writer) error {
+	ctx, span := tracing.Start(ctx, "BackupService.Export")
+	defer span.End()
+
+	if err := s.repo.Export(ctx, orgID, w.Write); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// Restore saves the records of the backup into the organization, which
+// must be empty. Nothing is saved if any record fails.
+func (s *BackupService) Restore(ctx context.Context, orgID string, r *backup.Reader) (*models.RestoreResult, error) {
+	ctx, span := tracing.Start(ctx, "BackupService.Restore")
+	defer span.End()
+
+	return s.repo.Restore(ctx, orgID, r)
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"reviewers/internal/codeowners"
+	"reviewers/internal/models"
+	"reviewers/internal/repository"
+)
+
+type CodeOwnerService struct {
+	repo        repository.CodeOwnerStore
+	repoService *RepoService
+	userService *UserService
+}
+
+func NewCodeOwnerService(
+	repo repository.CodeOwnerStore,
+	repoService *RepoService,
+	userService *UserService,
+) *CodeOwnerService {
+	return &CodeOwnerService{repo, repoService, userService}
+}
+
+func (s *CodeOwnerService) SetRules(ctx context.Context, orgID, repositoryName string, rules []models.CodeOwnerRule) error {
+	repo, err := s.repoService.GetByName(ctx, orgID, repositoryName)
+	if err != nil {
+		return err
+	}
+	return s.repo.SetRules(ctx, repo.ID, rules)
+}
+
+func (s *CodeOwnerService) GetRules(ctx context.Context, orgID, repositoryName string) ([]models.CodeOwnerRule, error) {
+	repo, err := s.repoService.GetByName(ctx, orgID, repositoryName)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.repo.GetRules(ctx, repo.ID)
+	if rules == nil {
+		rules = []models.CodeOwnerRule{}
+	}
+	return rules, err
+}
+
+// GetOwners returns active owners of the files. Owners of a file are taken
+// from the last rule matching it.
+func (s *CodeOwnerService) GetOwners(ctx context.Context, repo *models.Repository, files []string) ([]*models.User, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	rules, err := s.repo.GetRules(ctx, repo.ID)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+
+	var ownerIDs []string
+	seen := make(map[string]bool)
+	for _, file := range files {
+		for i := len(rules) - 1; i >= 0; i-- {
+			if !codeowners.Match(rules[i].Pattern, file) {
+				continue
+			}
+
+			for _, userID := range rules[i].OwnerIDs {
+				if !seen[userID] {
+					seen[userID] = true
+					ownerIDs = append(ownerIDs, userID)
+				}
+			}
+			break
+		}
+	}
+
+	if len(ownerIDs) == 0 {
+		return nil, nil
+	}
+	return s.userService.GetActive(ctx, repo.OrganizationID, ownerIDs...)
+}
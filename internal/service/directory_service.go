@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"reviewers/internal/models"
+	"reviewers/internal/tracing"
+)
+
+// UnassignedTeam holds the users provisioned by a directory that are not
+// members of any of its groups.
+const UnassignedTeam = "unassigned"
+
+// DirectoryService syncs users and teams with an external directory such
+// as an identity provider. Groups of the directory are teams, a user is a
+// member of a single group, so adding them to a group moves them out of
+// the previous one.
+type DirectoryService struct {
+	userService *UserService
+	teamService *TeamService
+	prService   *PRService
+}
+
+func NewDirectoryService(userService *UserService, teamService *TeamService, prService *PRService) *DirectoryService {
+	return &DirectoryService{userService, teamService, prService}
+}
+
+// GetUser returns the user with the name of their team.
+func (s *DirectoryService) GetUser(ctx context.Context, orgID, userID string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "DirectoryService.GetUser")
+	defer span.End()
+
+	return s.userService.GetWithTeam(ctx, orgID, userID)
+}
+
+// ListUsers returns a page of users and the number of users on all pages.
+func (s *DirectoryService) ListUsers(ctx context.Context, orgID, username string, offset, limit int) ([]models.User, int64, error) {
+	ctx, span := tracing.Start(ctx, "DirectoryService.ListUsers")
+	defer span.End()
+
+	return s.userService.List(ctx, orgID, username, offset, limit)
+}
+
+// CreateUser provisions the user in the unassigned team until they are
+// added to a group.
+func (s *DirectoryService) CreateUser(ctx context.Context, orgID string, user *models.User) error {
+	ctx, span := tracing.Start(ctx, "DirectoryService.CreateUser")
+	defer span.End()
+
+	if err := s.teamService.EnsureTeam(ctx, orgID, UnassignedTeam); err != nil {
+		return err
+	}
+
+	user.TeamName = UnassignedTeam
+	return s.userService.Create(ctx, orgID, user)
+}
+
+// ReplaceUser saves the username, email and activity of the user. A
+// deactivated user is deprovisioned in the same transaction, so if a
+// review has no other candidate, nothing is changed and errs.NoCandidate
+// is returned.
+func (s *DirectoryService) ReplaceUser(ctx context.Context, orgID string, user *models.User) error {
+	ctx, span := tracing.Start(ctx, "DirectoryService.ReplaceUser")
+	defer span.End()
+
+	current, err := s.userService.GetWithTeam(ctx, orgID, user.ID)
+	if err != nil {
+		return err
+	}
+
+	user.OrganizationID = orgID
+	user.TeamName = current.TeamName
+	if current.IsActive && !user.IsActive {
+		// The changes are saved with the deactivation, so that a review
+		// without a candidate leaves the user as it was
+		return s.prService.ReleaseReviews(ctx, orgID, user.ID, func(reassignments []models.Reassignment) error {
+			return s.userService.UpdateAndDeactivate(ctx, user, reassignments)
+		})
+	}
+
+	if err := s.userService.Update(ctx, user); err != nil {
+		return err
+	}
+	if !current.IsActive && user.IsActive {
+		return s.userService.SetActiveStatus(ctx, orgID, user.ID, true)
+	}
+	return nil
+}
+
+// DeprovisionUser reassigns the open reviews of the user and deactivates
+// them in one transaction, so that they get no new reviews. If a review
+// has no other candidate, nothing is changed and errs.NoCandidate is
+// returned.
+func (s *DirectoryService) DeprovisionUser(ctx context.Context, orgID, userID string) error {
+	ctx, span := tracing.Start(ctx, "DirectoryService.DeprovisionUser")
+	defer span.End()
+
+	return s.prService.ReleaseReviews(ctx, orgID, userID, func(reassignments []models.Reassignment) error {
+		return s.userService.Deactivate(ctx, orgID, userID, reassignments)
+	})
+}
+
+// GetGroup returns the team with its members.
+func (s *DirectoryService) GetGroup(ctx context.Context, orgID, teamID string) (*models.Team, error) {
+	ctx, span := tracing.Start(ctx, "DirectoryService.GetGroup")
+	defer span.End()
+
+	return s.teamService.GetTeamByID(ctx, orgID, teamID)
+}
+
+// ListGroups returns a page of teams and the number of teams on all
+// pages.
+func (s *DirectoryService) ListGroups(ctx context.Context, orgID, name string, offset, limit int) ([]models.Team, int64, error) {
+	ctx, span := tracing.Start(ctx, "DirectoryService.ListGroups")
+	defer span.End()
+
+	return s.teamService.ListTeams(ctx, orgID, name, offset, limit)
+}
+
+// CreateGroup creates the team and moves its members, which must exist,
+// to it.
+func (s *DirectoryService) CreateGroup(ctx context.Context, orgID string, team *models.Team) error {
+	ctx, span := tracing.Start(ctx, "DirectoryService.CreateGroup")
+	defer span.End()
+
+	return s.teamService.CreateTeam(ctx, orgID, team)
+}
+
+// ReplaceGroup renames the team and makes team.Members its only members.
+// The users that leave the team are moved to the unassigned team.
+func (s *DirectoryService) ReplaceGroup(ctx context.Context, orgID string, team *models.Team) error {
+	ctx, span := tracing.Start(ctx, "DirectoryService.ReplaceGroup")
+	defer span.End()
+
+	return s.teamService.ReplaceTeam(ctx, orgID, team, UnassignedTeam)
+}
+
+// DeleteGroup deletes the team and moves its members to the unassigned
+// team.
+func (s *DirectoryService) DeleteGroup(ctx context.Context, orgID, teamID string) error {
+	ctx, span := tracing.Start(ctx, "DirectoryService.DeleteGroup")
+	defer span.End()
+
+	return s.teamService.DeleteTeam(ctx, orgID, teamID, UnassignedTeam)
+}
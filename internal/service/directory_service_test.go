@@ -0,0 +1,83 @@
+package service_test
+
+import (
+	"context"
+	"reviewers/internal/models"
+	"reviewers/pkg/errs"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectoryService_DeprovisionUser(t *testing.T) {
+	s := newServices()
+	ctx := context.Background()
+	team := s.createTeam(t, "backend", "", "author", "alice", "bob", "carol")
+
+	pr := &models.PullRequest{ID: "pr-1", Repository: models.DefaultRepository, AuthorID: team["author"]}
+	require.NoError(t, s.prs.Create(ctx, orgID, pr))
+	require.Len(t, pr.AssignedReviewers, 2)
+	first, second := pr.AssignedReviewers[0], pr.AssignedReviewers[1]
+	var spare string
+	for _, username := range []string{"alice", "bob", "carol"} {
+		if !slices.Contains(pr.AssignedReviewers, team[username]) {
+			spare = team[username]
+		}
+	}
+
+	require.NoError(t, s.directory.DeprovisionUser(ctx, orgID, first))
+	user, err := s.users.Get(ctx, orgID, first)
+	require.NoError(t, err)
+	assert.False(t, user.IsActive)
+	details, err := s.prs.Get(ctx, orgID, models.DefaultRepository, "pr-1")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{second, spare}, details.AssignedReviewers)
+
+	// Nobody is left to take over the review, so the user stays active
+	// with it
+	err = s.directory.DeprovisionUser(ctx, orgID, second)
+	assert.ErrorIs(t, err, errs.NoCandidate)
+	user, err = s.users.Get(ctx, orgID, second)
+	require.NoError(t, err)
+	assert.True(t, user.IsActive)
+	details, err = s.prs.Get(ctx, orgID, models.DefaultRepository, "pr-1")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{second, spare}, details.AssignedReviewers)
+}
+
+func TestDirectoryService_ReplaceUser(t *testing.T) {
+	s := newServices()
+	ctx := context.Background()
+	team := s.createTeam(t, "backend", "", "author", "alice", "bob")
+
+	pr := &models.PullRequest{ID: "pr-1", Repository: models.DefaultRepository, AuthorID: team["author"]}
+	require.NoError(t, s.prs.Create(ctx, orgID, pr))
+
+	// Nobody can take over the review of alice, so neither the username
+	// nor the activity changes
+	err := s.directory.ReplaceUser(ctx, orgID, &models.User{ID: team["alice"], Username: "alice.smith", Email: "alice@example.com"})
+	assert.ErrorIs(t, err, errs.NoCandidate)
+	user, err := s.users.Get(ctx, orgID, team["alice"])
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Username)
+	assert.Empty(t, user.Email)
+	assert.True(t, user.IsActive)
+
+	_, err = s.prs.Merge(ctx, orgID, models.DefaultRepository, "pr-1")
+	require.NoError(t, err)
+	require.NoError(t, s.directory.ReplaceUser(ctx, orgID, &models.User{ID: team["alice"], Username: "alice.smith", Email: "alice@example.com"}))
+	user, err = s.users.GetWithTeam(ctx, orgID, team["alice"])
+	require.NoError(t, err)
+	assert.Equal(t, "alice.smith", user.Username)
+	assert.Equal(t, "alice@example.com", user.Email)
+	assert.Equal(t, "backend", user.TeamName)
+	assert.False(t, user.IsActive)
+
+	require.NoError(t, s.directory.ReplaceUser(ctx, orgID, &models.User{ID: team["alice"], Username: "alice", IsActive: true}))
+	user, err = s.users.Get(ctx, orgID, team["alice"])
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Username)
+	assert.True(t, user.IsActive)
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"reviewers/internal/models"
+	"reviewers/internal/repository"
+	"reviewers/internal/tracing"
+)
+
+type OrganizationService struct {
+	repo repository.OrganizationStore
+}
+
+func NewOrganizationService(repo repository.OrganizationStore) *OrganizationService {
+	return &OrganizationService{repo}
+}
+
+func (s *OrganizationService) Create(ctx context.Context, org *models.Organization) error {
+	ctx, span := tracing.Start(ctx, "OrganizationService.Create")
+	defer span.End()
+
+	return s.repo.Create(ctx, org)
+}
+
+func (s *OrganizationService) Get(ctx context.Context, orgID string) (*models.Organization, error) {
+	ctx, span := tracing.Start(ctx, "OrganizationService.Get")
+	defer span.End()
+
+	return s.repo.Get(ctx, orgID)
+}
@@ -1,69 +1,161 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"math/rand/v2"
-	"reviewers/internal/errs"
+	"reviewers/internal/events"
+	"reviewers/internal/metrics"
 	"reviewers/internal/models"
 	"reviewers/internal/repository"
+	"reviewers/internal/tracing"
+	"reviewers/pkg/errs"
 	"slices"
 	"time"
 )
 
 type PRService struct {
-	repo        *repository.PRRepository
-	teamService *TeamService
-	userService *UserService
+	repo             repository.PRStore
+	repoService      *RepoService
+	teamService      *TeamService
+	userService      *UserService
+	codeOwnerService *CodeOwnerService
+	broker           *events.Broker
 }
 
 func NewPRService(
-	repo *repository.PRRepository,
+	repo repository.PRStore,
+	repoService *RepoService,
 	teamService *TeamService,
 	userService *UserService,
+	codeOwnerService *CodeOwnerService,
+	broker *events.Broker,
 ) *PRService {
-	return &PRService{repo, teamService, userService}
+	return &PRService{repo, repoService, teamService, userService, codeOwnerService, broker}
 }
 
-func (s *PRService) Create(pr *models.PullRequest) error {
+func (s *PRService) Create(ctx context.Context, orgID string, pr *models.PullRequest) error {
+	ctx, span := tracing.Start(ctx, "PRService.Create")
+	defer span.End()
+
+	repo, err := s.repoService.GetByName(ctx, orgID, pr.Repository)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.userService.Get(ctx, orgID, pr.AuthorID); err != nil {
+		return err
+	}
+
+	pr.OrganizationID = orgID
+	pr.RepositoryID = repo.ID
+	pr.Repository = repo.Name
 	pr.CreatedAt = time.Now()
 	pr.Status = models.StatusOpen
 
-	reviewers, err := s.teamService.GetReviewerIdsFromUserTeam(pr.AuthorID)
+	pr.Files = make([]models.PullRequestFile, 0, len(pr.ChangedFiles))
+	for _, path := range pr.ChangedFiles {
+		file := models.PullRequestFile{RepositoryID: pr.RepositoryID, PullRequestID: pr.ID, Path: path}
+		if !slices.Contains(pr.Files, file) {
+			pr.Files = append(pr.Files, file)
+		}
+	}
+
+	candidates, err := s.getCandidates(ctx, repo, pr, repo.ReviewersCount)
 	if err != nil {
 		return err
 	}
 
-	pr.Reviewers = getRandomReviewers(pr.ID, reviewers, 2)
+	pr.Reviewers = getRandomReviewers(pr, candidates, repo.ReviewersCount)
 	for _, reviewer := range pr.Reviewers {
 		pr.AssignedReviewers = append(pr.AssignedReviewers, reviewer.UserID)
 	}
 
-	return s.repo.Create(pr)
+	if err := s.repo.Create(ctx, pr); err != nil {
+		return err
+	}
+
+	metrics.PullRequestsCreated.Inc()
+
+	created := []events.Event{events.NewEvent(events.PullRequestCreated, pr)}
+	for _, reviewerID := range pr.AssignedReviewers {
+		assigned := events.NewEvent(events.ReviewerAssigned, pr)
+		assigned.ReviewerID = reviewerID
+		created = append(created, assigned)
+	}
+	s.broker.Publish(created...)
+	return nil
 }
 
-func (s *PRService) Merge(pullRequestID string) (*models.PullRequest, error) {
-	pr, err := s.repo.Get(pullRequestID)
+func (s *PRService) Merge(ctx context.Context, orgID, repositoryName, pullRequestID string) (*models.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PRService.Merge")
+	defer span.End()
+
+	repo, err := s.repoService.GetByName(ctx, orgID, repositoryName)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := s.repo.Get(ctx, orgID, repo.ID, pullRequestID)
 	if err != nil || pr == nil {
 		return nil, err
 	}
+	pr.Repository = repo.Name
 
 	if pr.Status == models.StatusMerged {
 		return pr, nil
 	}
 
-	err = s.repo.Merge(pullRequestID)
+	err = s.repo.Merge(ctx, orgID, repo.ID, pullRequestID)
 	if err != nil {
 		return nil, err
 	}
-	return s.repo.Get(pullRequestID)
+	metrics.PullRequestsMerged.Inc()
+
+	pr, err = s.repo.Get(ctx, orgID, repo.ID, pullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	pr.Repository = repo.Name
+
+	s.broker.Publish(events.NewEvent(events.PullRequestMerged, pr))
+	return pr, nil
 }
 
-func (s *PRService) Reassign(pullRequestID, oldReviewerID string) (*models.PullRequest, error) {
-	pr, err := s.repo.Get(pullRequestID)
+func (s *PRService) Reassign(ctx context.Context, orgID, repositoryName, pullRequestID, oldReviewerID string) (*models.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PRService.Reassign")
+	defer span.End()
+
+	pr, err := s.reassign(ctx, orgID, repositoryName, pullRequestID, oldReviewerID)
+
+	switch {
+	case err == nil:
+		metrics.Reassignments.WithLabelValues(metrics.OutcomeSuccess).Inc()
+	case errors.Is(err, errs.NoCandidate):
+		metrics.Reassignments.WithLabelValues(metrics.OutcomeNoCandidate).Inc()
+	case errors.Is(err, errs.NotAssigned):
+		metrics.Reassignments.WithLabelValues(metrics.OutcomeNotAssigned).Inc()
+	default:
+		metrics.Reassignments.WithLabelValues(metrics.OutcomeError).Inc()
+	}
+
+	return pr, err
+}
+
+func (s *PRService) reassign(ctx context.Context, orgID, repositoryName, pullRequestID, oldReviewerID string) (*models.PullRequest, error) {
+	repo, err := s.repoService.GetByName(ctx, orgID, repositoryName)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := s.repo.Get(ctx, orgID, repo.ID, pullRequestID)
 	if err != nil || pr == nil {
 		return nil, err
 	}
+	pr.Repository = repo.Name
 
-	_, err = s.userService.Get(oldReviewerID)
+	_, err = s.userService.Get(ctx, orgID, oldReviewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -79,39 +171,269 @@ func (s *PRService) Reassign(pullRequestID, oldReviewerID string) (*models.PullR
 		return nil, errs.NotAssigned
 	}
 
-	reviewers, err := s.teamService.GetReviewerIdsFromUserTeam(pr.AuthorID, pr.AssignedReviewers...)
+	candidates, err := s.getCandidates(ctx, repo, pr, 1, pr.AssignedReviewers...)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(reviewers) == 0 {
+	newReviewers := getRandomReviewers(pr, candidates, 1)
+	if len(newReviewers) == 0 {
 		return nil, errs.NoCandidate
 	}
 
-	newReviewer := getRandomReviewers(pullRequestID, reviewers, 1)[0]
-	pr.Reviewers[oldReviewerIdx] = newReviewer
+	reassignment, reassigned := replaceReviewer(pr, oldReviewerIdx, newReviewers[0])
+	if err := s.repo.Save(ctx, pr, reassignment.Events...); err != nil {
+		return nil, err
+	}
+	s.broker.Publish(reassigned)
+	return pr, nil
+}
+
+// ReleaseReviews reassigns the open pull requests the user reviews to
+// other candidates. Candidates are picked for every pull request first,
+// when one has none, nothing is changed and errs.NoCandidate is returned.
+// Then apply saves the reassignments together with the change of the user
+// that frees them from reviews, e.g. UserService.Delete, in one
+// transaction.
+func (s *PRService) ReleaseReviews(ctx context.Context, orgID, userID string, apply func([]models.Reassignment) error) error {
+	ctx, span := tracing.Start(ctx, "PRService.ReleaseReviews")
+	defer span.End()
+
+	reassignments, reassigned, err := s.planReleases(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := apply(reassignments); err != nil {
+		if len(reassignments) > 0 {
+			metrics.Reassignments.WithLabelValues(metrics.OutcomeError).Inc()
+		}
+		return err
+	}
+	metrics.Reassignments.WithLabelValues(metrics.OutcomeSuccess).Add(float64(len(reassignments)))
+	s.broker.Publish(reassigned...)
+	return nil
+}
+
+// planReleases picks another reviewer for every open pull request the
+// user reviews and returns the reassignments with the events to publish
+// once they are saved.
+func (s *PRService) planReleases(ctx context.Context, orgID, userID string) ([]models.Reassignment, []events.Event, error) {
+	prs, err := s.repo.GetByReviewerIDs(ctx, orgID, []string{userID})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var open []*models.PullRequest
+	var repositoryIDs []string
+	for i := range prs {
+		if prs[i].Status == models.StatusOpen {
+			open = append(open, &prs[i])
+			repositoryIDs = append(repositoryIDs, prs[i].RepositoryID)
+		}
+	}
+	if len(open) == 0 {
+		return nil, nil, nil
+	}
+
+	repos, err := s.repoService.GetByIDs(ctx, orgID, repositoryIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	reposByID := make(map[string]*models.Repository, len(repos))
+	for i := range repos {
+		reposByID[repos[i].ID] = &repos[i]
+	}
+
+	newReviewers := make([]models.PullRequestReviewer, len(open))
+	for i, pr := range open {
+		repo := reposByID[pr.RepositoryID]
+		pr.Repository = repo.Name
+
+		candidates, err := s.getCandidates(ctx, repo, pr, 1, pr.AssignedReviewers...)
+		if err != nil {
+			return nil, nil, err
+		}
+		picked := getRandomReviewers(pr, candidates, 1)
+		if len(picked) == 0 {
+			metrics.Reassignments.WithLabelValues(metrics.OutcomeNoCandidate).Inc()
+			return nil, nil, errs.NoCandidate.WithMessage(fmt.Sprintf("no candidate to take over the review of PR %s", pr.ID))
+		}
+		newReviewers[i] = picked[0]
+	}
+
+	reassignments := make([]models.Reassignment, len(open))
+	reassigned := make([]events.Event, len(open))
+	for i, pr := range open {
+		reassignments[i], reassigned[i] = replaceReviewer(pr, slices.Index(pr.AssignedReviewers, userID), newReviewers[i])
+	}
+	return reassignments, reassigned, nil
+}
+
+// replaceReviewer replaces the reviewer at the given index of the pull
+// request with the new one. It returns the change to save and the event
+// to publish once it is saved.
+func replaceReviewer(pr *models.PullRequest, idx int, newReviewer models.PullRequestReviewer) (models.Reassignment, events.Event) {
+	oldReviewerID := pr.Reviewers[idx].UserID
+	pr.Reviewers[idx] = newReviewer
 
 	pr.AssignedReviewers = make([]string, 0, len(pr.Reviewers))
 	for _, reviewer := range pr.Reviewers {
 		pr.AssignedReviewers = append(pr.AssignedReviewers, reviewer.UserID)
 	}
 
-	err = s.repo.Save(pr)
+	reassignment := models.Reassignment{
+		PullRequest: pr,
+		Events: []models.PullRequestEvent{
+			models.NewPullRequestEvent(pr, models.EventUnassigned, oldReviewerID),
+			models.NewPullRequestEvent(pr, models.EventAssigned, newReviewer.UserID),
+		},
+	}
+
+	reassigned := events.NewEvent(events.ReviewerReassigned, pr)
+	reassigned.ReviewerID = newReviewer.UserID
+	reassigned.OldReviewerID = oldReviewerID
+	return reassignment, reassigned
+}
+
+func (s *PRService) Approve(ctx context.Context, orgID, repositoryName, pullRequestID, reviewerID string) (*models.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PRService.Approve")
+	defer span.End()
+
+	repo, err := s.repoService.GetByName(ctx, orgID, repositoryName)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := s.repo.Get(ctx, orgID, repo.ID, pullRequestID)
+	if err != nil || pr == nil {
+		return nil, err
+	}
+	pr.Repository = repo.Name
+
+	if pr.Status == models.StatusMerged {
+		return nil, errs.PullRequestMerged
+	}
+
+	if !slices.Contains(pr.AssignedReviewers, reviewerID) {
+		return nil, errs.NotAssigned
+	}
+
+	err = s.repo.Approve(ctx, pr, reviewerID)
 	return pr, err
 }
 
-func getRandomReviewers(pullRequestID string, reviewers []*models.User, count int) []models.PullRequestReviewer {
-	shuffled := make([]models.PullRequestReviewer, 0, len(reviewers))
-	for _, user := range reviewers {
-		shuffled = append(shuffled, models.PullRequestReviewer{
-			UserID:        user.ID,
-			PullRequestID: pullRequestID,
-		})
+// Get returns the pull request with the details of its reviewers.
+func (s *PRService) Get(ctx context.Context, orgID, repositoryName, pullRequestID string) (*models.PullRequestDetails, error) {
+	ctx, span := tracing.Start(ctx, "PRService.Get")
+	defer span.End()
+
+	repo, err := s.repoService.GetByName(ctx, orgID, repositoryName)
+	if err != nil {
+		return nil, err
 	}
 
-	rand.Shuffle(len(shuffled), func(i, j int) {
-		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
-	})
+	details, err := s.repo.GetDetails(ctx, orgID, repo.ID, pullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	details.Repository = repo.Name
+	return details, nil
+}
+
+func (s *PRService) List(ctx context.Context, orgID string, filter models.PullRequestFilter) (*models.PullRequestPage, error) {
+	ctx, span := tracing.Start(ctx, "PRService.List")
+	defer span.End()
+
+	return s.repo.List(ctx, orgID, filter)
+}
+
+// getCandidates returns candidates for review of the PR grouped by priority.
+// Owners of the changed files come first, then the author's team (and its
+// parent units if the repository allows it) and the owning team of the
+// repository last.
+func (s *PRService) getCandidates(
+	ctx context.Context,
+	repo *models.Repository,
+	pr *models.PullRequest,
+	count int,
+	excludedUsers ...string,
+) ([][]*models.User, error) {
+	owners, err := s.codeOwnerService.GetOwners(ctx, repo, pr.ChangedFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := slices.Clone(excludedUsers)
+	ownersTier := make([]*models.User, 0, len(owners))
+	for _, owner := range owners {
+		if owner.ID == pr.AuthorID || slices.Contains(excludedUsers, owner.ID) {
+			continue
+		}
+		ownersTier = append(ownersTier, owner)
+		excluded = append(excluded, owner.ID)
+	}
+	tiers := [][]*models.User{ownersTier}
+	found := len(ownersTier)
+
+	var teamTiers [][]*models.User
+	if repo.ClimbHierarchy {
+		teamTiers, err = s.teamService.GetReviewerCandidates(ctx, repo.OrganizationID, pr.AuthorID, count-found, excluded...)
+	} else {
+		var candidates []*models.User
+		candidates, err = s.teamService.GetReviewerIdsFromUserTeam(ctx, repo.OrganizationID, pr.AuthorID, excluded...)
+		teamTiers = [][]*models.User{candidates}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tier := range teamTiers {
+		tiers = append(tiers, tier)
+		found += len(tier)
+		for _, candidate := range tier {
+			excluded = append(excluded, candidate.ID)
+		}
+	}
+
+	if found < count && repo.TeamID != nil {
+		candidates, err := s.teamService.GetReviewersFromTeam(ctx,
+			repo.OrganizationID,
+			*repo.TeamID,
+			append(excluded, pr.AuthorID)...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tiers = append(tiers, candidates)
+	}
+
+	return tiers, nil
+}
+
+// getRandomReviewers picks count reviewers from the candidate groups.
+// Groups are used in order, candidates within a group are picked randomly.
+func getRandomReviewers(pr *models.PullRequest, candidates [][]*models.User, count int) []models.PullRequestReviewer {
+	picked := make([]models.PullRequestReviewer, 0, count)
+	for _, tier := range candidates {
+		shuffled := make([]models.PullRequestReviewer, 0, len(tier))
+		for _, user := range tier {
+			shuffled = append(shuffled, models.PullRequestReviewer{
+				RepositoryID:  pr.RepositoryID,
+				PullRequestID: pr.ID,
+				UserID:        user.ID,
+			})
+		}
+
+		rand.Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+
+		picked = append(picked, shuffled[:min(len(shuffled), count-len(picked))]...)
+		if len(picked) == count {
+			break
+		}
+	}
 
-	return shuffled[:min(len(shuffled), count)]
+	return picked
 }
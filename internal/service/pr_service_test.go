@@ -0,0 +1,201 @@
+package service_test
+
+import (
+	"context"
+	"log/slog"
+	"reviewers/internal/events"
+	"reviewers/internal/models"
+	"reviewers/internal/repository"
+	"reviewers/internal/service"
+	"reviewers/pkg/errs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const orgID = models.DefaultOrganizationID
+
+type services struct {
+	stores    *repository.Stores
+	teams     *service.TeamService
+	users     *service.UserService
+	prs       *service.PRService
+	directory *service.DirectoryService
+}
+
+func newServices() *services {
+	stores := repository.NewMemoryStores(repository.NewMemoryDB(), slog.New(slog.DiscardHandler))
+	userService := service.NewUserService(stores.Users)
+	teamService := service.NewTeamService(stores.Teams)
+	repoService := service.NewRepoService(stores.Repositories)
+	codeOwnerService := service.NewCodeOwnerService(stores.CodeOwners, repoService, userService)
+	prService := service.NewPRService(stores.PullRequests, repoService, teamService, userService, codeOwnerService, events.NewBroker(100))
+	return &services{
+		stores:    stores,
+		teams:     teamService,
+		users:     userService,
+		prs:       prService,
+		directory: service.NewDirectoryService(userService, teamService, prService),
+	}
+}
+
+// createTeam creates a team with active members of the given usernames
+// and returns their IDs by username.
+func (s *services) createTeam(t *testing.T, name, parentName string, usernames ...string) map[string]string {
+	team := &models.Team{Name: name, ParentName: parentName}
+	for _, username := range usernames {
+		team.Members = append(team.Members, models.User{Username: username, IsActive: true})
+	}
+	require.NoError(t, s.teams.CreateTeam(context.Background(), orgID, team))
+
+	ids := make(map[string]string, len(team.Members))
+	for _, member := range team.Members {
+		ids[member.Username] = member.ID
+	}
+	return ids
+}
+
+func TestPRService_CreateAssignsTeammatesFirst(t *testing.T) {
+	s := newServices()
+	ctx := context.Background()
+	department := s.createTeam(t, "department", "", "lead", "architect")
+	squad := s.createTeam(t, "squad", "department", "author", "peer")
+
+	pr := &models.PullRequest{ID: "pr-1", Name: "Change", Repository: models.DefaultRepository, AuthorID: squad["author"]}
+	require.NoError(t, s.prs.Create(ctx, orgID, pr))
+
+	// The only teammate is picked, the second reviewer comes from the
+	// parent team
+	require.Len(t, pr.AssignedReviewers, 2)
+	assert.Equal(t, squad["peer"], pr.AssignedReviewers[0])
+	assert.Contains(t, []string{department["lead"], department["architect"]}, pr.AssignedReviewers[1])
+
+	details, err := s.prs.Get(ctx, orgID, models.DefaultRepository, "pr-1")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, pr.AssignedReviewers, details.AssignedReviewers)
+
+	err = s.prs.Create(ctx, orgID, &models.PullRequest{ID: "pr-1", Repository: models.DefaultRepository, AuthorID: squad["author"]})
+	assert.ErrorIs(t, err, errs.PullRequestExists)
+}
+
+func TestPRService_Reassign(t *testing.T) {
+	s := newServices()
+	ctx := context.Background()
+	team := s.createTeam(t, "backend", "", "author", "alice", "bob", "carol")
+
+	pr := &models.PullRequest{ID: "pr-1", Repository: models.DefaultRepository, AuthorID: team["author"]}
+	require.NoError(t, s.prs.Create(ctx, orgID, pr))
+	require.Len(t, pr.AssignedReviewers, 2)
+	old := pr.AssignedReviewers[0]
+
+	reassigned, err := s.prs.Reassign(ctx, orgID, models.DefaultRepository, "pr-1", old)
+	require.NoError(t, err)
+	assert.NotContains(t, reassigned.AssignedReviewers, old)
+	assert.NotContains(t, reassigned.AssignedReviewers, team["author"])
+	assert.Len(t, reassigned.AssignedReviewers, 2)
+
+	_, err = s.prs.Reassign(ctx, orgID, models.DefaultRepository, "pr-1", old)
+	assert.ErrorIs(t, err, errs.NotAssigned)
+
+	// Nobody but the reviewers is left to take over the review
+	require.NoError(t, s.users.SetActiveStatus(ctx, orgID, old, false))
+	_, err = s.prs.Reassign(ctx, orgID, models.DefaultRepository, "pr-1", reassigned.AssignedReviewers[0])
+	assert.ErrorIs(t, err, errs.NoCandidate)
+
+	_, err = s.prs.Merge(ctx, orgID, models.DefaultRepository, "pr-1")
+	require.NoError(t, err)
+	_, err = s.prs.Approve(ctx, orgID, models.DefaultRepository, "pr-1", reassigned.AssignedReviewers[0])
+	assert.ErrorIs(t, err, errs.PullRequestMerged)
+}
+
+func TestPRService_ReleaseReviews(t *testing.T) {
+	s := newServices()
+	ctx := context.Background()
+	team := s.createTeam(t, "backend", "", "author", "alice", "bob")
+
+	pr := &models.PullRequest{ID: "pr-1", Repository: models.DefaultRepository, AuthorID: team["author"]}
+	require.NoError(t, s.prs.Create(ctx, orgID, pr))
+	require.ElementsMatch(t, []string{team["alice"], team["bob"]}, pr.AssignedReviewers)
+
+	deleteAlice := func(reassignments []models.Reassignment) error {
+		return s.users.Delete(ctx, orgID, team["alice"], reassignments)
+	}
+
+	// Both teammates review already, so the review of alice cannot be
+	// handed over
+	assert.ErrorIs(t, s.prs.ReleaseReviews(ctx, orgID, team["alice"], deleteAlice), errs.NoCandidate)
+
+	s.createTeam(t, "frontend", "", "carol")
+	carol := mustFind(t, s, "carol")
+	require.NoError(t, s.users.Update(ctx, &models.User{ID: carol, OrganizationID: orgID, Username: "carol", TeamName: "backend"}))
+
+	// A review assigned after the reassignments were planned keeps the
+	// user and rolls the reassignments back
+	err := s.prs.ReleaseReviews(ctx, orgID, team["alice"], func(reassignments []models.Reassignment) error {
+		require.NoError(t, s.stores.PullRequests.Create(ctx, &models.PullRequest{
+			OrganizationID: orgID,
+			RepositoryID:   models.DefaultRepositoryID,
+			ID:             "pr-2",
+			Status:         models.StatusOpen,
+			AuthorID:       team["author"],
+			Reviewers:      []models.PullRequestReviewer{{UserID: team["alice"]}},
+		}))
+		return deleteAlice(reassignments)
+	})
+	assert.ErrorIs(t, err, errs.NoCandidate)
+	details, err := s.prs.Get(ctx, orgID, models.DefaultRepository, "pr-1")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{team["alice"], team["bob"]}, details.AssignedReviewers)
+
+	// Reviews of merged pull requests keep the user, who can only be
+	// deactivated then
+	_, err = s.prs.Merge(ctx, orgID, models.DefaultRepository, "pr-2")
+	require.NoError(t, err)
+	assert.ErrorIs(t, s.prs.ReleaseReviews(ctx, orgID, team["alice"], deleteAlice), errs.UserHasPullRequests)
+	details, err = s.prs.Get(ctx, orgID, models.DefaultRepository, "pr-1")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{team["alice"], team["bob"]}, details.AssignedReviewers)
+
+	require.NoError(t, s.prs.ReleaseReviews(ctx, orgID, team["alice"], func(reassignments []models.Reassignment) error {
+		return s.users.Deactivate(ctx, orgID, team["alice"], reassignments)
+	}))
+	details, err = s.prs.Get(ctx, orgID, models.DefaultRepository, "pr-1")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{team["bob"], carol}, details.AssignedReviewers)
+	user, err := s.users.Get(ctx, orgID, team["alice"])
+	require.NoError(t, err)
+	assert.False(t, user.IsActive)
+}
+
+func TestPRService_ReleaseReviews_Merged(t *testing.T) {
+	s := newServices()
+	ctx := context.Background()
+	team := s.createTeam(t, "backend", "", "author", "alice", "bob", "carol")
+
+	pr := &models.PullRequest{ID: "pr-1", Repository: models.DefaultRepository, AuthorID: team["author"]}
+	require.NoError(t, s.prs.Create(ctx, orgID, pr))
+	reviewer := pr.AssignedReviewers[0]
+
+	// A pull request merged after the reassignments were planned stays
+	// merged with its reviewers
+	err := s.prs.ReleaseReviews(ctx, orgID, reviewer, func(reassignments []models.Reassignment) error {
+		require.Len(t, reassignments, 1)
+		_, err := s.prs.Merge(ctx, orgID, models.DefaultRepository, "pr-1")
+		require.NoError(t, err)
+		return s.users.Deactivate(ctx, orgID, reviewer, reassignments)
+	})
+	require.NoError(t, err)
+
+	details, err := s.prs.Get(ctx, orgID, models.DefaultRepository, "pr-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusMerged, details.Status)
+	assert.ElementsMatch(t, pr.AssignedReviewers, details.AssignedReviewers)
+}
+
+func mustFind(t *testing.T, s *services, username string) string {
+	users, _, err := s.users.List(context.Background(), orgID, username, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	return users[0].ID
+}
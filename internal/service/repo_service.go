@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"reviewers/internal/models"
+	"reviewers/internal/repository"
+)
+
+type RepoService struct {
+	repo repository.RepoStore
+}
+
+func NewRepoService(repo repository.RepoStore) *RepoService {
+	return &RepoService{repo}
+}
+
+func (s *RepoService) Create(ctx context.Context, orgID string, repo *models.Repository) error {
+	repo.OrganizationID = orgID
+	return s.repo.Create(ctx, repo)
+}
+
+func (s *RepoService) Update(ctx context.Context, repo *models.Repository) error {
+	return s.repo.Update(ctx, repo)
+}
+
+// GetByName returns the repository with the given name. An empty name
+// refers to the default repository.
+func (s *RepoService) GetByName(ctx context.Context, orgID, name string) (*models.Repository, error) {
+	if name == "" {
+		name = models.DefaultRepository
+	}
+	return s.repo.GetByName(ctx, orgID, name)
+}
+
+// GetByIDs returns the repositories with the given IDs. Their owning teams
+// are not resolved.
+func (s *RepoService) GetByIDs(ctx context.Context, orgID string, repositoryIDs []string) ([]models.Repository, error) {
+	return s.repo.GetByIDs(ctx, orgID, repositoryIDs)
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"reviewers/internal/models"
+	"reviewers/internal/repository"
+	"reviewers/internal/tracing"
+	"slices"
+)
+
+type StatsService struct {
+	repo        repository.StatsStore
+	teamService *TeamService
+}
+
+func NewStatsService(repo repository.StatsStore, teamService *TeamService) *StatsService {
+	return &StatsService{repo, teamService}
+}
+
+func (s *StatsService) GetReviewerStats(ctx context.Context, orgID string, filter models.StatsFilter) ([]models.ReviewerStats, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.GetReviewerStats")
+	defer span.End()
+
+	if filter.TeamName != "" {
+		if _, err := s.teamService.GetTeam(ctx, orgID, filter.TeamName, false); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.GetReviewerStats(ctx, orgID, filter)
+}
+
+// GetTeamReport returns the fairness report of the team. Review load of a
+// member is the number of reviews assigned to them within the time range.
+func (s *StatsService) GetTeamReport(ctx context.Context, orgID string, filter models.StatsFilter) (*models.TeamReport, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.GetTeamReport")
+	defer span.End()
+
+	reviewers, err := s.GetReviewerStats(ctx, orgID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	merges, err := s.repo.GetMergeStats(ctx, orgID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.TeamReport{
+		TeamName:              filter.TeamName,
+		Members:               len(reviewers),
+		MergedPullRequests:    merges.Merged,
+		MeanTimeToMerge:       merges.MeanTimeToMerge,
+		MembersWithoutReviews: []models.UserShort{},
+		Reviewers:             reviewers,
+	}
+	if !filter.From.IsZero() {
+		report.From = &filter.From
+	}
+	if !filter.To.IsZero() {
+		report.To = &filter.To
+	}
+
+	load := make([]float64, 0, len(reviewers))
+	var reassigned int64
+	for _, reviewer := range reviewers {
+		load = append(load, float64(reviewer.Assigned))
+		report.TotalAssigned += reviewer.Assigned
+		reassigned += reviewer.ReassignedAway
+
+		if reviewer.Assigned == 0 {
+			report.MembersWithoutReviews = append(report.MembersWithoutReviews, models.UserShort{
+				ID:       reviewer.UserID,
+				Username: reviewer.Username,
+			})
+		}
+	}
+
+	report.Gini = gini(load)
+	if len(load) > 0 {
+		if minLoad, maxLoad := slices.Min(load), slices.Max(load); minLoad > 0 {
+			ratio := maxLoad / minLoad
+			report.MaxMinRatio = &ratio
+		}
+	}
+	if report.TotalAssigned > 0 {
+		rate := float64(reassigned) / float64(report.TotalAssigned)
+		report.ReassignmentRate = &rate
+	}
+
+	return report, nil
+}
+
+// gini returns the Gini coefficient of the values: 0 when all values are
+// equal and close to 1 when a single value holds everything.
+func gini(values []float64) float64 {
+	sorted := slices.Sorted(slices.Values(values))
+
+	var sum, weighted float64
+	for i, value := range sorted {
+		sum += value
+		weighted += float64(i+1) * value
+	}
+	if sum == 0 {
+		return 0
+	}
+
+	n := float64(len(sorted))
+	return 2*weighted/(n*sum) - (n+1)/n
+}
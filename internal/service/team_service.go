@@ -1,30 +1,179 @@
 package service
 
 import (
+	"context"
 	"reviewers/internal/models"
 	"reviewers/internal/repository"
+	"reviewers/internal/tracing"
 )
 
 type TeamService struct {
-	repo *repository.TeamRepository
+	repo repository.TeamStore
 }
 
-func NewTeamService(repo *repository.TeamRepository) *TeamService {
+func NewTeamService(repo repository.TeamStore) *TeamService {
 	return &TeamService{repo}
 }
 
-func (s *TeamService) GetTeam(name string) (*models.Team, error) {
-	return s.repo.GetTeam(name)
+func (s *TeamService) GetTeam(ctx context.Context, orgID, name string, withSubteams bool) (*models.Team, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.GetTeam")
+	defer span.End()
+
+	return s.repo.GetTeam(ctx, orgID, name, withSubteams)
+}
+
+func (s *TeamService) CreateTeam(ctx context.Context, orgID string, newTeam *models.Team) error {
+	ctx, span := tracing.Start(ctx, "TeamService.CreateTeam")
+	defer span.End()
+
+	return s.repo.CreateTeam(ctx, orgID, newTeam)
+}
+
+func (s *TeamService) GetReviewerIdsFromUserTeam(ctx context.Context, orgID, userID string, excludedUsers ...string) ([]*models.User, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.GetReviewerIdsFromUserTeam")
+	defer span.End()
+
+	return s.repo.GetReviewerIdsFromUserTeam(ctx, orgID, userID, excludedUsers...)
+}
+
+// GetReviewerCandidates returns candidates for review grouped by priority.
+// The first group contains members of the user's team. If it has fewer
+// than count candidates, the search climbs up the team hierarchy and every
+// next group contains the rest of the parent unit.
+func (s *TeamService) GetReviewerCandidates(
+	ctx context.Context,
+	orgID, userID string,
+	count int,
+	excludedUsers ...string,
+) ([][]*models.User, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.GetReviewerCandidates")
+	defer span.End()
+
+	candidates, err := s.repo.GetReviewerIdsFromUserTeam(ctx, orgID, userID, excludedUsers...)
+	if err != nil {
+		return nil, err
+	}
+
+	tiers := [][]*models.User{candidates}
+	found := len(candidates)
+	if found >= count {
+		return tiers, nil
+	}
+
+	ancestors, err := s.repo.GetAncestorTeamIDs(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := append([]string{userID}, excludedUsers...)
+	for _, teamID := range ancestors {
+		if found >= count {
+			break
+		}
+
+		for _, candidate := range candidates {
+			excluded = append(excluded, candidate.ID)
+		}
+
+		candidates, err = s.repo.GetReviewersFromTeamTree(ctx, orgID, teamID, excluded...)
+		if err != nil {
+			return nil, err
+		}
+
+		tiers = append(tiers, candidates)
+		found += len(candidates)
+	}
+
+	return tiers, nil
+}
+
+// GetReviewersFromTeam returns active users of the team and its subteams.
+func (s *TeamService) GetReviewersFromTeam(ctx context.Context, orgID, teamID string, excludedUsers ...string) ([]*models.User, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.GetReviewersFromTeam")
+	defer span.End()
+
+	return s.repo.GetReviewersFromTeamTree(ctx, orgID, teamID, excludedUsers...)
+}
+
+func (s *TeamService) DeactivateTeam(ctx context.Context, orgID, teamID string) error {
+	ctx, span := tracing.Start(ctx, "TeamService.DeactivateTeam")
+	defer span.End()
+
+	return s.repo.DeactivateTeam(ctx, orgID, teamID)
+}
+
+func (s *TeamService) SetParent(ctx context.Context, orgID, teamName, parentName string) error {
+	ctx, span := tracing.Start(ctx, "TeamService.SetParent")
+	defer span.End()
+
+	return s.repo.SetParent(ctx, orgID, teamName, parentName)
 }
 
-func (s *TeamService) CreateTeam(newTeam *models.Team) error {
-	return s.repo.CreateTeam(newTeam)
+func (s *TeamService) GetStats(ctx context.Context, orgID, name string) (*models.TeamStats, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.GetStats")
+	defer span.End()
+
+	team, err := s.repo.GetTeam(ctx, orgID, name, true)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetStats(ctx, team)
 }
 
-func (s *TeamService) GetReviewerIdsFromUserTeam(userID string, excludedUsers ...string) ([]*models.User, error) {
-	return s.repo.GetReviewerIdsFromUserTeam(userID, excludedUsers...)
+// Import applies the roster to the teams and users of the organization,
+// with dryRun only the changes it would make are returned.
+func (s *TeamService) Import(ctx context.Context, orgID string, roster *models.Roster, dryRun bool) (*models.ImportResult, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.Import")
+	defer span.End()
+
+	changes, err := s.repo.Import(ctx, orgID, roster, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ImportResult{DryRun: dryRun, Changes: changes}, nil
+}
+
+// GetTeamByID returns the team with its members.
+func (s *TeamService) GetTeamByID(ctx context.Context, orgID, teamID string) (*models.Team, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.GetTeamByID")
+	defer span.End()
+
+	return s.repo.GetTeamByID(ctx, orgID, teamID)
 }
 
-func (s *TeamService) DeactivateTeam(teamID string) error {
-	return s.repo.DeactivateTeam(teamID)
+// ListTeams returns a page of teams with their members and the number of
+// teams on all pages. A non-empty name selects the team with it
+// regardless of case.
+func (s *TeamService) ListTeams(ctx context.Context, orgID, name string, offset, limit int) ([]models.Team, int64, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.ListTeams")
+	defer span.End()
+
+	return s.repo.ListTeams(ctx, orgID, name, offset, limit)
+}
+
+// EnsureTeam creates the team unless it exists.
+func (s *TeamService) EnsureTeam(ctx context.Context, orgID, name string) error {
+	ctx, span := tracing.Start(ctx, "TeamService.EnsureTeam")
+	defer span.End()
+
+	return s.repo.EnsureTeam(ctx, orgID, name)
+}
+
+// ReplaceTeam renames the team and makes team.Members its only members,
+// the users that leave it are moved to the fallback team.
+func (s *TeamService) ReplaceTeam(ctx context.Context, orgID string, team *models.Team, fallbackName string) error {
+	ctx, span := tracing.Start(ctx, "TeamService.ReplaceTeam")
+	defer span.End()
+
+	return s.repo.ReplaceTeam(ctx, orgID, team, fallbackName)
+}
+
+// DeleteTeam deletes the team after moving its members to the fallback
+// team.
+func (s *TeamService) DeleteTeam(ctx context.Context, orgID, teamID, fallbackName string) error {
+	ctx, span := tracing.Start(ctx, "TeamService.DeleteTeam")
+	defer span.End()
+
+	return s.repo.DeleteTeam(ctx, orgID, teamID, fallbackName)
 }
@@ -1,26 +1,122 @@
 package service
 
 import (
+	"context"
 	"reviewers/internal/models"
 	"reviewers/internal/repository"
+	"reviewers/internal/tracing"
 )
 
 type UserService struct {
-	repo *repository.UserRepository
+	repo repository.UserStore
 }
 
-func NewUserService(repo *repository.UserRepository) *UserService {
+func NewUserService(repo repository.UserStore) *UserService {
 	return &UserService{repo}
 }
 
-func (s *UserService) SetActiveStatus(userID string, active bool) error {
-	return s.repo.SetActiveStatus(userID, active)
+func (s *UserService) SetActiveStatus(ctx context.Context, orgID, userID string, active bool) error {
+	ctx, span := tracing.Start(ctx, "UserService.SetActiveStatus")
+	defer span.End()
+
+	return s.repo.SetActiveStatus(ctx, orgID, userID, active)
+}
+
+// GetReview returns a page of the pull requests the user reviews. Callers
+// that do not page, giving neither a limit nor a cursor, get every pull
+// request.
+func (s *UserService) GetReview(
+	ctx context.Context,
+	orgID, userID string,
+	filter models.PullRequestFilter,
+) (*models.PullRequestPage, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetReview")
+	defer span.End()
+
+	if filter.Limit == 0 && filter.Cursor == "" {
+		filter.Limit = -1
+	}
+	return s.repo.GetReview(ctx, orgID, userID, filter)
+}
+
+func (s *UserService) Get(ctx context.Context, orgID, userID string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Get")
+	defer span.End()
+
+	return s.repo.Get(ctx, orgID, userID)
 }
 
-func (s *UserService) GetReview(userID string) ([]models.PullRequestShort, error) {
-	return s.repo.GetReview(userID)
+func (s *UserService) GetActive(ctx context.Context, orgID string, userIDs ...string) ([]*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetActive")
+	defer span.End()
+
+	return s.repo.GetActive(ctx, orgID, userIDs...)
+}
+
+// GetWithTeam returns the user with the name of their team.
+func (s *UserService) GetWithTeam(ctx context.Context, orgID, userID string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetWithTeam")
+	defer span.End()
+
+	return s.repo.GetWithTeam(ctx, orgID, userID)
+}
+
+// Create adds the user to the team named by user.TeamName.
+func (s *UserService) Create(ctx context.Context, orgID string, user *models.User) error {
+	ctx, span := tracing.Start(ctx, "UserService.Create")
+	defer span.End()
+
+	user.OrganizationID = orgID
+	return s.repo.Create(ctx, user)
 }
 
-func (s *UserService) Get(userID string) (*models.User, error) {
-	return s.repo.Get(userID)
+// Update saves the username, email and team of the user.
+func (s *UserService) Update(ctx context.Context, user *models.User) error {
+	ctx, span := tracing.Start(ctx, "UserService.Update")
+	defer span.End()
+
+	return s.repo.Update(ctx, user)
+}
+
+// Delete saves the reassignments of the open reviews of the user, see
+// PRService.ReleaseReviews, and removes the user in one transaction. If
+// the user still reviews an open pull request, nothing is changed and
+// errs.NoCandidate is returned. A user who authored or reviewed other pull
+// requests is kept with errs.UserHasPullRequests.
+func (s *UserService) Delete(ctx context.Context, orgID, userID string, reassignments []models.Reassignment) error {
+	ctx, span := tracing.Start(ctx, "UserService.Delete")
+	defer span.End()
+
+	return s.repo.Delete(ctx, orgID, userID, reassignments)
+}
+
+// Deactivate saves the reassignments of the open reviews of the user, see
+// PRService.ReleaseReviews, and deactivates the user in one transaction.
+// If the user still reviews an open pull request, nothing is changed and
+// errs.NoCandidate is returned.
+func (s *UserService) Deactivate(ctx context.Context, orgID, userID string, reassignments []models.Reassignment) error {
+	ctx, span := tracing.Start(ctx, "UserService.Deactivate")
+	defer span.End()
+
+	return s.repo.Deactivate(ctx, orgID, userID, reassignments)
+}
+
+// UpdateAndDeactivate saves the username, email and team of the user and
+// deactivates them like Deactivate in one transaction. If the user cannot
+// be deactivated, nothing is changed.
+func (s *UserService) UpdateAndDeactivate(ctx context.Context, user *models.User, reassignments []models.Reassignment) error {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateAndDeactivate")
+	defer span.End()
+
+	return s.repo.UpdateAndDeactivate(ctx, user, reassignments)
+}
+
+// List returns a page of users with the names of their teams and the
+// number of users on all pages. A non-empty username selects the user
+// with it regardless of case.
+func (s *UserService) List(ctx context.Context, orgID, username string, offset, limit int) ([]models.User, int64, error) {
+	ctx, span := tracing.Start(ctx, "UserService.List")
+	defer span.End()
+
+	return s.repo.List(ctx, orgID, username, offset, limit)
 }
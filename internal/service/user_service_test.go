@@ -0,0 +1,38 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"reviewers/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_GetReviewUnpaged(t *testing.T) {
+	s := newServices()
+	ctx := context.Background()
+	team := s.createTeam(t, "backend", "", "author", "alice")
+
+	// More pull requests than fit on a default page
+	for i := range 101 {
+		pr := &models.PullRequest{ID: fmt.Sprintf("pr-%d", i), Repository: models.DefaultRepository, AuthorID: team["author"]}
+		require.NoError(t, s.prs.Create(ctx, orgID, pr))
+	}
+
+	page, err := s.users.GetReview(ctx, orgID, team["alice"], models.PullRequestFilter{})
+	require.NoError(t, err)
+	assert.Len(t, page.PullRequests, 101)
+	assert.Empty(t, page.NextCursor)
+
+	page, err = s.users.GetReview(ctx, orgID, team["alice"], models.PullRequestFilter{Limit: 100})
+	require.NoError(t, err)
+	assert.Len(t, page.PullRequests, 100)
+	require.NotEmpty(t, page.NextCursor)
+
+	page, err = s.users.GetReview(ctx, orgID, team["alice"], models.PullRequestFilter{Cursor: page.NextCursor})
+	require.NoError(t, err)
+	assert.Len(t, page.PullRequests, 1)
+	assert.Empty(t, page.NextCursor)
+}
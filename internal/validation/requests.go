@@ -0,0 +1,75 @@
+package validation
+
+import "reviewers/internal/models"
+
+// Requests shared by the HTTP and gRPC transports. The HTTP handlers bind
+// them from JSON bodies and query parameters, the gRPC servers fill them
+// from messages, and both check them with Validate.
+
+type CreatePRRequest struct {
+	ID           string   `json:"pull_request_id" binding:"required,identifier,max=64"`
+	Name         string   `json:"pull_request_name" binding:"required,notblank,max=255"`
+	AuthorID     string   `json:"author_id" binding:"required,uuid"`
+	Repository   string   `json:"repository" binding:"omitempty,identifier,max=100"`
+	ChangedFiles []string `json:"changed_files" binding:"max=10000,dive,required,max=1024"`
+}
+
+type MergePRRequest struct {
+	Repository    string `json:"repository" binding:"omitempty,identifier,max=100"`
+	PullRequestID string `json:"pull_request_id" binding:"required,identifier,max=64"`
+}
+
+type ApprovePRRequest struct {
+	Repository    string `json:"repository" binding:"omitempty,identifier,max=100"`
+	PullRequestID string `json:"pull_request_id" binding:"required,identifier,max=64"`
+	ReviewerID    string `json:"reviewer_id" binding:"required,uuid"`
+}
+
+type ReassignReviewerRequest struct {
+	Repository    string `json:"repository" binding:"omitempty,identifier,max=100"`
+	PullRequestID string `json:"pull_request_id" binding:"required,identifier,max=64"`
+	OldReviewerID string `json:"old_reviewer_id" binding:"required,uuid"`
+}
+
+// ListQuery holds the pagination, filtering and sorting parameters of a
+// pull request listing.
+type ListQuery struct {
+	Status       string `form:"status" binding:"omitempty,oneof=OPEN MERGED"`
+	Sort         string `form:"sort" binding:"omitempty,oneof=created_at -created_at name -name"`
+	Cursor       string `form:"cursor" binding:"max=1024"`
+	Limit        int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+	IncludeTotal bool   `form:"include_total"`
+}
+
+func (q *ListQuery) Filter() models.PullRequestFilter {
+	return models.PullRequestFilter{
+		Status:       q.Status,
+		Sort:         q.Sort,
+		Cursor:       q.Cursor,
+		Limit:        q.Limit,
+		IncludeTotal: q.IncludeTotal,
+	}
+}
+
+type SetParentRequest struct {
+	TeamName       string `json:"team_name" binding:"required,notblank,max=255"`
+	ParentTeamName string `json:"parent_team_name" binding:"max=255"`
+}
+
+type GetTeamQuery struct {
+	TeamName        string `form:"team_name" binding:"required,notblank,max=255"`
+	IncludeSubteams bool   `form:"include_subteams"`
+}
+
+type DeactivateTeamQuery struct {
+	TeamID string `form:"team_id" binding:"required,uuid"`
+}
+
+type SetActiveRequest struct {
+	UserID   string `json:"user_id" binding:"required,uuid"`
+	IsActive bool   `json:"is_active"`
+}
+
+type GetReviewQuery struct {
+	UserID string `form:"user_id" binding:"required,uuid"`
+}
@@ -0,0 +1,126 @@
+// Package validation checks requests against the rules of their binding
+// tags. The rules are shared by every transport of the service, so that
+// a request is rejected the same way whichever way it arrives.
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"reviewers/pkg/errs"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// identifierPattern is the character set of pull request IDs and
+// repository names.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+
+	// Report fields by their names in the request
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+
+	v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+	v.RegisterValidation("identifier", func(fl validator.FieldLevel) bool {
+		return identifierPattern.MatchString(fl.Field().String())
+	})
+	return v
+}
+
+// Engine returns the underlying validator.
+func Engine() *validator.Validate {
+	return validate
+}
+
+// Struct checks obj, a struct or a pointer to one, against its binding
+// tags and returns the errors of the validator as they are. Other values
+// are not checked.
+func Struct(obj any) error {
+	value := reflect.ValueOf(obj)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+	return validate.Struct(obj)
+}
+
+// Validate checks obj against its binding tags and reports the failing
+// fields as an errs.ValidationError.
+func Validate(obj any) error {
+	if err := Struct(obj); err != nil {
+		return Error(err)
+	}
+	return nil
+}
+
+// Error converts an error of decoding or checking a request to an
+// errs.ValidationError listing the failing fields.
+func Error(err error) error {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return &errs.ValidationError{Message: "invalid body"}
+	}
+
+	fields := make([]errs.FieldError, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		// Drop the name of the request struct
+		_, field, _ := strings.Cut(fieldErr.Namespace(), ".")
+		fields = append(fields, errs.FieldError{
+			Field:   field,
+			Message: fieldMessage(fieldErr),
+		})
+	}
+	return errs.NewValidationError(fields...)
+}
+
+func fieldMessage(fieldErr validator.FieldError) string {
+	unit := ""
+	if fieldErr.Kind() == reflect.String {
+		unit = " characters"
+	}
+
+	switch fieldErr.Tag() {
+	case "required", "required_without":
+		return "is required"
+	case "notblank":
+		return "must not be blank"
+	case "uuid":
+		return "must be a UUID"
+	case "email":
+		return "must be an email address"
+	case "identifier":
+		return "may contain only letters, digits, '.', '_' and '-'"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
+	case "min":
+		return "must be at least " + fieldErr.Param() + unit
+	case "max":
+		return "must be at most " + fieldErr.Param() + unit
+	default:
+		return "failed on the " + fieldErr.Tag() + " rule"
+	}
+}
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS teams_parent_team_id_idx;
+
+ALTER TABLE teams DROP COLUMN IF EXISTS parent_team_id;
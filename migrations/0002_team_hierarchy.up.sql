@@ -0,0 +1,4 @@
+ALTER TABLE teams
+  ADD COLUMN IF NOT EXISTS parent_team_id UUID REFERENCES teams (team_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS teams_parent_team_id_idx ON teams (parent_team_id);
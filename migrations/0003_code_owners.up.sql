@@ -0,0 +1,29 @@
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS repository TEXT;
+
+CREATE TABLE IF NOT EXISTS pull_request_files(
+  pull_request_id TEXT NOT NULL,
+  path TEXT NOT NULL,
+
+  PRIMARY KEY (pull_request_id, path),
+
+  FOREIGN KEY (pull_request_id) REFERENCES pull_requests (pull_request_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS code_owner_rules(
+  rule_id BIGSERIAL PRIMARY KEY,
+  repository TEXT NOT NULL,
+  position INT NOT NULL,
+  pattern TEXT NOT NULL,
+
+  UNIQUE (repository, position)
+);
+
+CREATE TABLE IF NOT EXISTS code_owners(
+  rule_id BIGINT NOT NULL,
+  user_id UUID NOT NULL,
+
+  PRIMARY KEY (rule_id, user_id),
+
+  FOREIGN KEY (rule_id) REFERENCES code_owner_rules (rule_id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
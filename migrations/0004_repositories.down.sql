@@ -0,0 +1,38 @@
+-- Code owners
+ALTER TABLE code_owner_rules ADD COLUMN repository TEXT;
+UPDATE code_owner_rules cor SET repository = r.name
+FROM repositories r WHERE r.repository_id = cor.repository_id;
+ALTER TABLE code_owner_rules
+  ALTER COLUMN repository SET NOT NULL,
+  DROP COLUMN repository_id,
+  ADD UNIQUE (repository, position);
+
+-- Pull requests. Identifiers colliding across repositories cannot be restored.
+DROP INDEX IF EXISTS pull_request_reviewers_user_id_idx;
+
+ALTER TABLE pull_request_reviewers
+  DROP CONSTRAINT pull_request_reviewers_repository_id_pull_request_id_fkey,
+  DROP CONSTRAINT pull_request_reviewers_pkey;
+ALTER TABLE pull_request_files
+  DROP CONSTRAINT pull_request_files_repository_id_pull_request_id_fkey,
+  DROP CONSTRAINT pull_request_files_pkey;
+
+ALTER TABLE pull_requests ADD COLUMN repository TEXT;
+UPDATE pull_requests pr SET repository = r.name
+FROM repositories r WHERE r.repository_id = pr.repository_id AND r.name <> 'default';
+
+ALTER TABLE pull_requests
+  DROP CONSTRAINT pull_requests_pkey,
+  DROP COLUMN repository_id,
+  ADD PRIMARY KEY (pull_request_id);
+
+ALTER TABLE pull_request_reviewers
+  DROP COLUMN repository_id,
+  ADD PRIMARY KEY (pull_request_id, user_id),
+  ADD FOREIGN KEY (pull_request_id) REFERENCES pull_requests (pull_request_id) ON DELETE CASCADE;
+ALTER TABLE pull_request_files
+  DROP COLUMN repository_id,
+  ADD PRIMARY KEY (pull_request_id, path),
+  ADD FOREIGN KEY (pull_request_id) REFERENCES pull_requests (pull_request_id) ON DELETE CASCADE;
+
+DROP TABLE IF EXISTS repositories;
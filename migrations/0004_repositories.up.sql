@@ -0,0 +1,76 @@
+CREATE TABLE IF NOT EXISTS repositories(
+  repository_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  name TEXT UNIQUE NOT NULL,
+  team_id UUID,
+  reviewers_count INT NOT NULL DEFAULT 2 CHECK (reviewers_count > 0),
+  climb_hierarchy BOOLEAN NOT NULL DEFAULT TRUE,
+
+  FOREIGN KEY (team_id) REFERENCES teams (team_id) ON DELETE SET NULL
+);
+
+-- Pull requests created before repositories existed belong to the default one
+INSERT INTO repositories (repository_id, name)
+VALUES ('00000000-0000-0000-0000-000000000000', 'default')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO repositories (name)
+SELECT repository FROM pull_requests WHERE repository IS NOT NULL
+UNION
+SELECT repository FROM code_owner_rules
+ON CONFLICT (name) DO NOTHING;
+
+-- Pull requests
+ALTER TABLE pull_requests
+  ADD COLUMN repository_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000'
+  REFERENCES repositories (repository_id) ON DELETE CASCADE;
+
+UPDATE pull_requests pr SET repository_id = r.repository_id
+FROM repositories r WHERE r.name = pr.repository;
+
+ALTER TABLE pull_requests DROP COLUMN repository;
+
+ALTER TABLE pull_request_reviewers ADD COLUMN repository_id UUID;
+UPDATE pull_request_reviewers prr SET repository_id = pr.repository_id
+FROM pull_requests pr WHERE pr.pull_request_id = prr.pull_request_id;
+ALTER TABLE pull_request_reviewers
+  ALTER COLUMN repository_id SET NOT NULL,
+  ALTER COLUMN repository_id SET DEFAULT '00000000-0000-0000-0000-000000000000';
+
+ALTER TABLE pull_request_files ADD COLUMN repository_id UUID;
+UPDATE pull_request_files prf SET repository_id = pr.repository_id
+FROM pull_requests pr WHERE pr.pull_request_id = prf.pull_request_id;
+ALTER TABLE pull_request_files
+  ALTER COLUMN repository_id SET NOT NULL,
+  ALTER COLUMN repository_id SET DEFAULT '00000000-0000-0000-0000-000000000000';
+
+ALTER TABLE pull_request_reviewers
+  DROP CONSTRAINT pull_request_reviewers_pull_request_id_fkey,
+  DROP CONSTRAINT pull_request_reviewers_pkey;
+ALTER TABLE pull_request_files
+  DROP CONSTRAINT pull_request_files_pull_request_id_fkey,
+  DROP CONSTRAINT pull_request_files_pkey;
+
+ALTER TABLE pull_requests
+  DROP CONSTRAINT pull_requests_pkey,
+  ADD PRIMARY KEY (repository_id, pull_request_id);
+
+ALTER TABLE pull_request_reviewers
+  ADD PRIMARY KEY (repository_id, pull_request_id, user_id),
+  ADD FOREIGN KEY (repository_id, pull_request_id)
+    REFERENCES pull_requests (repository_id, pull_request_id) ON DELETE CASCADE;
+ALTER TABLE pull_request_files
+  ADD PRIMARY KEY (repository_id, pull_request_id, path),
+  ADD FOREIGN KEY (repository_id, pull_request_id)
+    REFERENCES pull_requests (repository_id, pull_request_id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS pull_request_reviewers_user_id_idx ON pull_request_reviewers (user_id);
+
+-- Code owners
+ALTER TABLE code_owner_rules
+  ADD COLUMN repository_id UUID REFERENCES repositories (repository_id) ON DELETE CASCADE;
+UPDATE code_owner_rules cor SET repository_id = r.repository_id
+FROM repositories r WHERE r.name = cor.repository;
+ALTER TABLE code_owner_rules
+  ALTER COLUMN repository_id SET NOT NULL,
+  DROP COLUMN repository,
+  ADD UNIQUE (repository_id, position);
@@ -0,0 +1,18 @@
+DROP INDEX IF EXISTS users_team_id_idx;
+DROP INDEX IF EXISTS pull_requests_organization_id_idx;
+
+ALTER TABLE pull_requests DROP COLUMN organization_id;
+
+ALTER TABLE repositories
+  DROP COLUMN organization_id,
+  ADD UNIQUE (name);
+
+ALTER TABLE users
+  DROP COLUMN organization_id,
+  ADD UNIQUE (username);
+
+ALTER TABLE teams
+  DROP COLUMN organization_id,
+  ADD UNIQUE (name);
+
+DROP TABLE IF EXISTS organizations;
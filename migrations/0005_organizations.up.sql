@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS organizations(
+  organization_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  name TEXT UNIQUE NOT NULL
+);
+
+-- Existing data belongs to the default organization
+INSERT INTO organizations (organization_id, name)
+VALUES ('00000000-0000-0000-0000-000000000000', 'default')
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE teams
+  ADD COLUMN organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000'
+  REFERENCES organizations (organization_id) ON DELETE CASCADE,
+  DROP CONSTRAINT teams_name_key,
+  ADD UNIQUE (organization_id, name);
+
+ALTER TABLE users
+  ADD COLUMN organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000'
+  REFERENCES organizations (organization_id) ON DELETE CASCADE,
+  DROP CONSTRAINT users_username_key,
+  ADD UNIQUE (organization_id, username);
+
+ALTER TABLE repositories
+  ADD COLUMN organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000'
+  REFERENCES organizations (organization_id) ON DELETE CASCADE,
+  DROP CONSTRAINT repositories_name_key,
+  ADD UNIQUE (organization_id, name);
+
+ALTER TABLE pull_requests
+  ADD COLUMN organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000'
+  REFERENCES organizations (organization_id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS pull_requests_organization_id_idx ON pull_requests (organization_id);
+CREATE INDEX IF NOT EXISTS users_team_id_idx ON users (team_id);
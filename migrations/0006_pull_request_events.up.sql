@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS pull_request_events(
+  event_id BIGSERIAL PRIMARY KEY,
+  organization_id UUID NOT NULL,
+  repository_id UUID NOT NULL,
+  pull_request_id TEXT NOT NULL,
+  event_type TEXT NOT NULL CHECK (event_type IN ('ASSIGNED', 'UNASSIGNED', 'APPROVED', 'MERGED')),
+  user_id UUID,
+  created_at TIMESTAMP NOT NULL DEFAULT now(),
+
+  FOREIGN KEY (organization_id) REFERENCES organizations (organization_id) ON DELETE CASCADE,
+  FOREIGN KEY (repository_id, pull_request_id)
+    REFERENCES pull_requests (repository_id, pull_request_id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS pull_request_events_user_idx
+  ON pull_request_events (organization_id, user_id, created_at);
+CREATE INDEX IF NOT EXISTS pull_request_events_pull_request_idx
+  ON pull_request_events (repository_id, pull_request_id);
+
+-- History of existing pull requests
+INSERT INTO pull_request_events (organization_id, repository_id, pull_request_id, event_type, user_id, created_at)
+SELECT pr.organization_id, pr.repository_id, pr.pull_request_id, 'ASSIGNED', prr.user_id, pr.created_at
+FROM pull_request_reviewers prr
+JOIN pull_requests pr ON pr.repository_id = prr.repository_id AND pr.pull_request_id = prr.pull_request_id;
+
+INSERT INTO pull_request_events (organization_id, repository_id, pull_request_id, event_type, created_at)
+SELECT organization_id, repository_id, pull_request_id, 'MERGED', merged_at
+FROM pull_requests
+WHERE merged_at IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS pull_requests_name_idx;
+DROP INDEX IF EXISTS pull_requests_created_at_idx;
@@ -0,0 +1,5 @@
+-- Listings are ordered by these columns with the primary key as a tiebreaker
+CREATE INDEX IF NOT EXISTS pull_requests_created_at_idx
+  ON pull_requests (organization_id, created_at, repository_id, pull_request_id);
+CREATE INDEX IF NOT EXISTS pull_requests_name_idx
+  ON pull_requests (organization_id, pull_request_name, repository_id, pull_request_id);
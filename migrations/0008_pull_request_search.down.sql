@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS pull_requests_name_trgm_idx;
+DROP INDEX IF EXISTS pull_requests_status_idx;
+DROP INDEX IF EXISTS pull_requests_author_id_idx;
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -0,0 +1,9 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS pull_requests_author_id_idx
+  ON pull_requests (organization_id, author_id, created_at);
+CREATE INDEX IF NOT EXISTS pull_requests_status_idx
+  ON pull_requests (organization_id, status, created_at);
+-- Substring search by name
+CREATE INDEX IF NOT EXISTS pull_requests_name_trgm_idx
+  ON pull_requests USING gin (pull_request_name gin_trgm_ops);
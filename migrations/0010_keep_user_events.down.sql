@@ -0,0 +1,4 @@
+ALTER TABLE pull_request_events
+  DROP CONSTRAINT IF EXISTS pull_request_events_user_id_fkey,
+  ADD CONSTRAINT pull_request_events_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE;
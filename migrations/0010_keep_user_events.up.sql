@@ -0,0 +1,5 @@
+-- Events of a deleted user stay in the history of their pull requests
+ALTER TABLE pull_request_events
+  DROP CONSTRAINT IF EXISTS pull_request_events_user_id_fkey,
+  ADD CONSTRAINT pull_request_events_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE SET NULL;
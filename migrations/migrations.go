@@ -0,0 +1,11 @@
+// Package migrations embeds the SQL migrations of the database schema, so
+// that the binary can apply them without the files next to it.
+package migrations
+
+import "embed"
+
+// FS holds the migrations as <version>_<title>.up.sql and
+// <version>_<title>.down.sql files.
+//
+//go:embed *.sql
+var FS embed.FS
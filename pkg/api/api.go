@@ -0,0 +1,214 @@
+// Package api holds the requests and responses of the reviewers service
+// API as they are sent over the wire.
+package api
+
+import "time"
+
+const DefaultOrganizationID = "00000000-0000-0000-0000-000000000000"
+
+const DefaultRepository = "default"
+
+const StatusOpen = "OPEN"
+const StatusMerged = "MERGED"
+
+const SortCreatedAt = "created_at"
+const SortName = "name"
+
+// Organization is a tenant. Data of different organizations is fully
+// isolated from each other.
+type Organization struct {
+	ID   string `json:"organization_id"`
+	Name string `json:"name"`
+}
+
+type User struct {
+	ID       string `json:"user_id,omitempty"`
+	Username string `json:"username"`
+	Email    string `json:"email,omitempty"`
+	IsActive bool   `json:"is_active"`
+	TeamName string `json:"team_name,omitempty"`
+}
+
+type Team struct {
+	Name       string `json:"team_name"`
+	ParentName string `json:"parent_team_name,omitempty"`
+	Members    []User `json:"members"`
+	Subteams   []Team `json:"subteams,omitempty"`
+}
+
+// TeamStats holds review counters of a team. Every counter includes
+// the counters of all subteams.
+type TeamStats struct {
+	TeamName        string      `json:"team_name"`
+	Members         int64       `json:"members"`
+	ActiveMembers   int64       `json:"active_members"`
+	AssignedReviews int64       `json:"assigned_reviews"`
+	OpenReviews     int64       `json:"open_reviews"`
+	Subteams        []TeamStats `json:"subteams,omitempty"`
+}
+
+// Repository groups pull requests and defines how reviewers are picked
+// for them. Pull request IDs are unique within a repository.
+type Repository struct {
+	Name           string `json:"name"`
+	TeamName       string `json:"team_name,omitempty"`
+	ReviewersCount int    `json:"reviewers_count"`
+	ClimbHierarchy bool   `json:"climb_hierarchy"`
+}
+
+type PullRequest struct {
+	Repository        string     `json:"repository"`
+	ID                string     `json:"pull_request_id"`
+	Name              string     `json:"pull_request_name"`
+	Status            string     `json:"status"`
+	CreatedAt         time.Time  `json:"created_at"`
+	MergedAt          *time.Time `json:"merged_at"`
+	AuthorID          string     `json:"author_id"`
+	AssignedReviewers []string   `json:"assigned_reviewers"`
+	ChangedFiles      []string   `json:"changed_files,omitempty"`
+}
+
+// PullRequestDetails is a pull request with the details of its reviewers.
+type PullRequestDetails struct {
+	PullRequest
+	Reviewers []ReviewerDetails `json:"reviewers"`
+}
+
+// ReviewerDetails is a reviewer of a pull request. ApprovedAt is the time
+// of the approval by the reviewer, if any.
+type ReviewerDetails struct {
+	UserID     string     `json:"user_id"`
+	Username   string     `json:"username"`
+	TeamName   string     `json:"team_name"`
+	IsActive   bool       `json:"is_active"`
+	ApprovedAt *time.Time `json:"approved_at"`
+}
+
+type PullRequestShort struct {
+	Repository string    `json:"repository"`
+	ID         string    `json:"pull_request_id"`
+	Name       string    `json:"pull_request_name"`
+	AuthorID   string    `json:"author_id"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// PullRequestFilter selects a page of a pull request listing. TeamName
+// selects pull requests authored by members of the team and its subteams,
+// Name is a case-insensitive substring of the pull request name, and the
+// ages bound the time since creation. Sort is a field optionally prefixed
+// with "-" for the descending order, Cursor is the next cursor of the
+// previous page. A zero Limit selects the default page size, a negative
+// one lists every pull request. Other zero values mean no limit.
+type PullRequestFilter struct {
+	AuthorID     string
+	TeamName     string
+	ReviewerID   string
+	Status       string
+	Name         string
+	From         time.Time
+	To           time.Time
+	MinAge       time.Duration
+	MaxAge       time.Duration
+	Sort         string
+	Cursor       string
+	Limit        int
+	IncludeTotal bool
+}
+
+// PullRequestPage is a page of a pull request listing. NextCursor is empty
+// on the last page, Total counts pull requests of all pages and is only
+// set on request.
+type PullRequestPage struct {
+	PullRequests []PullRequestShort `json:"pull_requests"`
+	NextCursor   string             `json:"next_cursor,omitempty"`
+	Total        *int64             `json:"total,omitempty"`
+}
+
+// CodeOwnerRule assigns owners to the files matching the pattern.
+// When several rules of a repository match a file, the last one wins.
+type CodeOwnerRule struct {
+	Pattern string   `json:"pattern"`
+	Owners  []string `json:"owners"`
+}
+
+// StatsFilter limits statistics to a team and a time range.
+// Zero values mean no limit.
+type StatsFilter struct {
+	TeamName string
+	From     time.Time
+	To       time.Time
+}
+
+// ReviewerStats holds review counters of a user.
+type ReviewerStats struct {
+	UserID                  string   `json:"user_id"`
+	Username                string   `json:"username"`
+	TeamName                string   `json:"team_name"`
+	Assigned                int64    `json:"assigned"`
+	ReassignedAway          int64    `json:"reassigned_away"`
+	Approved                int64    `json:"approved"`
+	OpenReviews             int64    `json:"open_reviews"`
+	MedianTimeToFirstReview *float64 `json:"median_time_to_first_review_seconds"`
+}
+
+// TeamReport shows how review work is spread across members of a team
+// and its subteams.
+type TeamReport struct {
+	TeamName              string          `json:"team_name"`
+	From                  *time.Time      `json:"from,omitempty"`
+	To                    *time.Time      `json:"to,omitempty"`
+	Members               int             `json:"members"`
+	TotalAssigned         int64           `json:"total_assigned"`
+	Gini                  float64         `json:"gini"`
+	MaxMinRatio           *float64        `json:"max_min_ratio"`
+	MergedPullRequests    int64           `json:"merged_pull_requests"`
+	MeanTimeToMerge       *float64        `json:"mean_time_to_merge_seconds"`
+	ReassignmentRate      *float64        `json:"reassignment_rate"`
+	MembersWithoutReviews []UserShort     `json:"members_without_reviews"`
+	Reviewers             []ReviewerStats `json:"reviewers"`
+}
+
+type UserShort struct {
+	ID       string `json:"user_id"`
+	Username string `json:"username"`
+}
+
+const (
+	ImportCreateTeam     = "create_team"
+	ImportSetParent      = "set_parent"
+	ImportCreateUser     = "create_user"
+	ImportMoveUser       = "move_user"
+	ImportUpdateEmail    = "update_email"
+	ImportActivateUser   = "activate_user"
+	ImportDeactivateUser = "deactivate_user"
+)
+
+// ImportChange is a change made by an import. OldTeamName is the previous
+// team of a moved user, ParentTeamName the parent of a created team or the
+// new parent of an existing one.
+type ImportChange struct {
+	Line           int    `json:"line"`
+	Action         string `json:"action"`
+	TeamName       string `json:"team_name"`
+	ParentTeamName string `json:"parent_team_name,omitempty"`
+	Username       string `json:"username,omitempty"`
+	OldTeamName    string `json:"old_team_name,omitempty"`
+}
+
+// ImportResult lists the changes of an import. Changes of a dry run are
+// not saved.
+type ImportResult struct {
+	DryRun  bool           `json:"dry_run"`
+	Changes []ImportChange `json:"changes"`
+}
+
+// RestoreResult counts the restored records by their type.
+type RestoreResult struct {
+	Teams          int `json:"teams"`
+	Users          int `json:"users"`
+	Repositories   int `json:"repositories"`
+	CodeOwnerRules int `json:"code_owner_rules"`
+	PullRequests   int `json:"pull_requests"`
+	Events         int `json:"events"`
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"reviewers/pkg/api"
+	"strconv"
+)
+
+// rosterContentTypes maps the formats of rosters to their content types.
+var rosterContentTypes = map[string]string{
+	"yaml": "application/yaml",
+	"csv":  "text/csv",
+}
+
+// Import applies a roster of teams and their members in the yaml or csv
+// format. With dryRun the changes are returned but not applied.
+func (c *Client) Import(ctx context.Context, roster []byte, format string, dryRun bool) (*api.ImportResult, error) {
+	query := url.Values{
+		"format":  {format},
+		"dry_run": {strconv.FormatBool(dryRun)},
+	}
+	contentType, ok := rosterContentTypes[format]
+	if !ok {
+		contentType = "application/octet-stream"
+	}
+
+	var result api.ImportResult
+	err := c.do(ctx, http.MethodPost, "/admin/import?"+query.Encode(), contentType, roster, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Export writes a backup of the organization to w. The backup is
+// streamed, so neither the timeout nor the retries of the client apply.
+func (c *Client) Export(ctx context.Context, w io.Writer) error {
+	return c.stream(ctx, http.MethodGet, "/admin/export", "", nil, w)
+}
+
+// Restore loads a backup made by Export into the organization, which must
+// be empty. The backup is streamed, so neither the timeout nor the retries
+// of the client apply.
+func (c *Client) Restore(ctx context.Context, backup io.Reader) (*api.RestoreResult, error) {
+	var body bytes.Buffer
+	if err := c.stream(ctx, http.MethodPost, "/admin/restore", "application/x-ndjson", backup, &body); err != nil {
+		return nil, err
+	}
+
+	var result api.RestoreResult
+	if err := json.Unmarshal(body.Bytes(), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,271 @@
+// Package client is a Go client of the reviewers service API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reviewers/pkg/errs"
+	"strings"
+	"time"
+)
+
+const (
+	organizationHeader = "X-Organization-ID"
+	requestIDHeader    = "X-Request-ID"
+)
+
+// Client calls the API of a reviewers service. It is safe for concurrent
+// use.
+type Client struct {
+	baseURL        string
+	httpClient     *http.Client
+	organizationID string
+	token          string
+	timeout        time.Duration
+	retries        int
+	backoff        time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used to send requests.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithOrganization scopes every request to the organization. Requests
+// belong to the organization of the token or to the default organization
+// otherwise. Selecting another organization than the default one requires
+// its token.
+func WithOrganization(organizationID string) Option {
+	return func(c *Client) {
+		c.organizationID = organizationID
+	}
+}
+
+// WithToken authenticates every request with the bearer token, which acts
+// for one organization.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithTimeout limits the time of a single attempt. Zero disables the
+// limit.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetries sets how many times a failed request is retried. The
+// delay before a retry starts from backoff and doubles with each attempt.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// New returns a client of the service at baseURL. By default requests
+// time out after 10 seconds and are retried twice.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		timeout:    10 * time.Second,
+		retries:    2,
+		backoff:    100 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Error is returned when the service responds with an error. It wraps an
+// errs.ApiError or an *errs.ValidationError, so it can be matched with
+// errors.Is against the errors of the errs package.
+type Error struct {
+	StatusCode int
+	RequestID  string
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("reviewers: %d %s", e.StatusCode, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, result any) error {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return c.do(ctx, http.MethodGet, path, "", nil, result)
+}
+
+func (c *Client) post(ctx context.Context, path string, body any, result any) error {
+	var payload []byte
+	var contentType string
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+		contentType = "application/json"
+	}
+	return c.do(ctx, http.MethodPost, path, contentType, payload, result)
+}
+
+// do sends the request and decodes the response into result. GET
+// requests are retried on network errors and on 429, 502, 503 and 504
+// responses. Other requests change data, so they are retried only on 429
+// and 503 responses, which mean that the request has not been processed.
+func (c *Client) do(ctx context.Context, method, path, contentType string, payload []byte, result any) error {
+	delay := c.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := c.attempt(ctx, method, path, contentType, payload, result)
+		if err == nil || !retry || attempt >= c.retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, method, path, contentType string, payload []byte, result any) (bool, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := c.newRequest(ctx, method, path, contentType, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		idempotent := method == http.MethodGet
+		return idempotent && !errors.Is(err, context.Canceled), err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return method == http.MethodGet, err
+	}
+
+	if resp.StatusCode >= 400 {
+		return retryable(method, resp.StatusCode), responseError(resp, body)
+	}
+
+	if result == nil {
+		return false, nil
+	}
+	return false, json.Unmarshal(body, result)
+}
+
+// stream sends the request with the body read from r once and without
+// the timeout of an attempt, since it may transfer the whole data of an
+// organization. The successful response is copied to w.
+func (c *Client) stream(ctx context.Context, method, path, contentType string, r io.Reader, w io.Writer) error {
+	req, err := c.newRequest(ctx, method, path, contentType, r)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return responseError(resp, body)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path, contentType string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.organizationID != "" {
+		req.Header.Set(organizationHeader, c.organizationID)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+func retryable(method string, status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return method == http.MethodGet
+	default:
+		return false
+	}
+}
+
+// responseError converts an error response to an Error. Responses that
+// are not in the error format of the service, e.g. the ones of a proxy,
+// are reported by their status code.
+func responseError(resp *http.Response, body []byte) error {
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get(requestIDHeader),
+	}
+
+	var response errs.ErrorResponse
+	if err := json.Unmarshal(body, &response); err == nil && response.Error.Code != "" {
+		apiErr.Err = errs.FromResponse(response)
+		if response.Error.RequestID != "" {
+			apiErr.RequestID = response.Error.RequestID
+		}
+		return apiErr
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		apiErr.Err = errs.ResourceNotFound
+	case http.StatusGatewayTimeout:
+		apiErr.Err = errs.Timeout
+	default:
+		apiErr.Err = errs.Internal.WithMessage(http.StatusText(resp.StatusCode))
+	}
+	return apiErr
+}
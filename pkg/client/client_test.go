@@ -0,0 +1,82 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reviewers/pkg/api"
+	"reviewers/pkg/client"
+	"reviewers/pkg/errs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_DecodesResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/team/get", r.URL.Path)
+		assert.Equal(t, "backend", r.URL.Query().Get("team_name"))
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "org-1", r.Header.Get("X-Organization-ID"))
+
+		json.NewEncoder(w).Encode(api.Team{
+			Name:    "backend",
+			Members: []api.User{{ID: "user-1", Username: "alice", IsActive: true}},
+		})
+	}))
+	defer server.Close()
+
+	c := client.New(server.URL, client.WithToken("secret"), client.WithOrganization("org-1"))
+	team, err := c.GetTeam(context.Background(), "backend", false)
+	require.NoError(t, err)
+	assert.Equal(t, "backend", team.Name)
+	assert.Equal(t, []api.User{{ID: "user-1", Username: "alice", IsActive: true}}, team.Members)
+}
+
+func TestClient_Errors(t *testing.T) {
+	responses := map[string]errs.ErrorResponse{
+		"/pullRequest/create": errs.NewErrorResponse(errs.CodePRExists, "PR pr-1 already exists"),
+		"/users/create": {Error: errs.ErrorBody{
+			Code:      "VALIDATION_FAILED",
+			Message:   "validation failed",
+			RequestID: "request-1",
+			Fields:    []errs.FieldError{{Field: "username", Message: "is required"}},
+		}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	c := client.New(server.URL, client.WithRetries(0, 0))
+	ctx := context.Background()
+
+	_, err := c.CreatePullRequest(ctx, &api.PullRequest{ID: "pr-1"})
+	assert.ErrorIs(t, err, errs.PullRequestExists)
+	assert.EqualError(t, errors.Unwrap(err), "PR pr-1 already exists")
+
+	_, err = c.CreateUser(ctx, client.UserRequest{TeamName: "backend"})
+	assert.ErrorIs(t, err, errs.ValidationFailed)
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, "request-1", apiErr.RequestID)
+	var validationErr *errs.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []errs.FieldError{{Field: "username", Message: "is required"}}, validationErr.Fields)
+
+	// Responses of a proxy are reported by their status code
+	_, err = c.GetOrganization(ctx)
+	assert.ErrorIs(t, err, errs.Internal)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+}
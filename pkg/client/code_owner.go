@@ -0,0 +1,33 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"reviewers/pkg/api"
+)
+
+type codeOwners struct {
+	Repository string              `json:"repository"`
+	Rules      []api.CodeOwnerRule `json:"rules"`
+}
+
+// GetCodeOwners returns the code owner rules of the repository in the
+// order they are applied.
+func (c *Client) GetCodeOwners(ctx context.Context, repository string) ([]api.CodeOwnerRule, error) {
+	var resp codeOwners
+	query := url.Values{"repository": {repository}}
+	if err := c.get(ctx, "/codeOwners/get", query, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Rules, nil
+}
+
+// SetCodeOwners replaces the code owner rules of the repository.
+func (c *Client) SetCodeOwners(ctx context.Context, repository string, rules []api.CodeOwnerRule) ([]api.CodeOwnerRule, error) {
+	var resp codeOwners
+	body := codeOwners{Repository: repository, Rules: rules}
+	if err := c.post(ctx, "/codeOwners/set", body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Rules, nil
+}
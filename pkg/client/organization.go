@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"reviewers/pkg/api"
+)
+
+// CreateOrganization creates an organization with the given name. It
+// requires a token, see WithToken.
+func (c *Client) CreateOrganization(ctx context.Context, name string) (*api.Organization, error) {
+	var org api.Organization
+	body := map[string]string{"name": name}
+	if err := c.post(ctx, "/organization/add", body, &org); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// GetOrganization returns the organization of the client.
+func (c *Client) GetOrganization(ctx context.Context) (*api.Organization, error) {
+	var org api.Organization
+	if err := c.get(ctx, "/organization/get", nil, &org); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
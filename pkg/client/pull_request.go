@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"reviewers/pkg/api"
+	"strconv"
+	"time"
+)
+
+type pullRequestRef struct {
+	Repository    string `json:"repository,omitempty"`
+	PullRequestID string `json:"pull_request_id"`
+	ReviewerID    string `json:"reviewer_id,omitempty"`
+	OldReviewerID string `json:"old_reviewer_id,omitempty"`
+}
+
+type pullRequestResponse struct {
+	PR *api.PullRequest `json:"pr"`
+}
+
+// CreatePullRequest creates the pull request and assigns reviewers to it.
+// The ID, name, author, repository and changed files of pr are sent.
+func (c *Client) CreatePullRequest(ctx context.Context, pr *api.PullRequest) (*api.PullRequest, error) {
+	body := map[string]any{
+		"pull_request_id":   pr.ID,
+		"pull_request_name": pr.Name,
+		"author_id":         pr.AuthorID,
+		"repository":        pr.Repository,
+		"changed_files":     pr.ChangedFiles,
+	}
+	return c.pullRequest(ctx, "/pullRequest/create", body)
+}
+
+// MergePullRequest merges the pull request. Merging a merged pull request
+// does nothing. An empty repository selects the default one.
+func (c *Client) MergePullRequest(ctx context.Context, repository, pullRequestID string) (*api.PullRequest, error) {
+	body := pullRequestRef{Repository: repository, PullRequestID: pullRequestID}
+	return c.pullRequest(ctx, "/pullRequest/merge", body)
+}
+
+// ReassignReviewer replaces the reviewer of the pull request with another
+// candidate.
+func (c *Client) ReassignReviewer(ctx context.Context, repository, pullRequestID, oldReviewerID string) (*api.PullRequest, error) {
+	body := pullRequestRef{Repository: repository, PullRequestID: pullRequestID, OldReviewerID: oldReviewerID}
+	return c.pullRequest(ctx, "/pullRequest/reassign", body)
+}
+
+// ApprovePullRequest records the approval of the reviewer.
+func (c *Client) ApprovePullRequest(ctx context.Context, repository, pullRequestID, reviewerID string) (*api.PullRequest, error) {
+	body := pullRequestRef{Repository: repository, PullRequestID: pullRequestID, ReviewerID: reviewerID}
+	return c.pullRequest(ctx, "/pullRequest/approve", body)
+}
+
+// GetPullRequest returns the pull request with the details of its
+// reviewers. An empty repository selects the default one.
+func (c *Client) GetPullRequest(ctx context.Context, repository, pullRequestID string) (*api.PullRequestDetails, error) {
+	query := url.Values{"pull_request_id": {pullRequestID}}
+	if repository != "" {
+		query.Set("repository", repository)
+	}
+	var resp struct {
+		PR *api.PullRequestDetails `json:"pr"`
+	}
+	if err := c.get(ctx, "/pullRequest/get", query, &resp); err != nil {
+		return nil, err
+	}
+	return resp.PR, nil
+}
+
+func (c *Client) pullRequest(ctx context.Context, path string, body any) (*api.PullRequest, error) {
+	var resp pullRequestResponse
+	if err := c.post(ctx, path, body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.PR, nil
+}
+
+// ListPullRequests returns a page of the pull requests of the
+// organization. Pass NextCursor of a page as the cursor of the filter to
+// get the next one.
+func (c *Client) ListPullRequests(ctx context.Context, filter api.PullRequestFilter) (*api.PullRequestPage, error) {
+	var page api.PullRequestPage
+	if err := c.get(ctx, "/pullRequest/list", listQuery(filter), &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func listQuery(filter api.PullRequestFilter) url.Values {
+	query := url.Values{}
+	if filter.AuthorID != "" {
+		query.Set("author_id", filter.AuthorID)
+	}
+	if filter.TeamName != "" {
+		query.Set("team_name", filter.TeamName)
+	}
+	if filter.ReviewerID != "" {
+		query.Set("reviewer_id", filter.ReviewerID)
+	}
+	if filter.Name != "" {
+		query.Set("name", filter.Name)
+	}
+	if filter.MinAge > 0 {
+		query.Set("min_age", filter.MinAge.String())
+	}
+	if filter.MaxAge > 0 {
+		query.Set("max_age", filter.MaxAge.String())
+	}
+	if filter.Status != "" {
+		query.Set("status", filter.Status)
+	}
+	if !filter.From.IsZero() {
+		query.Set("from", filter.From.Format(time.RFC3339))
+	}
+	if !filter.To.IsZero() {
+		query.Set("to", filter.To.Format(time.RFC3339))
+	}
+	if filter.Sort != "" {
+		query.Set("sort", filter.Sort)
+	}
+	if filter.Cursor != "" {
+		query.Set("cursor", filter.Cursor)
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	if filter.IncludeTotal {
+		query.Set("include_total", "true")
+	}
+	return query
+}
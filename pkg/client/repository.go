@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"reviewers/pkg/api"
+)
+
+// RepositoryRequest creates or updates a repository. Nil fields keep
+// their current or default values.
+type RepositoryRequest struct {
+	Name           string `json:"name"`
+	TeamName       string `json:"team_name,omitempty"`
+	ReviewersCount *int   `json:"reviewers_count,omitempty"`
+	ClimbHierarchy *bool  `json:"climb_hierarchy,omitempty"`
+}
+
+// GetRepository returns the repository. An empty name selects the
+// default repository.
+func (c *Client) GetRepository(ctx context.Context, name string) (*api.Repository, error) {
+	var repo api.Repository
+	query := url.Values{}
+	if name != "" {
+		query.Set("name", name)
+	}
+	if err := c.get(ctx, "/repository/get", query, &repo); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+// CreateRepository creates a repository. By default two reviewers are
+// assigned and the search climbs the team hierarchy.
+func (c *Client) CreateRepository(ctx context.Context, req RepositoryRequest) (*api.Repository, error) {
+	var repo api.Repository
+	if err := c.post(ctx, "/repository/add", req, &repo); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+// UpdateRepository updates the settings of the repository.
+func (c *Client) UpdateRepository(ctx context.Context, req RepositoryRequest) (*api.Repository, error) {
+	var repo api.Repository
+	if err := c.post(ctx, "/repository/update", req, &repo); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"reviewers/pkg/api"
+	"time"
+)
+
+// GetReviewerStats returns review counters of every user matching the
+// filter.
+func (c *Client) GetReviewerStats(ctx context.Context, filter api.StatsFilter) ([]api.ReviewerStats, error) {
+	var resp struct {
+		Reviewers []api.ReviewerStats `json:"reviewers"`
+	}
+	query := statsQuery(filter)
+	if filter.TeamName != "" {
+		query.Set("team_name", filter.TeamName)
+	}
+	if err := c.get(ctx, "/stats/reviewers", query, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Reviewers, nil
+}
+
+// GetTeamReport returns how review work is spread across the team of the
+// filter.
+func (c *Client) GetTeamReport(ctx context.Context, filter api.StatsFilter) (*api.TeamReport, error) {
+	var report api.TeamReport
+	path := "/stats/teams/" + url.PathEscape(filter.TeamName)
+	if err := c.get(ctx, path, statsQuery(filter), &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func statsQuery(filter api.StatsFilter) url.Values {
+	query := url.Values{}
+	if !filter.From.IsZero() {
+		query.Set("from", filter.From.Format(time.RFC3339))
+	}
+	if !filter.To.IsZero() {
+		query.Set("to", filter.To.Format(time.RFC3339))
+	}
+	return query
+}
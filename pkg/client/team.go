@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"reviewers/pkg/api"
+	"strconv"
+)
+
+// GetTeam returns the team with its members. Subteams are included
+// recursively if requested.
+func (c *Client) GetTeam(ctx context.Context, name string, includeSubteams bool) (*api.Team, error) {
+	var team api.Team
+	query := url.Values{
+		"team_name":        {name},
+		"include_subteams": {strconv.FormatBool(includeSubteams)},
+	}
+	if err := c.get(ctx, "/team/get", query, &team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// CreateTeam creates the team. Members with an ID are existing users
+// moved to the team, the rest are created.
+func (c *Client) CreateTeam(ctx context.Context, team *api.Team) (*api.Team, error) {
+	var created api.Team
+	if err := c.post(ctx, "/team/add", team, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// DeactivateTeam deactivates every member of the team.
+func (c *Client) DeactivateTeam(ctx context.Context, teamID string) error {
+	query := url.Values{"team_id": {teamID}}
+	return c.post(ctx, "/team/deactivate?"+query.Encode(), nil, nil)
+}
+
+// SetTeamParent nests the team under the parent team. An empty parent
+// makes the team a top-level one.
+func (c *Client) SetTeamParent(ctx context.Context, teamName, parentTeamName string) error {
+	body := map[string]string{"team_name": teamName, "parent_team_name": parentTeamName}
+	return c.post(ctx, "/team/setParent", body, nil)
+}
+
+// GetTeamStats returns review counters of the team and its subteams.
+func (c *Client) GetTeamStats(ctx context.Context, teamName string) (*api.TeamStats, error) {
+	var stats api.TeamStats
+	query := url.Values{"team_name": {teamName}}
+	if err := c.get(ctx, "/team/stats", query, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
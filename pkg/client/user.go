@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"reviewers/pkg/api"
+)
+
+// SetUserActive activates or deactivates the user.
+func (c *Client) SetUserActive(ctx context.Context, userID string, active bool) error {
+	body := map[string]any{"user_id": userID, "is_active": active}
+	return c.post(ctx, "/users/setIsActive", body, nil)
+}
+
+// GetUserReviews returns a page of the pull requests the user is assigned
+// to review. The reviewer of the filter is ignored. Without a limit and a
+// cursor every pull request is returned.
+func (c *Client) GetUserReviews(ctx context.Context, userID string, filter api.PullRequestFilter) (*api.PullRequestPage, error) {
+	var page api.PullRequestPage
+	query := listQuery(filter)
+	query.Set("user_id", userID)
+	if err := c.get(ctx, "/users/getReview", query, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// UserRequest creates or updates a user. On update the user is selected by
+// UserID, empty fields and nil Email keep their current values.
+type UserRequest struct {
+	UserID   string  `json:"user_id,omitempty"`
+	Username string  `json:"username,omitempty"`
+	TeamName string  `json:"team_name,omitempty"`
+	Email    *string `json:"email,omitempty"`
+	IsActive *bool   `json:"is_active,omitempty"`
+}
+
+type userResponse struct {
+	User *api.User `json:"user"`
+}
+
+// GetUser returns the user with the name of their team.
+func (c *Client) GetUser(ctx context.Context, userID string) (*api.User, error) {
+	var resp userResponse
+	if err := c.get(ctx, "/users/get", url.Values{"user_id": {userID}}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.User, nil
+}
+
+// CreateUser adds a user to the team. A taken username fails with
+// errs.UserExists.
+func (c *Client) CreateUser(ctx context.Context, req UserRequest) (*api.User, error) {
+	var resp userResponse
+	if err := c.post(ctx, "/users/create", req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.User, nil
+}
+
+// UpdateUser renames the user, moves them to another team or changes
+// their email.
+func (c *Client) UpdateUser(ctx context.Context, req UserRequest) (*api.User, error) {
+	var resp userResponse
+	if err := c.post(ctx, "/users/update", req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.User, nil
+}
+
+// DeleteUser reassigns the open reviews of the user and deletes them. If
+// some review has no other candidate, errs.NoCandidate is returned and
+// the user is kept. A user who authored or reviewed other pull requests
+// is kept too, errs.UserHasPullRequests is returned.
+func (c *Client) DeleteUser(ctx context.Context, userID string) error {
+	return c.post(ctx, "/users/delete", map[string]any{"user_id": userID}, nil)
+}
@@ -0,0 +1,244 @@
+// Package errs defines the errors of the reviewers service and the format
+// of error responses of its API.
+package errs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+type ErrorCode int
+
+const (
+	CodeNotFound = iota
+	CodeTeamExists
+	CodePRExists
+	CodePRMerged
+	CodeNotAssigned
+	CodeNoCandidate
+	CodeInvalidHierarchy
+	CodeRepositoryExists
+	CodeOrganizationExists
+	CodeUserExists
+	CodeNotEmpty
+	CodeUserHasPullRequests
+	CodeUnauthorized
+	CodeForbidden
+	CodeValidationFailed
+	CodeTimeout
+	CodeInternal
+)
+
+func (e ErrorCode) String() string {
+	switch e {
+	case CodeNotFound:
+		return "NOT_FOUND"
+	case CodeTeamExists:
+		return "TEAM_EXISTS"
+	case CodePRMerged:
+		return "PR_MERGED"
+	case CodePRExists:
+		return "PR_EXISTS"
+	case CodeNotAssigned:
+		return "NOT_ASSIGNED"
+	case CodeNoCandidate:
+		return "NO_CANDIDATE"
+	case CodeInvalidHierarchy:
+		return "INVALID_HIERARCHY"
+	case CodeRepositoryExists:
+		return "REPOSITORY_EXISTS"
+	case CodeOrganizationExists:
+		return "ORGANIZATION_EXISTS"
+	case CodeUserExists:
+		return "USER_EXISTS"
+	case CodeNotEmpty:
+		return "NOT_EMPTY"
+	case CodeUserHasPullRequests:
+		return "USER_HAS_PULL_REQUESTS"
+	case CodeUnauthorized:
+		return "UNAUTHORIZED"
+	case CodeForbidden:
+		return "FORBIDDEN"
+	case CodeValidationFailed:
+		return "VALIDATION_FAILED"
+	case CodeTimeout:
+		return "TIMEOUT"
+	case CodeInternal:
+		return "INTERNAL"
+	default:
+		return "ERROR"
+	}
+}
+
+// ParseErrorCode returns the code with the given name.
+func ParseErrorCode(name string) (ErrorCode, bool) {
+	for code := ErrorCode(CodeNotFound); code <= CodeInternal; code++ {
+		if code.String() == name {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+func (e ErrorCode) StatusCode() int {
+	switch e {
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodeTeamExists:
+		return http.StatusBadRequest
+	case CodePRMerged:
+		return http.StatusConflict
+	case CodePRExists:
+		return http.StatusConflict
+	case CodeNotAssigned:
+		return http.StatusConflict
+	case CodeNoCandidate:
+		return http.StatusConflict
+	case CodeInvalidHierarchy:
+		return http.StatusConflict
+	case CodeRepositoryExists:
+		return http.StatusBadRequest
+	case CodeOrganizationExists:
+		return http.StatusBadRequest
+	case CodeUserExists:
+		return http.StatusBadRequest
+	case CodeNotEmpty:
+		return http.StatusConflict
+	case CodeUserHasPullRequests:
+		return http.StatusConflict
+	case CodeUnauthorized:
+		return http.StatusUnauthorized
+	case CodeForbidden:
+		return http.StatusForbidden
+	case CodeValidationFailed:
+		return http.StatusBadRequest
+	case CodeTimeout:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+type ApiError struct {
+	Code    ErrorCode
+	Message string
+}
+
+func (e ApiError) Error() string {
+	return e.Message
+}
+
+// Is reports whether the target is an ApiError with the same code, so
+// errors with a custom message still match the predefined ones.
+func (e ApiError) Is(target error) bool {
+	t, ok := target.(ApiError)
+	return ok && t.Code == e.Code
+}
+
+// WithMessage returns a copy of the error with another message.
+func (e ApiError) WithMessage(message string) ApiError {
+	return ApiError{e.Code, message}
+}
+
+func NewApiError(code ErrorCode, message string) ApiError {
+	return ApiError{code, message}
+}
+
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	RequestID string       `json:"request_id,omitempty"`
+	Fields    []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError is an invalid field of a request. Line is the line of the
+// field in an uploaded file, if the request has one.
+type FieldError struct {
+	Line    int    `json:"line,omitempty"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned for requests with invalid fields.
+type ValidationError struct {
+	Message string
+	Fields  []FieldError
+}
+
+func NewValidationError(fields ...FieldError) *ValidationError {
+	return &ValidationError{Message: "validation failed", Fields: fields}
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ValidationFailed
+}
+
+func NewErrorResponse(code ErrorCode, message string) ErrorResponse {
+	return ErrorResponse{
+		Error: ErrorBody{
+			Code:    code.String(),
+			Message: message,
+		},
+	}
+}
+
+// ToResponse converts the error to the status code and body of the
+// response. Errors that are not an ApiError are reported as internal ones.
+func ToResponse(err error) (int, ErrorResponse) {
+	var validationErr *ValidationError
+	var apiErr ApiError
+
+	switch {
+	case errors.As(err, &validationErr):
+		response := NewErrorResponse(CodeValidationFailed, validationErr.Message)
+		response.Error.Fields = validationErr.Fields
+		return http.StatusBadRequest, response
+	case errors.As(err, &apiErr):
+		return apiErr.Code.StatusCode(), NewErrorResponse(apiErr.Code, apiErr.Message)
+	case errors.Is(err, context.DeadlineExceeded):
+		return Timeout.Code.StatusCode(), NewErrorResponse(Timeout.Code, Timeout.Message)
+	default:
+		return Internal.Code.StatusCode(), NewErrorResponse(Internal.Code, Internal.Message)
+	}
+}
+
+// FromResponse converts an error response back to the error it was made
+// from, so that clients can match it with errors.Is.
+func FromResponse(response ErrorResponse) error {
+	code, ok := ParseErrorCode(response.Error.Code)
+	switch {
+	case !ok:
+		return NewApiError(CodeInternal, response.Error.Message)
+	case code == CodeValidationFailed:
+		return &ValidationError{Message: response.Error.Message, Fields: response.Error.Fields}
+	default:
+		return NewApiError(code, response.Error.Message)
+	}
+}
+
+var ResourceNotFound = NewApiError(CodeNotFound, "resource not found")
+var TeamExists = NewApiError(CodeTeamExists, "team exists")
+var PullRequestExists = NewApiError(CodePRExists, "pull request exists")
+var PullRequestMerged = NewApiError(CodePRMerged, "pull request merged")
+var NotAssigned = NewApiError(CodeNotAssigned, "reviewer not assigned")
+var NoCandidate = NewApiError(CodeNoCandidate, "no candidate for review")
+var InvalidHierarchy = NewApiError(CodeInvalidHierarchy, "team cannot be nested under its own subteam")
+var RepositoryExists = NewApiError(CodeRepositoryExists, "repository exists")
+var OrganizationExists = NewApiError(CodeOrganizationExists, "organization exists")
+var UserExists = NewApiError(CodeUserExists, "user exists")
+var NotEmpty = NewApiError(CodeNotEmpty, "organization is not empty")
+var UserHasPullRequests = NewApiError(CodeUserHasPullRequests, "user has pull requests")
+var Unauthorized = NewApiError(CodeUnauthorized, "authentication required")
+var Forbidden = NewApiError(CodeForbidden, "access to the organization is forbidden")
+var ValidationFailed = NewApiError(CodeValidationFailed, "validation failed")
+var Timeout = NewApiError(CodeTimeout, "request timed out")
+var Internal = NewApiError(CodeInternal, "something bad happened")
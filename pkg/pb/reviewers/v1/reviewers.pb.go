@@ -0,0 +1,1475 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: reviewers/v1/reviewers.proto
+
+package reviewersv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PullRequestStatus int32
+
+const (
+	PullRequestStatus_PULL_REQUEST_STATUS_UNSPECIFIED PullRequestStatus = 0
+	PullRequestStatus_PULL_REQUEST_STATUS_OPEN        PullRequestStatus = 1
+	PullRequestStatus_PULL_REQUEST_STATUS_MERGED      PullRequestStatus = 2
+)
+
+// Enum value maps for PullRequestStatus.
+var (
+	PullRequestStatus_name = map[int32]string{
+		0: "PULL_REQUEST_STATUS_UNSPECIFIED",
+		1: "PULL_REQUEST_STATUS_OPEN",
+		2: "PULL_REQUEST_STATUS_MERGED",
+	}
+	PullRequestStatus_value = map[string]int32{
+		"PULL_REQUEST_STATUS_UNSPECIFIED": 0,
+		"PULL_REQUEST_STATUS_OPEN":        1,
+		"PULL_REQUEST_STATUS_MERGED":      2,
+	}
+)
+
+func (x PullRequestStatus) Enum() *PullRequestStatus {
+	p := new(PullRequestStatus)
+	*p = x
+	return p
+}
+
+func (x PullRequestStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PullRequestStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_reviewers_v1_reviewers_proto_enumTypes[0].Descriptor()
+}
+
+func (PullRequestStatus) Type() protoreflect.EnumType {
+	return &file_reviewers_v1_reviewers_proto_enumTypes[0]
+}
+
+func (x PullRequestStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PullRequestStatus.Descriptor instead.
+func (PullRequestStatus) EnumDescriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{0}
+}
+
+type User struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	IsActive      bool                   `protobuf:"varint,3,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *User) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *User) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *User) GetIsActive() bool {
+	if x != nil {
+		return x.IsActive
+	}
+	return false
+}
+
+type Team struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	TeamName       string                 `protobuf:"bytes,1,opt,name=team_name,json=teamName,proto3" json:"team_name,omitempty"`
+	ParentTeamName string                 `protobuf:"bytes,2,opt,name=parent_team_name,json=parentTeamName,proto3" json:"parent_team_name,omitempty"`
+	Members        []*User                `protobuf:"bytes,3,rep,name=members,proto3" json:"members,omitempty"`
+	Subteams       []*Team                `protobuf:"bytes,4,rep,name=subteams,proto3" json:"subteams,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Team) Reset() {
+	*x = Team{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Team) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Team) ProtoMessage() {}
+
+func (x *Team) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Team.ProtoReflect.Descriptor instead.
+func (*Team) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Team) GetTeamName() string {
+	if x != nil {
+		return x.TeamName
+	}
+	return ""
+}
+
+func (x *Team) GetParentTeamName() string {
+	if x != nil {
+		return x.ParentTeamName
+	}
+	return ""
+}
+
+func (x *Team) GetMembers() []*User {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *Team) GetSubteams() []*Team {
+	if x != nil {
+		return x.Subteams
+	}
+	return nil
+}
+
+// Every counter includes the counters of all subteams.
+type TeamStats struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	TeamName        string                 `protobuf:"bytes,1,opt,name=team_name,json=teamName,proto3" json:"team_name,omitempty"`
+	Members         int64                  `protobuf:"varint,2,opt,name=members,proto3" json:"members,omitempty"`
+	ActiveMembers   int64                  `protobuf:"varint,3,opt,name=active_members,json=activeMembers,proto3" json:"active_members,omitempty"`
+	AssignedReviews int64                  `protobuf:"varint,4,opt,name=assigned_reviews,json=assignedReviews,proto3" json:"assigned_reviews,omitempty"`
+	OpenReviews     int64                  `protobuf:"varint,5,opt,name=open_reviews,json=openReviews,proto3" json:"open_reviews,omitempty"`
+	Subteams        []*TeamStats           `protobuf:"bytes,6,rep,name=subteams,proto3" json:"subteams,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *TeamStats) Reset() {
+	*x = TeamStats{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TeamStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TeamStats) ProtoMessage() {}
+
+func (x *TeamStats) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TeamStats.ProtoReflect.Descriptor instead.
+func (*TeamStats) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TeamStats) GetTeamName() string {
+	if x != nil {
+		return x.TeamName
+	}
+	return ""
+}
+
+func (x *TeamStats) GetMembers() int64 {
+	if x != nil {
+		return x.Members
+	}
+	return 0
+}
+
+func (x *TeamStats) GetActiveMembers() int64 {
+	if x != nil {
+		return x.ActiveMembers
+	}
+	return 0
+}
+
+func (x *TeamStats) GetAssignedReviews() int64 {
+	if x != nil {
+		return x.AssignedReviews
+	}
+	return 0
+}
+
+func (x *TeamStats) GetOpenReviews() int64 {
+	if x != nil {
+		return x.OpenReviews
+	}
+	return 0
+}
+
+func (x *TeamStats) GetSubteams() []*TeamStats {
+	if x != nil {
+		return x.Subteams
+	}
+	return nil
+}
+
+type PullRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Repository        string                 `protobuf:"bytes,1,opt,name=repository,proto3" json:"repository,omitempty"`
+	PullRequestId     string                 `protobuf:"bytes,2,opt,name=pull_request_id,json=pullRequestId,proto3" json:"pull_request_id,omitempty"`
+	PullRequestName   string                 `protobuf:"bytes,3,opt,name=pull_request_name,json=pullRequestName,proto3" json:"pull_request_name,omitempty"`
+	AuthorId          string                 `protobuf:"bytes,4,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	Status            PullRequestStatus      `protobuf:"varint,5,opt,name=status,proto3,enum=reviewers.v1.PullRequestStatus" json:"status,omitempty"`
+	CreatedAt         *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	MergedAt          *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=merged_at,json=mergedAt,proto3" json:"merged_at,omitempty"`
+	AssignedReviewers []string               `protobuf:"bytes,8,rep,name=assigned_reviewers,json=assignedReviewers,proto3" json:"assigned_reviewers,omitempty"`
+	ChangedFiles      []string               `protobuf:"bytes,9,rep,name=changed_files,json=changedFiles,proto3" json:"changed_files,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *PullRequest) Reset() {
+	*x = PullRequest{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PullRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PullRequest) ProtoMessage() {}
+
+func (x *PullRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PullRequest.ProtoReflect.Descriptor instead.
+func (*PullRequest) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PullRequest) GetRepository() string {
+	if x != nil {
+		return x.Repository
+	}
+	return ""
+}
+
+func (x *PullRequest) GetPullRequestId() string {
+	if x != nil {
+		return x.PullRequestId
+	}
+	return ""
+}
+
+func (x *PullRequest) GetPullRequestName() string {
+	if x != nil {
+		return x.PullRequestName
+	}
+	return ""
+}
+
+func (x *PullRequest) GetAuthorId() string {
+	if x != nil {
+		return x.AuthorId
+	}
+	return ""
+}
+
+func (x *PullRequest) GetStatus() PullRequestStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PullRequestStatus_PULL_REQUEST_STATUS_UNSPECIFIED
+}
+
+func (x *PullRequest) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *PullRequest) GetMergedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.MergedAt
+	}
+	return nil
+}
+
+func (x *PullRequest) GetAssignedReviewers() []string {
+	if x != nil {
+		return x.AssignedReviewers
+	}
+	return nil
+}
+
+func (x *PullRequest) GetChangedFiles() []string {
+	if x != nil {
+		return x.ChangedFiles
+	}
+	return nil
+}
+
+type PullRequestShort struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Repository      string                 `protobuf:"bytes,1,opt,name=repository,proto3" json:"repository,omitempty"`
+	PullRequestId   string                 `protobuf:"bytes,2,opt,name=pull_request_id,json=pullRequestId,proto3" json:"pull_request_id,omitempty"`
+	PullRequestName string                 `protobuf:"bytes,3,opt,name=pull_request_name,json=pullRequestName,proto3" json:"pull_request_name,omitempty"`
+	AuthorId        string                 `protobuf:"bytes,4,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	Status          PullRequestStatus      `protobuf:"varint,5,opt,name=status,proto3,enum=reviewers.v1.PullRequestStatus" json:"status,omitempty"`
+	CreatedAt       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *PullRequestShort) Reset() {
+	*x = PullRequestShort{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PullRequestShort) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PullRequestShort) ProtoMessage() {}
+
+func (x *PullRequestShort) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PullRequestShort.ProtoReflect.Descriptor instead.
+func (*PullRequestShort) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PullRequestShort) GetRepository() string {
+	if x != nil {
+		return x.Repository
+	}
+	return ""
+}
+
+func (x *PullRequestShort) GetPullRequestId() string {
+	if x != nil {
+		return x.PullRequestId
+	}
+	return ""
+}
+
+func (x *PullRequestShort) GetPullRequestName() string {
+	if x != nil {
+		return x.PullRequestName
+	}
+	return ""
+}
+
+func (x *PullRequestShort) GetAuthorId() string {
+	if x != nil {
+		return x.AuthorId
+	}
+	return ""
+}
+
+func (x *PullRequestShort) GetStatus() PullRequestStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PullRequestStatus_PULL_REQUEST_STATUS_UNSPECIFIED
+}
+
+func (x *PullRequestShort) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type GetTeamRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	TeamName        string                 `protobuf:"bytes,1,opt,name=team_name,json=teamName,proto3" json:"team_name,omitempty"`
+	IncludeSubteams bool                   `protobuf:"varint,2,opt,name=include_subteams,json=includeSubteams,proto3" json:"include_subteams,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GetTeamRequest) Reset() {
+	*x = GetTeamRequest{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTeamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTeamRequest) ProtoMessage() {}
+
+func (x *GetTeamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTeamRequest.ProtoReflect.Descriptor instead.
+func (*GetTeamRequest) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetTeamRequest) GetTeamName() string {
+	if x != nil {
+		return x.TeamName
+	}
+	return ""
+}
+
+func (x *GetTeamRequest) GetIncludeSubteams() bool {
+	if x != nil {
+		return x.IncludeSubteams
+	}
+	return false
+}
+
+type CreateTeamRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Team          *Team                  `protobuf:"bytes,1,opt,name=team,proto3" json:"team,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTeamRequest) Reset() {
+	*x = CreateTeamRequest{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTeamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTeamRequest) ProtoMessage() {}
+
+func (x *CreateTeamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTeamRequest.ProtoReflect.Descriptor instead.
+func (*CreateTeamRequest) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CreateTeamRequest) GetTeam() *Team {
+	if x != nil {
+		return x.Team
+	}
+	return nil
+}
+
+type DeactivateTeamRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TeamId        string                 `protobuf:"bytes,1,opt,name=team_id,json=teamId,proto3" json:"team_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeactivateTeamRequest) Reset() {
+	*x = DeactivateTeamRequest{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeactivateTeamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeactivateTeamRequest) ProtoMessage() {}
+
+func (x *DeactivateTeamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeactivateTeamRequest.ProtoReflect.Descriptor instead.
+func (*DeactivateTeamRequest) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeactivateTeamRequest) GetTeamId() string {
+	if x != nil {
+		return x.TeamId
+	}
+	return ""
+}
+
+type DeactivateTeamResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeactivateTeamResponse) Reset() {
+	*x = DeactivateTeamResponse{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeactivateTeamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeactivateTeamResponse) ProtoMessage() {}
+
+func (x *DeactivateTeamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeactivateTeamResponse.ProtoReflect.Descriptor instead.
+func (*DeactivateTeamResponse) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{8}
+}
+
+type SetParentRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	TeamName       string                 `protobuf:"bytes,1,opt,name=team_name,json=teamName,proto3" json:"team_name,omitempty"`
+	ParentTeamName string                 `protobuf:"bytes,2,opt,name=parent_team_name,json=parentTeamName,proto3" json:"parent_team_name,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SetParentRequest) Reset() {
+	*x = SetParentRequest{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetParentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetParentRequest) ProtoMessage() {}
+
+func (x *SetParentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetParentRequest.ProtoReflect.Descriptor instead.
+func (*SetParentRequest) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SetParentRequest) GetTeamName() string {
+	if x != nil {
+		return x.TeamName
+	}
+	return ""
+}
+
+func (x *SetParentRequest) GetParentTeamName() string {
+	if x != nil {
+		return x.ParentTeamName
+	}
+	return ""
+}
+
+type SetParentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetParentResponse) Reset() {
+	*x = SetParentResponse{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetParentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetParentResponse) ProtoMessage() {}
+
+func (x *SetParentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetParentResponse.ProtoReflect.Descriptor instead.
+func (*SetParentResponse) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{10}
+}
+
+type GetTeamStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TeamName      string                 `protobuf:"bytes,1,opt,name=team_name,json=teamName,proto3" json:"team_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTeamStatsRequest) Reset() {
+	*x = GetTeamStatsRequest{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTeamStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTeamStatsRequest) ProtoMessage() {}
+
+func (x *GetTeamStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTeamStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetTeamStatsRequest) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetTeamStatsRequest) GetTeamName() string {
+	if x != nil {
+		return x.TeamName
+	}
+	return ""
+}
+
+type SetIsActiveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	IsActive      bool                   `protobuf:"varint,2,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetIsActiveRequest) Reset() {
+	*x = SetIsActiveRequest{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetIsActiveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetIsActiveRequest) ProtoMessage() {}
+
+func (x *SetIsActiveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetIsActiveRequest.ProtoReflect.Descriptor instead.
+func (*SetIsActiveRequest) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SetIsActiveRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *SetIsActiveRequest) GetIsActive() bool {
+	if x != nil {
+		return x.IsActive
+	}
+	return false
+}
+
+type SetIsActiveResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetIsActiveResponse) Reset() {
+	*x = SetIsActiveResponse{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetIsActiveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetIsActiveResponse) ProtoMessage() {}
+
+func (x *SetIsActiveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetIsActiveResponse.ProtoReflect.Descriptor instead.
+func (*SetIsActiveResponse) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{13}
+}
+
+type GetReviewRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Unspecified status selects pull requests of any status.
+	Status PullRequestStatus `protobuf:"varint,2,opt,name=status,proto3,enum=reviewers.v1.PullRequestStatus" json:"status,omitempty"`
+	// Defaults to 100, at most 1000.
+	PageSize int32 `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token of the previous page.
+	PageToken     string `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReviewRequest) Reset() {
+	*x = GetReviewRequest{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReviewRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReviewRequest) ProtoMessage() {}
+
+func (x *GetReviewRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReviewRequest.ProtoReflect.Descriptor instead.
+func (*GetReviewRequest) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetReviewRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetReviewRequest) GetStatus() PullRequestStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PullRequestStatus_PULL_REQUEST_STATUS_UNSPECIFIED
+}
+
+func (x *GetReviewRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *GetReviewRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type GetReviewResponse struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	UserId       string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PullRequests []*PullRequestShort    `protobuf:"bytes,2,rep,name=pull_requests,json=pullRequests,proto3" json:"pull_requests,omitempty"`
+	// Empty on the last page.
+	NextPageToken string `protobuf:"bytes,3,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReviewResponse) Reset() {
+	*x = GetReviewResponse{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReviewResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReviewResponse) ProtoMessage() {}
+
+func (x *GetReviewResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReviewResponse.ProtoReflect.Descriptor instead.
+func (*GetReviewResponse) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetReviewResponse) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetReviewResponse) GetPullRequests() []*PullRequestShort {
+	if x != nil {
+		return x.PullRequests
+	}
+	return nil
+}
+
+func (x *GetReviewResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type CreatePullRequestRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Repository      string                 `protobuf:"bytes,1,opt,name=repository,proto3" json:"repository,omitempty"`
+	PullRequestId   string                 `protobuf:"bytes,2,opt,name=pull_request_id,json=pullRequestId,proto3" json:"pull_request_id,omitempty"`
+	PullRequestName string                 `protobuf:"bytes,3,opt,name=pull_request_name,json=pullRequestName,proto3" json:"pull_request_name,omitempty"`
+	AuthorId        string                 `protobuf:"bytes,4,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	ChangedFiles    []string               `protobuf:"bytes,5,rep,name=changed_files,json=changedFiles,proto3" json:"changed_files,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CreatePullRequestRequest) Reset() {
+	*x = CreatePullRequestRequest{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePullRequestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePullRequestRequest) ProtoMessage() {}
+
+func (x *CreatePullRequestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePullRequestRequest.ProtoReflect.Descriptor instead.
+func (*CreatePullRequestRequest) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CreatePullRequestRequest) GetRepository() string {
+	if x != nil {
+		return x.Repository
+	}
+	return ""
+}
+
+func (x *CreatePullRequestRequest) GetPullRequestId() string {
+	if x != nil {
+		return x.PullRequestId
+	}
+	return ""
+}
+
+func (x *CreatePullRequestRequest) GetPullRequestName() string {
+	if x != nil {
+		return x.PullRequestName
+	}
+	return ""
+}
+
+func (x *CreatePullRequestRequest) GetAuthorId() string {
+	if x != nil {
+		return x.AuthorId
+	}
+	return ""
+}
+
+func (x *CreatePullRequestRequest) GetChangedFiles() []string {
+	if x != nil {
+		return x.ChangedFiles
+	}
+	return nil
+}
+
+type MergePullRequestRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Repository    string                 `protobuf:"bytes,1,opt,name=repository,proto3" json:"repository,omitempty"`
+	PullRequestId string                 `protobuf:"bytes,2,opt,name=pull_request_id,json=pullRequestId,proto3" json:"pull_request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MergePullRequestRequest) Reset() {
+	*x = MergePullRequestRequest{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MergePullRequestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MergePullRequestRequest) ProtoMessage() {}
+
+func (x *MergePullRequestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MergePullRequestRequest.ProtoReflect.Descriptor instead.
+func (*MergePullRequestRequest) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *MergePullRequestRequest) GetRepository() string {
+	if x != nil {
+		return x.Repository
+	}
+	return ""
+}
+
+func (x *MergePullRequestRequest) GetPullRequestId() string {
+	if x != nil {
+		return x.PullRequestId
+	}
+	return ""
+}
+
+type ReassignReviewerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Repository    string                 `protobuf:"bytes,1,opt,name=repository,proto3" json:"repository,omitempty"`
+	PullRequestId string                 `protobuf:"bytes,2,opt,name=pull_request_id,json=pullRequestId,proto3" json:"pull_request_id,omitempty"`
+	OldReviewerId string                 `protobuf:"bytes,3,opt,name=old_reviewer_id,json=oldReviewerId,proto3" json:"old_reviewer_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReassignReviewerRequest) Reset() {
+	*x = ReassignReviewerRequest{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReassignReviewerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReassignReviewerRequest) ProtoMessage() {}
+
+func (x *ReassignReviewerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReassignReviewerRequest.ProtoReflect.Descriptor instead.
+func (*ReassignReviewerRequest) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ReassignReviewerRequest) GetRepository() string {
+	if x != nil {
+		return x.Repository
+	}
+	return ""
+}
+
+func (x *ReassignReviewerRequest) GetPullRequestId() string {
+	if x != nil {
+		return x.PullRequestId
+	}
+	return ""
+}
+
+func (x *ReassignReviewerRequest) GetOldReviewerId() string {
+	if x != nil {
+		return x.OldReviewerId
+	}
+	return ""
+}
+
+type ApprovePullRequestRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Repository    string                 `protobuf:"bytes,1,opt,name=repository,proto3" json:"repository,omitempty"`
+	PullRequestId string                 `protobuf:"bytes,2,opt,name=pull_request_id,json=pullRequestId,proto3" json:"pull_request_id,omitempty"`
+	ReviewerId    string                 `protobuf:"bytes,3,opt,name=reviewer_id,json=reviewerId,proto3" json:"reviewer_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApprovePullRequestRequest) Reset() {
+	*x = ApprovePullRequestRequest{}
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApprovePullRequestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApprovePullRequestRequest) ProtoMessage() {}
+
+func (x *ApprovePullRequestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reviewers_v1_reviewers_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApprovePullRequestRequest.ProtoReflect.Descriptor instead.
+func (*ApprovePullRequestRequest) Descriptor() ([]byte, []int) {
+	return file_reviewers_v1_reviewers_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ApprovePullRequestRequest) GetRepository() string {
+	if x != nil {
+		return x.Repository
+	}
+	return ""
+}
+
+func (x *ApprovePullRequestRequest) GetPullRequestId() string {
+	if x != nil {
+		return x.PullRequestId
+	}
+	return ""
+}
+
+func (x *ApprovePullRequestRequest) GetReviewerId() string {
+	if x != nil {
+		return x.ReviewerId
+	}
+	return ""
+}
+
+var File_reviewers_v1_reviewers_proto protoreflect.FileDescriptor
+
+const file_reviewers_v1_reviewers_proto_rawDesc = "" +
+	"\n" +
+	"\x1creviewers/v1/reviewers.proto\x12\freviewers.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"X\n" +
+	"\x04User\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12\x1b\n" +
+	"\tis_active\x18\x03 \x01(\bR\bisActive\"\xab\x01\n" +
+	"\x04Team\x12\x1b\n" +
+	"\tteam_name\x18\x01 \x01(\tR\bteamName\x12(\n" +
+	"\x10parent_team_name\x18\x02 \x01(\tR\x0eparentTeamName\x12,\n" +
+	"\amembers\x18\x03 \x03(\v2\x12.reviewers.v1.UserR\amembers\x12.\n" +
+	"\bsubteams\x18\x04 \x03(\v2\x12.reviewers.v1.TeamR\bsubteams\"\xec\x01\n" +
+	"\tTeamStats\x12\x1b\n" +
+	"\tteam_name\x18\x01 \x01(\tR\bteamName\x12\x18\n" +
+	"\amembers\x18\x02 \x01(\x03R\amembers\x12%\n" +
+	"\x0eactive_members\x18\x03 \x01(\x03R\ractiveMembers\x12)\n" +
+	"\x10assigned_reviews\x18\x04 \x01(\x03R\x0fassignedReviews\x12!\n" +
+	"\fopen_reviews\x18\x05 \x01(\x03R\vopenReviews\x123\n" +
+	"\bsubteams\x18\x06 \x03(\v2\x17.reviewers.v1.TeamStatsR\bsubteams\"\x9f\x03\n" +
+	"\vPullRequest\x12\x1e\n" +
+	"\n" +
+	"repository\x18\x01 \x01(\tR\n" +
+	"repository\x12&\n" +
+	"\x0fpull_request_id\x18\x02 \x01(\tR\rpullRequestId\x12*\n" +
+	"\x11pull_request_name\x18\x03 \x01(\tR\x0fpullRequestName\x12\x1b\n" +
+	"\tauthor_id\x18\x04 \x01(\tR\bauthorId\x127\n" +
+	"\x06status\x18\x05 \x01(\x0e2\x1f.reviewers.v1.PullRequestStatusR\x06status\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x127\n" +
+	"\tmerged_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\bmergedAt\x12-\n" +
+	"\x12assigned_reviewers\x18\b \x03(\tR\x11assignedReviewers\x12#\n" +
+	"\rchanged_files\x18\t \x03(\tR\fchangedFiles\"\x97\x02\n" +
+	"\x10PullRequestShort\x12\x1e\n" +
+	"\n" +
+	"repository\x18\x01 \x01(\tR\n" +
+	"repository\x12&\n" +
+	"\x0fpull_request_id\x18\x02 \x01(\tR\rpullRequestId\x12*\n" +
+	"\x11pull_request_name\x18\x03 \x01(\tR\x0fpullRequestName\x12\x1b\n" +
+	"\tauthor_id\x18\x04 \x01(\tR\bauthorId\x127\n" +
+	"\x06status\x18\x05 \x01(\x0e2\x1f.reviewers.v1.PullRequestStatusR\x06status\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"X\n" +
+	"\x0eGetTeamRequest\x12\x1b\n" +
+	"\tteam_name\x18\x01 \x01(\tR\bteamName\x12)\n" +
+	"\x10include_subteams\x18\x02 \x01(\bR\x0fincludeSubteams\";\n" +
+	"\x11CreateTeamRequest\x12&\n" +
+	"\x04team\x18\x01 \x01(\v2\x12.reviewers.v1.TeamR\x04team\"0\n" +
+	"\x15DeactivateTeamRequest\x12\x17\n" +
+	"\ateam_id\x18\x01 \x01(\tR\x06teamId\"\x18\n" +
+	"\x16DeactivateTeamResponse\"Y\n" +
+	"\x10SetParentRequest\x12\x1b\n" +
+	"\tteam_name\x18\x01 \x01(\tR\bteamName\x12(\n" +
+	"\x10parent_team_name\x18\x02 \x01(\tR\x0eparentTeamName\"\x13\n" +
+	"\x11SetParentResponse\"2\n" +
+	"\x13GetTeamStatsRequest\x12\x1b\n" +
+	"\tteam_name\x18\x01 \x01(\tR\bteamName\"J\n" +
+	"\x12SetIsActiveRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tis_active\x18\x02 \x01(\bR\bisActive\"\x15\n" +
+	"\x13SetIsActiveResponse\"\xa0\x01\n" +
+	"\x10GetReviewRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x127\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x1f.reviewers.v1.PullRequestStatusR\x06status\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\"\x99\x01\n" +
+	"\x11GetReviewResponse\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12C\n" +
+	"\rpull_requests\x18\x02 \x03(\v2\x1e.reviewers.v1.PullRequestShortR\fpullRequests\x12&\n" +
+	"\x0fnext_page_token\x18\x03 \x01(\tR\rnextPageToken\"\xd0\x01\n" +
+	"\x18CreatePullRequestRequest\x12\x1e\n" +
+	"\n" +
+	"repository\x18\x01 \x01(\tR\n" +
+	"repository\x12&\n" +
+	"\x0fpull_request_id\x18\x02 \x01(\tR\rpullRequestId\x12*\n" +
+	"\x11pull_request_name\x18\x03 \x01(\tR\x0fpullRequestName\x12\x1b\n" +
+	"\tauthor_id\x18\x04 \x01(\tR\bauthorId\x12#\n" +
+	"\rchanged_files\x18\x05 \x03(\tR\fchangedFiles\"a\n" +
+	"\x17MergePullRequestRequest\x12\x1e\n" +
+	"\n" +
+	"repository\x18\x01 \x01(\tR\n" +
+	"repository\x12&\n" +
+	"\x0fpull_request_id\x18\x02 \x01(\tR\rpullRequestId\"\x89\x01\n" +
+	"\x17ReassignReviewerRequest\x12\x1e\n" +
+	"\n" +
+	"repository\x18\x01 \x01(\tR\n" +
+	"repository\x12&\n" +
+	"\x0fpull_request_id\x18\x02 \x01(\tR\rpullRequestId\x12&\n" +
+	"\x0fold_reviewer_id\x18\x03 \x01(\tR\roldReviewerId\"\x84\x01\n" +
+	"\x19ApprovePullRequestRequest\x12\x1e\n" +
+	"\n" +
+	"repository\x18\x01 \x01(\tR\n" +
+	"repository\x12&\n" +
+	"\x0fpull_request_id\x18\x02 \x01(\tR\rpullRequestId\x12\x1f\n" +
+	"\vreviewer_id\x18\x03 \x01(\tR\n" +
+	"reviewerId*v\n" +
+	"\x11PullRequestStatus\x12#\n" +
+	"\x1fPULL_REQUEST_STATUS_UNSPECIFIED\x10\x00\x12\x1c\n" +
+	"\x18PULL_REQUEST_STATUS_OPEN\x10\x01\x12\x1e\n" +
+	"\x1aPULL_REQUEST_STATUS_MERGED\x10\x022\x84\x03\n" +
+	"\vTeamService\x12;\n" +
+	"\aGetTeam\x12\x1c.reviewers.v1.GetTeamRequest\x1a\x12.reviewers.v1.Team\x12A\n" +
+	"\n" +
+	"CreateTeam\x12\x1f.reviewers.v1.CreateTeamRequest\x1a\x12.reviewers.v1.Team\x12[\n" +
+	"\x0eDeactivateTeam\x12#.reviewers.v1.DeactivateTeamRequest\x1a$.reviewers.v1.DeactivateTeamResponse\x12L\n" +
+	"\tSetParent\x12\x1e.reviewers.v1.SetParentRequest\x1a\x1f.reviewers.v1.SetParentResponse\x12J\n" +
+	"\fGetTeamStats\x12!.reviewers.v1.GetTeamStatsRequest\x1a\x17.reviewers.v1.TeamStats2\xaf\x01\n" +
+	"\vUserService\x12R\n" +
+	"\vSetIsActive\x12 .reviewers.v1.SetIsActiveRequest\x1a!.reviewers.v1.SetIsActiveResponse\x12L\n" +
+	"\tGetReview\x12\x1e.reviewers.v1.GetReviewRequest\x1a\x1f.reviewers.v1.GetReviewResponse2\xf2\x02\n" +
+	"\x12PullRequestService\x12V\n" +
+	"\x11CreatePullRequest\x12&.reviewers.v1.CreatePullRequestRequest\x1a\x19.reviewers.v1.PullRequest\x12T\n" +
+	"\x10MergePullRequest\x12%.reviewers.v1.MergePullRequestRequest\x1a\x19.reviewers.v1.PullRequest\x12T\n" +
+	"\x10ReassignReviewer\x12%.reviewers.v1.ReassignReviewerRequest\x1a\x19.reviewers.v1.PullRequest\x12X\n" +
+	"\x12ApprovePullRequest\x12'.reviewers.v1.ApprovePullRequestRequest\x1a\x19.reviewers.v1.PullRequestB+Z)reviewers/pkg/pb/reviewers/v1;reviewersv1b\x06proto3"
+
+var (
+	file_reviewers_v1_reviewers_proto_rawDescOnce sync.Once
+	file_reviewers_v1_reviewers_proto_rawDescData []byte
+)
+
+func file_reviewers_v1_reviewers_proto_rawDescGZIP() []byte {
+	file_reviewers_v1_reviewers_proto_rawDescOnce.Do(func() {
+		file_reviewers_v1_reviewers_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_reviewers_v1_reviewers_proto_rawDesc), len(file_reviewers_v1_reviewers_proto_rawDesc)))
+	})
+	return file_reviewers_v1_reviewers_proto_rawDescData
+}
+
+var file_reviewers_v1_reviewers_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_reviewers_v1_reviewers_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_reviewers_v1_reviewers_proto_goTypes = []any{
+	(PullRequestStatus)(0),            // 0: reviewers.v1.PullRequestStatus
+	(*User)(nil),                      // 1: reviewers.v1.User
+	(*Team)(nil),                      // 2: reviewers.v1.Team
+	(*TeamStats)(nil),                 // 3: reviewers.v1.TeamStats
+	(*PullRequest)(nil),               // 4: reviewers.v1.PullRequest
+	(*PullRequestShort)(nil),          // 5: reviewers.v1.PullRequestShort
+	(*GetTeamRequest)(nil),            // 6: reviewers.v1.GetTeamRequest
+	(*CreateTeamRequest)(nil),         // 7: reviewers.v1.CreateTeamRequest
+	(*DeactivateTeamRequest)(nil),     // 8: reviewers.v1.DeactivateTeamRequest
+	(*DeactivateTeamResponse)(nil),    // 9: reviewers.v1.DeactivateTeamResponse
+	(*SetParentRequest)(nil),          // 10: reviewers.v1.SetParentRequest
+	(*SetParentResponse)(nil),         // 11: reviewers.v1.SetParentResponse
+	(*GetTeamStatsRequest)(nil),       // 12: reviewers.v1.GetTeamStatsRequest
+	(*SetIsActiveRequest)(nil),        // 13: reviewers.v1.SetIsActiveRequest
+	(*SetIsActiveResponse)(nil),       // 14: reviewers.v1.SetIsActiveResponse
+	(*GetReviewRequest)(nil),          // 15: reviewers.v1.GetReviewRequest
+	(*GetReviewResponse)(nil),         // 16: reviewers.v1.GetReviewResponse
+	(*CreatePullRequestRequest)(nil),  // 17: reviewers.v1.CreatePullRequestRequest
+	(*MergePullRequestRequest)(nil),   // 18: reviewers.v1.MergePullRequestRequest
+	(*ReassignReviewerRequest)(nil),   // 19: reviewers.v1.ReassignReviewerRequest
+	(*ApprovePullRequestRequest)(nil), // 20: reviewers.v1.ApprovePullRequestRequest
+	(*timestamppb.Timestamp)(nil),     // 21: google.protobuf.Timestamp
+}
+var file_reviewers_v1_reviewers_proto_depIdxs = []int32{
+	1,  // 0: reviewers.v1.Team.members:type_name -> reviewers.v1.User
+	2,  // 1: reviewers.v1.Team.subteams:type_name -> reviewers.v1.Team
+	3,  // 2: reviewers.v1.TeamStats.subteams:type_name -> reviewers.v1.TeamStats
+	0,  // 3: reviewers.v1.PullRequest.status:type_name -> reviewers.v1.PullRequestStatus
+	21, // 4: reviewers.v1.PullRequest.created_at:type_name -> google.protobuf.Timestamp
+	21, // 5: reviewers.v1.PullRequest.merged_at:type_name -> google.protobuf.Timestamp
+	0,  // 6: reviewers.v1.PullRequestShort.status:type_name -> reviewers.v1.PullRequestStatus
+	21, // 7: reviewers.v1.PullRequestShort.created_at:type_name -> google.protobuf.Timestamp
+	2,  // 8: reviewers.v1.CreateTeamRequest.team:type_name -> reviewers.v1.Team
+	0,  // 9: reviewers.v1.GetReviewRequest.status:type_name -> reviewers.v1.PullRequestStatus
+	5,  // 10: reviewers.v1.GetReviewResponse.pull_requests:type_name -> reviewers.v1.PullRequestShort
+	6,  // 11: reviewers.v1.TeamService.GetTeam:input_type -> reviewers.v1.GetTeamRequest
+	7,  // 12: reviewers.v1.TeamService.CreateTeam:input_type -> reviewers.v1.CreateTeamRequest
+	8,  // 13: reviewers.v1.TeamService.DeactivateTeam:input_type -> reviewers.v1.DeactivateTeamRequest
+	10, // 14: reviewers.v1.TeamService.SetParent:input_type -> reviewers.v1.SetParentRequest
+	12, // 15: reviewers.v1.TeamService.GetTeamStats:input_type -> reviewers.v1.GetTeamStatsRequest
+	13, // 16: reviewers.v1.UserService.SetIsActive:input_type -> reviewers.v1.SetIsActiveRequest
+	15, // 17: reviewers.v1.UserService.GetReview:input_type -> reviewers.v1.GetReviewRequest
+	17, // 18: reviewers.v1.PullRequestService.CreatePullRequest:input_type -> reviewers.v1.CreatePullRequestRequest
+	18, // 19: reviewers.v1.PullRequestService.MergePullRequest:input_type -> reviewers.v1.MergePullRequestRequest
+	19, // 20: reviewers.v1.PullRequestService.ReassignReviewer:input_type -> reviewers.v1.ReassignReviewerRequest
+	20, // 21: reviewers.v1.PullRequestService.ApprovePullRequest:input_type -> reviewers.v1.ApprovePullRequestRequest
+	2,  // 22: reviewers.v1.TeamService.GetTeam:output_type -> reviewers.v1.Team
+	2,  // 23: reviewers.v1.TeamService.CreateTeam:output_type -> reviewers.v1.Team
+	9,  // 24: reviewers.v1.TeamService.DeactivateTeam:output_type -> reviewers.v1.DeactivateTeamResponse
+	11, // 25: reviewers.v1.TeamService.SetParent:output_type -> reviewers.v1.SetParentResponse
+	3,  // 26: reviewers.v1.TeamService.GetTeamStats:output_type -> reviewers.v1.TeamStats
+	14, // 27: reviewers.v1.UserService.SetIsActive:output_type -> reviewers.v1.SetIsActiveResponse
+	16, // 28: reviewers.v1.UserService.GetReview:output_type -> reviewers.v1.GetReviewResponse
+	4,  // 29: reviewers.v1.PullRequestService.CreatePullRequest:output_type -> reviewers.v1.PullRequest
+	4,  // 30: reviewers.v1.PullRequestService.MergePullRequest:output_type -> reviewers.v1.PullRequest
+	4,  // 31: reviewers.v1.PullRequestService.ReassignReviewer:output_type -> reviewers.v1.PullRequest
+	4,  // 32: reviewers.v1.PullRequestService.ApprovePullRequest:output_type -> reviewers.v1.PullRequest
+	22, // [22:33] is the sub-list for method output_type
+	11, // [11:22] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_reviewers_v1_reviewers_proto_init() }
+func file_reviewers_v1_reviewers_proto_init() {
+	if File_reviewers_v1_reviewers_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_reviewers_v1_reviewers_proto_rawDesc), len(file_reviewers_v1_reviewers_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   20,
+			NumExtensions: 0,
+			NumServices:   3,
+		},
+		GoTypes:           file_reviewers_v1_reviewers_proto_goTypes,
+		DependencyIndexes: file_reviewers_v1_reviewers_proto_depIdxs,
+		EnumInfos:         file_reviewers_v1_reviewers_proto_enumTypes,
+		MessageInfos:      file_reviewers_v1_reviewers_proto_msgTypes,
+	}.Build()
+	File_reviewers_v1_reviewers_proto = out.File
+	file_reviewers_v1_reviewers_proto_goTypes = nil
+	file_reviewers_v1_reviewers_proto_depIdxs = nil
+}